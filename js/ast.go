@@ -16,8 +16,13 @@ type JSONer interface {
 
 // AST is the full ECMAScript abstract syntax tree.
 type AST struct {
-	Comments  [][]byte // first comments in file
-	BlockStmt          // module
+	Comments   [][]byte   // first comments in file
+	CommentMap CommentMap // comments attached to nodes, only set when Options.Comments is set
+	Tokens     TokenList  // all tokens including whitespace and comments, only set when Options.Tokens is set
+	Strict     bool       // module code or a script with a use strict directive
+	BlockStmt             // module
+
+	refs []varRef // occurrences of variables in the source, used by AnalyzeScopes
 }
 
 func (ast *AST) String() string {
@@ -67,12 +72,13 @@ func (decl DeclType) String() string {
 	return "Invalid(" + strconv.Itoa(int(decl)) + ")"
 }
 
-// Var is a variable, where Decl is the type of declaration and can be var|function for function scoped variables, let|const|class for block scoped variables.
+// Var is a variable, where Decl is the type of declaration and can be var|function for function scoped variables, let|const|class for block scoped variables. A single Var is shared by the declaration and all references of a variable in the AST, so that its Span is that of the first occurrence of the variable and not of the identifier at which it is found. The spans of all references are given by Scopes.Refs.
 type Var struct {
 	Data []byte
 	Link *Var // is set when merging variable uses, as in:  {a} {var a}  where the first links to the second, only used for undeclared variables
 	Uses uint16
 	Decl DeclType
+	Span
 }
 
 // Name returns the variable name.
@@ -194,7 +200,7 @@ func (s *Scope) Declare(decl DeclType, name []byte) (*Var, bool) {
 	}
 	if v == nil {
 		// add variable to the context list and to the scope
-		v = &Var{name, nil, 0, decl}
+		v = &Var{name, nil, 0, decl, Span{}}
 	} else {
 		v.Decl = decl
 	}
@@ -216,7 +222,7 @@ func (s *Scope) Use(name []byte) *Var {
 		v = s.findUndeclared(name)
 		if v == nil {
 			// add variable to the context list and to the scope's undeclared
-			v = &Var{name, nil, 0, NoDecl}
+			v = &Var{name, nil, 0, NoDecl, Span{}}
 			s.Undeclared = append(s.Undeclared, v)
 		}
 	}
@@ -337,10 +343,28 @@ func (s *Scope) Unscope() {
 
 ////////////////////////////////////////////////////////////////
 
+// Span is the range of byte offsets of a node in the source, where End is exclusive.
+type Span struct {
+	Start, End int
+}
+
+// Range returns the range of byte offsets of the node in the source.
+func (s Span) Range() Span {
+	return s
+}
+
+// Position returns the line and column numbers of the start and end of the span in the source the node was parsed from. Both lines and columns start at 1.
+func (s Span) Position(src []byte) (line, col, endLine, endCol int) {
+	line, col, _ = parse.Position(bytes.NewReader(src), s.Start)
+	endLine, endCol, _ = parse.Position(bytes.NewReader(src), s.End)
+	return
+}
+
 // INode is an interface for AST nodes
 type INode interface {
 	String() string
 	JS() string
+	Range() Span // byte offsets in the source, where a Var gives its first occurrence
 }
 
 // IStmt is a dummy interface for statements.
@@ -367,6 +391,7 @@ type IExpr interface {
 type BlockStmt struct {
 	List []IStmt
 	Scope
+	Span
 }
 
 func (n BlockStmt) String() string {
@@ -396,6 +421,7 @@ func (n BlockStmt) JS() string {
 
 // EmptyStmt is an empty statement.
 type EmptyStmt struct {
+	Span
 }
 
 func (n EmptyStmt) String() string {
@@ -410,6 +436,7 @@ func (n EmptyStmt) JS() string {
 // ExprStmt is an expression statement.
 type ExprStmt struct {
 	Value IExpr
+	Span
 }
 
 func (n ExprStmt) String() string {
@@ -430,6 +457,7 @@ type IfStmt struct {
 	Cond IExpr
 	Body IStmt
 	Else IStmt // can be nil
+	Span
 }
 
 func (n IfStmt) String() string {
@@ -464,6 +492,7 @@ func (n IfStmt) JS() string {
 type DoWhileStmt struct {
 	Cond IExpr
 	Body IStmt
+	Span
 }
 
 func (n DoWhileStmt) String() string {
@@ -486,6 +515,7 @@ func (n DoWhileStmt) JS() string {
 type WhileStmt struct {
 	Cond IExpr
 	Body IStmt
+	Span
 }
 
 func (n WhileStmt) String() string {
@@ -507,6 +537,7 @@ type ForStmt struct {
 	Cond IExpr // can be nil
 	Post IExpr // can be nil
 	Body *BlockStmt
+	Span
 }
 
 func (n ForStmt) String() string {
@@ -549,6 +580,7 @@ type ForInStmt struct {
 	Init  IExpr
 	Value IExpr
 	Body  *BlockStmt
+	Span
 }
 
 func (n ForInStmt) String() string {
@@ -566,6 +598,7 @@ type ForOfStmt struct {
 	Init  IExpr
 	Value IExpr
 	Body  *BlockStmt
+	Span
 }
 
 func (n ForOfStmt) String() string {
@@ -590,6 +623,7 @@ type CaseClause struct {
 	TokenType
 	Cond IExpr // can be nil
 	List []IStmt
+	Span
 }
 
 func (n CaseClause) String() string {
@@ -623,6 +657,7 @@ type SwitchStmt struct {
 	Init IExpr
 	List []CaseClause
 	Scope
+	Span
 }
 
 func (n SwitchStmt) String() string {
@@ -646,6 +681,7 @@ func (n SwitchStmt) JS() string {
 type BranchStmt struct {
 	Type  TokenType
 	Label []byte // can be nil
+	Span
 }
 
 func (n BranchStmt) String() string {
@@ -668,6 +704,7 @@ func (n BranchStmt) JS() string {
 // ReturnStmt is a return statement.
 type ReturnStmt struct {
 	Value IExpr // can be nil
+	Span
 }
 
 func (n ReturnStmt) String() string {
@@ -691,6 +728,7 @@ func (n ReturnStmt) JS() string {
 type WithStmt struct {
 	Cond IExpr
 	Body IStmt
+	Span
 }
 
 func (n WithStmt) String() string {
@@ -706,6 +744,7 @@ func (n WithStmt) JS() string {
 type LabelledStmt struct {
 	Label []byte
 	Value IStmt
+	Span
 }
 
 func (n LabelledStmt) String() string {
@@ -720,6 +759,7 @@ func (n LabelledStmt) JS() string {
 // ThrowStmt is a throw statement.
 type ThrowStmt struct {
 	Value IExpr
+	Span
 }
 
 func (n ThrowStmt) String() string {
@@ -737,6 +777,7 @@ type TryStmt struct {
 	Binding IBinding   // can be nil
 	Catch   *BlockStmt // can be nil
 	Finally *BlockStmt // can be nil
+	Span
 }
 
 func (n TryStmt) String() string {
@@ -772,6 +813,7 @@ func (n TryStmt) JS() string {
 
 // DebuggerStmt is a debugger statement.
 type DebuggerStmt struct {
+	Span
 }
 
 func (n DebuggerStmt) String() string {
@@ -783,10 +825,26 @@ func (n DebuggerStmt) JS() string {
 	return "debugger"
 }
 
+// BadStmt is a statement that failed to parse, it is only used when error recovery is enabled.
+type BadStmt struct {
+	Data []byte // source code of the statement
+	Span
+}
+
+func (n BadStmt) String() string {
+	return "Stmt(bad " + string(n.Data) + ")"
+}
+
+// JS returns the original source code of the statement
+func (n BadStmt) JS() string {
+	return string(n.Data)
+}
+
 // Alias is a name space import or import/export specifier for import/export statements.
 type Alias struct {
 	Name    []byte // can be nil
 	Binding []byte // can be nil
+	Span
 }
 
 func (alias Alias) String() string {
@@ -802,11 +860,47 @@ func (alias Alias) JS() string {
 	return alias.String()
 }
 
+// ImportAttribute is a key and value of the import attributes of import/export statements.
+type ImportAttribute struct {
+	Key   []byte // identifier name or string
+	Value []byte // string
+	Span
+}
+
+func (n ImportAttribute) String() string {
+	return string(n.Key) + ": " + string(n.Value)
+}
+
+// JS converts the node back to valid JavaScript
+func (n ImportAttribute) JS() string {
+	return n.String()
+}
+
+func importAttributesString(list []ImportAttribute, assert bool) string {
+	if list == nil {
+		return ""
+	}
+	s := " with {"
+	if assert {
+		s = " assert {"
+	}
+	for i, item := range list {
+		if i != 0 {
+			s += ", "
+		}
+		s += item.String()
+	}
+	return s + "}"
+}
+
 // ImportStmt is an import statement.
 type ImportStmt struct {
-	List    []Alias
-	Default []byte // can be nil
-	Module  []byte
+	List       []Alias
+	Default    []byte // can be nil
+	Module     []byte
+	Attributes []ImportAttribute // can be nil
+	Assert     bool              // attributes use the legacy assert keyword
+	Span
 }
 
 func (n ImportStmt) String() string {
@@ -834,7 +928,7 @@ func (n ImportStmt) String() string {
 	if n.Default != nil || len(n.List) != 0 {
 		s += " from"
 	}
-	return s + " " + string(n.Module) + ")"
+	return s + " " + string(n.Module) + importAttributesString(n.Attributes, n.Assert) + ")"
 }
 
 // JS converts the node back to valid JavaScript
@@ -863,15 +957,18 @@ func (n ImportStmt) JS() string {
 	if n.Default != nil || len(n.List) != 0 {
 		s += " from"
 	}
-	return s + " " + string(n.Module)
+	return s + " " + string(n.Module) + importAttributesString(n.Attributes, n.Assert)
 }
 
 // ExportStmt is an export statement.
 type ExportStmt struct {
-	List    []Alias
-	Module  []byte // can be nil
-	Default bool
-	Decl    IExpr
+	List       []Alias
+	Module     []byte // can be nil
+	Default    bool
+	Decl       IExpr
+	Attributes []ImportAttribute // can be nil
+	Assert     bool              // attributes use the legacy assert keyword
+	Span
 }
 
 func (n ExportStmt) String() string {
@@ -896,7 +993,7 @@ func (n ExportStmt) String() string {
 		s += " }"
 	}
 	if n.Module != nil {
-		s += " from " + string(n.Module)
+		s += " from " + string(n.Module) + importAttributesString(n.Attributes, n.Assert)
 	}
 	return s + ")"
 }
@@ -924,7 +1021,7 @@ func (n ExportStmt) JS() string {
 		s += " }"
 	}
 	if n.Module != nil {
-		s += " from " + string(n.Module)
+		s += " from " + string(n.Module) + importAttributesString(n.Attributes, n.Assert)
 	}
 	return s
 }
@@ -932,6 +1029,7 @@ func (n ExportStmt) JS() string {
 // DirectivePrologueStmt is a string literal at the beginning of a function or module (usually "use strict").
 type DirectivePrologueStmt struct {
 	Value []byte
+	Span
 }
 
 func (n DirectivePrologueStmt) String() string {
@@ -960,6 +1058,7 @@ func (n LabelledStmt) stmtNode()          {}
 func (n ThrowStmt) stmtNode()             {}
 func (n TryStmt) stmtNode()               {}
 func (n DebuggerStmt) stmtNode()          {}
+func (n BadStmt) stmtNode()               {}
 func (n ImportStmt) stmtNode()            {}
 func (n ExportStmt) stmtNode()            {}
 func (n DirectivePrologueStmt) stmtNode() {}
@@ -970,6 +1069,7 @@ func (n DirectivePrologueStmt) stmtNode() {}
 type PropertyName struct {
 	Literal  LiteralExpr
 	Computed IExpr // can be nil
+	Span
 }
 
 // IsSet returns true is PropertyName is not nil.
@@ -1007,6 +1107,7 @@ func (n PropertyName) JS() string {
 type BindingArray struct {
 	List []BindingElement
 	Rest IBinding // can be nil
+	Span
 }
 
 func (n BindingArray) String() string {
@@ -1048,12 +1149,13 @@ func (n BindingArray) JS() string {
 type BindingObjectItem struct {
 	Key   *PropertyName // can be nil
 	Value BindingElement
+	Span
 }
 
 func (n BindingObjectItem) String() string {
 	s := ""
 	if n.Key != nil {
-		if v, ok := n.Value.Binding.(*Var); !ok || !n.Key.IsIdent(v.Data) {
+		if v, ok := n.Value.Binding.(*Var); !ok || !n.Key.IsIdent(v.Name()) {
 			s += " " + n.Key.String() + ":"
 		}
 	}
@@ -1064,7 +1166,7 @@ func (n BindingObjectItem) String() string {
 func (n BindingObjectItem) JS() string {
 	s := ""
 	if n.Key != nil {
-		if v, ok := n.Value.Binding.(*Var); !ok || !n.Key.IsIdent(v.Data) {
+		if v, ok := n.Value.Binding.(*Var); !ok || !n.Key.IsIdent(v.Name()) {
 			s += " " + n.Key.JS() + ":"
 		}
 	}
@@ -1075,6 +1177,7 @@ func (n BindingObjectItem) JS() string {
 type BindingObject struct {
 	List []BindingObjectItem
 	Rest *Var // can be nil
+	Span
 }
 
 func (n BindingObject) String() string {
@@ -1084,7 +1187,7 @@ func (n BindingObject) String() string {
 			s += ","
 		}
 		if item.Key != nil {
-			if v, ok := item.Value.Binding.(*Var); !ok || !item.Key.IsIdent(v.Data) {
+			if v, ok := item.Value.Binding.(*Var); !ok || !item.Key.IsIdent(v.Name()) {
 				s += " " + item.Key.String() + ":"
 			}
 		}
@@ -1107,7 +1210,7 @@ func (n BindingObject) JS() string {
 			s += ","
 		}
 		if item.Key != nil {
-			if v, ok := item.Value.Binding.(*Var); !ok || !item.Key.IsIdent(v.Data) {
+			if v, ok := item.Value.Binding.(*Var); !ok || !item.Key.IsIdent(v.Name()) {
 				s += " " + item.Key.JS() + ":"
 			}
 		}
@@ -1126,6 +1229,7 @@ func (n BindingObject) JS() string {
 type BindingElement struct {
 	Binding IBinding // can be nil (in case of ellision)
 	Default IExpr    // can be nil
+	Span
 }
 
 func (n BindingElement) String() string {
@@ -1163,6 +1267,7 @@ type VarDecl struct {
 	List             []BindingElement
 	Scope            *Scope
 	InFor, InForInOf bool
+	Span
 }
 
 func (n VarDecl) String() string {
@@ -1189,6 +1294,7 @@ func (n VarDecl) JS() string {
 type Params struct {
 	List []BindingElement
 	Rest IBinding // can be nil
+	Span
 }
 
 func (n Params) String() string {
@@ -1230,9 +1336,11 @@ func (n Params) JS() string {
 type FuncDecl struct {
 	Async     bool
 	Generator bool
+	Strict    bool // the function is strict mode code
 	Name      *Var // can be nil
 	Params    Params
 	Body      BlockStmt
+	Span
 }
 
 func (n FuncDecl) String() string {
@@ -1270,14 +1378,17 @@ func (n FuncDecl) JS() string {
 
 // MethodDecl is a method definition in a class declaration.
 type MethodDecl struct {
-	Static    bool
-	Async     bool
-	Generator bool
-	Get       bool
-	Set       bool
-	Name      PropertyName
-	Params    Params
-	Body      BlockStmt
+	Decorators []IExpr
+	Static     bool
+	Async      bool
+	Generator  bool
+	Get        bool
+	Set        bool
+	Strict     bool // the method is strict mode code, which is always the case in classes
+	Name       PropertyName
+	Params     Params
+	Body       BlockStmt
+	Span
 }
 
 func (n MethodDecl) String() string {
@@ -1298,7 +1409,7 @@ func (n MethodDecl) String() string {
 		s += " set"
 	}
 	s += " " + n.Name.String() + " " + n.Params.String() + " " + n.Body.String()
-	return "Method(" + s[1:] + ")"
+	return "Method(" + decoratorsString(n.Decorators) + s[1:] + ")"
 }
 
 // JS converts the node back to valid JavaScript
@@ -1320,21 +1431,27 @@ func (n MethodDecl) JS() string {
 		s += " set"
 	}
 	s += " " + n.Name.JS() + " " + n.Params.JS() + " " + n.Body.JS()
-	return s[1:]
+	return decoratorsJS(n.Decorators) + s[1:]
 }
 
 // Field is a field definition in a class declaration.
 type Field struct {
-	Static bool
-	Name   PropertyName
-	Init   IExpr
+	Decorators []IExpr
+	Static     bool
+	Accessor   bool // auto-accessor declared with the accessor keyword
+	Name       PropertyName
+	Init       IExpr
+	Span
 }
 
 func (n Field) String() string {
-	s := "Field("
+	s := "Field(" + decoratorsString(n.Decorators)
 	if n.Static {
 		s += "static "
 	}
+	if n.Accessor {
+		s += "accessor "
+	}
 	s += n.Name.String()
 	if n.Init != nil {
 		s += " = " + n.Init.String()
@@ -1344,10 +1461,13 @@ func (n Field) String() string {
 
 // JS converts the node back to valid JavaScript
 func (n Field) JS() string {
-	s := ""
+	s := decoratorsJS(n.Decorators)
 	if n.Static {
 		s += "static "
 	}
+	if n.Accessor {
+		s += "accessor "
+	}
 	s += n.Name.String()
 	if n.Init != nil {
 		s += " = " + n.Init.JS()
@@ -1360,6 +1480,7 @@ type ClassElement struct {
 	StaticBlock *BlockStmt  // can be nil
 	Method      *MethodDecl // can be nil
 	Field
+	Span
 }
 
 func (n ClassElement) String() string {
@@ -1383,13 +1504,15 @@ func (n ClassElement) JS() string {
 
 // ClassDecl is a class declaration.
 type ClassDecl struct {
-	Name    *Var  // can be nil
-	Extends IExpr // can be nil
-	List    []ClassElement
+	Decorators []IExpr
+	Name       *Var  // can be nil
+	Extends    IExpr // can be nil
+	List       []ClassElement
+	Span
 }
 
 func (n ClassDecl) String() string {
-	s := "Decl(class"
+	s := "Decl(" + decoratorsString(n.Decorators) + "class"
 	if n.Name != nil {
 		s += " " + string(n.Name.Data)
 	}
@@ -1404,7 +1527,7 @@ func (n ClassDecl) String() string {
 
 // JS converts the node back to valid JavaScript
 func (n ClassDecl) JS() string {
-	s := "class"
+	s := decoratorsJS(n.Decorators) + "class"
 	if n.Name != nil {
 		s += " " + string(n.Name.Data)
 	}
@@ -1418,6 +1541,46 @@ func (n ClassDecl) JS() string {
 	return s + "}"
 }
 
+func decoratorsString(decorators []IExpr) string {
+	s := ""
+	for _, decorator := range decorators {
+		s += "@" + decorator.String() + " "
+	}
+	return s
+}
+
+func decoratorsJS(decorators []IExpr) string {
+	s := ""
+	for _, decorator := range decorators {
+		if isDecoratorExpr(decorator) {
+			s += "@" + decorator.JS() + " "
+		} else {
+			s += "@(" + decorator.JS() + ") "
+		}
+	}
+	return s
+}
+
+// isDecoratorExpr returns true if the expression can be used as a decorator without parentheses, which is a variable or dotted name that is optionally called.
+func isDecoratorExpr(expr IExpr) bool {
+	if call, ok := expr.(*CallExpr); ok && !call.Optional {
+		expr = call.X
+	}
+	for {
+		switch n := expr.(type) {
+		case *Var, *GroupExpr:
+			return true
+		case *DotExpr:
+			if n.Optional {
+				return false
+			}
+			expr = n.X
+		default:
+			return false
+		}
+	}
+}
+
 func (n VarDecl) stmtNode()   {}
 func (n FuncDecl) stmtNode()  {}
 func (n ClassDecl) stmtNode() {}
@@ -1433,6 +1596,7 @@ func (n MethodDecl) exprNode() {} // not a real IExpr, used for ObjectExpression
 type LiteralExpr struct {
 	TokenType
 	Data []byte
+	Span
 }
 
 func (n LiteralExpr) String() string {
@@ -1467,6 +1631,7 @@ func (n LiteralExpr) JSON(buf *bytes.Buffer) error {
 type Element struct {
 	Value  IExpr // can be nil
 	Spread bool
+	Span
 }
 
 func (n Element) String() string {
@@ -1495,6 +1660,7 @@ func (n Element) JS() string {
 // ArrayExpr is an array literal.
 type ArrayExpr struct {
 	List []Element
+	Span
 }
 
 func (n ArrayExpr) String() string {
@@ -1565,12 +1731,13 @@ type Property struct {
 	Spread bool
 	Value  IExpr
 	Init   IExpr // can be nil
+	Span
 }
 
 func (n Property) String() string {
 	s := ""
 	if n.Name != nil {
-		if v, ok := n.Value.(*Var); !ok || !n.Name.IsIdent(v.Data) {
+		if v, ok := n.Value.(*Var); !ok || !n.Name.IsIdent(v.Name()) {
 			s += n.Name.String() + ": "
 		}
 	} else if n.Spread {
@@ -1587,7 +1754,7 @@ func (n Property) String() string {
 func (n Property) JS() string {
 	s := ""
 	if n.Name != nil {
-		if v, ok := n.Value.(*Var); !ok || !n.Name.IsIdent(v.Data) {
+		if v, ok := n.Value.(*Var); !ok || !n.Name.IsIdent(v.Name()) {
 			s += n.Name.JS() + ": "
 		}
 	} else if n.Spread {
@@ -1625,6 +1792,7 @@ func (n Property) JSON(buf *bytes.Buffer) error {
 // ObjectExpr is an object literal.
 type ObjectExpr struct {
 	List []Property
+	Span
 }
 
 func (n ObjectExpr) String() string {
@@ -1669,6 +1837,7 @@ func (n ObjectExpr) JSON(buf *bytes.Buffer) error {
 type TemplatePart struct {
 	Value []byte
 	Expr  IExpr
+	Span
 }
 
 func (n TemplatePart) String() string {
@@ -1687,6 +1856,7 @@ type TemplateExpr struct {
 	Tail     []byte
 	Prec     OpPrec
 	Optional bool
+	Span
 }
 
 func (n TemplateExpr) String() string {
@@ -1721,6 +1891,7 @@ func (n TemplateExpr) JS() string {
 // GroupExpr is a parenthesized expression.
 type GroupExpr struct {
 	X IExpr
+	Span
 }
 
 func (n GroupExpr) String() string {
@@ -1738,6 +1909,7 @@ type IndexExpr struct {
 	Y        IExpr
 	Prec     OpPrec
 	Optional bool
+	Span
 }
 
 func (n IndexExpr) String() string {
@@ -1761,6 +1933,7 @@ type DotExpr struct {
 	Y        LiteralExpr
 	Prec     OpPrec
 	Optional bool
+	Span
 }
 
 func (n DotExpr) String() string {
@@ -1780,6 +1953,7 @@ func (n DotExpr) JS() string {
 
 // NewTargetExpr is a new target meta property.
 type NewTargetExpr struct {
+	Span
 }
 
 func (n NewTargetExpr) String() string {
@@ -1793,6 +1967,7 @@ func (n NewTargetExpr) JS() string {
 
 // ImportMetaExpr is a import meta meta property.
 type ImportMetaExpr struct {
+	Span
 }
 
 func (n ImportMetaExpr) String() string {
@@ -1807,6 +1982,7 @@ func (n ImportMetaExpr) JS() string {
 type Arg struct {
 	Value IExpr
 	Rest  bool
+	Span
 }
 
 func (n Arg) String() string {
@@ -1829,6 +2005,7 @@ func (n Arg) JS() string {
 // Args is a list of arguments as used by new and call expressions.
 type Args struct {
 	List []Arg
+	Span
 }
 
 func (n Args) String() string {
@@ -1858,6 +2035,7 @@ func (n Args) JS() string {
 type NewExpr struct {
 	X    IExpr
 	Args *Args // can be nil
+	Span
 }
 
 func (n NewExpr) String() string {
@@ -1882,6 +2060,7 @@ type CallExpr struct {
 	X        IExpr
 	Args     Args
 	Optional bool
+	Span
 }
 
 func (n CallExpr) String() string {
@@ -1903,6 +2082,7 @@ func (n CallExpr) JS() string {
 type UnaryExpr struct {
 	Op TokenType
 	X  IExpr
+	Span
 }
 
 func (n UnaryExpr) String() string {
@@ -1938,6 +2118,7 @@ func (n UnaryExpr) JSON(buf *bytes.Buffer) error {
 type BinaryExpr struct {
 	Op   TokenType
 	X, Y IExpr
+	Span
 }
 
 func (n BinaryExpr) String() string {
@@ -1955,6 +2136,7 @@ func (n BinaryExpr) JS() string {
 // CondExpr is a conditional expression.
 type CondExpr struct {
 	Cond, X, Y IExpr
+	Span
 }
 
 func (n CondExpr) String() string {
@@ -1970,6 +2152,7 @@ func (n CondExpr) JS() string {
 type YieldExpr struct {
 	Generator bool
 	X         IExpr // can be nil
+	Span
 }
 
 func (n YieldExpr) String() string {
@@ -1998,8 +2181,10 @@ func (n YieldExpr) JS() string {
 // ArrowFunc is an (async) arrow function.
 type ArrowFunc struct {
 	Async  bool
+	Strict bool // the function is strict mode code
 	Params Params
 	Body   BlockStmt
+	Span
 }
 
 func (n ArrowFunc) String() string {
@@ -2022,6 +2207,7 @@ func (n ArrowFunc) JS() string {
 // CommaExpr is a series of comma expressions.
 type CommaExpr struct {
 	List []IExpr
+	Span
 }
 
 func (n CommaExpr) String() string {
@@ -2047,6 +2233,149 @@ func (n CommaExpr) JS() string {
 	return s
 }
 
+////////////////////////////////////////////////////////////////
+
+// JSXElement is a JSX element, or a fragment when Name is nil.
+type JSXElement struct {
+	Name        IExpr // nil for fragments, Var or DotExpr for components, JSXName for intrinsic elements such as div
+	Attrs       []JSXAttribute
+	Children    []IExpr // JSXText, JSXExpr, or JSXElement
+	SelfClosing bool
+	Span
+}
+
+func (n JSXElement) String() string {
+	s := "<"
+	if n.Name != nil {
+		s += n.Name.String()
+	}
+	for _, item := range n.Attrs {
+		s += " " + item.String()
+	}
+	if n.SelfClosing {
+		return s + " />"
+	}
+	s += ">"
+	for _, item := range n.Children {
+		s += item.String()
+	}
+	s += "</"
+	if n.Name != nil {
+		s += n.Name.String()
+	}
+	return s + ">"
+}
+
+// JS converts the node back to valid JavaScript with JSX
+func (n JSXElement) JS() string {
+	s := "<"
+	if n.Name != nil {
+		s += n.Name.JS()
+	}
+	for _, item := range n.Attrs {
+		s += " " + item.JS()
+	}
+	if n.SelfClosing {
+		return s + " />"
+	}
+	s += ">"
+	for _, item := range n.Children {
+		s += item.JS()
+	}
+	s += "</"
+	if n.Name != nil {
+		s += n.Name.JS()
+	}
+	return s + ">"
+}
+
+// JSXName is the name of an intrinsic JSX element or of a namespaced element, as in  div,  my-element, or  svg:rect.
+type JSXName struct {
+	Data []byte
+	Span
+}
+
+func (n JSXName) String() string {
+	return string(n.Data)
+}
+
+// JS converts the node back to valid JavaScript with JSX
+func (n JSXName) JS() string {
+	return string(n.Data)
+}
+
+// JSXAttribute is an attribute of a JSX element, as in  name="value",  name={value},  name, or the spread attribute  {...value}.
+type JSXAttribute struct {
+	Name   []byte // can be namespaced, as in xlink:href, nil for spread attributes
+	Value  IExpr  // can be nil, LiteralExpr for strings, JSXExpr, or JSXElement
+	Spread bool
+	Span
+}
+
+func (n JSXAttribute) String() string {
+	if n.Spread {
+		return "{..." + n.Value.String() + "}"
+	} else if n.Value == nil {
+		return string(n.Name)
+	}
+	return string(n.Name) + "=" + n.Value.String()
+}
+
+// JS converts the node back to valid JavaScript with JSX
+func (n JSXAttribute) JS() string {
+	if n.Spread {
+		return "{..." + n.Value.JS() + "}"
+	} else if n.Value == nil {
+		return string(n.Name)
+	}
+	return string(n.Name) + "=" + n.Value.JS()
+}
+
+// JSXExpr is an expression in braces as the value of a JSX attribute or as a child of a JSX element, where X can be nil for an empty expression such as  {/* comment */}.
+type JSXExpr struct {
+	X      IExpr // can be nil
+	Spread bool
+	Span
+}
+
+func (n JSXExpr) String() string {
+	s := "{"
+	if n.Spread {
+		s += "..."
+	}
+	if n.X != nil {
+		s += n.X.String()
+	}
+	return s + "}"
+}
+
+// JS converts the node back to valid JavaScript with JSX
+func (n JSXExpr) JS() string {
+	s := "{"
+	if n.Spread {
+		s += "..."
+	}
+	if n.X != nil {
+		s += n.X.JS()
+	}
+	return s + "}"
+}
+
+// JSXText is the text in between JSX tags.
+type JSXText struct {
+	Data []byte
+	Span
+}
+
+func (n JSXText) String() string {
+	return string(n.Data)
+}
+
+// JS converts the node back to valid JavaScript with JSX
+func (n JSXText) JS() string {
+	return string(n.Data)
+}
+
 func (v *Var) exprNode()           {}
 func (n LiteralExpr) exprNode()    {}
 func (n ArrayExpr) exprNode()      {}
@@ -2065,3 +2394,7 @@ func (n CondExpr) exprNode()       {}
 func (n YieldExpr) exprNode()      {}
 func (n ArrowFunc) exprNode()      {}
 func (n CommaExpr) exprNode()      {}
+func (n JSXElement) exprNode()     {}
+func (n JSXName) exprNode()        {}
+func (n JSXExpr) exprNode()        {}
+func (n JSXText) exprNode()        {}
@@ -0,0 +1,689 @@
+// Package cfg builds control-flow graphs of the bodies of JavaScript functions as parsed by the js package, for analyses such as finding unreachable code, switch clauses that fall through, and functions that do not always return a value.
+package cfg
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/tdewolff/parse/v2/js"
+)
+
+// EdgeKind is the kind of a control-flow edge.
+type EdgeKind uint8
+
+// EdgeKind values.
+const (
+	Normal      EdgeKind = iota // unconditional jump or sequential flow
+	True                        // the condition is truthy, or a case clause matches
+	False                       // the condition is falsy, or a case clause does not match
+	Fallthrough                 // from the end of the statements of a case clause to those of the next clause
+	Exception                   // an exception to a catch clause, a finally block, or out of the function
+)
+
+func (kind EdgeKind) String() string {
+	switch kind {
+	case Normal:
+		return "Normal"
+	case True:
+		return "True"
+	case False:
+		return "False"
+	case Fallthrough:
+		return "Fallthrough"
+	case Exception:
+		return "Exception"
+	}
+	return "Invalid(" + strconv.Itoa(int(kind)) + ")"
+}
+
+// Edge is a control-flow edge between two blocks.
+type Edge struct {
+	Kind     EdgeKind
+	From, To *Block
+}
+
+// Block is a basic block, which is a list of nodes that are executed in order. Nodes are statements, expressions, and binding elements, where a node stands for the execution of all its children except for those that precede it in the same or another block. Only logical expressions (&&, ||, ??, and their assignments) and conditional expressions are split into their operands, other expressions and statements without such an expression are a single node. The conditions of if statements, loops, and case clauses end a block, while the compound statements themselves are not nodes. The Init of a for-in or for-of loop is the first node of its body, as it is assigned at the start of each iteration. Nested functions and classes are single nodes.
+type Block struct {
+	Index     int // index in Graph.Blocks
+	Nodes     []js.INode
+	Succs     []*Edge
+	Preds     []*Edge
+	Reachable bool // the block is reachable from the entry block
+}
+
+// String returns the nodes of the block and the indices of its successors.
+func (b *Block) String() string {
+	sb := strings.Builder{}
+	sb.WriteString(strconv.Itoa(b.Index) + " [")
+	for i, n := range b.Nodes {
+		if i != 0 {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(n.JS())
+	}
+	sb.WriteString("]")
+	for i, edge := range b.Succs {
+		if i == 0 {
+			sb.WriteString(" ->")
+		} else {
+			sb.WriteString(",")
+		}
+		sb.WriteString(" " + strconv.Itoa(edge.To.Index))
+		if edge.Kind != Normal {
+			sb.WriteString(" " + edge.Kind.String())
+		}
+	}
+	return sb.String()
+}
+
+// Graph is the control-flow graph of a function body. Exceptions are only modelled for throw statements and inside try statements, where every block may throw an exception to the catch clause or finally block. A finally block continues to each of the targets of the return, break, and continue statements and of the normal completion that pass through it, and to the enclosing exception handler when it is entered by an exception.
+type Graph struct {
+	Entry  *Block
+	Exit   *Block   // reached by return statements, by the end of the body, and by uncaught exceptions
+	Blocks []*Block // in the order in which they start in the source, with Entry first and Exit last, excluding unreachable blocks without nodes
+}
+
+// New returns the control-flow graph of the body of a *js.FuncDecl, *js.ArrowFunc, or *js.MethodDecl, including its parameters, or of the statements of a *js.AST. It returns nil for other nodes.
+func New(n js.INode) *Graph {
+	var params *js.Params
+	var body *js.BlockStmt
+	switch n := n.(type) {
+	case *js.FuncDecl:
+		params, body = &n.Params, &n.Body
+	case *js.ArrowFunc:
+		params, body = &n.Params, &n.Body
+	case *js.MethodDecl:
+		params, body = &n.Params, &n.Body
+	case *js.AST:
+		body = &n.BlockStmt
+	default:
+		return nil
+	}
+
+	g := &Graph{}
+	b := &builder{g: g}
+	g.Entry = b.newBlock()
+	g.Exit = b.newBlock()
+	b.enter(g.Entry)
+	if params != nil {
+		b.node(params)
+	}
+	b.stmt(body)
+	b.edge(b.cur, g.Exit, Normal)
+	g.finish()
+	return g
+}
+
+// String returns the blocks of the graph, one per line, with unreachable blocks marked.
+func (g *Graph) String() string {
+	sb := strings.Builder{}
+	for _, b := range g.Blocks {
+		sb.WriteString(b.String())
+		if !b.Reachable {
+			sb.WriteString(" unreachable")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// finish marks the reachable blocks, removes unreachable blocks without nodes, and numbers the blocks.
+func (g *Graph) finish() {
+	stack := []*Block{g.Entry}
+	g.Entry.Reachable = true
+	for 0 < len(stack) {
+		b := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, edge := range b.Succs {
+			if !edge.To.Reachable {
+				edge.To.Reachable = true
+				stack = append(stack, edge.To)
+			}
+		}
+	}
+
+	blocks := g.Blocks[:0]
+	for _, b := range g.Blocks {
+		if b.Reachable || len(b.Nodes) != 0 {
+			blocks = append(blocks, b)
+			continue
+		}
+		for _, edge := range b.Succs {
+			edge.To.Preds = removeEdge(edge.To.Preds, edge)
+		}
+		for _, edge := range b.Preds {
+			edge.From.Succs = removeEdge(edge.From.Succs, edge)
+		}
+	}
+	g.Blocks = append(blocks, g.Exit)
+	for i, b := range g.Blocks {
+		b.Index = i
+	}
+}
+
+func removeEdge(edges []*Edge, edge *Edge) []*Edge {
+	for i, e := range edges {
+		if e == edge {
+			return append(edges[:i], edges[i+1:]...)
+		}
+	}
+	return edges
+}
+
+////////////////////////////////////////////////////////////////
+
+// context is a statement that is a target of break or continue statements, or a try statement.
+type context struct {
+	labels   [][]byte
+	breakTo  *Block // nil if the statement cannot be broken out of
+	contTo   *Block // nil for statements other than loops
+	implicit bool   // loops and switch statements, which are the targets of break without label
+
+	handler *Block // catch clause or finally block of a try statement that handles exceptions
+	finally *Block // finally block of a try statement
+	jumps   []jump // jumps that pass through the finally block
+}
+
+// jump is a jump to a target block out of the contexts above depth.
+type jump struct {
+	to    *Block
+	depth int
+}
+
+type builder struct {
+	g      *Graph
+	cur    *Block
+	ctxs   []context
+	labels [][]byte // labels of the next statement
+}
+
+func (b *builder) newBlock() *Block {
+	return &Block{Index: -1}
+}
+
+// enter makes the block the current block, which adds it to the graph when it is entered for the first time.
+func (b *builder) enter(block *Block) {
+	if block.Index == -1 {
+		block.Index = len(b.g.Blocks)
+		b.g.Blocks = append(b.g.Blocks, block)
+	}
+	b.cur = block
+}
+
+func (b *builder) edge(from, to *Block, kind EdgeKind) {
+	for _, edge := range from.Succs {
+		if edge.To == to && edge.Kind == kind {
+			return
+		}
+	}
+	edge := &Edge{kind, from, to}
+	from.Succs = append(from.Succs, edge)
+	to.Preds = append(to.Preds, edge)
+}
+
+// add adds a node to the current block. Blocks inside a try statement may throw an exception to its handler.
+func (b *builder) add(n js.INode) {
+	if len(b.cur.Nodes) == 0 {
+		if handler := b.handler(); handler != nil {
+			b.edge(b.cur, handler, Exception)
+		}
+	}
+	b.cur.Nodes = append(b.cur.Nodes, n)
+}
+
+// handler returns the block that handles exceptions, or nil if exceptions leave the function.
+func (b *builder) handler() *Block {
+	for i := len(b.ctxs) - 1; 0 <= i; i-- {
+		if b.ctxs[i].handler != nil {
+			return b.ctxs[i].handler
+		}
+	}
+	return nil
+}
+
+// throw adds an exception edge to the handler or to the exit block.
+func (b *builder) throw() {
+	handler := b.handler()
+	if handler == nil {
+		handler = b.g.Exit
+	}
+	b.edge(b.cur, handler, Exception)
+}
+
+// jump adds an edge from the current block to a target out of the contexts above depth, passing through the finally blocks of try statements in between.
+func (b *builder) jump(to *Block, depth int) {
+	for i := len(b.ctxs) - 1; depth < i; i-- {
+		if ctx := &b.ctxs[i]; ctx.finally != nil {
+			b.edge(b.cur, ctx.finally, Normal)
+			if b.live(b.cur) {
+				ctx.jumps = append(ctx.jumps, jump{to, depth})
+			}
+			return
+		}
+	}
+	b.edge(b.cur, to, Normal)
+}
+
+// live returns true if the block may be reachable, which is the case for the entry block and for blocks with predecessors. Blocks that follow a jump have no predecessors.
+func (b *builder) live(block *Block) bool {
+	return block == b.g.Entry || len(block.Preds) != 0
+}
+
+// unreachable continues with a new block after a jump.
+func (b *builder) unreachable() {
+	b.enter(b.newBlock())
+}
+
+func (b *builder) push(ctx context) {
+	b.ctxs = append(b.ctxs, ctx)
+}
+
+func (b *builder) pop() context {
+	ctx := b.ctxs[len(b.ctxs)-1]
+	b.ctxs = b.ctxs[:len(b.ctxs)-1]
+	return ctx
+}
+
+// loop pushes the context of a loop or switch statement with the labels that precede it.
+func (b *builder) loop(breakTo, contTo *Block) {
+	b.push(context{labels: b.labels, breakTo: breakTo, contTo: contTo, implicit: true})
+	b.labels = nil
+}
+
+// target returns the depth of the context that is the target of a break or continue statement, or -1 if there is none.
+func (b *builder) target(n *js.BranchStmt) int {
+	for i := len(b.ctxs) - 1; 0 <= i; i-- {
+		ctx := &b.ctxs[i]
+		if n.Type == js.ContinueToken && ctx.contTo == nil || n.Type == js.BreakToken && ctx.breakTo == nil {
+			continue
+		} else if n.Label == nil {
+			if ctx.implicit {
+				return i
+			}
+			continue
+		}
+		for _, label := range ctx.labels {
+			if bytes.Equal(label, n.Label) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (b *builder) stmt(n js.IStmt) {
+	switch n := n.(type) {
+	case nil:
+	case *js.BlockStmt:
+		if n == nil {
+			return
+		}
+		for _, item := range n.List {
+			b.stmt(item)
+		}
+	case *js.EmptyStmt:
+	case *js.IfStmt:
+		b.node(n.Cond)
+		cond := b.cur
+		body := b.newBlock()
+		b.edge(cond, body, True)
+		b.enter(body)
+		b.stmt(n.Body)
+		after := b.newBlock()
+		b.edge(b.cur, after, Normal)
+		if n.Else != nil {
+			elseBlock := b.newBlock()
+			b.edge(cond, elseBlock, False)
+			b.enter(elseBlock)
+			b.stmt(n.Else)
+			b.edge(b.cur, after, Normal)
+		} else {
+			b.edge(cond, after, False)
+		}
+		b.enter(after)
+	case *js.WhileStmt:
+		head := b.newBlock()
+		b.edge(b.cur, head, Normal)
+		b.enter(head)
+		b.node(n.Cond)
+		body, exit := b.newBlock(), b.newBlock()
+		b.edge(b.cur, body, True)
+		b.edge(b.cur, exit, False)
+		b.loop(exit, head)
+		b.enter(body)
+		b.stmt(n.Body)
+		b.edge(b.cur, head, Normal)
+		b.pop()
+		b.enter(exit)
+	case *js.DoWhileStmt:
+		body, cond, exit := b.newBlock(), b.newBlock(), b.newBlock()
+		b.edge(b.cur, body, Normal)
+		b.loop(exit, cond)
+		b.enter(body)
+		b.stmt(n.Body)
+		b.edge(b.cur, cond, Normal)
+		b.pop()
+		b.enter(cond)
+		b.node(n.Cond)
+		b.edge(b.cur, body, True)
+		b.edge(b.cur, exit, False)
+		b.enter(exit)
+	case *js.ForStmt:
+		if varDecl, ok := n.Init.(*js.VarDecl); !ok || len(varDecl.List) != 0 {
+			b.node(n.Init)
+		}
+		body, exit := b.newBlock(), b.newBlock()
+		head := body
+		if n.Cond != nil {
+			head = b.newBlock()
+			b.edge(b.cur, head, Normal)
+			b.enter(head)
+			b.node(n.Cond)
+			b.edge(b.cur, body, True)
+			b.edge(b.cur, exit, False)
+		} else {
+			b.edge(b.cur, body, Normal)
+		}
+		post := head
+		if n.Post != nil {
+			post = b.newBlock()
+		}
+		b.loop(exit, post)
+		b.enter(body)
+		b.stmt(n.Body)
+		b.edge(b.cur, post, Normal)
+		b.pop()
+		if n.Post != nil {
+			b.enter(post)
+			b.node(n.Post)
+			b.edge(b.cur, head, Normal)
+		}
+		b.enter(exit)
+	case *js.ForInStmt:
+		b.node(n.Value)
+		b.forInOf(n.Init, n.Body)
+	case *js.ForOfStmt:
+		b.node(n.Value)
+		b.forInOf(n.Init, n.Body)
+	case *js.SwitchStmt:
+		b.switchStmt(n)
+	case *js.LabelledStmt:
+		b.labels = append(b.labels, n.Label)
+		switch n.Value.(type) {
+		case *js.WhileStmt, *js.DoWhileStmt, *js.ForStmt, *js.ForInStmt, *js.ForOfStmt, *js.SwitchStmt, *js.LabelledStmt:
+			b.stmt(n.Value)
+		default:
+			after := b.newBlock()
+			b.push(context{labels: b.labels, breakTo: after})
+			b.labels = nil
+			b.stmt(n.Value)
+			b.pop()
+			b.edge(b.cur, after, Normal)
+			b.enter(after)
+		}
+	case *js.BranchStmt:
+		b.add(n)
+		if depth := b.target(n); depth != -1 {
+			ctx := &b.ctxs[depth]
+			if n.Type == js.ContinueToken {
+				b.jump(ctx.contTo, depth)
+			} else {
+				b.jump(ctx.breakTo, depth)
+			}
+		}
+		b.unreachable()
+	case *js.ReturnStmt:
+		b.node(n)
+		b.jump(b.g.Exit, -1)
+		b.unreachable()
+	case *js.ThrowStmt:
+		b.node(n)
+		b.throw()
+		b.unreachable()
+	case *js.TryStmt:
+		b.tryStmt(n)
+	case *js.WithStmt:
+		b.node(n.Cond)
+		b.stmt(n.Body)
+	default:
+		b.node(n)
+	}
+}
+
+func (b *builder) forInOf(init js.IExpr, body *js.BlockStmt) {
+	head, bodyBlock, exit := b.newBlock(), b.newBlock(), b.newBlock()
+	b.edge(b.cur, head, Normal)
+	b.enter(head)
+	b.edge(head, bodyBlock, True)
+	b.edge(head, exit, False)
+	b.loop(exit, head)
+	b.enter(bodyBlock)
+	b.node(init)
+	b.stmt(body)
+	b.edge(b.cur, head, Normal)
+	b.pop()
+	b.enter(exit)
+}
+
+func (b *builder) switchStmt(n *js.SwitchStmt) {
+	b.node(n.Init)
+	exit := b.newBlock()
+	bodies := make([]*Block, len(n.List))
+	for i := range bodies {
+		bodies[i] = b.newBlock()
+	}
+	b.loop(exit, nil)
+
+	// test the case clauses in order, and continue with the default clause if none matches
+	kind := Normal
+	def := exit
+	for i, clause := range n.List {
+		if clause.Cond == nil {
+			def = bodies[i]
+			continue
+		} else if kind == False {
+			test := b.newBlock()
+			b.edge(b.cur, test, False)
+			b.enter(test)
+		}
+		b.node(clause.Cond)
+		b.edge(b.cur, bodies[i], True)
+		kind = False
+	}
+	b.edge(b.cur, def, kind)
+
+	for i, clause := range n.List {
+		if i != 0 {
+			kind := Normal
+			if len(n.List[i-1].List) != 0 {
+				kind = Fallthrough
+			}
+			b.edge(b.cur, bodies[i], kind)
+		}
+		b.enter(bodies[i])
+		for _, item := range clause.List {
+			b.stmt(item)
+		}
+	}
+	b.edge(b.cur, exit, Normal)
+	b.pop()
+	b.enter(exit)
+}
+
+func (b *builder) tryStmt(n *js.TryStmt) {
+	var catch, finally *Block
+	if n.Catch != nil {
+		catch = b.newBlock()
+	}
+	if n.Finally != nil {
+		finally = b.newBlock()
+	}
+	handler := catch
+	if handler == nil {
+		handler = finally
+	}
+	after := b.newBlock()
+	depth := len(b.ctxs)
+	b.push(context{handler: handler, finally: finally})
+
+	body := b.newBlock()
+	b.edge(b.cur, body, Normal)
+	b.enter(body)
+	b.stmt(n.Body)
+	b.jump(after, depth-1)
+
+	if n.Catch != nil {
+		b.ctxs[depth].handler = finally
+		b.enter(catch)
+		if n.Binding != nil {
+			b.node(n.Binding)
+		}
+		b.stmt(n.Catch)
+		b.jump(after, depth-1)
+	}
+
+	ctx := b.pop()
+	if n.Finally != nil {
+		b.enter(finally)
+		b.stmt(n.Finally)
+		end := b.cur
+		for _, jump := range ctx.jumps {
+			b.cur = end
+			b.jump(jump.to, jump.depth)
+		}
+		for _, edge := range finally.Preds {
+			if edge.Kind == Exception && b.live(edge.From) {
+				b.cur = end
+				b.throw()
+				break
+			}
+		}
+	}
+	b.enter(after)
+}
+
+// node adds a node to the current block, after splitting off the operands of the logical and conditional expressions that it contains into their own blocks.
+func (b *builder) node(n js.INode) {
+	switch n := n.(type) {
+	case nil:
+		return
+	case *js.BinaryExpr:
+		var yKind, skipKind EdgeKind
+		switch n.Op {
+		case js.AndToken, js.AndEqToken:
+			yKind, skipKind = True, False
+		case js.OrToken, js.OrEqToken:
+			yKind, skipKind = False, True
+		case js.NullishToken, js.NullishEqToken:
+			yKind, skipKind = Normal, Normal
+		default:
+			b.split(n)
+			return
+		}
+		b.node(n.X)
+		x, y, after := b.cur, b.newBlock(), b.newBlock()
+		b.edge(x, y, yKind)
+		b.edge(x, after, skipKind)
+		b.enter(y)
+		b.node(n.Y)
+		b.edge(b.cur, after, Normal)
+		b.enter(after)
+		b.add(n)
+	case *js.CondExpr:
+		b.node(n.Cond)
+		cond, x, y, after := b.cur, b.newBlock(), b.newBlock(), b.newBlock()
+		b.edge(cond, x, True)
+		b.edge(cond, y, False)
+		b.enter(x)
+		b.node(n.X)
+		b.edge(b.cur, after, Normal)
+		b.enter(y)
+		b.node(n.Y)
+		b.edge(b.cur, after, Normal)
+		b.enter(after)
+		b.add(n)
+	case *js.BindingElement:
+		if !hasBranch(n) {
+			b.add(n)
+			return
+		}
+		b.node(n.Default)
+		if _, ok := n.Binding.(*js.Var); !ok {
+			b.node(n.Binding)
+		}
+		b.add(n)
+	case *js.PropertyName:
+		if n.IsComputed() {
+			b.node(n.Computed)
+		}
+	default:
+		b.split(n)
+	}
+}
+
+// split adds a node without splitting it if it has no logical or conditional expressions, or otherwise adds its children followed by the node itself.
+func (b *builder) split(n js.INode) {
+	_, isExpr := n.(js.IExpr)
+	_, isStmt := n.(js.IStmt)
+	if (isExpr || isStmt) && !hasBranch(n) {
+		b.add(n)
+		return
+	}
+	c := &childVisitor{}
+	js.Walk(c, n)
+	for _, child := range c.children {
+		b.node(child)
+	}
+	if isExpr || isStmt {
+		b.add(n)
+	}
+}
+
+// childVisitor collects the children of a node.
+type childVisitor struct {
+	entered  bool
+	children []js.INode
+}
+
+func (c *childVisitor) Enter(n js.INode) js.IVisitor {
+	if !c.entered {
+		c.entered = true
+		return c
+	}
+	c.children = append(c.children, n)
+	return nil
+}
+
+func (c *childVisitor) Exit(n js.INode) {}
+
+// hasBranch returns true if the node contains a logical or conditional expression, excluding those in nested functions and classes.
+func hasBranch(n js.INode) bool {
+	v := &branchVisitor{}
+	js.Walk(v, n)
+	return v.found
+}
+
+type branchVisitor struct {
+	found bool
+}
+
+func (v *branchVisitor) Enter(n js.INode) js.IVisitor {
+	switch n := n.(type) {
+	case *js.FuncDecl, *js.ArrowFunc, *js.MethodDecl, *js.ClassDecl:
+		return nil
+	case *js.BinaryExpr:
+		switch n.Op {
+		case js.AndToken, js.OrToken, js.NullishToken, js.AndEqToken, js.OrEqToken, js.NullishEqToken:
+			v.found = true
+		}
+	case *js.CondExpr:
+		v.found = true
+	}
+	if v.found {
+		return nil
+	}
+	return v
+}
+
+func (v *branchVisitor) Exit(n js.INode) {}
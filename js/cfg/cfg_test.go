@@ -0,0 +1,70 @@
+package cfg
+
+import (
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/js"
+	"github.com/tdewolff/test"
+)
+
+func TestGraph(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"function f(a, b = 1) { x(); return a }", "0 [a; b = 1; x(); return a] -> 1\n1 []\n"},
+		{"function f() { if (a) b(); else c(); d() }", "0 [a] -> 1 True, 2 False\n1 [b()] -> 3\n2 [c()] -> 3\n3 [d()] -> 4\n4 []\n"},
+		{"function f() { if (a) return 1 }", "0 [a] -> 1 True, 2 False\n1 [return 1] -> 3\n2 [] -> 3\n3 []\n"},
+		{"function f() { while (a) { if (b) continue; c() } }", "0 [] -> 1\n1 [a] -> 2 True, 5 False\n2 [b] -> 3 True, 4 False\n3 [continue] -> 1\n4 [c()] -> 1\n5 [] -> 6\n6 []\n"},
+		{"function f() { do a(); while (b) }", "0 [] -> 1\n1 [a()] -> 2\n2 [b] -> 1 True, 3 False\n3 [] -> 4\n4 []\n"},
+		{"function f() { for (var i = 0; i < n; i++) { if (a) break } }", "0 [var i = 0] -> 1\n1 [i < n] -> 2 True, 6 False\n2 [a] -> 3 True, 4 False\n3 [break] -> 6\n4 [] -> 5\n5 [i++] -> 1\n6 [] -> 7\n7 []\n"},
+		{"function f() { for (;;) { a() } b() }", "0 [] -> 1\n1 [a()] -> 1\n2 [b()] -> 3 unreachable\n3 [] unreachable\n"},
+		{"function f() { for (var x of a) b(x) }", "0 [a] -> 1\n1 [] -> 2 True, 3 False\n2 [var x; b(x)] -> 1\n3 [] -> 4\n4 []\n"},
+		{"function f() { switch (a) { case 1: b(); case 2: c(); break; default: d() } }", "0 [a; 1] -> 2 True, 1 False\n1 [2] -> 3 True, 4 False\n2 [b()] -> 3 Fallthrough\n3 [c(); break] -> 5\n4 [d()] -> 5\n5 [] -> 6\n6 []\n"},
+		{"function f() { switch (a) { case 1: case 2: b() } }", "0 [a; 1] -> 2 True, 1 False\n1 [2] -> 3 True, 4 False\n2 [] -> 3\n3 [b()] -> 4\n4 [] -> 5\n5 []\n"},
+		{"function f() { outer: for (;;) { for (;;) { continue outer } } }", "0 [] -> 1\n1 [] -> 2\n2 [continue outer] -> 1\n3 [] unreachable\n"},
+		{"function f() { l: { if (a) break l; b() } c() }", "0 [a] -> 1 True, 2 False\n1 [break l] -> 3\n2 [b()] -> 3\n3 [c()] -> 4\n4 []\n"},
+		{"function f() { try { a() } catch (e) { b() } c() }", "0 [] -> 1\n1 [a()] -> 2 Exception, 3\n2 [e; b()] -> 3\n3 [c()] -> 4\n4 []\n"},
+		{"function f() { try { return a() } finally { b() } c() }", "0 [] -> 1\n1 [return a()] -> 2 Exception, 2\n2 [b()] -> 4, 4 Exception\n3 [c()] -> 4 unreachable\n4 []\n"},
+		{"function f() { for (;;) { try { break } finally { a() } } b() }", "0 [] -> 1\n1 [] -> 2\n2 [break] -> 3 Exception, 3\n3 [a()] -> 4, 5 Exception\n4 [b()] -> 5\n5 []\n"},
+		{"function f() { throw a; b() }", "0 [throw a] -> 2 Exception\n1 [b()] -> 2 unreachable\n2 []\n"},
+		{"function f() { try { throw a } catch { b() } }", "0 [] -> 1\n1 [throw a] -> 2 Exception\n2 [b()] -> 3\n3 [] -> 4\n4 []\n"},
+		{"function f() { return a && b || c }", "0 [a] -> 1 True, 2 False\n1 [b] -> 2\n2 [a && b] -> 3 False, 4 True\n3 [c] -> 4\n4 [a && b || c; return a && b || c] -> 5\n5 []\n"},
+		{"function f() { x = a ? b() : c() }", "0 [x; a] -> 1 True, 2 False\n1 [b()] -> 3\n2 [c()] -> 3\n3 [a ? b() : c(); x = a ? b() : c(); x = a ? b() : c()] -> 4\n4 []\n"},
+		{"a => a ?? b", "0 [a; a] -> 1, 2\n1 [b] -> 2\n2 [a ?? b; return a ?? b] -> 3\n3 []\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := js.Parse(parse.NewInputString(tt.js), js.Options{})
+			test.Error(t, err)
+			var n js.INode = ast.List[0]
+			if stmt, ok := n.(*js.ExprStmt); ok {
+				n = stmt.Value
+			}
+			g := New(n)
+			test.String(t, g.String(), tt.expected)
+			test.T(t, g.Blocks[0], g.Entry)
+			test.T(t, g.Blocks[len(g.Blocks)-1], g.Exit)
+			for i, b := range g.Blocks {
+				test.T(t, b.Index, i)
+				for _, edge := range b.Succs {
+					test.T(t, edge.From, b)
+				}
+				for _, edge := range b.Preds {
+					test.T(t, edge.To, b)
+				}
+			}
+		})
+	}
+}
+
+func TestGraphAST(t *testing.T) {
+	ast, err := js.Parse(parse.NewInputString("a(); class A { m() { b() } }"), js.Options{})
+	test.Error(t, err)
+	test.String(t, New(ast).String(), "0 [a(); class A { m () { b(); }; }] -> 1\n1 []\n")
+	test.T(t, New(ast.List[0]), (*Graph)(nil))
+
+	method := ast.List[1].(*js.ClassDecl).List[0].Method
+	test.String(t, New(method).String(), "0 [b()] -> 1\n1 []\n")
+}
@@ -0,0 +1,813 @@
+package js
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/buffer"
+	"github.com/tdewolff/parse/v2/js/regexp"
+)
+
+// Check returns the early errors of an AST that was parsed from src, which are the errors of the static semantics that the parser does not report, such as break statements with an unknown label, return statements outside of functions, duplicate __proto__ properties, invalid assignment targets, or the restrictions of strict mode code. The strictness of the code is taken from AST.Strict and the Strict fields of the functions, which are set by the parser. The errors are sorted by their position, and nil is returned if there are none. Errors at identifiers are reported at the first occurrence of the variable when the AST was parsed with Options.NoRefs.
+func Check(ast *AST, src []byte) ErrorList {
+	return check(ast, src, false)
+}
+
+func check(ast *AST, src []byte, typescript bool) ErrorList {
+	c := &checker{
+		src:         src,
+		typescript:  typescript,
+		occurrences: newVarOccurrences(ast.refs),
+		targets:     map[INode]bool{},
+	}
+	Walk(c, ast)
+	sortErrors(c.errs)
+	return c.errs
+}
+
+// sortErrors sorts the errors by their position.
+func sortErrors(errs ErrorList) {
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Line < errs[j].Line || errs[i].Line == errs[j].Line && errs[i].Column < errs[j].Column
+	})
+}
+
+// checkContext is the context of the code being checked. A new context starts at functions, classes, class field initializers, and static blocks.
+type checkContext struct {
+	strict    bool
+	function  bool // return statements are allowed
+	newTarget bool // new.target is allowed
+	superProp bool // super properties are allowed
+	superCall bool // super calls are allowed
+	noArgs    bool // arguments is not allowed, as in class field initializers and static blocks
+
+	labels          []checkLabel
+	loops, switches int // number of enclosing iteration and switch statements
+}
+
+type checkLabel struct {
+	name []byte
+	loop bool // label of an iteration statement
+}
+
+// checkClass holds the private names that are declared in a class body.
+type checkClass struct {
+	names map[string]bool
+}
+
+type checkFrame struct {
+	scopeFrame
+	ctx *checkContext
+}
+
+type checker struct {
+	src        []byte
+	typescript bool // types have been removed, so that exports may refer to removed declarations
+	errs       ErrorList
+
+	stack       []checkFrame
+	classes     []checkClass
+	occurrences varOccurrences
+	targets     map[INode]bool // array and object literals that are destructuring assignment targets
+}
+
+func (c *checker) error(offset int, msg string) {
+	c.errs = append(c.errs, parse.NewError(buffer.NewReader(c.src), offset, msg))
+}
+
+func (c *checker) Enter(n INode) IVisitor {
+	if len(c.stack) == 0 {
+		ast := n.(*AST)
+		ctx := &checkContext{strict: ast.Strict}
+		c.stack = append(c.stack, checkFrame{scopeFrame{node: n}, ctx})
+		c.checkModule(ast)
+		return c
+	}
+
+	parent := &c.stack[len(c.stack)-1]
+	mode, _ := parent.child(n)
+	parent.index++
+	ctx := parent.ctx
+	if field, ok := parent.node.(*Field); ok && n == INode(field.Init) {
+		ctx = &checkContext{strict: true, newTarget: true, superProp: true, noArgs: true}
+	}
+	if v, ok := n.(*Var); ok {
+		c.checkVar(v, mode, ctx, c.occurrences.span(v, parent.node.Range()))
+		return nil
+	}
+
+	switch n := n.(type) {
+	case *FuncDecl:
+		ctx = &checkContext{
+			strict:    ctx.strict || n.Strict,
+			function:  true,
+			newTarget: true,
+		}
+		c.checkParams(&n.Params, &n.Body)
+	case *ArrowFunc:
+		ctx = &checkContext{
+			strict:    ctx.strict || n.Strict,
+			function:  true,
+			newTarget: ctx.newTarget,
+			superProp: ctx.superProp,
+			superCall: ctx.superCall,
+			noArgs:    ctx.noArgs,
+		}
+		c.checkParams(&n.Params, &n.Body)
+	case *MethodDecl:
+		class, _ := parent.node.(*ClassDecl)
+		ctx = &checkContext{
+			strict:    ctx.strict || n.Strict,
+			function:  true,
+			newTarget: true,
+			superProp: true,
+			superCall: class != nil && class.Extends != nil && !n.Static && isPropertyName(n.Name, "constructor"),
+		}
+		c.checkParams(&n.Params, &n.Body)
+		if n.Get && (len(n.Params.List) != 0 || n.Params.Rest != nil) {
+			c.error(n.Params.Start, "getter must not have any parameters")
+		} else if n.Set && (len(n.Params.List) != 1 || n.Params.Rest != nil) {
+			c.error(n.Params.Start, "setter must have exactly one parameter")
+		}
+	case *ClassDecl:
+		ctx = &checkContext{
+			strict:    true,
+			newTarget: ctx.newTarget,
+			superProp: ctx.superProp,
+			superCall: ctx.superCall,
+			noArgs:    ctx.noArgs,
+		}
+		c.checkClass(n)
+	case *BlockStmt:
+		if _, ok := parent.node.(*ClassDecl); ok {
+			// static block
+			ctx = &checkContext{strict: true, newTarget: true, superProp: true, noArgs: true}
+		} else if !isFuncNode(parent.node) {
+			c.checkBlockDecls(n.List, ctx.strict)
+		}
+	case *SwitchStmt:
+		list := []IStmt{}
+		for _, clause := range n.List {
+			list = append(list, clause.List...)
+		}
+		c.checkBlockDecls(list, ctx.strict)
+		ctx.switches++
+	case *DoWhileStmt, *WhileStmt, *ForStmt:
+		ctx.loops++
+	case *ForInStmt:
+		if _, ok := n.Init.(*VarDecl); !ok {
+			c.checkTarget(n.Init, true, ctx.strict)
+		}
+		ctx.loops++
+	case *ForOfStmt:
+		if _, ok := n.Init.(*VarDecl); !ok {
+			c.checkTarget(n.Init, true, ctx.strict)
+		}
+		ctx.loops++
+	case *LabelledStmt:
+		for _, label := range ctx.labels {
+			if bytes.Equal(label.name, n.Label) {
+				c.error(n.Start, "label "+string(n.Label)+" has already been declared")
+				break
+			}
+		}
+		body := n.Value
+		for {
+			if labelled, ok := body.(*LabelledStmt); ok {
+				body = labelled.Value
+			} else {
+				break
+			}
+		}
+		if _, ok := body.(*FuncDecl); ok {
+			if ctx.strict {
+				c.error(n.Start, "labelled function declarations are not allowed in strict mode")
+			} else if isStmtBody(parent.node, n) {
+				c.error(n.Start, "labelled function declarations are not allowed as the body of a statement")
+			}
+		}
+		isLoop := false
+		switch body.(type) {
+		case *DoWhileStmt, *WhileStmt, *ForStmt, *ForInStmt, *ForOfStmt:
+			isLoop = true
+		}
+		ctx.labels = append(ctx.labels, checkLabel{n.Label, isLoop})
+	case *BranchStmt:
+		c.checkBranch(n, ctx)
+	case *ReturnStmt:
+		if !ctx.function {
+			c.error(n.Start, "return statement is not allowed outside of a function")
+		}
+	case *WithStmt:
+		if ctx.strict {
+			c.error(n.Start, "with statement is not allowed in strict mode")
+		}
+	case *VarDecl:
+		if n.TokenType != VarToken {
+			for _, item := range n.List {
+				for _, v := range bindingVars(item.Binding, nil) {
+					if bytes.Equal(v.Data, []byte("let")) {
+						c.error(item.Start, "let is disallowed as a lexically bound name")
+					}
+				}
+			}
+		}
+	case *DirectivePrologueStmt:
+		if msg := checkEscapes(n.Value, false, ctx.strict); msg != "" {
+			c.error(n.Start, msg)
+		}
+	case *UnaryExpr:
+		switch n.Op {
+		case DeleteToken:
+			x := unparen(n.X)
+			if _, ok := x.(*Var); ok && ctx.strict {
+				c.error(n.Start, "delete of an unqualified identifier is not allowed in strict mode")
+			} else if dot, ok := x.(*DotExpr); ok && dot.Y.TokenType == PrivateIdentifierToken {
+				c.error(n.Start, "private fields can not be deleted")
+			}
+		case PreIncrToken, PreDecrToken, PostIncrToken, PostDecrToken:
+			c.checkTarget(n.X, false, ctx.strict)
+		}
+	case *BinaryExpr:
+		if n.Op == EqToken {
+			c.checkTarget(n.X, true, ctx.strict)
+		} else if n.Op == AndEqToken || n.Op == OrEqToken || n.Op == NullishEqToken {
+			c.checkSimpleTarget(n.X, false)
+		} else if isAssignment(n.Op) {
+			c.checkTarget(n.X, false, ctx.strict)
+		}
+	case *ObjectExpr:
+		if !c.targets[n] {
+			c.checkObject(n)
+		}
+	case *TemplateExpr:
+		if n.Tag == nil {
+			for _, part := range n.List {
+				if msg := checkEscapes(part.Value, true, ctx.strict); msg != "" {
+					c.error(part.Start, msg)
+				}
+			}
+			if msg := checkEscapes(n.Tail, true, ctx.strict); msg != "" {
+				c.error(n.Start, msg)
+			}
+		} else if n.Optional || isOptionalChain(n.Tag) {
+			c.error(n.Start, "tagged template cannot be used in optional chain")
+		}
+	case *NewTargetExpr:
+		if !ctx.newTarget {
+			c.error(n.Start, "new.target expression is not allowed here")
+		}
+	case *LiteralExpr:
+		c.checkLiteral(n, parent, ctx)
+	}
+
+	c.stack = append(c.stack, checkFrame{scopeFrame{node: n, mode: mode}, ctx})
+	return c
+}
+
+func (c *checker) Exit(n INode) {
+	ctx := c.stack[len(c.stack)-1].ctx
+	switch n.(type) {
+	case *SwitchStmt:
+		ctx.switches--
+	case *DoWhileStmt, *WhileStmt, *ForStmt, *ForInStmt, *ForOfStmt:
+		ctx.loops--
+	case *LabelledStmt:
+		ctx.labels = ctx.labels[:len(ctx.labels)-1]
+	case *ClassDecl:
+		c.classes = c.classes[:len(c.classes)-1]
+	}
+	c.stack = c.stack[:len(c.stack)-1]
+}
+
+// checkVar checks an identifier, where mode is the access of the variable.
+func (c *checker) checkVar(v *Var, mode RefFlags, ctx *checkContext, span Span) {
+	if ctx.strict {
+		switch Keywords[string(v.Data)] {
+		case LetToken, StaticToken, ImplementsToken, InterfaceToken, PackageToken, PrivateToken, ProtectedToken, PublicToken, YieldToken:
+			// only reported by the parser for an explicit goal or with early errors
+			c.error(span.Start, "unexpected strict mode reserved word "+string(v.Data))
+			return
+		}
+		if mode&(WriteRef|DeclRef) != 0 && (bytes.Equal(v.Data, []byte("eval")) || bytes.Equal(v.Data, []byte("arguments"))) {
+			c.error(span.Start, string(v.Data)+" can not be declared or assigned in strict mode")
+			return
+		}
+	}
+	if ctx.noArgs && mode&DeclRef == 0 && bytes.Equal(v.Data, []byte("arguments")) {
+		c.error(span.Start, "arguments is not allowed in class field initializer or static initialization block")
+	}
+}
+
+// checkLiteral checks string and regular expression literals, super, and private names.
+func (c *checker) checkLiteral(n *LiteralExpr, parent *checkFrame, ctx *checkContext) {
+	switch n.TokenType {
+	case StringToken:
+		if _, ok := parent.node.(*JSXAttribute); !ok {
+			if msg := checkEscapes(n.Data, false, ctx.strict); msg != "" {
+				c.error(n.Start, msg)
+			}
+		}
+	case RegExpToken:
+		if _, err := regexp.ParseLiteral(n.Data); err != nil {
+			rerr := err.(*regexp.Error)
+			c.error(n.Start+rerr.Offset, "invalid regular expression: "+rerr.Message)
+		}
+	case SuperToken:
+		if call, ok := parent.node.(*CallExpr); ok && call.X == IExpr(n) {
+			if !ctx.superCall {
+				c.error(n.Start, "super call is not allowed here")
+			}
+		} else if !ctx.superProp {
+			c.error(n.Start, "super property is not allowed here")
+		}
+	case PrivateIdentifierToken:
+		if _, ok := parent.node.(*DotExpr); ok {
+			for i := len(c.classes) - 1; 0 <= i; i-- {
+				if c.classes[i].names[string(n.Data)] {
+					return
+				}
+			}
+			c.error(n.Start, "private name "+string(n.Data)+" must be declared in an enclosing class")
+		}
+	}
+}
+
+// checkParams checks the parameters of a function together with the directive prologue of its body.
+func (c *checker) checkParams(params *Params, body *BlockStmt) {
+	if !hasUseStrict(body) {
+		return
+	}
+	simple := params.Rest == nil
+	for _, item := range params.List {
+		if _, ok := item.Binding.(*Var); !ok || item.Default != nil {
+			simple = false
+		}
+	}
+	if !simple {
+		c.error(params.Start, "use strict directive is not allowed in function with non-simple parameter list")
+	}
+}
+
+// checkBranch checks that a break or continue statement has a target.
+func (c *checker) checkBranch(n *BranchStmt, ctx *checkContext) {
+	if n.Label != nil {
+		for i := len(ctx.labels) - 1; 0 <= i; i-- {
+			if bytes.Equal(ctx.labels[i].name, n.Label) {
+				if n.Type == ContinueToken && !ctx.labels[i].loop {
+					c.error(n.Start, "continue statement must refer to the label of an iteration statement")
+				}
+				return
+			}
+		}
+		c.error(n.Start, "undefined label "+string(n.Label))
+	} else if n.Type == ContinueToken && ctx.loops == 0 {
+		c.error(n.Start, "continue statement is not allowed outside of an iteration statement")
+	} else if n.Type == BreakToken && ctx.loops == 0 && ctx.switches == 0 {
+		c.error(n.Start, "break statement is not allowed outside of an iteration or switch statement")
+	}
+}
+
+// checkTarget checks the target of an assignment, update expression, or for-in/of statement. Array and object literals are destructuring patterns when pattern is set. Calls are allowed as targets in non-strict code for web compatibility.
+func (c *checker) checkTarget(x IExpr, pattern, strict bool) {
+	switch x := x.(type) {
+	case *ArrayExpr:
+		if pattern {
+			c.checkPattern(x)
+			return
+		}
+	case *ObjectExpr:
+		if pattern {
+			c.checkPattern(x)
+			return
+		}
+	}
+	c.checkSimpleTarget(x, !strict)
+}
+
+// checkSimpleTarget checks that an expression is an identifier or a property reference.
+func (c *checker) checkSimpleTarget(x IExpr, call bool) {
+	if !isSimpleTarget(x, call) {
+		c.error(x.Range().Start, "invalid assignment target")
+	}
+}
+
+// checkPattern checks a destructuring assignment pattern.
+func (c *checker) checkPattern(x IExpr) {
+	c.targets[x] = true
+	switch x := x.(type) {
+	case *ArrayExpr:
+		for i, item := range x.List {
+			if item.Value == nil {
+				continue
+			} else if item.Spread {
+				if i != len(x.List)-1 {
+					c.error(item.Start, "rest element must be last element")
+				} else if binary, ok := item.Value.(*BinaryExpr); ok && binary.Op == EqToken {
+					c.error(item.Start, "rest element may not have a default initializer")
+				} else {
+					c.checkPatternTarget(item.Value)
+				}
+			} else if binary, ok := item.Value.(*BinaryExpr); ok && binary.Op == EqToken {
+				c.checkPatternTarget(binary.X)
+			} else {
+				c.checkPatternTarget(item.Value)
+			}
+		}
+	case *ObjectExpr:
+		for i, item := range x.List {
+			if item.Spread {
+				if i != len(x.List)-1 {
+					c.error(item.Start, "rest element must be last element")
+				} else {
+					c.checkSimpleTarget(item.Value, false)
+				}
+			} else if _, ok := item.Value.(*MethodDecl); ok {
+				c.error(item.Start, "invalid destructuring assignment target")
+			} else if item.Init == nil {
+				if binary, ok := item.Value.(*BinaryExpr); ok && binary.Op == EqToken {
+					c.checkPatternTarget(binary.X)
+				} else {
+					c.checkPatternTarget(item.Value)
+				}
+			}
+		}
+	}
+}
+
+// checkPatternTarget checks the target of an element or property in a destructuring assignment pattern.
+func (c *checker) checkPatternTarget(x IExpr) {
+	switch x.(type) {
+	case *ArrayExpr, *ObjectExpr:
+		c.checkPattern(x)
+	default:
+		c.checkSimpleTarget(x, false)
+	}
+}
+
+// checkObject checks an object literal that is not a destructuring pattern.
+func (c *checker) checkObject(n *ObjectExpr) {
+	hasProto := false
+	for _, item := range n.List {
+		if item.Init != nil {
+			c.error(item.Start, "invalid shorthand property initializer")
+		} else if item.Name != nil && isPropertyName(*item.Name, "__proto__") {
+			if _, ok := item.Value.(*MethodDecl); ok {
+				continue
+			} else if v, ok := item.Value.(*Var); ok && item.Name.IsIdent(v.Data) {
+				continue // shorthand property
+			}
+			if hasProto {
+				c.error(item.Start, "duplicate __proto__ fields are not allowed in object literals")
+			}
+			hasProto = true
+		}
+	}
+}
+
+// checkClass checks the constructor and the names of the class elements, and declares the private names of the class.
+func (c *checker) checkClass(n *ClassDecl) {
+	class := checkClass{map[string]bool{}}
+	kinds := map[string]int{} // kinds of the private elements per name
+	hasConstructor := false
+	for _, item := range n.List {
+		var name PropertyName
+		static, isField := false, false
+		if item.Method != nil {
+			name, static = item.Method.Name, item.Method.Static
+		} else if item.StaticBlock == nil {
+			name, static, isField = item.Field.Name, item.Field.Static, true
+		} else {
+			continue
+		}
+		start := name.Start
+
+		if name.Literal.TokenType == PrivateIdentifierToken {
+			privateName := string(name.Literal.Data)
+			if privateName == "#constructor" {
+				c.error(start, "classes may not have a private field named #constructor")
+				continue
+			}
+
+			kind := 3 // getter and setter bits, only a getter and setter pair may have the same name
+			if item.Method != nil && item.Method.Get {
+				kind = 1
+			} else if item.Method != nil && item.Method.Set {
+				kind = 2
+			}
+			if static {
+				kind |= 4
+			}
+			if prev, ok := kinds[privateName]; ok && (prev&3 == 3 || kind&3 == 3 || prev&kind&3 != 0 || prev&4 != kind&4) {
+				c.error(start, "identifier "+privateName+" has already been declared")
+			}
+			kinds[privateName] |= kind
+			class.names[privateName] = true
+			continue
+		}
+
+		if static && isPropertyName(name, "prototype") {
+			c.error(start, "classes may not have a static property named prototype")
+		} else if isField && isPropertyName(name, "constructor") {
+			c.error(start, "classes may not have a field named constructor")
+		} else if !isField && !static && isPropertyName(name, "constructor") {
+			if item.Method.Get || item.Method.Set {
+				c.error(start, "class constructor may not be an accessor")
+			} else if item.Method.Generator {
+				c.error(start, "class constructor may not be a generator")
+			} else if item.Method.Async {
+				c.error(start, "class constructor may not be an async method")
+			} else if hasConstructor {
+				c.error(start, "a class may only have one constructor")
+			}
+			hasConstructor = true
+		}
+	}
+	c.classes = append(c.classes, class)
+}
+
+// checkBlockDecls checks that the lexically declared names of a block are unique. Function declarations in blocks are lexical declarations, but non-strict code may declare the same function multiple times for web compatibility. The parser reports all other redeclarations.
+func (c *checker) checkBlockDecls(list []IStmt, strict bool) {
+	names := map[string]bool{} // whether the name is only declared by plain function declarations
+	for _, stmt := range list {
+		var vars []*Var
+		isFunc := false
+		switch stmt := stmt.(type) {
+		case *VarDecl:
+			if stmt.TokenType != VarToken {
+				for _, item := range stmt.List {
+					vars = bindingVars(item.Binding, vars)
+				}
+			}
+		case *ClassDecl:
+			if stmt.Name != nil {
+				vars = append(vars, stmt.Name)
+			}
+		case *FuncDecl:
+			if stmt.Name != nil {
+				vars = append(vars, stmt.Name)
+				isFunc = !stmt.Async && !stmt.Generator
+			}
+		}
+		for _, v := range vars {
+			onlyFuncs, ok := names[string(v.Data)]
+			if ok && (strict || !isFunc || !onlyFuncs) {
+				c.error(stmt.Range().Start, "identifier "+string(v.Data)+" has already been declared")
+			}
+			names[string(v.Data)] = isFunc && (!ok || onlyFuncs)
+		}
+	}
+}
+
+// checkModule checks that the bindings of imports are unique and that exported names are unique and refer to declared bindings.
+func (c *checker) checkModule(ast *AST) {
+	imports := map[string]bool{}
+	for _, stmt := range ast.List {
+		if importStmt, ok := stmt.(*ImportStmt); ok {
+			bindings := []Alias{}
+			if importStmt.Default != nil {
+				bindings = append(bindings, Alias{Binding: importStmt.Default, Span: importStmt.Span})
+			}
+			for _, alias := range append(bindings, importStmt.List...) {
+				if alias.Binding == nil {
+					continue
+				}
+				name := string(alias.Binding)
+				if imports[name] || !c.typescript && ast.Scope.findDeclared(alias.Binding, false) != nil {
+					c.error(alias.Start, "identifier "+name+" has already been declared")
+				}
+				imports[name] = true
+			}
+		}
+	}
+
+	exports := map[string]bool{}
+	addExport := func(name []byte, start int) {
+		if 1 < len(name) && (name[0] == '"' || name[0] == '\'') {
+			name = name[1 : len(name)-1]
+		}
+		if exports[string(name)] {
+			c.error(start, "duplicate export "+string(name))
+		}
+		exports[string(name)] = true
+	}
+	for _, stmt := range ast.List {
+		exportStmt, ok := stmt.(*ExportStmt)
+		if !ok {
+			continue
+		} else if exportStmt.Default {
+			addExport([]byte("default"), exportStmt.Start)
+			continue
+		}
+		switch decl := exportStmt.Decl.(type) {
+		case *VarDecl:
+			for _, item := range decl.List {
+				for _, v := range bindingVars(item.Binding, nil) {
+					addExport(v.Data, item.Start)
+				}
+			}
+		case *FuncDecl:
+			if decl.Name != nil {
+				addExport(decl.Name.Data, exportStmt.Start)
+			}
+		case *ClassDecl:
+			if decl.Name != nil {
+				addExport(decl.Name.Data, exportStmt.Start)
+			}
+		}
+		for _, alias := range exportStmt.List {
+			if alias.Binding == nil || exportStmt.Module != nil && alias.Name == nil && bytes.Equal(alias.Binding, []byte("*")) {
+				continue // trailing comma or export * from
+			}
+			addExport(alias.Binding, alias.Start)
+			if exportStmt.Module == nil && !c.typescript {
+				local := alias.Binding
+				if alias.Name != nil {
+					local = alias.Name
+				}
+				if !imports[string(local)] && ast.Scope.findDeclared(local, false) == nil {
+					c.error(alias.Start, "export "+string(local)+" is not defined")
+				}
+			}
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////
+
+// hasUseStrict returns true if the directive prologue of the body contains a use strict directive.
+func hasUseStrict(body *BlockStmt) bool {
+	for _, stmt := range body.List {
+		directive, ok := stmt.(*DirectivePrologueStmt)
+		if !ok {
+			break
+		} else if string(directive.Value) == `"use strict"` || string(directive.Value) == `'use strict'` {
+			return true
+		}
+	}
+	return false
+}
+
+// isFuncNode returns true for the nodes whose body is a function body or the module.
+func isFuncNode(n INode) bool {
+	switch n.(type) {
+	case *AST, *FuncDecl, *MethodDecl, *ArrowFunc:
+		return true
+	}
+	return false
+}
+
+// isStmtBody returns true if stmt is the body of an if, while, or do-while statement.
+func isStmtBody(n INode, stmt IStmt) bool {
+	switch n := n.(type) {
+	case *IfStmt:
+		return n.Body == stmt || n.Else == stmt
+	case *WhileStmt:
+		return n.Body == stmt
+	case *DoWhileStmt:
+		return n.Body == stmt
+	}
+	return false
+}
+
+// isPropertyName returns true if the property name is not computed and equals the given name as an identifier or string.
+func isPropertyName(n PropertyName, name string) bool {
+	if n.IsComputed() {
+		return false
+	} else if n.Literal.TokenType == StringToken {
+		return string(n.Literal.Data[1:len(n.Literal.Data)-1]) == name
+	}
+	return n.Literal.TokenType != PrivateIdentifierToken && string(n.Literal.Data) == name
+}
+
+// isSimpleTarget returns true if the expression is an identifier or a property reference, possibly parenthesized. Calls are allowed when call is set.
+func isSimpleTarget(x IExpr, call bool) bool {
+	switch x := x.(type) {
+	case *Var:
+		return true
+	case *DotExpr, *IndexExpr:
+		return !isOptionalChain(x)
+	case *CallExpr:
+		return call && !isOptionalChain(x)
+	case *GroupExpr:
+		return isSimpleTarget(x.X, call)
+	}
+	return false
+}
+
+// isOptionalChain returns true if the member, call, or tagged template expression is part of an optional chain.
+func isOptionalChain(x IExpr) bool {
+	for {
+		switch n := x.(type) {
+		case *DotExpr:
+			if n.Optional {
+				return true
+			}
+			x = n.X
+		case *IndexExpr:
+			if n.Optional {
+				return true
+			}
+			x = n.X
+		case *CallExpr:
+			if n.Optional {
+				return true
+			}
+			x = n.X
+		case *TemplateExpr:
+			if n.Optional {
+				return true
+			} else if n.Tag == nil {
+				return false
+			}
+			x = n.Tag
+		default:
+			return false
+		}
+	}
+}
+
+// unparen returns the expression inside any parentheses.
+func unparen(x IExpr) IExpr {
+	for {
+		if group, ok := x.(*GroupExpr); ok {
+			x = group.X
+		} else {
+			return x
+		}
+	}
+}
+
+// bindingVars appends the variables that are bound by a binding to vars.
+func bindingVars(binding IBinding, vars []*Var) []*Var {
+	switch b := binding.(type) {
+	case *Var:
+		vars = append(vars, b)
+	case *BindingArray:
+		for _, item := range b.List {
+			vars = bindingVars(item.Binding, vars)
+		}
+		if b.Rest != nil {
+			vars = bindingVars(b.Rest, vars)
+		}
+	case *BindingObject:
+		for _, item := range b.List {
+			vars = bindingVars(item.Value.Binding, vars)
+		}
+		if b.Rest != nil {
+			vars = append(vars, b.Rest)
+		}
+	}
+	return vars
+}
+
+// checkEscapes returns an error message if a string literal or the raw value of an untagged template contains an invalid escape sequence. Octal escape sequences are not allowed in templates and in strict mode code.
+func checkEscapes(b []byte, template, strict bool) string {
+	for i := 0; i+1 < len(b); i++ {
+		if b[i] != '\\' {
+			continue
+		}
+		i++
+		switch c := b[i]; c {
+		case 'x':
+			if len(b) < i+3 || !isHex(b[i+1]) || !isHex(b[i+2]) {
+				return "invalid hexadecimal escape sequence"
+			}
+		case 'u':
+			if i+1 < len(b) && b[i+1] == '{' {
+				j, r := i+2, 0
+				for ; j < len(b) && isHex(b[j]) && r <= 0x10FFFF; j++ {
+					r = r*16 + hexValue(b[j])
+				}
+				if j == i+2 || j == len(b) || b[j] != '}' || 0x10FFFF < r {
+					return "invalid unicode escape sequence"
+				}
+			} else if len(b) < i+5 || !isHex(b[i+1]) || !isHex(b[i+2]) || !isHex(b[i+3]) || !isHex(b[i+4]) {
+				return "invalid unicode escape sequence"
+			}
+		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			if c == '0' && (i+1 == len(b) || b[i+1] < '0' || '9' < b[i+1]) {
+				break
+			} else if template {
+				return "octal escape sequences are not allowed in template strings"
+			} else if strict {
+				return "octal escape sequences are not allowed in strict mode"
+			}
+		}
+	}
+	return ""
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func hexValue(c byte) int {
+	if c <= '9' {
+		return int(c - '0')
+	} else if c <= 'F' {
+		return int(c-'A') + 10
+	}
+	return int(c-'a') + 10
+}
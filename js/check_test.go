@@ -0,0 +1,184 @@
+package js
+
+import (
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestCheck(t *testing.T) {
+	var tests = []struct {
+		js string
+	}{
+		{"while(1){ continue; break }"},
+		{"a: b: while(1) continue a;"},
+		{"switch (a) { case 1: break }"},
+		{"function f() { return }"},
+		{"x = a => { return }"},
+		{"delete x; with (a) {}"},
+		{`"use strict"; delete a.b`},
+		{"({__proto__: 1, __proto__})"},
+		{"({__proto__: 1, __proto__(){}})"},
+		{"({__proto__: a, __proto__: b} = c)"},
+		{"f() = 1; f()++; for (f() in a);"},
+		{"[(a), b.c, d[0], ...e] = f"},
+		{"({a, b = 1, c: d.e, f: [g = 2], ...h} = i)"},
+		{"[{a = 1}] = b; ({a = 1} = b)"},
+		{"a &&= 1; a.b ||= 2; a[0] ??= 3"},
+		{"var eval, static; arguments = 1"},
+		{"function f() { '\\01'; '\\8' }"},
+		{"'\\0'; '\\u{10FFFF}'; '\\x41\\u0041'; x`\\01\\u`"},
+		{"function f(){ new.target; x = () => new.target }"},
+		{"class A extends B { constructor(){ super(); x = () => super() } m(){ super.x } }"},
+		{"({m(){ super.x }})"},
+		{"class A { #a; get #b(){} set #b(v){} m(){ this.#a; class B { m(){ this.#b } } } }"},
+		{"class A { static #a; static m(){ A.#a } }"},
+		{"class A { 'constructor'(){} ['constructor'](){} }"},
+		{"class A { static constructor(){} prototype(){} }"},
+		{"x = class { a = function() { arguments } }"},
+		{"x = class { static { x: while(1) break x } }"},
+		{"{ function f(){} function f(){} }"},
+		{"function f(){} function f(){}"},
+		{"x: function f(){}"},
+		{"var a; import b from 'x'; export {a, b as c}; export * from 'y'; export * as d from 'z'"},
+		{"export default 1; export {default as a} from 'x'"},
+		{"x = /a/gu; y = /(?<a>x)\\k<a>/"},
+		{"x = <a b='\\01'/>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{JSX: true})
+			test.Error(t, err)
+			errs := Check(ast, []byte(tt.js))
+			test.T(t, len(errs), 0, errs.Error())
+		})
+	}
+}
+
+func TestCheckError(t *testing.T) {
+	var tests = []struct {
+		js     string
+		err    string
+		column int
+	}{
+		{"break foo", "undefined label foo", 1},
+		{"a: { continue a }", "continue statement must refer to the label of an iteration statement", 6},
+		{"a: { b: while(1) continue a }", "continue statement must refer to the label of an iteration statement", 18},
+		{"break", "break statement is not allowed outside of an iteration or switch statement", 1},
+		{"continue", "continue statement is not allowed outside of an iteration statement", 1},
+		{"while(1) { function f() { break } }", "break statement is not allowed outside of an iteration or switch statement", 27},
+		{"label: for(;;) { function f() { break label } }", "undefined label label", 33},
+		{"a: a: ;", "label a has already been declared", 4},
+		{"a: { a: ; }", "label a has already been declared", 6},
+		{"return 1", "return statement is not allowed outside of a function", 1},
+		{"x = class { static { return } }", "return statement is not allowed outside of a function", 22},
+		{`"use strict"; delete x`, "delete of an unqualified identifier is not allowed in strict mode", 15},
+		{`"use strict"; delete ((x))`, "delete of an unqualified identifier is not allowed in strict mode", 15},
+		{"class A { #a; m(){ delete this.#a } }", "private fields can not be deleted", 20},
+		{`"use strict"; with (a) {}`, "with statement is not allowed in strict mode", 15},
+		{"({__proto__: 1, '__proto__': 2})", "duplicate __proto__ fields are not allowed in object literals", 17},
+		{"x = [{__proto__: 1, __proto__: 2}]", "duplicate __proto__ fields are not allowed in object literals", 21},
+		{"({a = 1})", "invalid shorthand property initializer", 3},
+		{"1 = 2", "invalid assignment target", 1},
+		{"++a?.b", "invalid assignment target", 3},
+		{"(a, b) = c", "invalid assignment target", 1},
+		{"a?.b = c", "invalid assignment target", 1},
+		{"'use strict'; f() = 1", "invalid assignment target", 15},
+		{"f() &&= 1", "invalid assignment target", 1},
+		{"for (a + b of c);", "invalid assignment target", 6},
+		{"[a + b] = c", "invalid assignment target", 2},
+		{"[f()] = c", "invalid assignment target", 2},
+		{"[({a})] = b", "invalid assignment target", 2},
+		{"({a: b + c} = d)", "invalid assignment target", 6},
+		{"({...{a}} = b)", "invalid assignment target", 6},
+		{"({a(){}} = b)", "invalid destructuring assignment target", 3},
+		{"[...a, b] = c", "rest element must be last element", 2},
+		{"[...a = 1] = c", "rest element may not have a default initializer", 2},
+		{`"use strict"; var eval`, "eval can not be declared or assigned in strict mode", 19},
+		{`"use strict"; [arguments] = a`, "arguments can not be declared or assigned in strict mode", 16},
+		{`"use strict"; x = static`, "unexpected strict mode reserved word static", 19},
+		{"function eval() { 'use strict' }", "eval can not be declared or assigned in strict mode", 10},
+		{"class let {}", "unexpected strict mode reserved word let", 7},
+		{"let let = 1", "let is disallowed as a lexically bound name", 5},
+		{"function f(a = 1) { 'use strict' }", "use strict directive is not allowed in function with non-simple parameter list", 11},
+		{"(...a) => { 'use strict' }", "use strict directive is not allowed in function with non-simple parameter list", 1},
+		{`"use strict"; "\01"`, "octal escape sequences are not allowed in strict mode", 15},
+		{`function f() { "\08"; "use strict" }`, "octal escape sequences are not allowed in strict mode", 16},
+		{"`\\1`", "octal escape sequences are not allowed in template strings", 1},
+		{`"\u{110000}"`, "invalid unicode escape sequence", 1},
+		{"`${a}\\u`", "invalid unicode escape sequence", 1},
+		{`"\x1"`, "invalid hexadecimal escape sequence", 1},
+		{"new.target", "new.target expression is not allowed here", 1},
+		{"function f() { x = { [new.target]: () => new.target } }", "", 0},
+		{"() => new.target", "new.target expression is not allowed here", 7},
+		{"super.x", "super property is not allowed here", 1},
+		{"function f() { super.x }", "super property is not allowed here", 16},
+		{"({m(){ super() }})", "super call is not allowed here", 8},
+		{"class A { constructor(){ super() } }", "super call is not allowed here", 26},
+		{"class A extends B { m(){ super() } }", "super call is not allowed here", 26},
+		{"x = class { a = arguments }", "arguments is not allowed in class field initializer or static initialization block", 17},
+		{"x = class { static { () => arguments } }", "arguments is not allowed in class field initializer or static initialization block", 28},
+		{"class A { constructor(){} constructor(){} }", "a class may only have one constructor", 27},
+		{"class A { get constructor(){} }", "class constructor may not be an accessor", 15},
+		{"class A { *constructor(){} }", "class constructor may not be a generator", 12},
+		{"class A { async constructor(){} }", "class constructor may not be an async method", 17},
+		{"class A { constructor = 1 }", "classes may not have a field named constructor", 11},
+		{"class A { static prototype(){} }", "classes may not have a static property named prototype", 18},
+		{"class A { #constructor }", "classes may not have a private field named #constructor", 11},
+		{"class A { #a; #a(){} }", "identifier #a has already been declared", 15},
+		{"class A { get #a(){} static set #a(v){} }", "identifier #a has already been declared", 33},
+		{"class A { m(){ this.#a } }", "private name #a must be declared in an enclosing class", 21},
+		{"this.#a", "private name #a must be declared in an enclosing class", 6},
+		{"x = { get a(b){} }", "getter must not have any parameters", 12},
+		{"class A { set a(b, c){} }", "setter must have exactly one parameter", 16},
+		{"a?.b`c`", "tagged template cannot be used in optional chain", 1},
+		{`"use strict"; a: function f(){}`, "labelled function declarations are not allowed in strict mode", 15},
+		{"if (a) b: function f(){}", "labelled function declarations are not allowed as the body of a statement", 8},
+		{"{ function f(){} let f }", "identifier f has already been declared", 18},
+		{"{ function f(){} function* f(){} }", "identifier f has already been declared", 18},
+		{"'use strict'; { function f(){} function f(){} }", "identifier f has already been declared", 32},
+		{"switch (a) { case 1: function f(){} default: let f }", "identifier f has already been declared", 46},
+		{"import {a, b as a} from 'x'", "identifier a has already been declared", 12},
+		{"import a from 'x'; var a", "identifier a has already been declared", 1},
+		{"var a; export {a, b as a}", "duplicate export a", 19},
+		{"export default 1; export default 2", "duplicate export default", 19},
+		{"export var a; export {b as 'a'} from 'x'", "duplicate export a", 23},
+		{"export {a}", "export a is not defined", 9},
+		{"x = /a**/", "invalid regular expression: nothing to repeat", 8},
+		{"x = /a/gg", "invalid regular expression: duplicate regular expression flag", 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+			errs := Check(ast, []byte(tt.js))
+			if tt.err == "" {
+				test.T(t, len(errs), 0, errs.Error())
+				return
+			}
+			test.That(t, 0 < len(errs), "must return error")
+			test.String(t, errs[0].Message, tt.err)
+			test.T(t, errs[0].Column, tt.column)
+		})
+	}
+}
+
+func TestCheckOptions(t *testing.T) {
+	_, err := Parse(parse.NewInputString("x = 1;\nbreak"), Options{})
+	test.Error(t, err)
+
+	_, err = Parse(parse.NewInputString("x = 1;\nbreak"), Options{EarlyErrors: true})
+	test.That(t, err != nil, "must return error")
+	test.String(t, err.(*parse.Error).Message, "break statement is not allowed outside of an iteration or switch statement")
+	test.T(t, err.(*parse.Error).Line, 2)
+
+	// errors are merged with the parse errors and sorted by position
+	_, err = Parse(parse.NewInputString("return;\nx = ;\na: a: 1"), Options{EarlyErrors: true, ErrorRecovery: true})
+	test.That(t, err != nil, "must return error")
+	errs := err.(ErrorList)
+	test.T(t, len(errs), 3)
+	test.String(t, errs[0].Message, "return statement is not allowed outside of a function")
+	test.String(t, errs[1].Message, "unexpected ; in expression")
+	test.String(t, errs[2].Message, "label a has already been declared")
+}
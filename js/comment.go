@@ -0,0 +1,225 @@
+package js
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Comment is a single-line, multi-line, or HTML-like comment in the source, Data includes the comment delimiters.
+type Comment struct {
+	Data []byte
+	Span
+}
+
+// IsJSDoc returns true for JSDoc comments, which are multi-line comments that start with /**.
+func (c Comment) IsJSDoc() bool {
+	return 4 < len(c.Data) && c.Data[0] == '/' && c.Data[1] == '*' && c.Data[2] == '*' && c.Data[3] != '/'
+}
+
+// IsLegal returns true for comments that should be preserved when minifying, these start with /*! or //! or contain @license or @preserve.
+func (c Comment) IsLegal() bool {
+	if 2 < len(c.Data) && c.Data[0] == '/' && (c.Data[1] == '*' || c.Data[1] == '/') && c.Data[2] == '!' {
+		return true
+	}
+	return bytes.Contains(c.Data, []byte("@license")) || bytes.Contains(c.Data, []byte("@preserve"))
+}
+
+// IsMultiLine returns true for comments that start with /*. Other comments run until the end of the line.
+func (c Comment) IsMultiLine() bool {
+	return 1 < len(c.Data) && c.Data[0] == '/' && c.Data[1] == '*'
+}
+
+func (c Comment) String() string {
+	return "Comment(" + string(c.Data) + ")"
+}
+
+// JS returns the comment.
+func (c Comment) JS() string {
+	return string(c.Data)
+}
+
+// CommentGroup are the comments attached to a node. Leading comments come before the node, trailing comments come after the node on the same line. Doc is the JSDoc comment that directly precedes a node without an empty line in between, it is also set for the declaration of an export statement, for function and class expression statements, and for methods of object literals.
+type CommentGroup struct {
+	Leading  []Comment
+	Trailing []Comment
+	Doc      *Comment
+}
+
+// CommentMap maps nodes to their comments. Each comment is attached to exactly one node, except for Doc comments which are also attached to the declaration they document. Variables are never keys in the map since they are shared between their uses.
+type CommentMap map[INode]*CommentGroup
+
+// Leading returns the comments before the node.
+func (cm CommentMap) Leading(n INode) []Comment {
+	if g, ok := cm[n]; ok {
+		return g.Leading
+	}
+	return nil
+}
+
+// Trailing returns the comments after the node on the same line.
+func (cm CommentMap) Trailing(n INode) []Comment {
+	if g, ok := cm[n]; ok {
+		return g.Trailing
+	}
+	return nil
+}
+
+// Doc returns the JSDoc comment of the node, or nil if the node has none.
+func (cm CommentMap) Doc(n INode) *Comment {
+	if g, ok := cm[n]; ok {
+		return g.Doc
+	}
+	return nil
+}
+
+func (cm CommentMap) group(n INode) *CommentGroup {
+	g, ok := cm[n]
+	if !ok {
+		g = &CommentGroup{}
+		cm[n] = g
+	}
+	return g
+}
+
+////////////////////////////////////////////////////////////////
+
+type nodeCollector struct {
+	nodes []INode
+}
+
+func (v *nodeCollector) Enter(n INode) IVisitor {
+	if _, ok := n.(*Var); !ok {
+		if span := n.Range(); span.Start < span.End {
+			v.nodes = append(v.nodes, n)
+		}
+	}
+	return v
+}
+
+func (v *nodeCollector) Exit(n INode) {}
+
+// hasLineTerminator returns true if b contains a line terminator.
+func hasLineTerminator(b []byte) bool {
+	for i := 0; i < len(b); i++ {
+		if b[i] == '\n' || b[i] == '\r' || b[i] == 0xE2 && i+2 < len(b) && b[i+1] == 0x80 && (b[i+2] == 0xA8 || b[i+2] == 0xA9) {
+			return true
+		}
+	}
+	return false
+}
+
+// newCommentMap attaches the comments to the nodes of the AST. A comment on the same line as and after the end of a node is a trailing comment of that node, unless it is followed by another node on the same line. Otherwise, it is a leading comment of the next node. Comments are only attached to nodes within the innermost node that encloses the comment, comments that are not followed by a node within it are trailing comments of the last node before it, or of the enclosing node itself. Comments at the end of the file on their own lines are trailing comments of the AST.
+func newCommentMap(ast *AST, src []byte, comments []Comment) CommentMap {
+	cm := CommentMap{}
+	if len(comments) == 0 {
+		return cm
+	}
+
+	v := &nodeCollector{}
+	for _, item := range ast.List {
+		Walk(v, item)
+	}
+	starts := v.nodes // by start, outer nodes first
+	sort.SliceStable(starts, func(i, j int) bool {
+		a, b := starts[i].Range(), starts[j].Range()
+		return a.Start < b.Start || a.Start == b.Start && b.End < a.End
+	})
+	ends := make([]INode, len(starts)) // by end, outer nodes last
+	for i, n := range starts {
+		ends[len(ends)-1-i] = n // reversed so that outer nodes with the same span remain last
+	}
+	sort.SliceStable(ends, func(i, j int) bool {
+		a, b := ends[i].Range(), ends[j].Range()
+		return a.End < b.End || a.End == b.End && b.Start < a.Start
+	})
+
+	var stack []INode // enclosing nodes
+	next := 0         // index in starts of the first node after the comment
+	for i, c := range comments {
+		for next < len(starts) && starts[next].Range().Start < c.Start {
+			stack = append(stack, starts[next])
+			next++
+		}
+		for 0 < len(stack) && stack[len(stack)-1].Range().End <= c.Start {
+			stack = stack[:len(stack)-1]
+		}
+		enclosing := Span{0, len(src)}
+		var parent INode = ast
+		if 0 < len(stack) {
+			parent = stack[len(stack)-1]
+			enclosing = parent.Range()
+		}
+
+		var prev INode
+		if j := sort.Search(len(ends), func(j int) bool { return c.Start < ends[j].Range().End }); 0 < j {
+			if n := ends[j-1]; enclosing.Start <= n.Range().Start {
+				prev = n
+			}
+		}
+		var succ INode
+		if next < len(starts) && starts[next].Range().Start < enclosing.End {
+			succ = starts[next]
+		}
+
+		// end of the comments on the same line
+		end := c.End
+		for _, c2 := range comments[i+1:] {
+			if hasLineTerminator(src[end:c2.Start]) {
+				break
+			}
+			end = c2.End
+		}
+
+		if prev != nil && !hasLineTerminator(src[prev.Range().End:c.Start]) && (succ == nil || hasLineTerminator(src[end:succ.Range().Start])) {
+			g := cm.group(prev)
+			g.Trailing = append(g.Trailing, c)
+		} else if succ != nil {
+			g := cm.group(succ)
+			g.Leading = append(g.Leading, c)
+		} else if prev != nil && parent != INode(ast) {
+			g := cm.group(prev)
+			g.Trailing = append(g.Trailing, c)
+		} else {
+			g := cm.group(parent)
+			g.Trailing = append(g.Trailing, c)
+		}
+	}
+
+	// set the JSDoc comments of the nodes and of the declarations they wrap
+	for _, n := range starts {
+		g, ok := cm[n]
+		if !ok || len(g.Leading) == 0 || !g.Leading[len(g.Leading)-1].IsJSDoc() {
+			continue
+		}
+		doc := &g.Leading[len(g.Leading)-1]
+		if 1 < bytes.Count(src[doc.End:n.Range().Start], []byte("\n")) {
+			continue // empty line in between
+		}
+		g.Doc = doc
+		if decl := documentedDecl(n); decl != nil {
+			cm.group(decl).Doc = doc
+		}
+	}
+	return cm
+}
+
+// documentedDecl returns the declaration that is wrapped by the node, such as the function of an export statement.
+func documentedDecl(n INode) INode {
+	switch n := n.(type) {
+	case *ExportStmt:
+		switch n.Decl.(type) {
+		case *FuncDecl, *ClassDecl, *VarDecl:
+			return n.Decl
+		}
+	case *ExprStmt:
+		switch n.Value.(type) {
+		case *FuncDecl, *ClassDecl:
+			return n.Value
+		}
+	case *Property:
+		if method, ok := n.Value.(*MethodDecl); ok {
+			return method
+		}
+	}
+	return nil
+}
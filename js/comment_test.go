@@ -0,0 +1,188 @@
+package js
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestComment(t *testing.T) {
+	var tests = []struct {
+		comment string
+		jsdoc   bool
+		legal   bool
+		multi   bool
+	}{
+		{"// a", false, false, false},
+		{"/* a */", false, false, true},
+		{"/** a */", true, false, true},
+		{"/**/", false, false, true},
+		{"/***/", true, false, true},
+		{"/*! a */", false, true, true},
+		{"//! a", false, true, false},
+		{"/* @license MIT */", false, true, true},
+		{"/** @preserve */", true, true, true},
+		{"<!-- a", false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.comment, func(t *testing.T) {
+			c := Comment{[]byte(tt.comment), Span{}}
+			test.T(t, c.IsJSDoc(), tt.jsdoc, "jsdoc")
+			test.T(t, c.IsLegal(), tt.legal, "legal")
+			test.T(t, c.IsMultiLine(), tt.multi, "multi-line")
+		})
+	}
+}
+
+func TestParseComments(t *testing.T) {
+	var tests = []struct {
+		js       string
+		node     string // source of the node
+		leading  []string
+		trailing []string
+	}{
+		{"// a\nb", "b", []string{"// a"}, nil},
+		{"/* a */ b", "b", []string{"/* a */"}, nil},
+		{"b // a\nc", "b", nil, []string{"// a"}},
+		{"b; /* a */ // c\nd", "b", nil, []string{"/* a */", "// c"}},
+		{"b; /* a */ c", "c", []string{"/* a */"}, nil},
+		{"b\n// a\n\n// c\nd", "d", []string{"// a", "// c"}, nil},
+		{"/*! license */\nfunction f() {}", "function f() {}", []string{"/*! license */"}, nil},
+		{"if (a) {\n\t// b\n\tc()\n}", "c()", []string{"// b"}, nil},
+		{"if (a) {\n\tb() // c\n}\nd()", "b()", nil, []string{"// c"}},
+		{"if (a) {\n\tb()\n\t// c\n}\nd()", "b()", nil, []string{"// c"}},
+		{"function f() { /* a */ }", "{ /* a */ }", nil, []string{"/* a */"}},
+		{"class A {\n\t// a\n\tx = 1 // b\n\tm() {}\n}", "x = 1", []string{"// a"}, []string{"// b"}},
+		{"x = {\n\t// a\n\tb: 1\n}", "b: 1", []string{"// a"}, nil},
+		{"a()\n// b", "", nil, []string{"// b"}},
+		{"// a", "", nil, []string{"// a"}},
+		{"a() <!-- b\nc()", "a()", nil, []string{"<!-- b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{Comments: true})
+			test.Error(t, err)
+
+			n := 0
+			for node, g := range ast.CommentMap {
+				n += len(g.Leading) + len(g.Trailing)
+				source := ""
+				if node != INode(ast) {
+					span := node.Range()
+					source = tt.js[span.Start:span.End]
+				}
+				if source != tt.node {
+					t.Errorf("comments attached to %T %q", node, source)
+					continue
+				}
+				leading, trailing := []string(nil), []string(nil)
+				for _, c := range g.Leading {
+					leading = append(leading, string(c.Data))
+					test.String(t, tt.js[c.Start:c.End], string(c.Data), "span")
+				}
+				for _, c := range g.Trailing {
+					trailing = append(trailing, string(c.Data))
+					test.String(t, tt.js[c.Start:c.End], string(c.Data), "span")
+				}
+				test.T(t, leading, tt.leading, "leading")
+				test.T(t, trailing, tt.trailing, "trailing")
+			}
+			test.T(t, n, len(tt.leading)+len(tt.trailing), "number of comments")
+		})
+	}
+
+	ast, err := Parse(parse.NewInputString("// a\nb"), Options{})
+	test.Error(t, err)
+	test.T(t, len(ast.Comments), 1)
+	test.T(t, ast.CommentMap == nil, true, "comment map without Options.Comments")
+}
+
+func TestParseCommentsError(t *testing.T) {
+	var tests = []struct {
+		js string
+		o  Options
+	}{
+		{"import// c", Options{Goal: ScriptGoal}},
+		{"a; export// c\nb", Options{Goal: ScriptGoal}},
+		{"a; export// c\nb", Options{Goal: ScriptGoal, ErrorRecovery: true}},
+		{"a; x = ;// c\nb", Options{}},
+		{"{ a; x = ;// c\n}", Options{}},
+		{"switch (a) { case 1: x = ;// c\n}", Options{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			tt.o.Comments = true
+			ast, err := Parse(parse.NewInputString(tt.js), tt.o)
+			test.That(t, err != nil, "must return error")
+			for _, stmt := range ast.List {
+				test.That(t, stmt != nil && !reflect.ValueOf(stmt).IsNil(), "nil statement")
+			}
+		})
+	}
+}
+
+func TestParseCommentsDoc(t *testing.T) {
+	js := "/** f */\nexport function f() {}\n\n/** not g */\n\nfunction g() {}\n/** A */\nclass A {\n\t/** m */\n\tstatic m() {}\n\t/* n */\n\tn() {}\n}\nx = {\n\t/** o */\n\to() {}\n}"
+	ast, err := Parse(parse.NewInputString(js), Options{Comments: true})
+	test.Error(t, err)
+
+	docs := map[string]string{}
+	v := &nodeCollector{}
+	Walk(v, ast)
+	for _, n := range v.nodes {
+		if doc := ast.CommentMap.Doc(n); doc != nil {
+			switch n := n.(type) {
+			case *FuncDecl:
+				docs["function "+string(n.Name.Data)] = string(doc.Data)
+			case *ClassDecl:
+				docs["class "+string(n.Name.Data)] = string(doc.Data)
+			case *MethodDecl:
+				docs["method "+n.Name.String()] = string(doc.Data)
+			}
+		}
+	}
+	test.T(t, docs, map[string]string{
+		"function f": "/** f */",
+		"class A":    "/** A */",
+		"method m":   "/** m */",
+		"method o":   "/** o */",
+	})
+}
+
+func TestPrinterComments(t *testing.T) {
+	var tests = []struct {
+		js       string
+		o        PrintOptions
+		expected string
+	}{
+		{"/*! a */\n// b\nc() // d\n/* e */ f()\n// g", PrintOptions{Comments: true}, "/*! a */ // b\nc(); // d\n /* e */ f(); \n// g\n"},
+		{"/*! a */\n// b\nc() // d\n/* e */ f()\n// g", PrintOptions{Pretty: true, Comments: true}, "/*! a */\n// b\nc(); // d\n/* e */\nf();\n// g\n"},
+		{"/*! a */\n// b\nc() // d\n/* e */ f()\n// g", PrintOptions{Compact: true, Comments: true}, "/*! a */c();f()"},
+		{"/*! a */\n// b\nc() // d\n/* e */ f()\n// g", PrintOptions{Pretty: true}, "c();\nf();\n"},
+		{"if (a) {\n\t// b\n\tc() // d\n}", PrintOptions{Pretty: true, Comments: true}, "if (a) {\n\t// b\n\tc(); // d\n}\n"},
+		{"class A {\n\t/** b */\n\tc() {} //! d\n\te = 1 /* f */\n}", PrintOptions{Pretty: true, Comments: true}, "class A {\n\t/** b */\n\tc() {} //! d\n\te = 1; /* f */\n}\n"},
+		{"class A {\n\t/** b */\n\tc() {} //! d\n\te = 1 /* f */\n}", PrintOptions{Compact: true, Comments: true}, "class A{c(){}//! d\ne=1}"},
+		{"switch (a) {\ncase 1:\n\t// b\n\tc()\n}", PrintOptions{Pretty: true, Comments: true}, "switch (a) {\n\tcase 1:\n\t\t// b\n\t\tc();\n}\n"},
+		{"x = /* a */ 1 + /* b */ 2", PrintOptions{Comments: true}, "x = /* a */ 1 + /* b */ 2; "},
+		{"f(a, // b\n\tc)", PrintOptions{Pretty: true, Comments: true}, "f(a /* b */, c);\n"},
+		{"x = [\n\t1, //*/ b\n\t2 /* c */\n]", PrintOptions{Pretty: true, Comments: true}, "x = [1 /** / b */, 2 /* c */];\n"},
+		{"x = a / /*! b */ /c/", PrintOptions{Compact: true, Comments: true}, "x=a/ /*! b */ /c/"},
+		{"export /* a */ function f() {}", PrintOptions{Pretty: true, Comments: true}, "export /* a */ function f() {}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{Comments: true})
+			test.Error(t, err)
+
+			buf := &bytes.Buffer{}
+			test.Error(t, NewPrinter(buf, tt.o).Print(ast))
+			test.String(t, buf.String(), tt.expected)
+
+			_, err = Parse(parse.NewInputBytes(buf.Bytes()), Options{})
+			test.Error(t, err, "reparse")
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package js
+
+// RemoveDeadCode removes unreachable statements after return, throw, break, and continue, replaces if statements with a constant condition by the branch that is taken, and removes declarations of functions and variables that are never read and of which the initializer has no side effects. Top-level declarations are only removed when module is set, since the top-level declarations of a script are global variables that other scripts may use. Function declarations and var declarations are hoisted, so that the declarations in removed code are kept without their initializers. Declarations are not removed when the code calls eval, which may refer to any variable. Conditions such as process.env.NODE_ENV === "production" become constant after Define.
+func RemoveDeadCode(ast *AST, module bool) {
+	Rewrite(deadCodeRemover{}, ast)
+
+	for {
+		scopes := AnalyzeScopes(ast)
+		for _, v := range scopes.Undeclared() {
+			if string(v.Data) == "eval" {
+				return
+			}
+		}
+
+		r := &unusedRemover{
+			scopes:   scopes,
+			module:   module,
+			exported: map[string]bool{},
+		}
+		for _, stmt := range ast.List {
+			if exportStmt, ok := stmt.(*ExportStmt); ok && exportStmt.Module == nil {
+				for _, alias := range exportStmt.List {
+					if alias.Name != nil {
+						r.exported[string(alias.Name)] = true
+					} else {
+						r.exported[string(alias.Binding)] = true
+					}
+				}
+			}
+		}
+		Rewrite(r, ast)
+		if !r.removed {
+			return
+		}
+	}
+}
+
+// deadCodeRemover removes unreachable statements and branches.
+type deadCodeRemover struct{}
+
+func (r deadCodeRemover) Enter(n INode) (INode, IRewriter) {
+	return n, r
+}
+
+func (r deadCodeRemover) Exit(n INode) INode {
+	switch n := n.(type) {
+	case *BlockStmt:
+		n.List = removeUnreachable(n.List)
+	case *CaseClause:
+		n.List = removeUnreachable(n.List)
+	case *IfStmt:
+		cond := Eval(n.Cond)
+		if !cond.IsKnown() {
+			return n
+		}
+		taken, skipped := n.Body, n.Else
+		if !cond.Truthy() {
+			taken, skipped = n.Else, n.Body
+		}
+		hoisted := hoistedDecl(skipped)
+		if hoisted == nil {
+			if taken == nil {
+				return nil
+			}
+			return taken
+		} else if taken == nil {
+			return hoisted
+		} else if block, ok := taken.(*BlockStmt); ok {
+			block.List = append([]IStmt{hoisted}, block.List...)
+			return block
+		}
+		return &BlockStmt{List: []IStmt{hoisted, taken}, Scope: Scope{Parent: hoisted.Scope, Func: hoisted.Scope.Func}, Span: n.Span}
+	}
+	return n
+}
+
+// removeUnreachable removes the statements after a statement that always jumps, except for function declarations and the variables of var declarations. Lexical declarations are kept when they are referenced by the remaining statements, such as by a hoisted function, since removing them would change a reference in their temporal dead zone into a reference to an outer variable.
+func removeUnreachable(list []IStmt) []IStmt {
+	for i, stmt := range list {
+		if !isJump(stmt) {
+			continue
+		}
+
+		refs := referencedVars{}
+		for _, stmt := range list[:i+1] {
+			Walk(refs, stmt)
+		}
+		for _, stmt := range list[i+1:] {
+			if _, ok := stmt.(*FuncDecl); ok {
+				Walk(refs, stmt)
+			}
+		}
+
+		j := i + 1
+		var hoisted []IStmt
+		for _, stmt := range list[i+1:] {
+			if _, ok := stmt.(*FuncDecl); ok || refs.declares(stmt) {
+				list[j] = stmt
+				j++
+			} else if varDecl := hoistedDecl(stmt); varDecl != nil {
+				hoisted = append(hoisted, varDecl)
+			}
+		}
+		return append(list[:j], hoisted...)
+	}
+	return list
+}
+
+// referencedVars collects the declarations of the variables that are referenced.
+type referencedVars map[*Var]bool
+
+func (refs referencedVars) Enter(n INode) IVisitor {
+	if v, ok := n.(*Var); ok {
+		refs[resolveVar(v)] = true
+		return nil
+	}
+	return refs
+}
+
+func (refs referencedVars) Exit(n INode) {}
+
+// declares returns true if stmt is a lexical declaration of which a variable is referenced.
+func (refs referencedVars) declares(stmt IStmt) bool {
+	switch n := stmt.(type) {
+	case *VarDecl:
+		if n.TokenType != VarToken {
+			for _, item := range n.List {
+				for _, v := range bindingVars(item.Binding, nil) {
+					if refs[resolveVar(v)] {
+						return true
+					}
+				}
+			}
+		}
+	case *ClassDecl:
+		return n.Name != nil && refs[resolveVar(n.Name)]
+	}
+	return false
+}
+
+// isJump returns true if the statement never completes normally, because it returns, throws, breaks, or continues.
+func isJump(stmt IStmt) bool {
+	switch n := stmt.(type) {
+	case *ReturnStmt, *ThrowStmt, *BranchStmt:
+		return true
+	case *BlockStmt:
+		return 0 < len(n.List) && isJump(n.List[len(n.List)-1])
+	case *IfStmt:
+		return n.Else != nil && isJump(n.Body) && isJump(n.Else)
+	}
+	return false
+}
+
+// hoistedDecl returns a var declaration without initializers of the variables that are declared by var declarations in stmt, excluding those in nested functions, or nil if there are none.
+func hoistedDecl(stmt IStmt) *VarDecl {
+	if stmt == nil {
+		return nil
+	}
+	h := &hoister{}
+	Walk(h, stmt)
+	if h.decl == nil {
+		return nil
+	}
+	h.decl.Scope.Func.VarDecls = append(h.decl.Scope.Func.VarDecls, h.decl)
+	return h.decl
+}
+
+type hoister struct {
+	decl *VarDecl
+}
+
+func (h *hoister) Enter(n INode) IVisitor {
+	switch n := n.(type) {
+	case *FuncDecl, *ArrowFunc, *MethodDecl, *ClassDecl:
+		return nil
+	case *VarDecl:
+		if n.TokenType == VarToken {
+			if h.decl == nil {
+				h.decl = &VarDecl{TokenType: VarToken, Scope: n.Scope, Span: n.Span}
+			}
+			for _, item := range n.List {
+				for _, v := range bindingVars(item.Binding, nil) {
+					h.decl.List = append(h.decl.List, BindingElement{Binding: v, Span: v.Span})
+				}
+			}
+		}
+		return nil
+	}
+	return h
+}
+
+func (h *hoister) Exit(n INode) {}
+
+////////////////////////////////////////////////////////////////
+
+// unusedRemover removes the declarations of unused functions and variables from statement lists.
+type unusedRemover struct {
+	scopes   *Scopes
+	module   bool
+	exported map[string]bool // local names that are exported by export statements without module
+	removed  bool
+}
+
+func (r *unusedRemover) Enter(n INode) (INode, IRewriter) {
+	return n, r
+}
+
+func (r *unusedRemover) Exit(n INode) INode {
+	switch n := n.(type) {
+	case *BlockStmt:
+		n.List = r.removeDecls(n.List)
+	case *CaseClause:
+		n.List = r.removeDecls(n.List)
+	}
+	return n
+}
+
+func (r *unusedRemover) removeDecls(list []IStmt) []IStmt {
+	j := 0
+	for _, stmt := range list {
+		switch n := stmt.(type) {
+		case *FuncDecl:
+			if n.Name != nil && r.isUnusedFunc(n) {
+				r.removed = true
+				continue
+			}
+		case *VarDecl:
+			k := 0
+			for _, item := range n.List {
+				if v, ok := item.Binding.(*Var); ok && r.isUnused(v, nil) && (item.Default == nil || !hasSideEffects(item.Default)) {
+					r.removed = true
+					continue
+				}
+				n.List[k] = item
+				k++
+			}
+			n.List = n.List[:k]
+			if len(n.List) == 0 {
+				varDecls := n.Scope.Func.VarDecls
+				for i, varDecl := range varDecls {
+					if varDecl == n {
+						n.Scope.Func.VarDecls = append(varDecls[:i], varDecls[i+1:]...)
+						break
+					}
+				}
+				continue
+			}
+		}
+		list[j] = stmt
+		j++
+	}
+	return list[:j]
+}
+
+func (r *unusedRemover) isUnusedFunc(n *FuncDecl) bool {
+	return r.isUnused(n.Name, r.scopes.Scope(&n.Body.Scope))
+}
+
+// isUnused returns true if the variable is declared in a scope from which it can be removed and if it is not referenced other than by its declarations or by references inside the scope self, such as recursive calls of a function.
+func (r *unusedRemover) isUnused(v *Var, self *ScopeNode) bool {
+	scope := r.scopes.DeclScope(v)
+	if scope == nil || scope == r.scopes.Root && (!r.module || r.exported[string(v.Data)]) {
+		return false
+	}
+	for _, ref := range r.scopes.Refs(v) {
+		if !ref.IsDecl() && (self == nil || !isInScope(ref.Scope, self)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isInScope returns true if scope is the same as or nested in parent.
+func isInScope(scope, parent *ScopeNode) bool {
+	for ; scope != nil; scope = scope.Parent {
+		if scope == parent {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSideEffects returns true if evaluating the expression may have side effects, such as calling a function, assigning a variable, or throwing an error. Reading an undeclared variable throws an error if it is not a global.
+func hasSideEffects(expr IExpr) bool {
+	if Eval(expr).IsKnown() {
+		return false
+	}
+	switch n := expr.(type) {
+	case *LiteralExpr:
+		return false
+	case *Var:
+		return resolveVar(n).Decl == NoDecl
+	case *FuncDecl, *ArrowFunc:
+		return false
+	case *ClassDecl:
+		if len(n.Decorators) != 0 || n.Extends != nil && hasSideEffects(n.Extends) {
+			return true
+		}
+		for _, item := range n.List {
+			if item.StaticBlock != nil {
+				return true
+			} else if item.Method != nil {
+				if len(item.Method.Decorators) != 0 || item.Method.Name.IsComputed() && hasSideEffects(item.Method.Name.Computed) {
+					return true
+				}
+			} else if len(item.Field.Decorators) != 0 || item.Field.Name.IsComputed() && hasSideEffects(item.Field.Name.Computed) || item.Field.Static && item.Field.Init != nil && hasSideEffects(item.Field.Init) {
+				return true
+			}
+		}
+		return false
+	case *GroupExpr:
+		return hasSideEffects(n.X)
+	case *ArrayExpr:
+		for _, item := range n.List {
+			if item.Spread || item.Value != nil && hasSideEffects(item.Value) {
+				return true
+			}
+		}
+		return false
+	case *ObjectExpr:
+		for _, item := range n.List {
+			if item.Spread || item.Name != nil && item.Name.IsComputed() && hasSideEffects(item.Name.Computed) || hasSideEffects(item.Value) {
+				return true
+			}
+		}
+		return false
+	case *UnaryExpr:
+		switch n.Op {
+		case TypeofToken:
+			if _, ok := n.X.(*Var); ok {
+				return false
+			}
+			return hasSideEffects(n.X)
+		case NotToken, VoidToken:
+			return hasSideEffects(n.X)
+		}
+	case *BinaryExpr:
+		switch n.Op {
+		case AndToken, OrToken, NullishToken, EqEqEqToken, NotEqEqToken:
+			return hasSideEffects(n.X) || hasSideEffects(n.Y)
+		}
+	case *CondExpr:
+		return hasSideEffects(n.Cond) || hasSideEffects(n.X) || hasSideEffects(n.Y)
+	case *CommaExpr:
+		for _, item := range n.List {
+			if hasSideEffects(item) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
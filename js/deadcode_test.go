@@ -0,0 +1,75 @@
+package js
+
+import (
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestRemoveDeadCode(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"function f() { return 1; g() }", "function f() { return 1 }"},
+		{"function f() { h(a, b); throw e; var a = g(), {b} = c; function h() {} let d = 1 }", "function f() { h(a, b); throw e; function h() {} var a, b }"},
+		{"function f() { g(); return; function g() { return x + new C() } let x; const y = 1; class C {} let z }", "function f() { g(); return; function g() { return x + new C() } let x; class C {} }"},
+		{"function f() { h = () => x; return; let x = 1 }", "function f() { h = () => x; return; let x = 1 }"},
+		{"for (;;) { if (a) continue; else break; b() }", "for (;;) { if (a) continue; else break }"},
+		{"switch (a) { case 1: b(); break; c(); case 2: d() }", "switch (a) { case 1: b(); break; case 2: d() }"},
+		{"function f() { { return } a() }", "function f() { { return } }"},
+		{"if (false) a(); else b()", "b()"},
+		{"if (true) { a() } else { b() }", "{ a() }"},
+		{"if (0) a(); c()", "c()"},
+		{"if (!1) { var a = 1 }", "var a"},
+		{"if (1) b(); else { var a = 1 }", "{ var a; b() }"},
+		{"if (1) { b() } else for (var a, c = 1;;) {}", "{ var a, c; b() }"},
+		{"if (a) b(); else if (0) c()", "if (a) b()"},
+		{"while (a) if (0) b()", "while (a);"},
+		{"if (x) a()", "if (x) a()"},
+		{"function f() { var a = 1, b = g(), c = () => a; let d = [1, {e: 2}]; const h = class { m() {} }; return b }", "function f() { var b = g(); return b }"},
+		{"function f() { function g() { h() } function h() {} function i() { i() } }", "function f() {}"},
+		{"function f() { var a = 1; a = 2 }", "function f() { var a = 1; a = 2 }"},
+		{"function f() { var a = x, b = typeof x, c = class extends x {}, d = class { static [k()] = 1 } }", "function f() { var a = x, c = class extends x {}, d = class { static [k()] = 1 } }"},
+		{"function f() { var a = 1; eval('a') }", "function f() { var a = 1; eval('a') }"},
+		{"var a = 1; function f() {}", "var a = 1; function f() {}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+			RemoveDeadCode(ast, false)
+
+			expected, err := Parse(parse.NewInputString(tt.expected), Options{})
+			test.Error(t, err)
+			test.String(t, ast.JS(), expected.JS())
+		})
+	}
+}
+
+func TestRemoveDeadCodeModule(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"var a = 1; function f() { return a } let b = f", ""},
+		{"var a = 1; function f() {} export {a, f as g}", "var a = 1; function f() {} export {a, f as g}"},
+		{"export function f() { var a = 1 } export const b = 2", "export function f() {} export const b = 2"},
+		{"const b = f(); if (process.env.NODE_ENV !== 'production') log(b)", "const b = f()"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{Goal: ModuleGoal})
+			test.Error(t, err)
+			Fold(Define(ast, map[string]Value{"process.env.NODE_ENV": {Type: StringValue, Str: "production"}}))
+			RemoveDeadCode(ast, true)
+
+			expected, err := Parse(parse.NewInputString(tt.expected), Options{Goal: ModuleGoal})
+			if tt.expected != "" {
+				test.Error(t, err)
+			}
+			test.String(t, ast.JS(), expected.JS())
+		})
+	}
+}
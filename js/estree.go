@@ -0,0 +1,2992 @@
+package js
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ESTree returns the AST as JSON in the ESTree format, which is used by JavaScript tools such as Acorn, ESLint, and Babel. The source the AST was parsed from is used for the positions of the nodes and comments, which are left out when src is nil. Positions are given by start and end offsets in UTF-16 code units and by loc with lines starting at 1 and columns at 0, as in Acorn. Comments are taken from the CommentMap when the AST was parsed with Options.Comments, and otherwise only the first comments of the file are included. Identifiers other than the first occurrence of a variable do not have their own position when the AST was parsed with Options.NoRefs.
+func ESTree(ast *AST, src []byte) ([]byte, error) {
+	w := &estreeWriter{
+		src:         src,
+		occurrences: newVarOccurrences(ast.refs),
+	}
+	if src != nil {
+		w.lines = newLineIndex(src)
+		w.starts = make([]int, len(w.lines.starts))
+		for i := 1; i < len(w.starts); i++ {
+			w.starts[i] = w.starts[i-1] + utf16Len(src[w.lines.starts[i-1]:w.lines.starts[i]])
+		}
+	}
+	w.program(ast)
+	if w.err != nil {
+		return nil, w.err
+	}
+	return w.buf.Bytes(), nil
+}
+
+type estreeWriter struct {
+	buf         bytes.Buffer
+	src         []byte
+	lines       lineIndex
+	starts      []int // offsets in UTF-16 code units of the start of each line
+	occurrences varOccurrences
+	parents     []Span // spans of the enclosing nodes
+	err         error
+}
+
+func (w *estreeWriter) fail(n INode) {
+	if w.err == nil {
+		w.err = fmt.Errorf("unsupported node %T in ESTree", n)
+	}
+}
+
+// position writes the line and column of a byte offset.
+func (w *estreeWriter) position(offset int) {
+	line, col := w.lines.position(offset)
+	w.buf.WriteString(`{"line":`)
+	w.buf.WriteString(strconv.Itoa(line + 1))
+	w.buf.WriteString(`,"column":`)
+	w.buf.WriteString(strconv.Itoa(col))
+	w.buf.WriteByte('}')
+}
+
+// offset returns the offset in UTF-16 code units of a byte offset.
+func (w *estreeWriter) offset(offset int) int {
+	line, col := w.lines.position(offset)
+	return w.starts[line] + col
+}
+
+// open starts a node with its type and position.
+func (w *estreeWriter) open(typ string, span Span) {
+	w.buf.WriteString(`{"type":"`)
+	w.buf.WriteString(typ)
+	w.buf.WriteByte('"')
+	if w.src != nil {
+		w.buf.WriteString(`,"start":`)
+		w.buf.WriteString(strconv.Itoa(w.offset(span.Start)))
+		w.buf.WriteString(`,"end":`)
+		w.buf.WriteString(strconv.Itoa(w.offset(span.End)))
+		w.buf.WriteString(`,"loc":{"start":`)
+		w.position(span.Start)
+		w.buf.WriteString(`,"end":`)
+		w.position(span.End)
+		w.buf.WriteByte('}')
+	}
+	w.parents = append(w.parents, span)
+}
+
+// close ends the node that was started last.
+func (w *estreeWriter) close() {
+	w.buf.WriteByte('}')
+	w.parents = w.parents[:len(w.parents)-1]
+}
+
+// parent returns the span of the node that is being written.
+func (w *estreeWriter) parent() Span {
+	if len(w.parents) == 0 {
+		return Span{0, len(w.src)}
+	}
+	return w.parents[len(w.parents)-1]
+}
+
+func (w *estreeWriter) key(key string) {
+	w.buf.WriteString(`,"`)
+	w.buf.WriteString(key)
+	w.buf.WriteString(`":`)
+}
+
+func (w *estreeWriter) null(key string) {
+	w.key(key)
+	w.buf.WriteString("null")
+}
+
+func (w *estreeWriter) bool(key string, b bool) {
+	w.key(key)
+	w.buf.WriteString(strconv.FormatBool(b))
+}
+
+func (w *estreeWriter) string(key string, s []byte) {
+	w.key(key)
+	writeJSONString(&w.buf, s)
+}
+
+// list writes an array of n items.
+func (w *estreeWriter) list(key string, n int, item func(i int)) {
+	w.key(key)
+	w.buf.WriteByte('[')
+	for i := 0; i < n; i++ {
+		if i != 0 {
+			w.buf.WriteByte(',')
+		}
+		item(i)
+	}
+	w.buf.WriteByte(']')
+}
+
+// index returns the offset of s in the source between start and end, or start if it cannot be found.
+func (w *estreeWriter) index(s string, start, end int) int {
+	if w.src == nil || end < start || len(w.src) < end {
+		return start
+	} else if i := bytes.Index(w.src[start:end], []byte(s)); i != -1 {
+		return start + i
+	}
+	return start
+}
+
+// lastIndex returns the offset of the last s in the source between start and end, or start if it cannot be found.
+func (w *estreeWriter) lastIndex(s string, start, end int) int {
+	if w.src == nil || end < start || len(w.src) < end {
+		return start
+	} else if i := bytes.LastIndex(w.src[start:end], []byte(s)); i != -1 {
+		return start + i
+	}
+	return start
+}
+
+func (w *estreeWriter) program(ast *AST) {
+	span := Span{0, len(w.src)}
+	w.open("Program", span)
+	w.list("body", len(ast.List), func(i int) { w.stmt(ast.List[i]) })
+	sourceType := "script"
+	for _, item := range ast.List {
+		switch item.(type) {
+		case *ImportStmt, *ExportStmt:
+			sourceType = "module"
+		}
+	}
+	w.string("sourceType", []byte(sourceType))
+	comments := w.comments(ast)
+	w.list("comments", len(comments), func(i int) { w.comment(comments[i]) })
+	w.close()
+}
+
+// comments returns the comments of the AST sorted by position.
+func (w *estreeWriter) comments(ast *AST) []Comment {
+	comments := []Comment{}
+	if w.src == nil {
+		return comments
+	}
+	seen := map[int]bool{}
+	for _, g := range ast.CommentMap {
+		for _, list := range [][]Comment{g.Leading, g.Trailing} {
+			for _, c := range list {
+				if !seen[c.Start] {
+					seen[c.Start] = true
+					comments = append(comments, c)
+				}
+			}
+		}
+	}
+	// the shebang and the first comments of the file
+	offset := 0
+	for _, data := range ast.Comments {
+		i := bytes.Index(w.src[offset:], data)
+		if i == -1 {
+			break
+		}
+		offset += i
+		if !seen[offset] {
+			seen[offset] = true
+			comments = append(comments, Comment{data, Span{offset, offset + len(data)}})
+		}
+		offset += len(data)
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].Start < comments[j].Start })
+	return comments
+}
+
+func (w *estreeWriter) comment(c Comment) {
+	typ, value := "Line", c.Data
+	if c.IsMultiLine() {
+		typ, value = "Block", c.Data[2:]
+		if bytes.HasSuffix(value, []byte("*/")) {
+			value = value[:len(value)-2] // unterminated comments have no closing */
+		}
+	} else if bytes.HasPrefix(c.Data, []byte("<!--")) {
+		value = c.Data[4:]
+	} else if bytes.HasPrefix(c.Data, []byte("-->")) {
+		value = c.Data[3:]
+	} else if 2 <= len(c.Data) {
+		value = c.Data[2:] // // or #!
+	}
+	w.open(typ, c.Span)
+	w.string("value", bytes.TrimRight(value, "\r\n"))
+	w.close()
+}
+
+////////////////////////////////////////////////////////////////
+
+func (w *estreeWriter) stmt(stmt IStmt) {
+	span := w.stmtSpan(stmt)
+	switch n := stmt.(type) {
+	case *BlockStmt:
+		w.block(n)
+	case *EmptyStmt:
+		w.open("EmptyStatement", n.Span)
+		w.close()
+	case *ExprStmt:
+		w.open("ExpressionStatement", span)
+		w.key("expression")
+		w.expr(n.Value)
+		w.close()
+	case *DirectivePrologueStmt:
+		w.open("ExpressionStatement", span)
+		w.key("expression")
+		w.literal(&LiteralExpr{StringToken, n.Value, Span{n.Start, n.Start + len(n.Value)}})
+		w.string("directive", n.Value[1:len(n.Value)-1])
+		w.close()
+	case *IfStmt:
+		w.open("IfStatement", span)
+		w.key("test")
+		w.expr(n.Cond)
+		w.key("consequent")
+		w.stmt(n.Body)
+		w.key("alternate")
+		w.optionalStmt(n.Else)
+		w.close()
+	case *DoWhileStmt:
+		w.open("DoWhileStatement", span)
+		w.key("body")
+		w.stmt(n.Body)
+		w.key("test")
+		w.expr(n.Cond)
+		w.close()
+	case *WhileStmt:
+		w.open("WhileStatement", span)
+		w.key("test")
+		w.expr(n.Cond)
+		w.key("body")
+		w.stmt(n.Body)
+		w.close()
+	case *ForStmt:
+		w.open("ForStatement", span)
+		w.key("init")
+		if varDecl, ok := n.Init.(*VarDecl); ok && len(varDecl.List) == 0 {
+			w.buf.WriteString("null")
+		} else if ok {
+			w.varDecl(varDecl, varDecl.Span)
+		} else {
+			w.expr(n.Init)
+		}
+		w.key("test")
+		w.expr(n.Cond)
+		w.key("update")
+		w.expr(n.Post)
+		w.key("body")
+		w.loopBody(n.Body)
+		w.close()
+	case *ForInStmt:
+		w.open("ForInStatement", span)
+		w.key("left")
+		w.forInit(n.Init)
+		w.key("right")
+		w.expr(n.Value)
+		w.key("body")
+		w.loopBody(n.Body)
+		w.close()
+	case *ForOfStmt:
+		w.open("ForOfStatement", span)
+		w.bool("await", n.Await)
+		w.key("left")
+		w.forInit(n.Init)
+		w.key("right")
+		w.expr(n.Value)
+		w.key("body")
+		w.loopBody(n.Body)
+		w.close()
+	case *SwitchStmt:
+		w.open("SwitchStatement", n.Span)
+		w.key("discriminant")
+		w.expr(n.Init)
+		w.list("cases", len(n.List), func(i int) {
+			clause := n.List[i]
+			w.open("SwitchCase", clause.Span)
+			w.key("test")
+			w.expr(clause.Cond)
+			w.list("consequent", len(clause.List), func(i int) { w.stmt(clause.List[i]) })
+			w.close()
+		})
+		w.close()
+	case *BranchStmt:
+		typ := "BreakStatement"
+		if n.Type == ContinueToken {
+			typ = "ContinueStatement"
+		}
+		w.open(typ, span)
+		w.key("label")
+		w.label(n.Label, w.index(string(n.Label), n.Start+len(n.Type.String()), n.End))
+		w.close()
+	case *ReturnStmt:
+		w.open("ReturnStatement", span)
+		w.key("argument")
+		w.expr(n.Value)
+		w.close()
+	case *WithStmt:
+		w.open("WithStatement", span)
+		w.key("object")
+		w.expr(n.Cond)
+		w.key("body")
+		w.stmt(n.Body)
+		w.close()
+	case *LabelledStmt:
+		w.open("LabeledStatement", span)
+		w.key("label")
+		w.label(n.Label, n.Start)
+		w.key("body")
+		w.stmt(n.Value)
+		w.close()
+	case *ThrowStmt:
+		w.open("ThrowStatement", span)
+		w.key("argument")
+		w.expr(n.Value)
+		w.close()
+	case *TryStmt:
+		w.open("TryStatement", n.Span)
+		w.key("block")
+		w.block(n.Body)
+		w.key("handler")
+		if n.Catch != nil {
+			w.open("CatchClause", Span{w.lastIndex("catch", n.Body.End, n.Catch.Start), n.Catch.End})
+			w.key("param")
+			w.pattern(n.Binding)
+			w.key("body")
+			w.block(n.Catch)
+			w.close()
+		} else {
+			w.buf.WriteString("null")
+		}
+		w.key("finalizer")
+		if n.Finally != nil {
+			w.block(n.Finally)
+		} else {
+			w.buf.WriteString("null")
+		}
+		w.close()
+	case *DebuggerStmt:
+		w.open("DebuggerStatement", span)
+		w.close()
+	case *ImportStmt:
+		w.importStmt(n, span)
+	case *ExportStmt:
+		w.exportStmt(n, span)
+	case *VarDecl:
+		w.varDecl(n, span)
+	case *FuncDecl:
+		w.function("FunctionDeclaration", n)
+	case *ClassDecl:
+		w.class("ClassDeclaration", n)
+	default:
+		w.fail(n)
+	}
+}
+
+// stmtSpan returns the span of a statement including its terminating semicolon, which ESTree includes in the statements that end with an optional semicolon and in the statements that end with such a statement.
+func (w *estreeWriter) stmtSpan(stmt IStmt) Span {
+	span := stmt.Range()
+	switch n := stmt.(type) {
+	case *ExprStmt, *DirectivePrologueStmt, *DoWhileStmt, *BranchStmt, *ReturnStmt, *ThrowStmt, *DebuggerStmt, *ImportStmt, *VarDecl:
+		span.End = w.semicolon(span.End)
+	case *ExportStmt:
+		switch n.Decl.(type) {
+		case *FuncDecl, *ClassDecl:
+		default:
+			span.End = w.semicolon(span.End)
+		}
+	case *IfStmt:
+		if n.Else != nil {
+			span.End = w.stmtSpan(n.Else).End
+		} else {
+			span.End = w.stmtSpan(n.Body).End
+		}
+	case *WhileStmt:
+		span.End = w.stmtSpan(n.Body).End
+	case *ForStmt:
+		span.End = w.stmtSpan(n.Body).End
+	case *ForInStmt:
+		span.End = w.stmtSpan(n.Body).End
+	case *ForOfStmt:
+		span.End = w.stmtSpan(n.Body).End
+	case *WithStmt:
+		span.End = w.stmtSpan(n.Body).End
+	case *LabelledStmt:
+		span.End = w.stmtSpan(n.Value).End
+	case *BlockStmt:
+		if len(n.List) == 1 && n.Start == n.List[0].Range().Start {
+			// body of a for statement without braces
+			span.End = w.stmtSpan(n.List[0]).End
+		}
+	}
+	if span.End < stmt.Range().End {
+		span.End = stmt.Range().End
+	}
+	return span
+}
+
+// semicolon returns the offset after the semicolon that follows end, skipping whitespace and comments, or end if it is not followed by a semicolon.
+func (w *estreeWriter) semicolon(end int) int {
+	for i := end; i < len(w.src); i++ {
+		switch c := w.src[i]; c {
+		case ';':
+			return i + 1
+		case ' ', '\t', '\n', '\r', '\v', '\f':
+		case '/':
+			if i+1 < len(w.src) && w.src[i+1] == '/' {
+				for i+1 < len(w.src) && w.src[i+1] != '\n' && w.src[i+1] != '\r' {
+					i++
+				}
+			} else if i+1 < len(w.src) && w.src[i+1] == '*' {
+				n := bytes.Index(w.src[i+2:], []byte("*/"))
+				if n == -1 {
+					return end
+				}
+				i += n + 3
+			} else {
+				return end
+			}
+		default:
+			return end
+		}
+	}
+	return end
+}
+
+func (w *estreeWriter) optionalStmt(stmt IStmt) {
+	if stmt == nil {
+		w.buf.WriteString("null")
+	} else {
+		w.stmt(stmt)
+	}
+}
+
+func (w *estreeWriter) block(n *BlockStmt) {
+	w.open("BlockStatement", n.Span)
+	w.list("body", len(n.List), func(i int) { w.stmt(n.List[i]) })
+	w.close()
+}
+
+// loopBody writes the body of a for statement, which is a block that holds the single statement of a body without braces.
+func (w *estreeWriter) loopBody(n *BlockStmt) {
+	if len(n.List) == 1 && n.Start == n.List[0].Range().Start {
+		w.stmt(n.List[0])
+	} else if len(n.List) == 0 && n.Start == n.End {
+		start := w.index(";", n.Start, w.parent().End)
+		w.open("EmptyStatement", Span{start, start + 1})
+		w.close()
+	} else {
+		w.block(n)
+	}
+}
+
+func (w *estreeWriter) forInit(init IExpr) {
+	if varDecl, ok := init.(*VarDecl); ok {
+		w.varDecl(varDecl, varDecl.Span)
+	} else {
+		w.target(init)
+	}
+}
+
+func (w *estreeWriter) label(label []byte, start int) {
+	if label == nil {
+		w.buf.WriteString("null")
+		return
+	}
+	w.open("Identifier", Span{start, start + len(label)})
+	w.name(label)
+	w.close()
+}
+
+func (w *estreeWriter) varDecl(n *VarDecl, span Span) {
+	w.open("VariableDeclaration", span)
+	w.list("declarations", len(n.List), func(i int) {
+		item := n.List[i]
+		w.open("VariableDeclarator", item.Span)
+		w.key("id")
+		w.pattern(item.Binding)
+		w.key("init")
+		w.expr(item.Default)
+		w.close()
+	})
+	w.string("kind", n.TokenType.Bytes())
+	w.close()
+}
+
+func (w *estreeWriter) importStmt(n *ImportStmt, span Span) {
+	w.open("ImportDeclaration", span)
+	var specifiers []func()
+	if n.Default != nil {
+		start := w.index(string(n.Default), n.Start+len("import"), n.End)
+		specifiers = append(specifiers, func() {
+			w.open("ImportDefaultSpecifier", Span{start, start + len(n.Default)})
+			w.key("local")
+			w.moduleName(n.Default, start)
+			w.close()
+		})
+	}
+	for _, alias := range n.List {
+		alias := alias
+		if alias.Binding == nil {
+			continue // trailing comma
+		}
+		specifiers = append(specifiers, func() {
+			binding := alias.End - len(alias.Binding)
+			if bytes.Equal(alias.Name, []byte("*")) {
+				w.open("ImportNamespaceSpecifier", alias.Span)
+				w.key("local")
+				w.moduleName(alias.Binding, binding)
+				w.close()
+				return
+			}
+			w.open("ImportSpecifier", alias.Span)
+			w.key("imported")
+			if alias.Name != nil {
+				w.moduleName(alias.Name, alias.Start)
+			} else {
+				w.moduleName(alias.Binding, binding)
+			}
+			w.key("local")
+			w.moduleName(alias.Binding, binding)
+			w.close()
+		})
+	}
+	w.list("specifiers", len(specifiers), func(i int) { specifiers[i]() })
+	w.key("source")
+	w.source(n.Module, n.Span)
+	w.attributes(n.Attributes, n.Span)
+	w.close()
+}
+
+func (w *estreeWriter) exportStmt(n *ExportStmt, span Span) {
+	if n.Default {
+		w.open("ExportDefaultDeclaration", span)
+		w.key("declaration")
+		switch decl := n.Decl.(type) {
+		case *FuncDecl:
+			w.function("FunctionDeclaration", decl)
+		case *ClassDecl:
+			w.class("ClassDeclaration", decl)
+		default:
+			w.expr(decl)
+		}
+		w.close()
+		return
+	} else if len(n.List) == 1 && (bytes.Equal(n.List[0].Name, []byte("*")) || n.List[0].Name == nil && bytes.Equal(n.List[0].Binding, []byte("*"))) {
+		alias := n.List[0]
+		w.open("ExportAllDeclaration", span)
+		w.key("exported")
+		if alias.Name != nil {
+			w.moduleName(alias.Binding, alias.End-len(alias.Binding))
+		} else {
+			w.buf.WriteString("null")
+		}
+		w.key("source")
+		w.source(n.Module, n.Span)
+		w.attributes(n.Attributes, n.Span)
+		w.close()
+		return
+	}
+
+	w.open("ExportNamedDeclaration", span)
+	w.key("declaration")
+	switch decl := n.Decl.(type) {
+	case nil:
+		w.buf.WriteString("null")
+	case *VarDecl:
+		w.varDecl(decl, Span{decl.Start, span.End})
+	case *FuncDecl:
+		w.function("FunctionDeclaration", decl)
+	case *ClassDecl:
+		w.class("ClassDeclaration", decl)
+	default:
+		w.fail(decl)
+	}
+	aliases := []Alias{}
+	for _, alias := range n.List {
+		if alias.Binding != nil {
+			aliases = append(aliases, alias)
+		}
+	}
+	w.list("specifiers", len(aliases), func(i int) {
+		alias := aliases[i]
+		w.open("ExportSpecifier", alias.Span)
+		w.key("local")
+		if alias.Name != nil {
+			w.moduleName(alias.Name, alias.Start)
+		} else {
+			w.moduleName(alias.Binding, alias.Start)
+		}
+		w.key("exported")
+		w.moduleName(alias.Binding, alias.End-len(alias.Binding))
+		w.close()
+	})
+	w.key("source")
+	w.source(n.Module, n.Span)
+	w.attributes(n.Attributes, n.Span)
+	w.close()
+}
+
+// moduleName writes an imported or exported name, which is an identifier or a string.
+func (w *estreeWriter) moduleName(name []byte, start int) {
+	if 0 < len(name) && (name[0] == '"' || name[0] == '\'') {
+		w.literal(&LiteralExpr{StringToken, name, Span{start, start + len(name)}})
+		return
+	}
+	w.open("Identifier", Span{start, start + len(name)})
+	w.name(name)
+	w.close()
+}
+
+// source writes the module specifier of an import or export statement.
+func (w *estreeWriter) source(module []byte, span Span) {
+	if module == nil {
+		w.buf.WriteString("null")
+		return
+	}
+	start := w.lastIndex(string(module), span.Start, span.End)
+	w.literal(&LiteralExpr{StringToken, module, Span{start, start + len(module)}})
+}
+
+func (w *estreeWriter) attributes(list []ImportAttribute, span Span) {
+	w.list("attributes", len(list), func(i int) {
+		attr := list[i]
+		w.open("ImportAttribute", attr.Span)
+		w.key("key")
+		w.moduleName(attr.Key, attr.Start)
+		w.key("value")
+		w.literal(&LiteralExpr{StringToken, attr.Value, Span{attr.End - len(attr.Value), attr.End}})
+		w.close()
+	})
+}
+
+////////////////////////////////////////////////////////////////
+
+func (w *estreeWriter) function(typ string, n *FuncDecl) {
+	w.open(typ, n.Span)
+	w.key("id")
+	if n.Name != nil {
+		w.ident(n.Name)
+	} else {
+		w.buf.WriteString("null")
+	}
+	w.bool("expression", false)
+	w.bool("generator", n.Generator)
+	w.bool("async", n.Async)
+	w.params(n.Params)
+	w.key("body")
+	w.block(&n.Body)
+	w.close()
+}
+
+// method writes the function of a method, which starts at its parameters.
+func (w *estreeWriter) method(n *MethodDecl) {
+	w.open("FunctionExpression", Span{n.Params.Start, n.End})
+	w.null("id")
+	w.bool("expression", false)
+	w.bool("generator", n.Generator)
+	w.bool("async", n.Async)
+	w.params(n.Params)
+	w.key("body")
+	w.block(&n.Body)
+	w.close()
+}
+
+func (w *estreeWriter) arrowFunc(n *ArrowFunc) {
+	w.open("ArrowFunctionExpression", n.Span)
+	w.null("id")
+	ret, expression := (*ReturnStmt)(nil), false
+	if len(n.Body.List) == 1 {
+		ret, expression = n.Body.List[0].(*ReturnStmt)
+		expression = expression && ret.Span == n.Body.Span
+	}
+	w.bool("expression", expression)
+	w.bool("generator", false)
+	w.bool("async", n.Async)
+	w.params(n.Params)
+	w.key("body")
+	if expression {
+		w.expr(ret.Value)
+	} else {
+		w.block(&n.Body)
+	}
+	w.close()
+}
+
+func (w *estreeWriter) params(n Params) {
+	n2 := len(n.List)
+	if n.Rest != nil {
+		n2++
+	}
+	w.list("params", n2, func(i int) {
+		if i < len(n.List) {
+			w.bindingElement(n.List[i])
+		} else {
+			w.rest(n.Rest)
+		}
+	})
+}
+
+func (w *estreeWriter) rest(binding IBinding) {
+	span := binding.Range()
+	w.open("RestElement", Span{w.lastIndex("...", w.parent().Start, span.Start), span.End})
+	w.key("argument")
+	w.pattern(binding)
+	w.close()
+}
+
+func (w *estreeWriter) bindingElement(n BindingElement) {
+	if n.Binding == nil {
+		w.buf.WriteString("null")
+	} else if n.Default != nil {
+		w.open("AssignmentPattern", n.Span)
+		w.key("left")
+		w.pattern(n.Binding)
+		w.key("right")
+		w.expr(n.Default)
+		w.close()
+	} else {
+		w.pattern(n.Binding)
+	}
+}
+
+func (w *estreeWriter) pattern(binding IBinding) {
+	switch n := binding.(type) {
+	case nil:
+		w.buf.WriteString("null")
+	case *Var:
+		w.ident(n)
+	case *BindingArray:
+		w.open("ArrayPattern", n.Span)
+		n2 := len(n.List)
+		if n.Rest != nil {
+			n2++
+		}
+		w.list("elements", n2, func(i int) {
+			if i < len(n.List) {
+				w.bindingElement(n.List[i])
+			} else {
+				w.rest(n.Rest)
+			}
+		})
+		w.close()
+	case *BindingObject:
+		w.open("ObjectPattern", n.Span)
+		n2 := len(n.List)
+		if n.Rest != nil {
+			n2++
+		}
+		w.list("properties", n2, func(i int) {
+			if len(n.List) <= i {
+				w.rest(n.Rest)
+				return
+			}
+			item := n.List[i]
+			v, isVar := item.Value.Binding.(*Var)
+			shorthand := item.Key == nil || isVar && item.Key.IsIdent(v.Name()) && item.Value.Start == item.Key.Start
+			w.open("Property", item.Span)
+			w.bool("method", false)
+			w.bool("shorthand", shorthand)
+			w.bool("computed", item.Key != nil && item.Key.IsComputed())
+			w.key("key")
+			if item.Key != nil {
+				w.propertyName(item.Key)
+			} else {
+				w.pattern(item.Value.Binding)
+			}
+			w.key("value")
+			w.bindingElement(item.Value)
+			w.string("kind", []byte("init"))
+			w.close()
+		})
+		w.close()
+	default:
+		w.fail(n)
+	}
+}
+
+func (w *estreeWriter) class(typ string, n *ClassDecl) {
+	w.open(typ, n.Span)
+	w.decorators(n.Decorators)
+	w.key("id")
+	start := n.Start
+	if n.Name != nil {
+		w.ident(n.Name)
+		start = n.Name.End
+	} else {
+		w.buf.WriteString("null")
+	}
+	w.key("superClass")
+	w.expr(n.Extends)
+	if n.Extends != nil {
+		start = n.Extends.Range().End
+	}
+	w.key("body")
+	w.open("ClassBody", Span{w.index("{", start, n.End), n.End})
+	w.list("body", len(n.List), func(i int) {
+		item := n.List[i]
+		if item.StaticBlock != nil {
+			w.open("StaticBlock", item.Span)
+			w.list("body", len(item.StaticBlock.List), func(i int) { w.stmt(item.StaticBlock.List[i]) })
+			w.close()
+		} else if item.Method != nil {
+			method := item.Method
+			kind := "method"
+			if method.Get {
+				kind = "get"
+			} else if method.Set {
+				kind = "set"
+			} else if !method.Static && (method.Name.IsIdent([]byte("constructor")) || method.Name.Literal.TokenType == StringToken && string(method.Name.Literal.Data[1:len(method.Name.Literal.Data)-1]) == "constructor") {
+				kind = "constructor"
+			}
+			w.open("MethodDefinition", method.Span)
+			w.decorators(method.Decorators)
+			w.bool("static", method.Static)
+			w.bool("computed", method.Name.IsComputed())
+			w.key("key")
+			w.propertyName(&method.Name)
+			w.string("kind", []byte(kind))
+			w.key("value")
+			w.method(method)
+			w.close()
+		} else {
+			typ := "PropertyDefinition"
+			if item.Field.Accessor {
+				typ = "AccessorProperty"
+			}
+			w.open(typ, Span{item.Field.Start, w.semicolon(item.Field.End)})
+			w.decorators(item.Field.Decorators)
+			w.bool("static", item.Field.Static)
+			w.bool("computed", item.Field.Name.IsComputed())
+			w.key("key")
+			w.propertyName(&item.Field.Name)
+			w.key("value")
+			w.expr(item.Field.Init)
+			w.close()
+		}
+	})
+	w.close()
+	w.close()
+}
+
+func (w *estreeWriter) decorators(list []IExpr) {
+	if len(list) == 0 {
+		return
+	}
+	w.list("decorators", len(list), func(i int) {
+		span := list[i].Range()
+		w.open("Decorator", Span{w.lastIndex("@", w.parent().Start, span.Start), span.End})
+		w.key("expression")
+		w.expr(list[i])
+		w.close()
+	})
+}
+
+func (w *estreeWriter) propertyName(n *PropertyName) {
+	if n.IsComputed() {
+		w.expr(n.Computed)
+		return
+	}
+	lit := &n.Literal
+	if w.src != nil && lit.TokenType != StringToken && lit.Start < lit.End && lit.End <= len(w.src) && (w.src[lit.Start] == '"' || w.src[lit.Start] == '\'') {
+		// string that has been converted to an identifier or number
+		lit = &LiteralExpr{StringToken, w.src[lit.Start:lit.End], lit.Span}
+	}
+	if lit.TokenType == PrivateIdentifierToken {
+		w.open("PrivateIdentifier", lit.Span)
+		w.name(lit.Data[1:])
+		w.close()
+	} else if IsIdentifierName(lit.TokenType) {
+		w.open("Identifier", lit.Span)
+		w.name(lit.Data)
+		w.close()
+	} else {
+		w.literal(lit)
+	}
+}
+
+////////////////////////////////////////////////////////////////
+
+// ident writes an identifier at its occurrence within the node that is being written.
+func (w *estreeWriter) ident(v *Var) {
+	w.open("Identifier", w.occurrences.span(v, w.parent()))
+	w.name(v.Name())
+	w.close()
+}
+
+// name writes the name of an identifier, of which unicode escapes are decoded.
+func (w *estreeWriter) name(data []byte) {
+	if bytes.IndexByte(data, '\\') != -1 {
+		units, _ := cookString(data, false)
+		w.key("name")
+		writeJSONUTF16(&w.buf, units)
+		return
+	}
+	w.string("name", data)
+}
+
+func (w *estreeWriter) literal(n *LiteralExpr) {
+	switch n.TokenType {
+	case ThisToken:
+		w.open("ThisExpression", n.Span)
+		w.close()
+		return
+	case SuperToken:
+		w.open("Super", n.Span)
+		w.close()
+		return
+	case PrivateIdentifierToken:
+		w.open("PrivateIdentifier", n.Span)
+		w.name(n.Data[1:])
+		w.close()
+		return
+	case IdentifierToken:
+		w.open("Identifier", n.Span)
+		w.name(n.Data)
+		w.close()
+		return
+	}
+
+	w.open("Literal", n.Span)
+	w.key("value")
+	switch n.TokenType {
+	case StringToken:
+		units, _ := cookString(n.Data[1:len(n.Data)-1], false)
+		writeJSONUTF16(&w.buf, units)
+	case TrueToken, FalseToken, NullToken:
+		w.buf.Write(n.Data)
+	case BigIntToken, RegExpToken:
+		w.buf.WriteString("null")
+	default:
+		if f := numericValue(n.TokenType, n.Data); math.IsInf(f, 0) || math.IsNaN(f) {
+			w.buf.WriteString("null")
+		} else {
+			w.buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+		}
+	}
+	w.string("raw", n.Data)
+	if n.TokenType == BigIntToken {
+		w.string("bigint", bytes.ReplaceAll(n.Data[:len(n.Data)-1], []byte("_"), nil))
+	} else if n.TokenType == RegExpToken {
+		i := bytes.LastIndexByte(n.Data, '/')
+		w.key("regex")
+		w.buf.WriteString(`{"pattern":`)
+		writeJSONString(&w.buf, n.Data[1:i])
+		w.buf.WriteString(`,"flags":`)
+		writeJSONString(&w.buf, n.Data[i+1:])
+		w.buf.WriteByte('}')
+	}
+	w.close()
+}
+
+func (w *estreeWriter) expr(expr IExpr) {
+	switch n := expr.(type) {
+	case nil:
+		w.buf.WriteString("null")
+	case *Var:
+		w.ident(n)
+	case *LiteralExpr:
+		if n.TokenType == ImportToken {
+			w.fail(n)
+			return
+		}
+		w.literal(n)
+	case *GroupExpr:
+		w.expr(n.X)
+	case *ArrayExpr:
+		w.open("ArrayExpression", n.Span)
+		w.list("elements", len(n.List), func(i int) {
+			item := n.List[i]
+			if item.Value == nil {
+				w.buf.WriteString("null")
+			} else if item.Spread {
+				w.spread(item.Value)
+			} else {
+				w.expr(item.Value)
+			}
+		})
+		w.close()
+	case *ObjectExpr:
+		w.open("ObjectExpression", n.Span)
+		w.list("properties", len(n.List), func(i int) { w.property(&n.List[i], false) })
+		w.close()
+	case *TemplateExpr:
+		if n.Tag == nil {
+			w.template(n, n.Span)
+		} else {
+			w.chain(n)
+		}
+	case *DotExpr, *IndexExpr, *CallExpr:
+		w.chain(n)
+	case *NewTargetExpr:
+		w.metaProperty(n.Span, "new", "target")
+	case *ImportMetaExpr:
+		w.metaProperty(n.Span, "import", "meta")
+	case *NewExpr:
+		w.open("NewExpression", n.Span)
+		w.key("callee")
+		w.expr(n.X)
+		var args []Arg
+		if n.Args != nil {
+			args = n.Args.List
+		}
+		w.args(args)
+		w.close()
+	case *UnaryExpr:
+		switch n.Op {
+		case AwaitToken:
+			w.open("AwaitExpression", n.Span)
+			w.key("argument")
+			w.expr(n.X)
+			w.close()
+		case PreIncrToken, PreDecrToken, PostIncrToken, PostDecrToken:
+			w.open("UpdateExpression", n.Span)
+			w.string("operator", n.Op.Bytes())
+			w.bool("prefix", n.Op == PreIncrToken || n.Op == PreDecrToken)
+			w.key("argument")
+			w.expr(n.X)
+			w.close()
+		default:
+			w.open("UnaryExpression", n.Span)
+			w.string("operator", n.Op.Bytes())
+			w.bool("prefix", true)
+			w.key("argument")
+			w.expr(n.X)
+			w.close()
+		}
+	case *BinaryExpr:
+		if isAssignment(n.Op) {
+			w.open("AssignmentExpression", n.Span)
+			w.string("operator", n.Op.Bytes())
+			w.key("left")
+			if n.Op == EqToken {
+				w.target(n.X)
+			} else {
+				w.expr(n.X)
+			}
+		} else {
+			typ := "BinaryExpression"
+			if n.Op == AndToken || n.Op == OrToken || n.Op == NullishToken {
+				typ = "LogicalExpression"
+			}
+			w.open(typ, n.Span)
+			w.key("left")
+			w.expr(n.X)
+			w.string("operator", n.Op.Bytes())
+		}
+		w.key("right")
+		w.expr(n.Y)
+		w.close()
+	case *CondExpr:
+		w.open("ConditionalExpression", n.Span)
+		w.key("test")
+		w.expr(n.Cond)
+		w.key("consequent")
+		w.expr(n.X)
+		w.key("alternate")
+		w.expr(n.Y)
+		w.close()
+	case *YieldExpr:
+		w.open("YieldExpression", n.Span)
+		w.bool("delegate", n.Generator)
+		w.key("argument")
+		w.expr(n.X)
+		w.close()
+	case *ArrowFunc:
+		w.arrowFunc(n)
+	case *CommaExpr:
+		w.open("SequenceExpression", n.Span)
+		w.list("expressions", len(n.List), func(i int) { w.expr(n.List[i]) })
+		w.close()
+	case *FuncDecl:
+		w.function("FunctionExpression", n)
+	case *ClassDecl:
+		w.class("ClassExpression", n)
+	case *JSXElement:
+		w.jsxElement(n)
+	default:
+		w.fail(n)
+	}
+}
+
+func (w *estreeWriter) spread(x IExpr) {
+	span := x.Range()
+	w.open("SpreadElement", Span{w.lastIndex("...", w.parent().Start, span.Start), span.End})
+	w.key("argument")
+	w.expr(x)
+	w.close()
+}
+
+func (w *estreeWriter) args(args []Arg) {
+	w.list("arguments", len(args), func(i int) {
+		if args[i].Rest {
+			w.spread(args[i].Value)
+		} else {
+			w.expr(args[i].Value)
+		}
+	})
+}
+
+func (w *estreeWriter) metaProperty(span Span, meta, property string) {
+	w.open("MetaProperty", span)
+	w.key("meta")
+	w.open("Identifier", Span{span.Start, span.Start + len(meta)})
+	w.string("name", []byte(meta))
+	w.close()
+	w.key("property")
+	w.open("Identifier", Span{span.End - len(property), span.End})
+	w.string("name", []byte(property))
+	w.close()
+	w.close()
+}
+
+// property writes a property of an object literal or object pattern.
+func (w *estreeWriter) property(n *Property, pattern bool) {
+	if n.Spread {
+		if pattern {
+			span := n.Value.Range()
+			w.open("RestElement", Span{w.lastIndex("...", w.parent().Start, span.Start), span.End})
+			w.key("argument")
+			w.target(n.Value)
+			w.close()
+		} else {
+			w.spread(n.Value)
+		}
+		return
+	}
+
+	w.open("Property", n.Span)
+	if method, ok := n.Value.(*MethodDecl); ok {
+		kind := "init"
+		if method.Get {
+			kind = "get"
+		} else if method.Set {
+			kind = "set"
+		}
+		w.bool("method", kind == "init")
+		w.bool("shorthand", false)
+		w.bool("computed", method.Name.IsComputed())
+		w.key("key")
+		w.propertyName(&method.Name)
+		w.key("value")
+		w.method(method)
+		w.string("kind", []byte(kind))
+		w.close()
+		return
+	}
+
+	v, isVar := n.Value.(*Var)
+	shorthand := n.Init != nil || isVar && n.Name.IsIdent(v.Name()) && n.Name.End == n.End
+	w.bool("method", false)
+	w.bool("shorthand", shorthand)
+	w.bool("computed", n.Name.IsComputed())
+	w.key("key")
+	w.propertyName(n.Name)
+	w.key("value")
+	if n.Init != nil {
+		w.open("AssignmentPattern", n.Span)
+		w.key("left")
+		w.expr(n.Value)
+		w.key("right")
+		w.expr(n.Init)
+		w.close()
+	} else if pattern {
+		w.target(n.Value)
+	} else {
+		w.expr(n.Value)
+	}
+	w.string("kind", []byte("init"))
+	w.close()
+}
+
+// target writes an assignment target, where array and object literals are patterns.
+func (w *estreeWriter) target(expr IExpr) {
+	switch n := expr.(type) {
+	case *GroupExpr:
+		w.target(n.X)
+	case *ArrayExpr:
+		w.open("ArrayPattern", n.Span)
+		w.list("elements", len(n.List), func(i int) {
+			item := n.List[i]
+			if item.Value == nil {
+				w.buf.WriteString("null")
+			} else if item.Spread {
+				span := item.Value.Range()
+				w.open("RestElement", Span{w.lastIndex("...", w.parent().Start, span.Start), span.End})
+				w.key("argument")
+				w.target(item.Value)
+				w.close()
+			} else {
+				w.target(item.Value)
+			}
+		})
+		w.close()
+	case *ObjectExpr:
+		w.open("ObjectPattern", n.Span)
+		w.list("properties", len(n.List), func(i int) { w.property(&n.List[i], true) })
+		w.close()
+	case *BinaryExpr:
+		if n.Op != EqToken {
+			w.expr(n)
+			return
+		}
+		w.open("AssignmentPattern", n.Span)
+		w.key("left")
+		w.target(n.X)
+		w.key("right")
+		w.expr(n.Y)
+		w.close()
+	default:
+		w.expr(n)
+	}
+}
+
+// chain writes a member, call, or tagged template expression, which is wrapped in a ChainExpression when it is an optional chain.
+func (w *estreeWriter) chain(expr IExpr) {
+	if isOptionalChain(expr) {
+		w.open("ChainExpression", expr.Range())
+		w.key("expression")
+		w.chainElement(expr)
+		w.close()
+	} else {
+		w.chainElement(expr)
+	}
+}
+
+// chainObject writes the object or callee of a chain element, which continues the chain.
+func (w *estreeWriter) chainObject(expr IExpr) {
+	switch expr.(type) {
+	case *DotExpr, *IndexExpr, *CallExpr:
+		w.chainElement(expr)
+	case *TemplateExpr:
+		if expr.(*TemplateExpr).Tag != nil {
+			w.chainElement(expr)
+		} else {
+			w.expr(expr)
+		}
+	default:
+		w.expr(expr)
+	}
+}
+
+func (w *estreeWriter) chainElement(expr IExpr) {
+	switch n := expr.(type) {
+	case *DotExpr:
+		w.open("MemberExpression", n.Span)
+		w.key("object")
+		w.chainObject(n.X)
+		w.key("property")
+		w.literal(&n.Y)
+		w.bool("computed", false)
+		w.bool("optional", n.Optional)
+		w.close()
+	case *IndexExpr:
+		w.open("MemberExpression", n.Span)
+		w.key("object")
+		w.chainObject(n.X)
+		w.key("property")
+		w.expr(n.Y)
+		w.bool("computed", true)
+		w.bool("optional", n.Optional)
+		w.close()
+	case *CallExpr:
+		if lit, ok := n.X.(*LiteralExpr); ok && lit.TokenType == ImportToken {
+			w.open("ImportExpression", n.Span)
+			w.key("source")
+			if 0 < len(n.Args.List) {
+				w.expr(n.Args.List[0].Value)
+			} else {
+				w.buf.WriteString("null")
+			}
+			w.key("options")
+			if 1 < len(n.Args.List) {
+				w.expr(n.Args.List[1].Value)
+			} else {
+				w.buf.WriteString("null")
+			}
+			w.close()
+			return
+		}
+		w.open("CallExpression", n.Span)
+		w.key("callee")
+		w.chainObject(n.X)
+		w.args(n.Args.List)
+		w.bool("optional", n.Optional)
+		w.close()
+	case *TemplateExpr:
+		start := n.End - len(n.Tail)
+		if 0 < len(n.List) {
+			start = n.List[0].Start
+		}
+		w.open("TaggedTemplateExpression", n.Span)
+		w.key("tag")
+		w.chainObject(n.Tag)
+		w.key("quasi")
+		w.template(n, Span{start, n.End})
+		w.close()
+	}
+}
+
+// template writes a template literal, where the quasis do not include the delimiters.
+func (w *estreeWriter) template(n *TemplateExpr, span Span) {
+	w.open("TemplateLiteral", span)
+	w.list("quasis", len(n.List)+1, func(i int) {
+		var raw []byte
+		var start int
+		if i < len(n.List) {
+			raw = n.List[i].Value[1 : len(n.List[i].Value)-2]
+			start = n.List[i].Start + 1
+		} else {
+			raw = n.Tail[1 : len(n.Tail)-1]
+			start = n.End - len(n.Tail) + 1
+		}
+		w.open("TemplateElement", Span{start, start + len(raw)})
+		raw = normalizeLineTerminators(raw)
+		w.key("value")
+		w.buf.WriteString(`{"raw":`)
+		writeJSONString(&w.buf, raw)
+		w.buf.WriteString(`,"cooked":`)
+		if units, invalid := cookString(raw, true); invalid == -1 {
+			writeJSONUTF16(&w.buf, units)
+		} else {
+			w.buf.WriteString("null")
+		}
+		w.buf.WriteByte('}')
+		w.bool("tail", i == len(n.List))
+		w.close()
+	})
+	w.list("expressions", len(n.List), func(i int) { w.expr(n.List[i].Expr) })
+	w.close()
+}
+
+func (w *estreeWriter) jsxElement(n *JSXElement) {
+	closing := w.lastIndex("</", n.Start, n.End)
+	if n.Name == nil {
+		w.open("JSXFragment", n.Span)
+		w.key("openingFragment")
+		w.open("JSXOpeningFragment", Span{n.Start, w.index(">", n.Start, n.End) + 1})
+		w.close()
+		w.key("closingFragment")
+		w.open("JSXClosingFragment", Span{closing, n.End})
+		w.close()
+		w.jsxChildren(n.Children)
+		w.close()
+		return
+	}
+
+	end := n.End
+	if !n.SelfClosing {
+		end = n.Name.Range().End
+		if 0 < len(n.Attrs) {
+			end = n.Attrs[len(n.Attrs)-1].End
+		}
+		end = w.index(">", end, n.End) + 1
+	}
+	w.open("JSXElement", n.Span)
+	w.key("openingElement")
+	w.open("JSXOpeningElement", Span{n.Start, end})
+	w.key("name")
+	w.jsxName(n.Name, 0)
+	w.list("attributes", len(n.Attrs), func(i int) {
+		attr := n.Attrs[i]
+		if attr.Spread {
+			w.open("JSXSpreadAttribute", attr.Span)
+			w.key("argument")
+			w.expr(attr.Value)
+			w.close()
+			return
+		}
+		w.open("JSXAttribute", attr.Span)
+		w.key("name")
+		w.jsxIdentifier(attr.Name, attr.Start)
+		w.key("value")
+		switch value := attr.Value.(type) {
+		case nil:
+			w.buf.WriteString("null")
+		case *LiteralExpr:
+			w.literal(value)
+		default:
+			w.jsxChild(value)
+		}
+		w.close()
+	})
+	w.bool("selfClosing", n.SelfClosing)
+	w.close()
+	w.key("closingElement")
+	if n.SelfClosing {
+		w.buf.WriteString("null")
+	} else {
+		w.open("JSXClosingElement", Span{closing, n.End})
+		w.key("name")
+		w.jsxName(n.Name, closing+2-n.Start)
+		w.close()
+	}
+	w.jsxChildren(n.Children)
+	w.close()
+}
+
+// jsxName writes the name of a JSX element, which is shifted by offset for the closing element.
+func (w *estreeWriter) jsxName(name IExpr, offset int) {
+	span := name.Range()
+	span.Start += offset
+	span.End += offset
+	switch n := name.(type) {
+	case *JSXName:
+		w.jsxIdentifier(n.Data, span.Start)
+	case *Var:
+		w.open("JSXIdentifier", span)
+		w.string("name", n.Name())
+		w.close()
+	case *LiteralExpr:
+		w.open("JSXIdentifier", span)
+		w.string("name", n.Data)
+		w.close()
+	case *DotExpr:
+		w.open("JSXMemberExpression", span)
+		w.key("object")
+		w.jsxName(n.X, offset)
+		w.key("property")
+		w.open("JSXIdentifier", Span{n.Y.Start + offset, n.Y.End + offset})
+		w.string("name", n.Y.Data)
+		w.close()
+		w.close()
+	default:
+		w.fail(n)
+	}
+}
+
+// jsxIdentifier writes a JSX identifier or a namespaced name such as xlink:href.
+func (w *estreeWriter) jsxIdentifier(name []byte, start int) {
+	if i := bytes.IndexByte(name, ':'); i != -1 {
+		w.open("JSXNamespacedName", Span{start, start + len(name)})
+		w.key("namespace")
+		w.jsxIdentifier(name[:i], start)
+		w.key("name")
+		w.jsxIdentifier(name[i+1:], start+i+1)
+		w.close()
+		return
+	}
+	w.open("JSXIdentifier", Span{start, start + len(name)})
+	w.string("name", name)
+	w.close()
+}
+
+func (w *estreeWriter) jsxChildren(children []IExpr) {
+	w.list("children", len(children), func(i int) { w.jsxChild(children[i]) })
+}
+
+func (w *estreeWriter) jsxChild(child IExpr) {
+	switch n := child.(type) {
+	case *JSXText:
+		w.open("JSXText", n.Span)
+		w.string("value", n.Data)
+		w.string("raw", n.Data)
+		w.close()
+	case *JSXExpr:
+		if n.Spread {
+			w.open("JSXSpreadChild", n.Span)
+		} else {
+			w.open("JSXExpressionContainer", n.Span)
+		}
+		w.key("expression")
+		if n.X == nil {
+			w.open("JSXEmptyExpression", Span{n.Start + 1, n.End - 1})
+			w.close()
+		} else {
+			w.expr(n.X)
+		}
+		w.close()
+	case *JSXElement:
+		w.jsxElement(n)
+	default:
+		w.fail(n)
+	}
+}
+
+////////////////////////////////////////////////////////////////
+
+// normalizeLineTerminators replaces CRLF and CR by LF, as is done for the raw value of templates.
+func normalizeLineTerminators(b []byte) []byte {
+	if bytes.IndexByte(b, '\r') == -1 {
+		return b
+	}
+	b = bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(b, []byte("\r"), []byte("\n"))
+}
+
+// numericValue returns the value of a numeric literal.
+func numericValue(tt TokenType, data []byte) float64 {
+	data = bytes.ReplaceAll(data, []byte("_"), nil)
+	base := 0
+	switch tt {
+	case DecimalToken:
+		f, _ := strconv.ParseFloat(string(data), 64)
+		return f
+	case BinaryToken:
+		base, data = 2, data[2:]
+	case OctalToken:
+		base, data = 8, data[2:]
+	case HexadecimalToken:
+		base, data = 16, data[2:]
+	case LegacyOctalToken:
+		base, data = 8, data[1:]
+	default:
+		return math.NaN()
+	}
+	f := 0.0
+	for _, c := range data {
+		f = f*float64(base) + float64(hexValue(c))
+	}
+	return f
+}
+
+// writeJSONString writes a JSON string of UTF-8 encoded b.
+func writeJSONString(buf *bytes.Buffer, b []byte) {
+	units := make([]uint16, 0, len(b))
+	for i := 0; i < len(b); {
+		r, n := utf8.DecodeRune(b[i:])
+		units = appendUTF16(units, r)
+		i += n
+	}
+	writeJSONUTF16(buf, units)
+}
+
+// writeJSONUTF16 writes a JSON string of UTF-16 code units, where lone surrogates are escaped.
+func writeJSONUTF16(buf *bytes.Buffer, units []uint16) {
+	const hex = "0123456789abcdef"
+	buf.WriteByte('"')
+	for i := 0; i < len(units); i++ {
+		r := rune(units[i])
+		if utf16.IsSurrogate(r) && r < 0xDC00 && i+1 < len(units) && 0xDC00 <= units[i+1] && units[i+1] < 0xE000 {
+			r = utf16.DecodeRune(r, rune(units[i+1]))
+			i++
+		} else if utf16.IsSurrogate(r) {
+			buf.WriteString(`\u`)
+			buf.WriteByte(hex[r>>12])
+			buf.WriteByte(hex[r>>8&0xF])
+			buf.WriteByte(hex[r>>4&0xF])
+			buf.WriteByte(hex[r&0xF])
+			continue
+		}
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hex[r>>4])
+				buf.WriteByte(hex[r&0xF])
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+////////////////////////////////////////////////////////////////
+
+// ParseESTree returns the AST of ESTree JSON, as returned by ESTree or by JavaScript tools such as Acorn. The offsets of the nodes, which are in UTF-16 code units, are converted to byte offsets of src when it is given, which is also required to attach the comments to the nodes. Parentheses are added where they are required by the precedence of operators. Identifiers are not resolved: every identifier has its own Var and the scopes have no declared or undeclared variables.
+func ParseESTree(b, src []byte) (*AST, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	root, _ := v.(map[string]interface{})
+	if estreeNode(root).typ() != "Program" {
+		return nil, fmt.Errorf("ESTree root must be a Program node")
+	}
+
+	r := &estreeReader{src: src}
+	if src != nil {
+		r.offsets = make([]int, 0, len(src)+1)
+		for i := 0; i < len(src); {
+			r.offsets = append(r.offsets, i)
+			c, n := utf8.DecodeRune(src[i:])
+			if 0x10000 <= c {
+				r.offsets = append(r.offsets, i) // second code unit of a surrogate pair
+			}
+			i += n
+		}
+		r.offsets = append(r.offsets, len(src))
+	}
+	ast := r.program(root)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return ast, nil
+}
+
+// estreeNode is a node of ESTree JSON.
+type estreeNode map[string]interface{}
+
+func (n estreeNode) typ() string {
+	s, _ := n["type"].(string)
+	return s
+}
+
+func (n estreeNode) node(key string) estreeNode {
+	m, _ := n[key].(map[string]interface{})
+	return m
+}
+
+// nodes returns the list of nodes of key, where null items are nil.
+func (n estreeNode) nodes(key string) []estreeNode {
+	list, _ := n[key].([]interface{})
+	nodes := make([]estreeNode, 0, len(list))
+	for _, item := range list {
+		m, _ := item.(map[string]interface{})
+		nodes = append(nodes, m)
+	}
+	return nodes
+}
+
+func (n estreeNode) str(key string) string {
+	s, _ := n[key].(string)
+	return s
+}
+
+func (n estreeNode) bool(key string) bool {
+	b, _ := n[key].(bool)
+	return b
+}
+
+func (n estreeNode) int(key string) (int, bool) {
+	if num, ok := n[key].(json.Number); ok {
+		i, err := num.Int64()
+		return int(i), err == nil
+	}
+	return 0, false
+}
+
+type estreeReader struct {
+	src     []byte
+	offsets []int // byte offsets of the UTF-16 offsets in src
+	scope   *Scope
+	strict  bool
+	err     error
+}
+
+func (r *estreeReader) fail(n estreeNode, in string) {
+	if r.err == nil {
+		if n == nil {
+			r.err = fmt.Errorf("missing ESTree node in %s", in)
+		} else {
+			r.err = fmt.Errorf("unexpected ESTree node %s in %s", n.typ(), in)
+		}
+	}
+}
+
+// span returns the span of a node in byte offsets, from either start and end or range.
+func (r *estreeReader) span(n estreeNode) Span {
+	start, ok := n.int("start")
+	end, _ := n.int("end")
+	if !ok {
+		if list, _ := n["range"].([]interface{}); len(list) == 2 {
+			n2 := estreeNode{"start": list[0], "end": list[1]}
+			start, _ = n2.int("start")
+			end, _ = n2.int("end")
+		}
+	}
+	return Span{r.offset(start), r.offset(end)}
+}
+
+func (r *estreeReader) offset(offset int) int {
+	if r.offsets == nil {
+		return offset
+	} else if offset < 0 {
+		return 0
+	} else if len(r.offsets) <= offset {
+		return len(r.src)
+	}
+	return r.offsets[offset]
+}
+
+// index returns the offset of s in the source between start and end, or start if it cannot be found.
+func (r *estreeReader) index(s string, start, end int) int {
+	if r.src == nil || end < start || len(r.src) < end {
+		return start
+	} else if i := bytes.Index(r.src[start:end], []byte(s)); i != -1 {
+		return start + i
+	}
+	return start
+}
+
+func (r *estreeReader) enterScope(scope *Scope, isFunc bool) *Scope {
+	parent := r.scope
+	r.scope = scope
+	*scope = Scope{
+		Parent: parent,
+	}
+	if isFunc {
+		scope.Func = scope
+	} else if parent != nil {
+		scope.Func = parent.Func
+	}
+	return parent
+}
+
+func (r *estreeReader) exitScope(parent *Scope) {
+	r.scope = parent
+}
+
+func (r *estreeReader) program(n estreeNode) *AST {
+	ast := &AST{}
+	r.enterScope(&ast.BlockStmt.Scope, true)
+	r.strict = n.str("sourceType") == "module"
+	ast.List = r.stmtList(n.nodes("body"), true)
+	ast.Span = r.span(n)
+	ast.Strict = r.strict
+
+	comments := []Comment{}
+	for _, c := range n.nodes("comments") {
+		comment := Comment{Span: r.span(c)}
+		if r.src != nil && comment.Start < comment.End && comment.End <= len(r.src) {
+			comment.Data = r.src[comment.Start:comment.End]
+		} else if c.typ() == "Block" {
+			comment.Data = []byte("/*" + c.str("value") + "*/")
+		} else {
+			comment.Data = []byte("//" + c.str("value"))
+		}
+		comments = append(comments, comment)
+	}
+	for _, c := range comments {
+		if 0 < len(ast.List) && ast.List[0].Range().Start <= c.Start {
+			break
+		}
+		ast.Comments = append(ast.Comments, c.Data)
+	}
+	if r.src != nil && 0 < len(comments) {
+		ast.CommentMap = newCommentMap(ast, r.src, comments)
+	}
+	return ast
+}
+
+// stmtList converts a list of statements, which starts with a directive prologue for programs and function bodies.
+func (r *estreeReader) stmtList(list []estreeNode, directives bool) []IStmt {
+	stmts := make([]IStmt, 0, len(list))
+	for _, item := range list {
+		if directive, ok := item["directive"].(string); ok && directives && item.typ() == "ExpressionStatement" {
+			if directive == "use strict" {
+				r.strict = true
+			}
+			stmts = append(stmts, &DirectivePrologueStmt{r.literal(item.node("expression")).Data, r.span(item)})
+			continue
+		}
+		directives = false
+		stmts = append(stmts, r.stmt(item))
+	}
+	return stmts
+}
+
+// block converts a block statement into b, which has its own scope.
+func (r *estreeReader) block(b *BlockStmt, n estreeNode) {
+	parent := r.enterScope(&b.Scope, false)
+	r.blockBody(b, n, false)
+	r.exitScope(parent)
+}
+
+// blockBody converts the statements of a block statement into b, of which the scope has been entered already.
+func (r *estreeReader) blockBody(b *BlockStmt, n estreeNode, directives bool) {
+	if n.typ() != "BlockStatement" {
+		r.fail(n, "block statement")
+		return
+	}
+	b.List = r.stmtList(n.nodes("body"), directives)
+	b.Span = r.span(n)
+}
+
+func (r *estreeReader) stmt(n estreeNode) IStmt {
+	span := r.span(n)
+	switch n.typ() {
+	case "BlockStatement":
+		b := &BlockStmt{}
+		r.block(b, n)
+		return b
+	case "EmptyStatement":
+		return &EmptyStmt{span}
+	case "DebuggerStatement":
+		return &DebuggerStmt{span}
+	case "ExpressionStatement":
+		x := r.expr(n.node("expression"), OpExpr)
+		if lit, ok := x.(*LiteralExpr); ok && lit.TokenType == StringToken {
+			x = &GroupExpr{x, x.Range()} // not a directive
+		} else {
+			groupLeftmost(&x, true)
+		}
+		return &ExprStmt{x, span}
+	case "IfStatement":
+		cond := r.expr(n.node("test"), OpExpr)
+		body := r.stmt(n.node("consequent"))
+		var elseStmt IStmt
+		if alternate := n.node("alternate"); alternate != nil {
+			if isDanglingIf(body) {
+				b := &BlockStmt{List: []IStmt{body}, Span: body.Range()}
+				parent := r.enterScope(&b.Scope, false)
+				r.exitScope(parent)
+				body = b
+			}
+			elseStmt = r.stmt(alternate)
+		}
+		return &IfStmt{cond, body, elseStmt, span}
+	case "WhileStatement":
+		return &WhileStmt{r.expr(n.node("test"), OpExpr), r.stmt(n.node("body")), span}
+	case "DoWhileStatement":
+		return &DoWhileStmt{r.expr(n.node("test"), OpExpr), r.stmt(n.node("body")), span}
+	case "ForStatement":
+		body := &BlockStmt{}
+		parent := r.enterScope(&body.Scope, false)
+		var init IExpr
+		if n2 := n.node("init"); n2 == nil {
+			init = &VarDecl{TokenType: VarToken, Scope: r.scope, InFor: true}
+		} else if n2.typ() == "VariableDeclaration" {
+			varDecl := r.varDecl(n2)
+			varDecl.InFor = true
+			for i := range varDecl.List {
+				if varDecl.List[i].Default != nil && hasInOperator(varDecl.List[i].Default) {
+					varDecl.List[i].Default = &GroupExpr{varDecl.List[i].Default, varDecl.List[i].Default.Range()}
+				}
+			}
+			init = varDecl
+		} else {
+			init = r.expr(n2, OpExpr)
+			if hasInOperator(init) {
+				init = &GroupExpr{init, init.Range()}
+			} else {
+				groupLeftmost(&init, false)
+			}
+		}
+		cond := r.optionalExpr(n.node("test"), OpExpr)
+		post := r.optionalExpr(n.node("update"), OpExpr)
+		r.loopBody(body, n.node("body"))
+		r.exitScope(parent)
+		return &ForStmt{init, cond, post, body, span}
+	case "ForInStatement", "ForOfStatement":
+		body := &BlockStmt{}
+		parent := r.enterScope(&body.Scope, false)
+		var init IExpr
+		if left := n.node("left"); left.typ() == "VariableDeclaration" {
+			varDecl := r.varDecl(left)
+			varDecl.InForInOf = true
+			init = varDecl
+		} else {
+			init = r.target(left)
+		}
+		var stmt IStmt
+		if n.typ() == "ForInStatement" {
+			value := r.expr(n.node("right"), OpExpr)
+			r.loopBody(body, n.node("body"))
+			stmt = &ForInStmt{init, value, body, span}
+		} else {
+			value := r.expr(n.node("right"), OpAssign)
+			r.loopBody(body, n.node("body"))
+			stmt = &ForOfStmt{n.bool("await"), init, value, body, span}
+		}
+		r.exitScope(parent)
+		return stmt
+	case "SwitchStatement":
+		switchStmt := &SwitchStmt{Init: r.expr(n.node("discriminant"), OpExpr), Span: span}
+		parent := r.enterScope(&switchStmt.Scope, false)
+		for _, item := range n.nodes("cases") {
+			clause := CaseClause{TokenType: CaseToken, Span: r.span(item)}
+			if test := item.node("test"); test != nil {
+				clause.Cond = r.expr(test, OpExpr)
+			} else {
+				clause.TokenType = DefaultToken
+			}
+			clause.List = r.stmtList(item.nodes("consequent"), false)
+			switchStmt.List = append(switchStmt.List, clause)
+		}
+		r.exitScope(parent)
+		return switchStmt
+	case "BreakStatement", "ContinueStatement":
+		tt := BreakToken
+		if n.typ() == "ContinueStatement" {
+			tt = ContinueToken
+		}
+		var label []byte
+		if n2 := n.node("label"); n2 != nil {
+			label = []byte(n2.str("name"))
+		}
+		return &BranchStmt{tt, label, span}
+	case "ReturnStatement":
+		return &ReturnStmt{r.optionalExpr(n.node("argument"), OpExpr), span}
+	case "ThrowStatement":
+		return &ThrowStmt{r.expr(n.node("argument"), OpExpr), span}
+	case "WithStatement":
+		return &WithStmt{r.expr(n.node("object"), OpExpr), r.stmt(n.node("body")), span}
+	case "LabeledStatement":
+		return &LabelledStmt{[]byte(n.node("label").str("name")), r.stmt(n.node("body")), span}
+	case "TryStatement":
+		tryStmt := &TryStmt{Body: &BlockStmt{}, Span: span}
+		r.block(tryStmt.Body, n.node("block"))
+		if handler := n.node("handler"); handler != nil {
+			tryStmt.Catch = &BlockStmt{}
+			parent := r.enterScope(&tryStmt.Catch.Scope, false)
+			if param := handler.node("param"); param != nil {
+				tryStmt.Binding = r.binding(param, CatchDecl)
+			}
+			r.blockBody(tryStmt.Catch, handler.node("body"), false)
+			r.exitScope(parent)
+		}
+		if finalizer := n.node("finalizer"); finalizer != nil {
+			tryStmt.Finally = &BlockStmt{}
+			r.block(tryStmt.Finally, finalizer)
+		}
+		return tryStmt
+	case "VariableDeclaration":
+		return r.varDecl(n)
+	case "FunctionDeclaration":
+		return r.function(n, FunctionDecl)
+	case "ClassDeclaration":
+		return r.class(n, LexicalDecl)
+	case "ImportDeclaration":
+		return r.importStmt(n)
+	case "ExportNamedDeclaration", "ExportDefaultDeclaration", "ExportAllDeclaration":
+		return r.exportStmt(n)
+	}
+	r.fail(n, "statement")
+	return &EmptyStmt{span}
+}
+
+// loopBody converts the body of a for statement into body, which holds the statement when it is not a block statement.
+func (r *estreeReader) loopBody(body *BlockStmt, n estreeNode) {
+	switch n.typ() {
+	case "BlockStatement":
+		r.blockBody(body, n, false)
+	case "EmptyStatement":
+		span := r.span(n)
+		body.Span = Span{span.Start, span.Start}
+	default:
+		stmt := r.stmt(n)
+		body.List = []IStmt{stmt}
+		body.Span = stmt.Range()
+	}
+}
+
+func (r *estreeReader) varDecl(n estreeNode) *VarDecl {
+	varDecl := &VarDecl{Scope: r.scope, Span: r.span(n)}
+	decl := LexicalDecl
+	switch n.str("kind") {
+	case "var":
+		varDecl.TokenType = VarToken
+		decl = VariableDecl
+		r.scope.Func.VarDecls = append(r.scope.Func.VarDecls, varDecl)
+	case "let":
+		varDecl.TokenType = LetToken
+	case "const":
+		varDecl.TokenType = ConstToken
+	default:
+		r.fail(n, "variable declaration")
+	}
+	for _, item := range n.nodes("declarations") {
+		varDecl.List = append(varDecl.List, BindingElement{
+			Binding: r.binding(item.node("id"), decl),
+			Default: r.optionalExpr(item.node("init"), OpAssign),
+			Span:    r.span(item),
+		})
+	}
+	return varDecl
+}
+
+func (r *estreeReader) importStmt(n estreeNode) *ImportStmt {
+	importStmt := &ImportStmt{Module: r.moduleString(n.node("source")), Span: r.span(n)}
+	for _, item := range n.nodes("specifiers") {
+		local := []byte(item.node("local").str("name"))
+		switch item.typ() {
+		case "ImportDefaultSpecifier":
+			importStmt.Default = local
+		case "ImportNamespaceSpecifier":
+			importStmt.List = append(importStmt.List, Alias{[]byte("*"), local, r.span(item)})
+		case "ImportSpecifier":
+			alias := Alias{Binding: local, Span: r.span(item)}
+			if imported := item.node("imported"); imported.typ() != "Identifier" || imported.str("name") != string(local) {
+				alias.Name = r.moduleName(imported)
+			}
+			importStmt.List = append(importStmt.List, alias)
+		default:
+			r.fail(item, "import declaration")
+		}
+	}
+	importStmt.Attributes, importStmt.Assert = r.attributes(n)
+	return importStmt
+}
+
+func (r *estreeReader) exportStmt(n estreeNode) *ExportStmt {
+	exportStmt := &ExportStmt{Span: r.span(n)}
+	switch n.typ() {
+	case "ExportDefaultDeclaration":
+		exportStmt.Default = true
+		switch decl := n.node("declaration"); decl.typ() {
+		case "FunctionDeclaration":
+			exportStmt.Decl = r.function(decl, FunctionDecl)
+		case "ClassDeclaration":
+			exportStmt.Decl = r.class(decl, LexicalDecl)
+		default:
+			x := r.expr(decl, OpAssign)
+			if left := leftmostExpr(&x); isFuncOrClass(*left) {
+				*left = &GroupExpr{*left, (*left).Range()}
+			}
+			exportStmt.Decl = x
+		}
+		return exportStmt
+	case "ExportAllDeclaration":
+		alias := Alias{Binding: []byte("*"), Span: exportStmt.Span}
+		if exported := n.node("exported"); exported != nil {
+			alias = Alias{[]byte("*"), r.moduleName(exported), r.span(exported)}
+		}
+		exportStmt.List = []Alias{alias}
+	default:
+		if decl := n.node("declaration"); decl != nil {
+			switch decl.typ() {
+			case "VariableDeclaration":
+				exportStmt.Decl = r.varDecl(decl)
+			case "FunctionDeclaration":
+				exportStmt.Decl = r.function(decl, FunctionDecl)
+			case "ClassDeclaration":
+				exportStmt.Decl = r.class(decl, LexicalDecl)
+			default:
+				r.fail(decl, "export declaration")
+			}
+			return exportStmt
+		}
+		for _, item := range n.nodes("specifiers") {
+			local, exported := r.moduleName(item.node("local")), r.moduleName(item.node("exported"))
+			alias := Alias{Binding: exported, Span: r.span(item)}
+			if !bytes.Equal(local, exported) {
+				alias.Name = local
+			}
+			exportStmt.List = append(exportStmt.List, alias)
+		}
+	}
+	if source := n.node("source"); source != nil {
+		exportStmt.Module = r.moduleString(source)
+	}
+	exportStmt.Attributes, exportStmt.Assert = r.attributes(n)
+	return exportStmt
+}
+
+// moduleName returns an imported or exported name, which is an identifier or a string literal.
+func (r *estreeReader) moduleName(n estreeNode) []byte {
+	if n.typ() == "Literal" {
+		return r.moduleString(n)
+	} else if n.typ() != "Identifier" {
+		r.fail(n, "import or export specifier")
+	}
+	return []byte(n.str("name"))
+}
+
+// moduleString returns the string literal of a module specifier or an import attribute.
+func (r *estreeReader) moduleString(n estreeNode) []byte {
+	lit := r.literal(n)
+	if lit.TokenType != StringToken {
+		r.fail(n, "module specifier")
+	}
+	return lit.Data
+}
+
+func (r *estreeReader) attributes(n estreeNode) ([]ImportAttribute, bool) {
+	list, assert := n.nodes("attributes"), false
+	if len(list) == 0 {
+		list, assert = n.nodes("assertions"), true
+	}
+	var attrs []ImportAttribute
+	for _, item := range list {
+		attrs = append(attrs, ImportAttribute{r.moduleName(item.node("key")), r.moduleString(item.node("value")), r.span(item)})
+	}
+	return attrs, assert && 0 < len(attrs)
+}
+
+////////////////////////////////////////////////////////////////
+
+func (r *estreeReader) ident(n estreeNode, decl DeclType) *Var {
+	if n.typ() != "Identifier" {
+		r.fail(n, "identifier")
+	}
+	return &Var{Data: []byte(n.str("name")), Uses: 1, Decl: decl, Span: r.span(n)}
+}
+
+func (r *estreeReader) function(n estreeNode, decl DeclType) *FuncDecl {
+	funcDecl := &FuncDecl{Async: n.bool("async"), Generator: n.bool("generator"), Span: r.span(n)}
+	start := funcDecl.Start
+	if id := n.node("id"); id != nil {
+		funcDecl.Name = r.ident(id, decl)
+		start = funcDecl.Name.End
+	}
+	parentStrict := r.strict
+	parent := r.enterScope(&funcDecl.Body.Scope, true)
+	funcDecl.Params = r.params(n.nodes("params"))
+	r.blockBody(&funcDecl.Body, n.node("body"), true)
+	funcDecl.Params.Span = Span{r.index("(", start, funcDecl.Body.Start), funcDecl.Body.Start}
+	funcDecl.Strict = r.strict
+	r.exitScope(parent)
+	r.strict = parentStrict
+	return funcDecl
+}
+
+// method converts the function of a method definition or object method.
+func (r *estreeReader) method(n estreeNode, name PropertyName, kind string) *MethodDecl {
+	method := &MethodDecl{
+		Async:     n.bool("async"),
+		Generator: n.bool("generator"),
+		Get:       kind == "get",
+		Set:       kind == "set",
+		Name:      name,
+	}
+	parentStrict := r.strict
+	parent := r.enterScope(&method.Body.Scope, true)
+	method.Params = r.params(n.nodes("params"))
+	r.blockBody(&method.Body, n.node("body"), true)
+	method.Params.Span = Span{r.span(n).Start, method.Body.Start}
+	method.Strict = r.strict
+	r.exitScope(parent)
+	r.strict = parentStrict
+	return method
+}
+
+func (r *estreeReader) arrowFunc(n estreeNode) *ArrowFunc {
+	arrowFunc := &ArrowFunc{Async: n.bool("async"), Span: r.span(n)}
+	parentStrict := r.strict
+	parent := r.enterScope(&arrowFunc.Body.Scope, true)
+	arrowFunc.Params = r.params(n.nodes("params"))
+	if body := n.node("body"); body.typ() == "BlockStatement" {
+		r.blockBody(&arrowFunc.Body, body, true)
+	} else {
+		x := r.expr(body, OpAssign)
+		if left := leftmostExpr(&x); isObject(*left) || isObjectAssign(*left) {
+			*left = &GroupExpr{*left, (*left).Range()}
+		}
+		arrowFunc.Body.List = []IStmt{&ReturnStmt{x, x.Range()}}
+		arrowFunc.Body.Span = x.Range()
+	}
+	arrowFunc.Strict = r.strict
+	r.exitScope(parent)
+	r.strict = parentStrict
+	return arrowFunc
+}
+
+func (r *estreeReader) params(list []estreeNode) (params Params) {
+	for i, item := range list {
+		if item.typ() == "RestElement" {
+			if i != len(list)-1 {
+				r.fail(item, "parameters")
+			}
+			params.Rest = r.binding(item.node("argument"), ArgumentDecl)
+		} else {
+			params.List = append(params.List, r.bindingElement(item, ArgumentDecl))
+		}
+	}
+	return
+}
+
+func (r *estreeReader) bindingElement(n estreeNode, decl DeclType) BindingElement {
+	if n == nil {
+		return BindingElement{} // elision
+	} else if n.typ() == "AssignmentPattern" {
+		return BindingElement{r.binding(n.node("left"), decl), r.expr(n.node("right"), OpAssign), r.span(n)}
+	}
+	return BindingElement{Binding: r.binding(n, decl), Span: r.span(n)}
+}
+
+func (r *estreeReader) binding(n estreeNode, decl DeclType) IBinding {
+	switch n.typ() {
+	case "Identifier":
+		return r.ident(n, decl)
+	case "ArrayPattern":
+		array := &BindingArray{Span: r.span(n)}
+		list := n.nodes("elements")
+		for i, item := range list {
+			if item.typ() == "RestElement" {
+				if i != len(list)-1 {
+					r.fail(item, "array pattern")
+				}
+				array.Rest = r.binding(item.node("argument"), decl)
+			} else {
+				array.List = append(array.List, r.bindingElement(item, decl))
+			}
+		}
+		return array
+	case "ObjectPattern":
+		object := &BindingObject{Span: r.span(n)}
+		list := n.nodes("properties")
+		for i, item := range list {
+			if item.typ() == "RestElement" {
+				if i != len(list)-1 {
+					r.fail(item, "object pattern")
+				}
+				object.Rest = r.ident(item.node("argument"), decl)
+				continue
+			}
+			key := r.propertyName(item.node("key"), item.bool("computed"))
+			value := r.bindingElement(item.node("value"), decl)
+			if item.bool("shorthand") {
+				value.Span.Start = key.Start
+			}
+			object.List = append(object.List, BindingObjectItem{&key, value, r.span(item)})
+		}
+		return object
+	}
+	r.fail(n, "binding pattern")
+	return &Var{Data: []byte("_")}
+}
+
+func (r *estreeReader) class(n estreeNode, decl DeclType) *ClassDecl {
+	classDecl := &ClassDecl{Decorators: r.decorators(n), Span: r.span(n)}
+	parentStrict := r.strict
+	r.strict = true
+	if id := n.node("id"); id != nil {
+		classDecl.Name = r.ident(id, decl)
+	}
+	classDecl.Extends = r.optionalExpr(n.node("superClass"), OpLHS)
+	for _, item := range n.node("body").nodes("body") {
+		span := r.span(item)
+		switch item.typ() {
+		case "MethodDefinition":
+			method := r.method(item.node("value"), r.propertyName(item.node("key"), item.bool("computed")), item.str("kind"))
+			method.Decorators = r.decorators(item)
+			method.Static = item.bool("static")
+			method.Span = span
+			classDecl.List = append(classDecl.List, ClassElement{Method: method, Span: span})
+		case "PropertyDefinition", "AccessorProperty":
+			field := Field{
+				Decorators: r.decorators(item),
+				Static:     item.bool("static"),
+				Accessor:   item.typ() == "AccessorProperty",
+				Name:       r.propertyName(item.node("key"), item.bool("computed")),
+				Init:       r.optionalExpr(item.node("value"), OpAssign),
+				Span:       span,
+			}
+			classDecl.List = append(classDecl.List, ClassElement{Field: field, Span: span})
+		case "StaticBlock":
+			block := &BlockStmt{Span: span}
+			parent := r.enterScope(&block.Scope, false)
+			block.List = r.stmtList(item.nodes("body"), false)
+			r.exitScope(parent)
+			classDecl.List = append(classDecl.List, ClassElement{StaticBlock: block, Span: span})
+		default:
+			r.fail(item, "class body")
+		}
+	}
+	r.strict = parentStrict
+	return classDecl
+}
+
+func (r *estreeReader) decorators(n estreeNode) []IExpr {
+	var decorators []IExpr
+	for _, item := range n.nodes("decorators") {
+		x := r.expr(item.node("expression"), OpLHS)
+		if !isDecoratorExpr(x) {
+			x = &GroupExpr{x, x.Range()}
+		}
+		decorators = append(decorators, x)
+	}
+	return decorators
+}
+
+func (r *estreeReader) propertyName(n estreeNode, computed bool) PropertyName {
+	span := r.span(n)
+	if computed {
+		return PropertyName{LiteralExpr{Span: Span{span.Start, span.Start}}, r.expr(n, OpAssign), span}
+	}
+	switch n.typ() {
+	case "Identifier":
+		return PropertyName{LiteralExpr{IdentifierToken, []byte(n.str("name")), span}, nil, span}
+	case "PrivateIdentifier":
+		return PropertyName{LiteralExpr{PrivateIdentifierToken, []byte("#" + n.str("name")), span}, nil, span}
+	case "Literal":
+		lit := r.literal(n)
+		if lit.TokenType == StringToken {
+			// reinterpret string as identifier or number as the parser does
+			if s := lit.Data[1 : len(lit.Data)-1]; AsIdentifierName(s) {
+				lit.TokenType, lit.Data = IdentifierToken, s
+			} else if AsDecimalLiteral(s) {
+				lit.TokenType, lit.Data = DecimalToken, s
+			}
+			return PropertyName{*lit, nil, span}
+		} else if IsNumeric(lit.TokenType) {
+			return PropertyName{*lit, nil, span}
+		}
+	}
+	r.fail(n, "property name")
+	return PropertyName{}
+}
+
+////////////////////////////////////////////////////////////////
+
+func (r *estreeReader) literal(n estreeNode) *LiteralExpr {
+	if n.typ() != "Literal" {
+		r.fail(n, "literal")
+		return &LiteralExpr{NullToken, []byte("null"), Span{}}
+	}
+	raw := n.str("raw")
+	lit := &LiteralExpr{Span: r.span(n)}
+	switch value := n["value"].(type) {
+	case string:
+		lit.TokenType = StringToken
+		if raw == "" || raw[0] != '"' && raw[0] != '\'' {
+			raw = string(QuoteString(value, '"'))
+		}
+	case bool:
+		lit.TokenType = FalseToken
+		raw = "false"
+		if value {
+			lit.TokenType = TrueToken
+			raw = "true"
+		}
+	case json.Number:
+		if raw == "" {
+			raw = value.String()
+		}
+		lit.TokenType = numericToken(raw)
+	default:
+		if regex := n.node("regex"); regex != nil {
+			lit.TokenType = RegExpToken
+			if raw == "" {
+				raw = "/" + regex.str("pattern") + "/" + regex.str("flags")
+			}
+		} else if bigint := n.str("bigint"); bigint != "" {
+			lit.TokenType = BigIntToken
+			if raw == "" {
+				raw = bigint + "n"
+			}
+		} else if raw != "" && ('0' <= raw[0] && raw[0] <= '9' || raw[0] == '.') {
+			lit.TokenType = numericToken(raw) // value that cannot be represented in JSON
+		} else {
+			lit.TokenType = NullToken
+			raw = "null"
+		}
+	}
+	lit.Data = []byte(raw)
+	return lit
+}
+
+// numericToken returns the token type of a numeric literal.
+func numericToken(raw string) TokenType {
+	if raw[len(raw)-1] == 'n' {
+		return BigIntToken
+	} else if 1 < len(raw) && raw[0] == '0' {
+		switch raw[1] {
+		case 'x', 'X':
+			return HexadecimalToken
+		case 'o', 'O':
+			return OctalToken
+		case 'b', 'B':
+			return BinaryToken
+		}
+		for _, c := range raw[1:] {
+			if c < '0' || '7' < c {
+				return DecimalToken
+			}
+		}
+		return LegacyOctalToken
+	}
+	return DecimalToken
+}
+
+// optionalExpr converts an expression that can be null.
+func (r *estreeReader) optionalExpr(n estreeNode, prec OpPrec) IExpr {
+	if n == nil {
+		return nil
+	}
+	return r.expr(n, prec)
+}
+
+// expr converts an expression, which is parenthesized when its precedence is lower than prec.
+func (r *estreeReader) expr(n estreeNode, prec OpPrec) IExpr {
+	if n == nil {
+		r.fail(n, "expression")
+		return &LiteralExpr{NullToken, []byte("null"), Span{}}
+	}
+	x := r.exprNode(n)
+	if exprPrec(x) < prec {
+		x = &GroupExpr{x, x.Range()}
+	}
+	return x
+}
+
+// object converts the object of a member expression, the callee of a call expression, or the tag of a tagged template, which is parenthesized when it would otherwise continue an optional chain or when it is a new expression without arguments or an integer.
+func (r *estreeReader) object(n estreeNode) IExpr {
+	x := r.expr(n, OpCall)
+	if prec := exprPrec(x); prec == OpNew || n.typ() == "ChainExpression" {
+		x = &GroupExpr{x, x.Range()}
+	} else if lit, ok := x.(*LiteralExpr); ok && lit.TokenType == DecimalToken && bytes.IndexAny(lit.Data, ".eE") == -1 {
+		x = &GroupExpr{x, x.Range()}
+	}
+	return x
+}
+
+func (r *estreeReader) args(list []estreeNode, span Span) Args {
+	args := Args{Span: span}
+	for _, item := range list {
+		if item.typ() == "SpreadElement" {
+			args.List = append(args.List, Arg{r.expr(item.node("argument"), OpAssign), true, r.span(item)})
+		} else {
+			args.List = append(args.List, Arg{r.expr(item, OpAssign), false, r.span(item)})
+		}
+	}
+	return args
+}
+
+func (r *estreeReader) exprNode(n estreeNode) IExpr {
+	span := r.span(n)
+	switch n.typ() {
+	case "Identifier":
+		return r.ident(n, NoDecl)
+	case "Literal":
+		return r.literal(n)
+	case "ThisExpression":
+		return &LiteralExpr{ThisToken, []byte("this"), span}
+	case "Super":
+		return &LiteralExpr{SuperToken, []byte("super"), span}
+	case "PrivateIdentifier":
+		return &LiteralExpr{PrivateIdentifierToken, []byte("#" + n.str("name")), span}
+	case "ParenthesizedExpression":
+		return &GroupExpr{r.expr(n.node("expression"), OpExpr), span}
+	case "ArrayExpression":
+		array := &ArrayExpr{Span: span}
+		for _, item := range n.nodes("elements") {
+			if item == nil {
+				array.List = append(array.List, Element{})
+			} else if item.typ() == "SpreadElement" {
+				array.List = append(array.List, Element{r.expr(item.node("argument"), OpAssign), true, r.span(item)})
+			} else {
+				array.List = append(array.List, Element{r.expr(item, OpAssign), false, r.span(item)})
+			}
+		}
+		return array
+	case "ObjectExpression":
+		object := &ObjectExpr{Span: span}
+		for _, item := range n.nodes("properties") {
+			object.List = append(object.List, r.property(item, false))
+		}
+		return object
+	case "FunctionExpression":
+		return r.function(n, ExprDecl)
+	case "ArrowFunctionExpression":
+		return r.arrowFunc(n)
+	case "ClassExpression":
+		return r.class(n, ExprDecl)
+	case "TemplateLiteral":
+		return r.template(n, span)
+	case "TaggedTemplateExpression":
+		tag := r.object(n.node("tag"))
+		template := r.template(n.node("quasi"), span)
+		template.Tag = tag
+		template.Prec = memberPrec(tag, false)
+		return template
+	case "MemberExpression":
+		x := r.object(n.node("object"))
+		optional := n.bool("optional")
+		if n.bool("computed") {
+			return &IndexExpr{x, r.expr(n.node("property"), OpExpr), memberPrec(x, optional), optional, span}
+		}
+		property := n.node("property")
+		y := LiteralExpr{IdentifierToken, []byte(property.str("name")), r.span(property)}
+		if property.typ() == "PrivateIdentifier" {
+			y.TokenType = PrivateIdentifierToken
+			y.Data = []byte("#" + property.str("name"))
+		} else if property.typ() != "Identifier" {
+			r.fail(property, "member expression")
+		}
+		return &DotExpr{x, y, memberPrec(x, optional), optional, span}
+	case "ChainExpression":
+		return r.exprNode(n.node("expression"))
+	case "CallExpression":
+		x := r.object(n.node("callee"))
+		return &CallExpr{x, r.args(n.nodes("arguments"), Span{x.Range().End, span.End}), n.bool("optional"), span}
+	case "ImportExpression":
+		list := []estreeNode{n.node("source")}
+		if options := n.node("options"); options != nil {
+			list = append(list, options)
+		}
+		x := &LiteralExpr{ImportToken, []byte("import"), Span{span.Start, span.Start + len("import")}}
+		return &CallExpr{x, r.args(list, Span{x.End, span.End}), false, span}
+	case "NewExpression":
+		x := r.expr(n.node("callee"), OpMember)
+		args := r.args(n.nodes("arguments"), Span{x.Range().End, span.End})
+		return &NewExpr{x, &args, span}
+	case "MetaProperty":
+		if n.node("meta").str("name") == "new" {
+			return &NewTargetExpr{span}
+		}
+		return &ImportMetaExpr{span}
+	case "UnaryExpression":
+		op, ok := unaryOps[n.str("operator")]
+		if !ok {
+			r.fail(n, "unary expression")
+		}
+		x := r.expr(n.node("argument"), OpUnary)
+		if unary, ok := x.(*UnaryExpr); ok && (op == NegToken && (unary.Op == NegToken || unary.Op == PreDecrToken) || op == PosToken && (unary.Op == PosToken || unary.Op == PreIncrToken)) {
+			x = &GroupExpr{x, x.Range()}
+		}
+		return &UnaryExpr{op, x, span}
+	case "UpdateExpression":
+		op := PostIncrToken
+		if n.str("operator") == "--" {
+			op = PostDecrToken
+		}
+		if n.bool("prefix") {
+			op -= PostIncrToken - PreIncrToken
+		}
+		return &UnaryExpr{op, r.expr(n.node("argument"), OpLHS), span}
+	case "AwaitExpression":
+		return &UnaryExpr{AwaitToken, r.expr(n.node("argument"), OpUnary), span}
+	case "BinaryExpression", "LogicalExpression":
+		op, ok := binaryOps[n.str("operator")]
+		if !ok || isAssignment(op) {
+			r.fail(n, "binary expression")
+		}
+		prec := binaryPrec(op)
+		var x, y IExpr
+		if op == ExpToken {
+			x = r.expr(n.node("left"), OpUpdate)
+			y = r.expr(n.node("right"), OpExp)
+		} else {
+			x = r.expr(n.node("left"), prec)
+			y = r.expr(n.node("right"), prec+1)
+		}
+		if op == NullishToken {
+			x, y = groupLogical(x), groupLogical(y)
+		}
+		return &BinaryExpr{op, x, y, span}
+	case "AssignmentExpression":
+		op, ok := binaryOps[n.str("operator")]
+		if !ok || !isAssignment(op) {
+			r.fail(n, "assignment expression")
+		}
+		var x IExpr
+		if op == EqToken {
+			x = r.target(n.node("left"))
+		} else {
+			x = r.expr(n.node("left"), OpLHS)
+		}
+		return &BinaryExpr{op, x, r.expr(n.node("right"), OpAssign), span}
+	case "ConditionalExpression":
+		return &CondExpr{r.expr(n.node("test"), OpCoalesce), r.expr(n.node("consequent"), OpAssign), r.expr(n.node("alternate"), OpAssign), span}
+	case "YieldExpression":
+		return &YieldExpr{n.bool("delegate"), r.optionalExpr(n.node("argument"), OpAssign), span}
+	case "SequenceExpression":
+		comma := &CommaExpr{Span: span}
+		for _, item := range n.nodes("expressions") {
+			comma.List = append(comma.List, r.expr(item, OpAssign))
+		}
+		return comma
+	case "JSXElement", "JSXFragment":
+		return r.jsxElement(n)
+	}
+	r.fail(n, "expression")
+	return &LiteralExpr{NullToken, []byte("null"), span}
+}
+
+// property converts a property of an object literal or object pattern.
+func (r *estreeReader) property(n estreeNode, pattern bool) Property {
+	span := r.span(n)
+	switch n.typ() {
+	case "SpreadElement", "RestElement":
+		if pattern {
+			return Property{Spread: true, Value: r.target(n.node("argument")), Span: span}
+		}
+		return Property{Spread: true, Value: r.expr(n.node("argument"), OpAssign), Span: span}
+	case "Property":
+	default:
+		r.fail(n, "object")
+		return Property{Spread: true, Value: &LiteralExpr{NullToken, []byte("null"), span}}
+	}
+
+	name := r.propertyName(n.node("key"), n.bool("computed"))
+	value := n.node("value")
+	if kind := n.str("kind"); kind == "get" || kind == "set" || n.bool("method") {
+		method := r.method(value, name, kind)
+		method.Span = span
+		return Property{Value: method, Span: span}
+	} else if n.bool("shorthand") {
+		if value.typ() == "AssignmentPattern" {
+			return Property{Name: &name, Value: r.ident(value.node("left"), NoDecl), Init: r.expr(value.node("right"), OpAssign), Span: span}
+		}
+		return Property{Name: &name, Value: r.ident(value, NoDecl), Span: span}
+	} else if pattern {
+		return Property{Name: &name, Value: r.target(value), Span: span}
+	}
+	return Property{Name: &name, Value: r.expr(value, OpAssign), Span: span}
+}
+
+// target converts an assignment target, where patterns are converted to array and object literals.
+func (r *estreeReader) target(n estreeNode) IExpr {
+	span := r.span(n)
+	switch n.typ() {
+	case "ArrayPattern":
+		array := &ArrayExpr{Span: span}
+		for _, item := range n.nodes("elements") {
+			if item == nil {
+				array.List = append(array.List, Element{})
+			} else if item.typ() == "RestElement" {
+				array.List = append(array.List, Element{r.target(item.node("argument")), true, r.span(item)})
+			} else {
+				array.List = append(array.List, Element{r.target(item), false, r.span(item)})
+			}
+		}
+		return array
+	case "ObjectPattern":
+		object := &ObjectExpr{Span: span}
+		for _, item := range n.nodes("properties") {
+			object.List = append(object.List, r.property(item, true))
+		}
+		return object
+	case "AssignmentPattern":
+		return &BinaryExpr{EqToken, r.target(n.node("left")), r.expr(n.node("right"), OpAssign), span}
+	}
+	return r.expr(n, OpLHS)
+}
+
+// template converts a template literal, where the quasis are given the delimiters of the parts.
+func (r *estreeReader) template(n estreeNode, span Span) *TemplateExpr {
+	if n.typ() != "TemplateLiteral" {
+		r.fail(n, "template literal")
+		return &TemplateExpr{Tail: []byte("``"), Prec: OpMember, Span: span}
+	}
+	template := &TemplateExpr{Prec: OpMember, Span: span}
+	quasis, exprs := n.nodes("quasis"), n.nodes("expressions")
+	if len(quasis) != len(exprs)+1 {
+		r.fail(n, "template literal")
+		return template
+	}
+	for i, quasi := range quasis {
+		raw := []byte(quasi.node("value").str("raw"))
+		start := r.span(quasi).Start - 1
+		open := byte('}')
+		if i == 0 {
+			open = '`'
+		}
+		if i == len(exprs) {
+			template.Tail = append(append([]byte{open}, raw...), '`')
+		} else {
+			x := r.expr(exprs[i], OpExpr)
+			value := append(append([]byte{open}, raw...), '$', '{')
+			template.List = append(template.List, TemplatePart{value, x, Span{start, x.Range().End}})
+		}
+	}
+	return template
+}
+
+func (r *estreeReader) jsxElement(n estreeNode) *JSXElement {
+	elem := &JSXElement{Span: r.span(n)}
+	if n.typ() == "JSXElement" {
+		opening := n.node("openingElement")
+		elem.Name = r.jsxName(opening.node("name"))
+		elem.SelfClosing = opening.bool("selfClosing")
+		for _, item := range opening.nodes("attributes") {
+			span := r.span(item)
+			if item.typ() == "JSXSpreadAttribute" {
+				elem.Attrs = append(elem.Attrs, JSXAttribute{Value: r.expr(item.node("argument"), OpAssign), Spread: true, Span: span})
+				continue
+			}
+			attr := JSXAttribute{Name: r.jsxAttributeName(item.node("name")), Span: span}
+			switch value := item.node("value"); value.typ() {
+			case "":
+			case "Literal":
+				attr.Value = r.literal(value)
+			case "JSXExpressionContainer":
+				attr.Value = r.jsxExpr(value)
+			case "JSXElement", "JSXFragment":
+				attr.Value = r.jsxElement(value)
+			default:
+				r.fail(value, "JSX attribute")
+			}
+			elem.Attrs = append(elem.Attrs, attr)
+		}
+	}
+	for _, item := range n.nodes("children") {
+		switch item.typ() {
+		case "JSXText":
+			raw, ok := item["raw"].(string)
+			if !ok {
+				raw = item.str("value")
+			}
+			elem.Children = append(elem.Children, &JSXText{[]byte(raw), r.span(item)})
+		case "JSXExpressionContainer", "JSXSpreadChild":
+			elem.Children = append(elem.Children, r.jsxExpr(item))
+		case "JSXElement", "JSXFragment":
+			elem.Children = append(elem.Children, r.jsxElement(item))
+		default:
+			r.fail(item, "JSX element")
+		}
+	}
+	return elem
+}
+
+func (r *estreeReader) jsxExpr(n estreeNode) *JSXExpr {
+	expr := &JSXExpr{Spread: n.typ() == "JSXSpreadChild", Span: r.span(n)}
+	if x := n.node("expression"); x.typ() != "JSXEmptyExpression" {
+		expr.X = r.expr(x, OpAssign)
+	}
+	return expr
+}
+
+// jsxName converts the name of a JSX element, where intrinsic elements have a JSXName and components refer to variables.
+func (r *estreeReader) jsxName(n estreeNode) IExpr {
+	span := r.span(n)
+	switch n.typ() {
+	case "JSXIdentifier":
+		name := []byte(n.str("name"))
+		if 0 < len(name) && ('a' <= name[0] && name[0] <= 'z' || bytes.IndexByte(name, '-') != -1) {
+			return &JSXName{name, span}
+		}
+		return r.jsxObject(n)
+	case "JSXNamespacedName":
+		return &JSXName{r.jsxAttributeName(n), span}
+	case "JSXMemberExpression":
+		return r.jsxObject(n)
+	}
+	r.fail(n, "JSX element name")
+	return &JSXName{[]byte("_"), span}
+}
+
+func (r *estreeReader) jsxObject(n estreeNode) IExpr {
+	span := r.span(n)
+	if n.typ() == "JSXMemberExpression" {
+		property := n.node("property")
+		y := LiteralExpr{IdentifierToken, []byte(property.str("name")), r.span(property)}
+		return &DotExpr{r.jsxObject(n.node("object")), y, OpMember, false, span}
+	} else if n.str("name") == "this" {
+		return &LiteralExpr{ThisToken, []byte("this"), span}
+	}
+	return &Var{Data: []byte(n.str("name")), Uses: 1, Span: span}
+}
+
+func (r *estreeReader) jsxAttributeName(n estreeNode) []byte {
+	if n.typ() == "JSXNamespacedName" {
+		return []byte(n.node("namespace").str("name") + ":" + n.node("name").str("name"))
+	}
+	return []byte(n.str("name"))
+}
+
+////////////////////////////////////////////////////////////////
+
+var unaryOps = map[string]TokenType{
+	"!":      NotToken,
+	"~":      BitNotToken,
+	"+":      PosToken,
+	"-":      NegToken,
+	"typeof": TypeofToken,
+	"void":   VoidToken,
+	"delete": DeleteToken,
+}
+
+var binaryOps = map[string]TokenType{}
+
+func init() {
+	for tt := EqToken; tt <= NullishEqToken; tt++ {
+		if binaryPrec(tt) != OpExpr || isAssignment(tt) {
+			binaryOps[tt.String()] = tt
+		}
+	}
+	binaryOps["in"] = InToken
+	binaryOps["instanceof"] = InstanceofToken
+}
+
+// binaryPrec returns the precedence of a binary or assignment operator, or OpExpr for other tokens.
+func binaryPrec(op TokenType) OpPrec {
+	switch op {
+	case NullishToken:
+		return OpCoalesce
+	case OrToken:
+		return OpOr
+	case AndToken:
+		return OpAnd
+	case BitOrToken:
+		return OpBitOr
+	case BitXorToken:
+		return OpBitXor
+	case BitAndToken:
+		return OpBitAnd
+	case EqEqToken, NotEqToken, EqEqEqToken, NotEqEqToken:
+		return OpEquals
+	case LtToken, GtToken, LtEqToken, GtEqToken, InstanceofToken, InToken:
+		return OpCompare
+	case LtLtToken, GtGtToken, GtGtGtToken:
+		return OpShift
+	case AddToken, SubToken:
+		return OpAdd
+	case MulToken, DivToken, ModToken:
+		return OpMul
+	case ExpToken:
+		return OpExp
+	}
+	if isAssignment(op) {
+		return OpAssign
+	}
+	return OpExpr
+}
+
+// exprPrec returns the precedence of an expression.
+func exprPrec(expr IExpr) OpPrec {
+	switch n := expr.(type) {
+	case *TemplateExpr:
+		if n.Tag != nil {
+			return n.Prec
+		}
+	case *DotExpr:
+		return n.Prec
+	case *IndexExpr:
+		return n.Prec
+	case *CallExpr:
+		return OpCall
+	case *NewExpr:
+		if n.Args == nil {
+			return OpNew
+		}
+		return OpMember
+	case *UnaryExpr:
+		if n.Op == PostIncrToken || n.Op == PostDecrToken {
+			return OpUpdate
+		}
+		return OpUnary
+	case *BinaryExpr:
+		return binaryPrec(n.Op)
+	case *CondExpr, *YieldExpr, *ArrowFunc:
+		return OpAssign
+	case *CommaExpr:
+		return OpExpr
+	}
+	return OpPrimary
+}
+
+// memberPrec returns the precedence of a member expression or tagged template with object x, which is OpCall when it is part of a call expression or an optional chain.
+func memberPrec(x IExpr, optional bool) OpPrec {
+	if optional || exprPrec(x) == OpCall {
+		return OpCall
+	}
+	return OpMember
+}
+
+// groupLogical parenthesizes || and && expressions, which cannot be mixed with ??.
+func groupLogical(x IExpr) IExpr {
+	if binary, ok := x.(*BinaryExpr); ok && (binary.Op == OrToken || binary.Op == AndToken) {
+		return &GroupExpr{x, x.Range()}
+	}
+	return x
+}
+
+// leftmostExpr returns the expression at the start of expr, such as the function of a call expression. An assignment to an object pattern is returned as a whole, since the pattern cannot be parenthesized by itself.
+func leftmostExpr(expr *IExpr) *IExpr {
+	for {
+		switch n := (*expr).(type) {
+		case *BinaryExpr:
+			if n.Op == EqToken && isObject(n.X) {
+				return expr
+			}
+			expr = &n.X
+		case *CondExpr:
+			expr = &n.Cond
+		case *CommaExpr:
+			if len(n.List) == 0 {
+				return expr
+			}
+			expr = &n.List[0]
+		case *CallExpr:
+			expr = &n.X
+		case *DotExpr:
+			expr = &n.X
+		case *IndexExpr:
+			expr = &n.X
+		case *TemplateExpr:
+			if n.Tag == nil {
+				return expr
+			}
+			expr = &n.Tag
+		case *UnaryExpr:
+			if n.Op != PostIncrToken && n.Op != PostDecrToken {
+				return expr
+			}
+			expr = &n.X
+		default:
+			return expr
+		}
+	}
+}
+
+// groupLeftmost parenthesizes the start of an expression that would otherwise be parsed as a declaration or block when it starts a statement.
+func groupLeftmost(expr *IExpr, stmt bool) {
+	left := leftmostExpr(expr)
+	if isObject(*left) || isObjectAssign(*left) || stmt && isFuncOrClass(*left) {
+		*left = &GroupExpr{*left, (*left).Range()}
+	} else if v, ok := (*left).(*Var); ok && stmt && string(v.Data) == "let" && left != expr {
+		if _, ok := (*expr).(*IndexExpr); ok {
+			*left = &GroupExpr{*left, (*left).Range()}
+		}
+	}
+}
+
+func isObject(expr IExpr) bool {
+	_, ok := expr.(*ObjectExpr)
+	return ok
+}
+
+func isObjectAssign(expr IExpr) bool {
+	binary, ok := expr.(*BinaryExpr)
+	return ok && binary.Op == EqToken && isObject(binary.X)
+}
+
+func isFuncOrClass(expr IExpr) bool {
+	switch expr.(type) {
+	case *FuncDecl, *ClassDecl:
+		return true
+	}
+	return false
+}
+
+// hasInOperator returns true if the expression has an in operator that is not parenthesized, which is not allowed in the initializer of a for statement.
+func hasInOperator(expr IExpr) bool {
+	switch n := expr.(type) {
+	case *BinaryExpr:
+		return n.Op == InToken || hasInOperator(n.X) || hasInOperator(n.Y)
+	case *CondExpr:
+		return hasInOperator(n.Cond) || hasInOperator(n.X) || hasInOperator(n.Y)
+	case *CommaExpr:
+		for _, item := range n.List {
+			if hasInOperator(item) {
+				return true
+			}
+		}
+	case *UnaryExpr:
+		return hasInOperator(n.X)
+	case *YieldExpr:
+		return n.X != nil && hasInOperator(n.X)
+	case *ArrowFunc:
+		if len(n.Body.List) == 1 {
+			if ret, ok := n.Body.List[0].(*ReturnStmt); ok && ret.Span == n.Body.Span {
+				return hasInOperator(ret.Value)
+			}
+		}
+	}
+	return false
+}
+
+// isDanglingIf returns true if the statement ends with an if statement without else, which would take the else of an enclosing if statement.
+func isDanglingIf(stmt IStmt) bool {
+	switch n := stmt.(type) {
+	case *IfStmt:
+		return n.Else == nil || isDanglingIf(n.Else)
+	case *LabelledStmt:
+		return isDanglingIf(n.Value)
+	case *WhileStmt:
+		return isDanglingIf(n.Body)
+	case *WithStmt:
+		return isDanglingIf(n.Body)
+	}
+	return false
+}
@@ -0,0 +1,364 @@
+package js
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestESTree(t *testing.T) {
+	var tests = []string{
+		// statements
+		"var a = 1, b; let c; const d = 2",
+		"if (a) b; else if (c) d; else { e }",
+		"if (a) { if (b) c } else d",
+		"if (a) if (b) c; else d",
+		"for (;;) ;",
+		"for (var i = 0; i < n; i++) { f(i) }",
+		"for (i = 0; i < n; i++) f(i)",
+		"for (let a in b) ;",
+		"for (a.b of c) { d }",
+		"for await (const [a, b] of c) ;",
+		"for (var a = (b in c); ;) ;",
+		"for ((a in b); ;) ;",
+		"while (a) b; do c; while (d)",
+		"switch (a) { case 1: b; break; default: c }",
+		"a: for (;;) { continue a; break a }",
+		"try { a } catch (e) { b } finally { c }",
+		"try { a } catch { b }",
+		"with (a) b",
+		"throw new Error('x')",
+		"debugger; ;",
+		"function f(a, b = 1, ...c) { return a }",
+		"async function* f() { yield 1; yield* g(); await h }",
+		"class A extends B { static a = 1; #b; constructor() { super() } get c() {} set c(v) {} static { d } async *e() {} [f]() {} 'g'() {} 1() {} #h() { this.#b } }",
+		"@dec class A { @dec m() {} @dec.a(1) f }",
+		"class A { @dec accessor a = 1; static accessor #b; accessor }",
+		"'use strict'; a",
+		"function f() { 'use strict'; 'b' }",
+		"('a')",
+		"(function () {})()",
+		"(class {}).a",
+		"({}).a = 1",
+		"(async function () {})",
+		"let\n[a] = b",
+
+		// expressions
+		"a = b ? c : d ? e : f",
+		"(a ? b : c) ? d : e",
+		"a || b && c | d ^ e & f == g < h << i + j * k ** l",
+		"(a || b) && c",
+		"a - (b - c); a - b - c",
+		"(a ** b) ** c; a ** b ** c; (-a) ** b; a ** -b",
+		"(a || b) ?? c; a ?? (b && c)",
+		"-(-a); +(+a); -+a; -(--a); !!a; typeof void delete a.b",
+		"a++; --a; a.b--; await a",
+		"a += 1; a ||= b; [a, b] = [b, a]; ({a, b: c, ...d} = e)",
+		"[a = 1, [b], ...c] = d",
+		"a, b, (c, d)",
+		"x = [, a, , ...b, c]",
+		"x = {a, b: 1, [c]: 2, 'd': 3, 4: 5, e() {}, get f() {}, set f(v) {}, async *g() {}, ...h}",
+		"x = {a = 1} = b",
+		"x = a.b.c[d]?.e?.[f]?.(g)",
+		"(a?.b).c; (a?.b)()",
+		"new A; new A(); new A.b(c); new (a())(); new (a().b)(); new A().b; new new A()()",
+		"a()()[b]`c`",
+		"x = `a${b}c${d}e`; y = tag`x\\n${1}`",
+		"x = 1..toString(); y = (1).toString(); z = 1.5.toFixed()",
+		"x = 0x1F + 0o17 + 0b11 + 1e3 + 123n + .5",
+		"x = /a[/]b/gi",
+		"x = null + true + false + this",
+		"x = import('a'); y = import.meta.url; function f() { new.target }",
+		"x = async (a, b) => a + b; y = a => ({}); z = () => { return }",
+		"x = async a => await a",
+		"x = (a, ...b) => ({a} = b)",
+		"x = function f() {}; y = class B {}",
+		"x = a => b => c",
+		"x = (a = 1, {b, c: [d]} = {}) => a",
+		"x = 'a\\'b\"c'",
+		"x = yield",
+
+		// modules
+		"import a, {b, c as d, 'e' as f} from 'x'; import * as g from 'y'; import 'z'",
+		"import a from 'x' with {type: 'json'}",
+		"export {a, b as c}; export * from 'x'; export * as d from 'y'; export {e as 'f'} from 'z'",
+		"export var a = 1; export function f() {} export class A {}",
+		"export default function () {}",
+		"export default class A {}",
+		"export default (function () {})()",
+		"export default a + b",
+	}
+	for _, js := range tests {
+		t.Run(js, func(t *testing.T) {
+			src := []byte(js)
+			ast, err := Parse(parse.NewInputBytes(src), Options{})
+			test.Error(t, err)
+
+			b, err := ESTree(ast, src)
+			test.Error(t, err)
+			test.That(t, json.Valid(b), "must be valid JSON")
+
+			ast2, err := ParseESTree(b, src)
+			test.Error(t, err)
+			test.String(t, ast2.JS(), ast.JS())
+
+			// positions are optional
+			b, err = ESTree(ast, nil)
+			test.Error(t, err)
+			ast2, err = ParseESTree(b, nil)
+			test.Error(t, err)
+			test.String(t, ast2.JS(), ast.JS())
+		})
+	}
+}
+
+func TestESTreeJSX(t *testing.T) {
+	var tests = []string{
+		"x = <a.b c='d' {...e}>f{g}<h-i/></a.b>",
+		"x = <><A/>{/* c */}<this.b x:y={1}>text</this.b></>",
+	}
+	for _, js := range tests {
+		t.Run(js, func(t *testing.T) {
+			src := []byte(js)
+			ast, err := Parse(parse.NewInputBytes(src), Options{JSX: true})
+			test.Error(t, err)
+
+			b, err := ESTree(ast, src)
+			test.Error(t, err)
+			ast2, err := ParseESTree(b, src)
+			test.Error(t, err)
+			test.String(t, ast2.JS(), ast.JS())
+		})
+	}
+}
+
+func TestESTreeFields(t *testing.T) {
+	var tests = []struct {
+		js       string
+		path     []interface{}
+		expected interface{}
+	}{
+		{"x", []interface{}{"sourceType"}, "script"},
+		{"import a from 'b'", []interface{}{"sourceType"}, "module"},
+		{"'use strict'", []interface{}{"body", 0, "directive"}, "use strict"},
+		{"('use strict')", []interface{}{"body", 0, "directive"}, nil},
+		{"x = 'é😀' + y", []interface{}{"body", 0, "expression", "right", "right", "start"}, 12.0},
+		{"x;\n  'é'; y", []interface{}{"body", 2, "loc", "start"}, map[string]interface{}{"line": 2.0, "column": 7.0}},
+		{"x = 1n", []interface{}{"body", 0, "expression", "right", "bigint"}, "1"},
+		{"x = /a/g", []interface{}{"body", 0, "expression", "right", "regex", "flags"}, "g"},
+		{"x = 0x10", []interface{}{"body", 0, "expression", "right", "value"}, 16.0},
+		{"x = '\\x41\\u{1F600}'", []interface{}{"body", 0, "expression", "right", "value"}, "A😀"},
+		{"x = `a\\n${b}`", []interface{}{"body", 0, "expression", "right", "quasis", 0, "value", "cooked"}, "a\n"},
+		{"x = a?.b.c", []interface{}{"body", 0, "expression", "right", "type"}, "ChainExpression"},
+		{"x = {a}", []interface{}{"body", 0, "expression", "right", "properties", 0, "shorthand"}, true},
+		{"x = (a)", []interface{}{"body", 0, "expression", "right", "type"}, "Identifier"},
+		{"class A { constructor() {} }", []interface{}{"body", 0, "body", "body", 0, "kind"}, "constructor"},
+		{"class A { accessor a }", []interface{}{"body", 0, "body", "body", 0, "type"}, "AccessorProperty"},
+		{"/* a */ x // b", []interface{}{"comments", 1, "value"}, " b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			src := []byte(tt.js)
+			ast, err := Parse(parse.NewInputBytes(src), Options{Comments: true})
+			test.Error(t, err)
+
+			b, err := ESTree(ast, src)
+			test.Error(t, err)
+
+			var v interface{}
+			test.Error(t, json.Unmarshal(b, &v))
+			for _, key := range tt.path {
+				switch key := key.(type) {
+				case string:
+					v = v.(map[string]interface{})[key]
+				case int:
+					v = v.([]interface{})[key]
+				}
+			}
+			test.T(t, v, tt.expected)
+		})
+	}
+}
+
+func TestESTreeComments(t *testing.T) {
+	js := "/* a */\nx = 1; // b\n// c\ny()"
+	ast, err := Parse(parse.NewInputString(js), Options{Comments: true})
+	test.Error(t, err)
+
+	b, err := ESTree(ast, []byte(js))
+	test.Error(t, err)
+	ast2, err := ParseESTree(b, []byte(js))
+	test.Error(t, err)
+	test.T(t, len(ast2.Comments), 1)
+	test.String(t, string(ast2.Comments[0]), "/* a */")
+	test.T(t, len(ast2.CommentMap.Leading(ast2.List[1])), 1)
+	test.String(t, string(ast2.CommentMap.Leading(ast2.List[1])[0].Data), "// c")
+
+	// without Options.Comments only the first comments are known
+	ast, err = Parse(parse.NewInputString(js), Options{})
+	test.Error(t, err)
+	b, err = ESTree(ast, []byte(js))
+	test.Error(t, err)
+	var program struct {
+		Comments []struct {
+			Type  string
+			Value string
+			Start int
+		}
+	}
+	test.Error(t, json.Unmarshal(b, &program))
+	test.T(t, len(program.Comments), 1)
+	test.String(t, program.Comments[0].Type, "Block")
+	test.String(t, program.Comments[0].Value, " a ")
+	test.T(t, program.Comments[0].Start, 0)
+}
+
+func TestESTreeCommentsUnterminated(t *testing.T) {
+	var tests = []struct {
+		js    string
+		value string
+	}{
+		{"/*!", "!"},
+		{"/*/", "/"},
+		{"a; /*x", "x"},
+		{"/**/", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{Comments: true})
+			test.Error(t, err)
+			b, err := ESTree(ast, []byte(tt.js))
+			test.Error(t, err)
+			var program struct {
+				Comments []struct {
+					Type  string
+					Value string
+				}
+			}
+			test.Error(t, json.Unmarshal(b, &program))
+			test.T(t, len(program.Comments), 1)
+			test.String(t, program.Comments[0].Type, "Block")
+			test.String(t, program.Comments[0].Value, tt.value)
+		})
+	}
+}
+
+func TestESTreePositions(t *testing.T) {
+	// positions of the nodes as given by Acorn, ordered by start, by end in reverse, and by depth
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"a = b;", "Program 0 6, ExpressionStatement 0 6, AssignmentExpression 0 5, Identifier 0 1, Identifier 4 5"},
+		{"var x = 1;", "Program 0 10, VariableDeclaration 0 10, VariableDeclarator 4 9, Identifier 4 5, Literal 8 9"},
+		{"'use strict' ;", "Program 0 14, ExpressionStatement 0 14, Literal 0 12"},
+		{"if (a) b; else c /* d */ ;\ndo e\nwhile (f);", "Program 0 42, IfStatement 0 26, Identifier 4 5, ExpressionStatement 7 9, Identifier 7 8, ExpressionStatement 15 26, Identifier 15 16, DoWhileStatement 27 42, ExpressionStatement 30 31, Identifier 30 31, Identifier 39 40"},
+		{"function f() { return; }\nfor (;;) break\n;", "Program 0 41, FunctionDeclaration 0 24, Identifier 9 10, BlockStatement 13 24, ReturnStatement 15 22, ForStatement 25 41, BreakStatement 34 41"},
+		{"a: while (b) c;", "Program 0 15, LabeledStatement 0 15, Identifier 0 1, WhileStatement 3 15, Identifier 10 11, ExpressionStatement 13 15, Identifier 13 14"},
+		{"class A { x = 1; y\n z; }", "Program 0 24, ClassDeclaration 0 24, Identifier 6 7, ClassBody 8 24, PropertyDefinition 10 16, Identifier 10 11, Literal 14 15, PropertyDefinition 17 18, Identifier 17 18, PropertyDefinition 20 22, Identifier 20 21"},
+		{"import a from 'b';\nexport default a;\nexport var c = 1;\nexport function d() {}", "Program 0 77, ImportDeclaration 0 18, ImportDefaultSpecifier 7 8, Identifier 7 8, Literal 14 17, ExportDefaultDeclaration 19 36, Identifier 34 35, ExportNamedDeclaration 37 54, VariableDeclaration 44 54, VariableDeclarator 48 53, Identifier 48 49, Literal 52 53, ExportNamedDeclaration 55 77, FunctionDeclaration 62 77, Identifier 71 72, BlockStatement 75 77"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			src := []byte(tt.js)
+			ast, err := Parse(parse.NewInputBytes(src), Options{})
+			test.Error(t, err)
+			b, err := ESTree(ast, src)
+			test.Error(t, err)
+
+			var v interface{}
+			test.Error(t, json.Unmarshal(b, &v))
+			type position struct {
+				typ               string
+				start, end, depth int
+			}
+			positions := []position{}
+			var collect func(interface{}, int)
+			collect = func(v interface{}, depth int) {
+				switch v := v.(type) {
+				case map[string]interface{}:
+					if typ, ok := v["type"].(string); ok {
+						positions = append(positions, position{typ, int(v["start"].(float64)), int(v["end"].(float64)), depth})
+					}
+					for key, item := range v {
+						if key != "comments" && key != "loc" {
+							collect(item, depth+1)
+						}
+					}
+				case []interface{}:
+					for _, item := range v {
+						collect(item, depth)
+					}
+				}
+			}
+			collect(v, 0)
+			sort.Slice(positions, func(i, j int) bool {
+				if positions[i].start != positions[j].start {
+					return positions[i].start < positions[j].start
+				} else if positions[i].end != positions[j].end {
+					return positions[j].end < positions[i].end
+				} else if positions[i].depth != positions[j].depth {
+					return positions[i].depth < positions[j].depth
+				}
+				return positions[i].typ < positions[j].typ
+			})
+			list := []string{}
+			for _, pos := range positions {
+				list = append(list, fmt.Sprintf("%s %d %d", pos.typ, pos.start, pos.end))
+			}
+			test.String(t, strings.Join(list, ", "), tt.expected)
+		})
+	}
+}
+
+func TestParseESTree(t *testing.T) {
+	// output of Acorn without positions, with ranges, and without raw values
+	var tests = []struct {
+		json     string
+		expected string
+	}{
+		{`{"type":"Program","body":[{"type":"ExpressionStatement","expression":{"type":"BinaryExpression","operator":"*","left":{"type":"BinaryExpression","operator":"+","left":{"type":"Identifier","name":"a"},"right":{"type":"Literal","value":1}},"right":{"type":"Literal","value":"x\"y"}}}],"sourceType":"script"}`, `(a + 1) * "x\"y"; `},
+		{`{"type":"Program","range":[0,1],"body":[{"type":"ExpressionStatement","range":[0,1],"expression":{"type":"Identifier","range":[0,1],"name":"a"}}]}`, `a; `},
+		{`{"type":"Program","body":[{"type":"ExpressionStatement","expression":{"type":"CallExpression","callee":{"type":"FunctionExpression","id":null,"params":[],"body":{"type":"BlockStatement","body":[]},"async":false,"generator":false},"arguments":[],"optional":false}}]}`, `(function () { })(); `},
+		{`{"type":"Program","body":[{"type":"ExpressionStatement","expression":{"type":"MemberExpression","object":{"type":"Literal","value":1,"raw":"1"},"property":{"type":"Identifier","name":"a"},"computed":false,"optional":false}}]}`, `(1).a; `},
+		{`{"type":"Program","body":[{"type":"IfStatement","test":{"type":"Identifier","name":"a"},"consequent":{"type":"IfStatement","test":{"type":"Identifier","name":"b"},"consequent":{"type":"EmptyStatement"},"alternate":null},"alternate":{"type":"EmptyStatement"}}]}`, `if (a) { if (b) { ; }; } else { ; }; `},
+		{`{"type":"Program","body":[{"type":"ImportDeclaration","specifiers":[],"source":{"type":"Literal","value":"a"},"assertions":[{"type":"ImportAttribute","key":{"type":"Identifier","name":"type"},"value":{"type":"Literal","value":"json"}}]}],"sourceType":"module"}`, `import "a" assert {type: "json"}; `},
+	}
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			ast, err := ParseESTree([]byte(tt.json), nil)
+			test.Error(t, err)
+			test.String(t, ast.JS(), tt.expected)
+		})
+	}
+}
+
+func TestESTreeError(t *testing.T) {
+	var tests = []struct {
+		json string
+		err  string
+	}{
+		{`[]`, "ESTree root must be a Program node"},
+		{`{"type":"Program","body":[{"type":"Foo"}]}`, "unexpected ESTree node Foo in statement"},
+		{`{"type":"Program","body":[{"type":"ExpressionStatement","expression":{"type":"Foo"}}]}`, "unexpected ESTree node Foo in expression"},
+		{`{"type":"Program","body":[{"type":"ExpressionStatement"}]}`, "missing ESTree node in expression"},
+		{`{"type":"Program","body":[{"type":"ExpressionStatement","expression":{"type":"UnaryExpression","operator":"#","argument":{"type":"Identifier","name":"a"}}}]}`, "unexpected ESTree node UnaryExpression in unary expression"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			_, err := ParseESTree([]byte(tt.json), nil)
+			test.That(t, err != nil, "must return error")
+			test.String(t, err.Error(), tt.err)
+		})
+	}
+
+	ast, err := Parse(parse.NewInputString("x = ;"), Options{ErrorRecovery: true})
+	test.That(t, err != nil, "must return error")
+	_, err = ESTree(ast, nil)
+	test.That(t, err != nil, "must return error")
+}
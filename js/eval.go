@@ -0,0 +1,708 @@
+package js
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// ValueType is the type of a constant value.
+type ValueType uint8
+
+// ValueType values.
+const (
+	UnknownValue ValueType = iota // not a constant
+	UndefinedValue
+	NullValue
+	BooleanValue
+	NumberValue
+	StringValue
+)
+
+func (t ValueType) String() string {
+	switch t {
+	case UnknownValue:
+		return "UnknownValue"
+	case UndefinedValue:
+		return "UndefinedValue"
+	case NullValue:
+		return "NullValue"
+	case BooleanValue:
+		return "BooleanValue"
+	case NumberValue:
+		return "NumberValue"
+	case StringValue:
+		return "StringValue"
+	}
+	return "Invalid(" + strconv.Itoa(int(t)) + ")"
+}
+
+// Value is the constant value of an expression, where Bool, Num, or Str is set depending on its type. The zero value is unknown.
+type Value struct {
+	Type ValueType
+	Bool bool
+	Num  float64
+	Str  string // UTF-8 encoded
+}
+
+// IsKnown returns true if the value is a constant.
+func (v Value) IsKnown() bool {
+	return v.Type != UnknownValue
+}
+
+// Truthy returns true if the value converts to true, as in a condition.
+func (v Value) Truthy() bool {
+	switch v.Type {
+	case BooleanValue:
+		return v.Bool
+	case NumberValue:
+		return v.Num != 0 && !math.IsNaN(v.Num)
+	case StringValue:
+		return v.Str != ""
+	}
+	return false
+}
+
+// ToNumber returns the value converted to a number, as with unary plus.
+func (v Value) ToNumber() float64 {
+	switch v.Type {
+	case NullValue:
+		return 0
+	case BooleanValue:
+		if v.Bool {
+			return 1
+		}
+		return 0
+	case NumberValue:
+		return v.Num
+	case StringValue:
+		return stringToNumber(v.Str)
+	}
+	return math.NaN()
+}
+
+// ToString returns the value converted to a string, as in a template literal.
+func (v Value) ToString() string {
+	switch v.Type {
+	case UndefinedValue:
+		return "undefined"
+	case NullValue:
+		return "null"
+	case BooleanValue:
+		if v.Bool {
+			return "true"
+		}
+		return "false"
+	case NumberValue:
+		return numberToString(v.Num)
+	case StringValue:
+		return v.Str
+	}
+	return ""
+}
+
+func (v Value) String() string {
+	switch v.Type {
+	case UnknownValue:
+		return "unknown"
+	case StringValue:
+		return string(QuoteString(v.Str, '"'))
+	}
+	return v.ToString()
+}
+
+// Expr returns an expression that evaluates to the value, or nil if the value is unknown. Undefined is written as void 0, and NaN and Infinity as divisions by zero so that they cannot be shadowed.
+func (v Value) Expr() IExpr {
+	return valueExpr(v, Span{})
+}
+
+func valueExpr(v Value, span Span) IExpr {
+	switch v.Type {
+	case UndefinedValue:
+		return &UnaryExpr{VoidToken, &LiteralExpr{DecimalToken, []byte("0"), span}, span}
+	case NullValue:
+		return &LiteralExpr{NullToken, []byte("null"), span}
+	case BooleanValue:
+		if v.Bool {
+			return &LiteralExpr{TrueToken, []byte("true"), span}
+		}
+		return &LiteralExpr{FalseToken, []byte("false"), span}
+	case NumberValue:
+		if math.IsNaN(v.Num) {
+			return &BinaryExpr{DivToken, &LiteralExpr{DecimalToken, []byte("0"), span}, &LiteralExpr{DecimalToken, []byte("0"), span}, span}
+		} else if math.IsInf(v.Num, 0) {
+			x := valueExpr(Value{Type: NumberValue, Num: math.Copysign(1, v.Num)}, span)
+			return &BinaryExpr{DivToken, x, &LiteralExpr{DecimalToken, []byte("0"), span}, span}
+		} else if math.Signbit(v.Num) {
+			return &UnaryExpr{NegToken, &LiteralExpr{DecimalToken, []byte(numberToString(-v.Num)), span}, span}
+		}
+		return &LiteralExpr{DecimalToken, []byte(numberToString(v.Num)), span}
+	case StringValue:
+		return &LiteralExpr{StringToken, QuoteString(v.Str, '"'), span}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////
+
+// Eval returns the value of a constant expression, or an unknown value if it cannot be determined statically. Literals, the global undefined, NaN, and Infinity, and unary, binary, conditional, and untagged template expressions of constants are evaluated. An expression with a known value has no side effects, operands that are skipped by short-circuiting or by a conditional are not evaluated.
+func Eval(expr IExpr) Value {
+	switch n := expr.(type) {
+	case *LiteralExpr:
+		switch n.TokenType {
+		case NullToken:
+			return Value{Type: NullValue}
+		case TrueToken:
+			return Value{Type: BooleanValue, Bool: true}
+		case FalseToken:
+			return Value{Type: BooleanValue, Bool: false}
+		case DecimalToken, BinaryToken, OctalToken, HexadecimalToken, LegacyOctalToken:
+			return Value{Type: NumberValue, Num: numericValue(n.TokenType, n.Data)}
+		case StringToken:
+			if units, invalid := cookString(n.Data[1:len(n.Data)-1], false); invalid == -1 {
+				if s, ok := utf16String(units); ok {
+					return Value{Type: StringValue, Str: s}
+				}
+			}
+		}
+	case *Var:
+		if resolveVar(n).Decl == NoDecl {
+			switch string(n.Data) {
+			case "undefined":
+				return Value{Type: UndefinedValue}
+			case "NaN":
+				return Value{Type: NumberValue, Num: math.NaN()}
+			case "Infinity":
+				return Value{Type: NumberValue, Num: math.Inf(1)}
+			}
+		}
+	case *GroupExpr:
+		return Eval(n.X)
+	case *UnaryExpr:
+		return evalUnary(n.Op, Eval(n.X))
+	case *BinaryExpr:
+		x := Eval(n.X)
+		if !x.IsKnown() {
+			break
+		}
+		switch n.Op {
+		case AndToken:
+			if !x.Truthy() {
+				return x
+			}
+			return Eval(n.Y)
+		case OrToken:
+			if x.Truthy() {
+				return x
+			}
+			return Eval(n.Y)
+		case NullishToken:
+			if x.Type != UndefinedValue && x.Type != NullValue {
+				return x
+			}
+			return Eval(n.Y)
+		}
+		return evalBinary(n.Op, x, Eval(n.Y))
+	case *CondExpr:
+		if cond := Eval(n.Cond); cond.IsKnown() {
+			if cond.Truthy() {
+				return Eval(n.X)
+			}
+			return Eval(n.Y)
+		}
+	case *TemplateExpr:
+		if n.Tag != nil {
+			break
+		}
+		var sb strings.Builder
+		for _, part := range n.List {
+			v := Eval(part.Expr)
+			if !v.IsKnown() {
+				return Value{}
+			}
+			s, ok := cookTemplate(part.Value[1 : len(part.Value)-2])
+			if !ok {
+				return Value{}
+			}
+			sb.WriteString(s)
+			sb.WriteString(v.ToString())
+		}
+		s, ok := cookTemplate(n.Tail[1 : len(n.Tail)-1])
+		if !ok {
+			return Value{}
+		}
+		sb.WriteString(s)
+		return Value{Type: StringValue, Str: sb.String()}
+	}
+	return Value{}
+}
+
+func evalUnary(op TokenType, x Value) Value {
+	if !x.IsKnown() {
+		return Value{}
+	}
+	switch op {
+	case NotToken:
+		return Value{Type: BooleanValue, Bool: !x.Truthy()}
+	case PosToken:
+		return Value{Type: NumberValue, Num: x.ToNumber()}
+	case NegToken:
+		return Value{Type: NumberValue, Num: -x.ToNumber()}
+	case BitNotToken:
+		return Value{Type: NumberValue, Num: float64(^toInt32(x.ToNumber()))}
+	case VoidToken:
+		return Value{Type: UndefinedValue}
+	case TypeofToken:
+		switch x.Type {
+		case UndefinedValue:
+			return Value{Type: StringValue, Str: "undefined"}
+		case NullValue:
+			return Value{Type: StringValue, Str: "object"}
+		case BooleanValue:
+			return Value{Type: StringValue, Str: "boolean"}
+		case NumberValue:
+			return Value{Type: StringValue, Str: "number"}
+		case StringValue:
+			return Value{Type: StringValue, Str: "string"}
+		}
+	}
+	return Value{}
+}
+
+func evalBinary(op TokenType, x, y Value) Value {
+	if !x.IsKnown() || !y.IsKnown() {
+		return Value{}
+	}
+	switch op {
+	case AddToken:
+		if x.Type == StringValue || y.Type == StringValue {
+			return Value{Type: StringValue, Str: x.ToString() + y.ToString()}
+		}
+		return Value{Type: NumberValue, Num: x.ToNumber() + y.ToNumber()}
+	case SubToken:
+		return Value{Type: NumberValue, Num: x.ToNumber() - y.ToNumber()}
+	case MulToken:
+		return Value{Type: NumberValue, Num: x.ToNumber() * y.ToNumber()}
+	case DivToken:
+		return Value{Type: NumberValue, Num: x.ToNumber() / y.ToNumber()}
+	case ModToken:
+		return Value{Type: NumberValue, Num: math.Mod(x.ToNumber(), y.ToNumber())}
+	case ExpToken:
+		a, b := x.ToNumber(), y.ToNumber()
+		if math.IsNaN(b) || math.Abs(a) == 1 && math.IsInf(b, 0) {
+			return Value{Type: NumberValue, Num: math.NaN()}
+		}
+		return Value{Type: NumberValue, Num: math.Pow(a, b)}
+	case LtLtToken:
+		return Value{Type: NumberValue, Num: float64(toInt32(x.ToNumber()) << (toUint32(y.ToNumber()) & 31))}
+	case GtGtToken:
+		return Value{Type: NumberValue, Num: float64(toInt32(x.ToNumber()) >> (toUint32(y.ToNumber()) & 31))}
+	case GtGtGtToken:
+		return Value{Type: NumberValue, Num: float64(toUint32(x.ToNumber()) >> (toUint32(y.ToNumber()) & 31))}
+	case BitAndToken:
+		return Value{Type: NumberValue, Num: float64(toInt32(x.ToNumber()) & toInt32(y.ToNumber()))}
+	case BitOrToken:
+		return Value{Type: NumberValue, Num: float64(toInt32(x.ToNumber()) | toInt32(y.ToNumber()))}
+	case BitXorToken:
+		return Value{Type: NumberValue, Num: float64(toInt32(x.ToNumber()) ^ toInt32(y.ToNumber()))}
+	case EqEqEqToken:
+		return Value{Type: BooleanValue, Bool: strictEquals(x, y)}
+	case NotEqEqToken:
+		return Value{Type: BooleanValue, Bool: !strictEquals(x, y)}
+	case EqEqToken:
+		return Value{Type: BooleanValue, Bool: looseEquals(x, y)}
+	case NotEqToken:
+		return Value{Type: BooleanValue, Bool: !looseEquals(x, y)}
+	case LtToken:
+		less, ok := lessThan(x, y)
+		return Value{Type: BooleanValue, Bool: ok && less}
+	case GtToken:
+		less, ok := lessThan(y, x)
+		return Value{Type: BooleanValue, Bool: ok && less}
+	case LtEqToken:
+		less, ok := lessThan(y, x)
+		return Value{Type: BooleanValue, Bool: ok && !less}
+	case GtEqToken:
+		less, ok := lessThan(x, y)
+		return Value{Type: BooleanValue, Bool: ok && !less}
+	}
+	return Value{}
+}
+
+func strictEquals(x, y Value) bool {
+	if x.Type != y.Type {
+		return false
+	}
+	switch x.Type {
+	case BooleanValue:
+		return x.Bool == y.Bool
+	case NumberValue:
+		return x.Num == y.Num
+	case StringValue:
+		return x.Str == y.Str
+	}
+	return true
+}
+
+func looseEquals(x, y Value) bool {
+	if x.Type == y.Type {
+		return strictEquals(x, y)
+	}
+	xNullish := x.Type == UndefinedValue || x.Type == NullValue
+	yNullish := y.Type == UndefinedValue || y.Type == NullValue
+	if xNullish || yNullish {
+		return xNullish && yNullish
+	}
+	return x.ToNumber() == y.ToNumber()
+}
+
+// lessThan returns whether x is less than y, and false if the comparison is undefined because of NaN.
+func lessThan(x, y Value) (bool, bool) {
+	if x.Type == StringValue && y.Type == StringValue {
+		// strings are compared by their UTF-16 code units
+		a, b := utf16.Encode([]rune(x.Str)), utf16.Encode([]rune(y.Str))
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if a[i] != b[i] {
+				return a[i] < b[i], true
+			}
+		}
+		return len(a) < len(b), true
+	}
+	a, b := x.ToNumber(), y.ToNumber()
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return false, false
+	}
+	return a < b, true
+}
+
+func toUint32(f float64) uint32 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	f = math.Mod(math.Trunc(f), 1<<32)
+	if f < 0 {
+		f += 1 << 32
+	}
+	return uint32(f)
+}
+
+func toInt32(f float64) int32 {
+	return int32(toUint32(f))
+}
+
+// numberToString returns the shortest string of a number that converts back to the same number, in the format of JavaScript.
+func numberToString(f float64) string {
+	if math.IsNaN(f) {
+		return "NaN"
+	} else if f == 0 {
+		return "0"
+	} else if math.IsInf(f, 1) {
+		return "Infinity"
+	} else if math.IsInf(f, -1) {
+		return "-Infinity"
+	} else if f < 0 {
+		return "-" + numberToString(-f)
+	}
+
+	// f = 0.digits * 10^n
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	e := strings.IndexByte(s, 'e')
+	digits := strings.Replace(s[:e], ".", "", 1)
+	exp, _ := strconv.Atoi(s[e+1:])
+	n := exp + 1
+	k := len(digits)
+	if k <= n && n <= 21 {
+		return digits + strings.Repeat("0", n-k)
+	} else if 0 < n && n <= 21 {
+		return digits[:n] + "." + digits[n:]
+	} else if -6 < n && n <= 0 {
+		return "0." + strings.Repeat("0", -n) + digits
+	}
+	s = digits[:1]
+	if 1 < k {
+		s += "." + digits[1:]
+	}
+	if n-1 < 0 {
+		return s + "e-" + strconv.Itoa(1-n)
+	}
+	return s + "e+" + strconv.Itoa(n-1)
+}
+
+// stringToNumber converts a string to a number, which is NaN if it is not a numeric literal.
+func stringToNumber(s string) float64 {
+	s = strings.TrimFunc(s, isJSWhitespace)
+	if s == "" {
+		return 0
+	} else if 2 < len(s) && s[0] == '0' {
+		var tt TokenType
+		switch s[1] {
+		case 'x', 'X':
+			tt = HexadecimalToken
+		case 'o', 'O':
+			tt = OctalToken
+		case 'b', 'B':
+			tt = BinaryToken
+		}
+		if tt != ErrorToken {
+			for _, c := range []byte(s[2:]) {
+				if !isHex(c) || tt == OctalToken && '7' < c || tt == BinaryToken && '1' < c {
+					return math.NaN()
+				}
+			}
+			return numericValue(tt, []byte(s))
+		}
+	}
+
+	t := s
+	if t[0] == '+' || t[0] == '-' {
+		t = t[1:]
+	}
+	if t == "Infinity" {
+		if s[0] == '-' {
+			return math.Inf(-1)
+		}
+		return math.Inf(1)
+	}
+	// only allow a decimal literal, ParseFloat also accepts underscores, hexadecimal floats, and inf
+	i := 0
+	digits := 0
+	for i < len(t) && '0' <= t[i] && t[i] <= '9' {
+		i++
+		digits++
+	}
+	if i < len(t) && t[i] == '.' {
+		i++
+		for i < len(t) && '0' <= t[i] && t[i] <= '9' {
+			i++
+			digits++
+		}
+	}
+	if digits == 0 {
+		return math.NaN()
+	}
+	if i < len(t) && (t[i] == 'e' || t[i] == 'E') {
+		i++
+		if i < len(t) && (t[i] == '+' || t[i] == '-') {
+			i++
+		}
+		start := i
+		for i < len(t) && '0' <= t[i] && t[i] <= '9' {
+			i++
+		}
+		if i == start {
+			return math.NaN()
+		}
+	}
+	if i != len(t) {
+		return math.NaN()
+	}
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+func isJSWhitespace(r rune) bool {
+	switch r {
+	case '\t', '\n', '\v', '\f', '\r', ' ', '\u00A0', '\u2028', '\u2029', '\uFEFF':
+		return true
+	}
+	return unicode.Is(unicode.Zs, r)
+}
+
+// cookTemplate returns the string value of a template literal part without its delimiters.
+func cookTemplate(b []byte) (string, bool) {
+	units, invalid := cookString(b, true)
+	if invalid != -1 {
+		return "", false
+	}
+	return utf16String(units)
+}
+
+////////////////////////////////////////////////////////////////
+
+// Fold replaces the unary, binary, conditional, and untagged template expressions in n that have a constant value by that value, and it returns the node that replaces n. Logical and conditional expressions of which the condition is constant are replaced by the operand that is evaluated, such as true && x by x. Parentheses are added where the replacement would otherwise change the meaning of the surrounding code.
+func Fold(n INode) INode {
+	return Rewrite(&folder{refs: map[IExpr]bool{}}, n)
+}
+
+type folder struct {
+	refs map[IExpr]bool // callees and delete operands, whose replacement must not become a member expression
+}
+
+func (f *folder) Enter(n INode) (INode, IRewriter) {
+	switch n := n.(type) {
+	case *CallExpr:
+		f.refs[innerExpr(n.X)] = true
+	case *UnaryExpr:
+		if n.Op == DeleteToken {
+			f.refs[innerExpr(n.X)] = true
+		}
+	}
+	return n, f
+}
+
+func (f *folder) Exit(n INode) INode {
+	switch n := n.(type) {
+	case *ExprStmt:
+		groupLeftmost(&n.Value, true)
+	case *ArrowFunc:
+		if len(n.Body.List) == 1 {
+			if ret, ok := n.Body.List[0].(*ReturnStmt); ok && ret.Span == n.Body.Span {
+				groupLeftmost(&ret.Value, false)
+			}
+		}
+	case *UnaryExpr, *BinaryExpr, *CondExpr, *TemplateExpr:
+		expr := n.(IExpr)
+		repl := f.fold(expr)
+		if repl == nil || repl == expr {
+			return n
+		}
+		if f.refs[expr] {
+			// a folded callee or delete operand is always parenthesized, keep the receiver unbound as in (0, a.b)()
+			delete(f.refs, expr)
+			switch repl.(type) {
+			case *DotExpr, *IndexExpr:
+				repl = &CommaExpr{[]IExpr{&LiteralExpr{DecimalToken, []byte("0"), expr.Range()}, repl}, expr.Range()}
+			}
+		} else if exprPrec(repl) < exprPrec(expr) {
+			repl = &GroupExpr{repl, repl.Range()}
+		}
+		return repl
+	}
+	return n
+}
+
+// fold returns the replacement of an expression, or nil if it cannot be folded.
+func (f *folder) fold(expr IExpr) IExpr {
+	if v := Eval(expr); v.IsKnown() {
+		repl := valueExpr(v, expr.Range())
+		if repl.JS() == expr.JS() {
+			return nil // already folded, such as -1 or void 0
+		}
+		return repl
+	}
+
+	switch n := expr.(type) {
+	case *BinaryExpr:
+		x := Eval(n.X)
+		if !x.IsKnown() {
+			return nil
+		}
+		switch n.Op {
+		case AndToken:
+			if x.Truthy() {
+				return n.Y
+			}
+		case OrToken:
+			if !x.Truthy() {
+				return n.Y
+			}
+		case NullishToken:
+			if x.Type == UndefinedValue || x.Type == NullValue {
+				return n.Y
+			}
+		}
+	case *CondExpr:
+		if cond := Eval(n.Cond); cond.IsKnown() {
+			if cond.Truthy() {
+				return n.X
+			}
+			return n.Y
+		}
+	}
+	return nil
+}
+
+// innerExpr returns the expression inside parentheses.
+func innerExpr(expr IExpr) IExpr {
+	for {
+		group, ok := expr.(*GroupExpr)
+		if !ok {
+			return expr
+		}
+		expr = group.X
+	}
+}
+
+////////////////////////////////////////////////////////////////
+
+// Define replaces global identifiers and member expressions of global identifiers, such as DEBUG or process.env.NODE_ENV, by the values given in defines, and it returns the node that replaces n. Identifiers that refer to a declared variable and targets of assignments, updates, and delete are not replaced. The result can be simplified further by Fold.
+func Define(n INode, defines map[string]Value) INode {
+	return Rewrite(&definer{defines: defines, targets: map[IExpr]bool{}}, n)
+}
+
+type definer struct {
+	defines map[string]Value
+	targets map[IExpr]bool
+}
+
+func (d *definer) Enter(n INode) (INode, IRewriter) {
+	switch n := n.(type) {
+	case *BinaryExpr:
+		if isAssignment(n.Op) {
+			d.target(n.X)
+		}
+	case *UnaryExpr:
+		if n.Op == PreIncrToken || n.Op == PreDecrToken || n.Op == PostIncrToken || n.Op == PostDecrToken || n.Op == DeleteToken {
+			d.target(n.X)
+		}
+	case *ForInStmt:
+		d.target(n.Init)
+	case *ForOfStmt:
+		d.target(n.Init)
+	case *ArrayExpr:
+		if d.targets[n] {
+			for _, item := range n.List {
+				d.target(item.Value)
+			}
+		}
+	case *ObjectExpr:
+		if d.targets[n] {
+			for _, item := range n.List {
+				d.target(item.Value)
+			}
+		}
+	case *Var, *DotExpr:
+		if d.targets[n.(IExpr)] {
+			delete(d.targets, n.(IExpr))
+		} else if name, ok := defineName(n.(IExpr)); ok {
+			if v, ok := d.defines[name]; ok && v.IsKnown() {
+				expr := valueExpr(v, n.(IExpr).Range())
+				if exprPrec(expr) < exprPrec(n.(IExpr)) {
+					expr = &GroupExpr{expr, expr.Range()} // as in (-1).toFixed()
+				}
+				return expr, nil
+			}
+		}
+	}
+	return n, d
+}
+
+func (d *definer) Exit(n INode) INode {
+	return n
+}
+
+func (d *definer) target(expr IExpr) {
+	if expr != nil {
+		d.targets[innerExpr(expr)] = true
+	}
+}
+
+// defineName returns the dotted name of a global identifier or a member expression of a global identifier.
+func defineName(expr IExpr) (string, bool) {
+	switch n := expr.(type) {
+	case *Var:
+		if resolveVar(n).Decl == NoDecl {
+			return string(n.Data), true
+		}
+	case *DotExpr:
+		if n.Optional || n.Y.TokenType != IdentifierToken {
+			return "", false
+		}
+		if name, ok := defineName(n.X); ok {
+			return name + "." + string(n.Y.Data), true
+		}
+	}
+	return "", false
+}
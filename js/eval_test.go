@@ -0,0 +1,195 @@
+package js
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestEval(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"1", "1"},
+		{"0x1F + 0b11 + 0o7 + 1_000", "1041"},
+		{"'a' + 1", `"a1"`},
+		{"1 + 2 + 'a'", `"3a"`},
+		{"('a\\x41\\u{42}')", `"aAB"`},
+		{"!0", "true"},
+		{"!''", "true"},
+		{"-'3'", "-3"},
+		{"+' 12 '", "12"},
+		{"+'0x10'", "16"},
+		{"+'1e3'", "1000"},
+		{"+'1_000'", "NaN"},
+		{"+'-0x10'", "NaN"},
+		{"+''", "0"},
+		{"+'Infinity'", "Infinity"},
+		{"+null + +true", "1"},
+		{"+undefined", "NaN"},
+		{"~5", "-6"},
+		{"1 / 3", "0.3333333333333333"},
+		{"1e21 + ''", `"1e+21"`},
+		{"1e-7 + ''", `"1e-7"`},
+		{"123e-20 + ''", `"1.23e-18"`},
+		{"0.000001 + ''", `"0.000001"`},
+		{"2 ** 10", "1024"},
+		{"1 ** Infinity", "NaN"},
+		{"-7 % 3", "-1"},
+		{"1 << 31", "-2147483648"},
+		{"-1 >>> 0", "4294967295"},
+		{"-16 >> 2", "-4"},
+		{"4294967297 | 0", "1"},
+		{"6 & 3 ^ 1", "3"},
+		{"typeof null", `"object"`},
+		{"typeof void 0", `"undefined"`},
+		{"typeof 'a'", `"string"`},
+		{"null == undefined", "true"},
+		{"null == 0", "false"},
+		{"'1' == 1", "true"},
+		{"'1' === 1", "false"},
+		{"NaN === NaN", "false"},
+		{"0 === -0", "true"},
+		{"true != 1", "false"},
+		{"'a' < 'b'", "true"},
+		{"'10' < '9'", "true"},
+		{"'10' < 9", "false"},
+		{"NaN <= NaN", "false"},
+		{"2 >= 2", "true"},
+		{"0 && x", "0"},
+		{"1 && 'a'", `"a"`},
+		{"'' || 2", "2"},
+		{"null ?? 'b'", `"b"`},
+		{"0 ?? x", "0"},
+		{"1 ? 'a' : x", `"a"`},
+		{"(1, 2)", "unknown"},
+		{"`a${1 + 1}b${null}`", `"a2bnull"`},
+		{"`a\\nb`", `"a\nb"`},
+		{"x`a`", "unknown"},
+		{"x + 1", "unknown"},
+		{"void x", "unknown"},
+		{"typeof x", "unknown"},
+		{"1 && x", "unknown"},
+		{"1 in x", "unknown"},
+		{"10n + 1n", "unknown"},
+		{"('\\uD800')", "unknown"},
+		{"let undefined = 1; undefined", "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+			stmt := ast.List[len(ast.List)-1].(*ExprStmt)
+			test.String(t, Eval(stmt.Value).String(), tt.expected)
+		})
+	}
+}
+
+func TestValueExpr(t *testing.T) {
+	var tests = []struct {
+		v        Value
+		expected string
+	}{
+		{Value{}, "<nil>"},
+		{Value{Type: UndefinedValue}, "void 0"},
+		{Value{Type: NullValue}, "null"},
+		{Value{Type: BooleanValue, Bool: true}, "true"},
+		{Value{Type: NumberValue, Num: 1.5}, "1.5"},
+		{Value{Type: NumberValue, Num: -2}, "-2"},
+		{Value{Type: NumberValue, Num: math.Copysign(0, -1)}, "-0"},
+		{Value{Type: NumberValue, Num: math.NaN()}, "0 / 0"},
+		{Value{Type: NumberValue, Num: math.Inf(-1)}, "-1 / 0"},
+		{Value{Type: StringValue, Str: "a\"b\n"}, `"a\"b\n"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			expr := tt.v.Expr()
+			if expr == nil {
+				test.String(t, "<nil>", tt.expected)
+				return
+			}
+			test.String(t, expr.JS(), tt.expected)
+			test.String(t, Eval(expr).String(), tt.v.String())
+		})
+	}
+}
+
+func TestFold(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"x = 1 + 2 * 3", "x = 7"},
+		{"x = 'a' + 'b' + y", "x = \"ab\" + y"},
+		{"x = y + 'a' + 'b'", "x = y + 'a' + 'b'"},
+		{"x = (1 + 2) * y", "x = (3) * y"},
+		{"x = -1; y = void 0; z = 1 / 0", "x = -1; y = void 0; z = 1 / 0"},
+		{"x = 1 - 2; y = 0 - 0", "x = -1; y = 0"},
+		{"x = -(1 - 2)", "x = 1"},
+		{"x = `a${1}` + y", "x = \"a1\" + y"},
+		{"x = true && y; z = 0 || y; w = null ?? y", "x = y; z = y; w = y"},
+		{"x = y && true", "x = y && true"},
+		{"x = 'production' === 'production' ? a : b", "x = a"},
+		{"x = 1 > 2 ? a : b ? c : d", "x = b ? c : d"},
+		{"(true && a.b)(); (1 ? a[0] : b)()", "(0, a.b)(); (0, a[0])()"},
+		{"true && function() {}", "(function() {})"},
+		{"f = () => 1 ? {} : 2", "f = () => ({})"},
+		{"x = 2 ** -1", "x = 0.5"},
+		{"x = a ** (0 - 1)", "x = a ** (-1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+			test.T(t, Fold(ast), INode(ast))
+
+			expected, err := Parse(parse.NewInputString(tt.expected), Options{})
+			test.Error(t, err)
+			test.String(t, ast.JS(), expected.JS())
+		})
+	}
+}
+
+func TestDefine(t *testing.T) {
+	defines := map[string]Value{
+		"DEBUG":                {Type: BooleanValue, Bool: false},
+		"process.env.NODE_ENV": {Type: StringValue, Str: "production"},
+		"LEVEL":                {Type: NumberValue, Num: -1},
+	}
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"if (DEBUG) log()", "if (false) log()"},
+		{"x = process.env.NODE_ENV === 'production'", "x = \"production\" === 'production'"},
+		{"x = process.env.NODE_ENV.length", "x = \"production\".length"},
+		{"x = process.env.OTHER; y = process?.env.NODE_ENV", "x = process.env.OTHER; y = process?.env.NODE_ENV"},
+		{"x = LEVEL.toFixed(); y = -LEVEL; z = LEVEL", "x = (-1).toFixed(); y = -(-1); z = (-1)"},
+		{"x = {DEBUG}", "x = {DEBUG: false}"},
+		{"let DEBUG = 1; x = DEBUG", "let DEBUG = 1; x = DEBUG"},
+		{"function f(process) { return process.env.NODE_ENV }", "function f(process) { return process.env.NODE_ENV; }"},
+		{"DEBUG = 1; DEBUG++; delete process.env.NODE_ENV", "DEBUG = 1; DEBUG++; delete process.env.NODE_ENV"},
+		{"[DEBUG, {a: process.env.NODE_ENV}] = x; for (DEBUG in a);", "[DEBUG, {a: process.env.NODE_ENV}] = x; for (DEBUG in a);"},
+		{"a[DEBUG] = 1", "a[false] = 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+			test.T(t, Define(ast, defines), INode(ast))
+
+			expected, err := Parse(parse.NewInputString(tt.expected), Options{})
+			test.Error(t, err)
+			test.String(t, ast.JS(), expected.JS())
+		})
+	}
+
+	// defines are folded afterwards
+	ast, err := Parse(parse.NewInputString("if (process.env.NODE_ENV !== 'production' && DEBUG) log()"), Options{})
+	test.Error(t, err)
+	Fold(Define(ast, defines))
+	test.String(t, ast.JS(), "if (false) { log() }; ")
+}
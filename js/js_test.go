@@ -89,6 +89,8 @@ func TestJS(t *testing.T) {
 		{"import defaultExport, * as name from 'module-name';", "import defaultExport , * as name from 'module-name'; "},
 		{"import 'module-name';", "import 'module-name'; "},
 		{"var promise = import('module-name');", "var promise = import('module-name'); "},
+		{"import data from 'data.json' with { type: 'json' };", "import data from 'data.json' with {type: 'json'}; "},
+		{"export { a } from 'module-name' with { type: 'json' };", "export { a } from 'module-name' with {type: 'json'}; "},
 
 		// ExportStmt
 		{"export { myFunction as default };", "export { myFunction as default }; "},
@@ -128,6 +130,7 @@ func TestJS(t *testing.T) {
 		// FieldDefinition
 		{"class A { field; };", "class A { field; }; "},
 		{"class A { field = 5; };", "class A { field = 5; }; "},
+		{"@a @b.c() @(d[0]) class A { @e field; @f static method () { }; };", "@a @b.c() @(d[0]) class A { @e field; @f static method () { }; }; "},
 
 		// ClassDecl
 		{"class A { field; static get method () { }; };", "class A { field; static get method () { }; }; "},
@@ -210,6 +213,10 @@ func TestJS(t *testing.T) {
 				test.Error(t, err)
 			}
 			test.String(t, ast.JS(), tt.expected)
+
+			buf := &bytes.Buffer{}
+			test.Error(t, NewPrinter(buf, PrintOptions{}).Print(ast))
+			test.String(t, buf.String(), tt.expected, "printer")
 		})
 	}
 }
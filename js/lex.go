@@ -39,6 +39,9 @@ type Lexer struct {
 	prevNumericLiteral bool
 	level              int
 	templateLevels     []int
+
+	htmlComments bool // allow HTML-like comments, which are not allowed in modules
+	legacyOctal  bool // allow legacy octal numbers such as 010, which are only allowed in sloppy scripts
 }
 
 // NewLexer returns a new Lexer for a given io.Reader.
@@ -48,6 +51,7 @@ func NewLexer(r *parse.Input) *Lexer {
 		prevLineTerminator: true,
 		level:              0,
 		templateLevels:     []int{},
+		htmlComments:       true,
 	}
 }
 
@@ -78,6 +82,106 @@ func (l *Lexer) RegExp() (TokenType, []byte) {
 	return ErrorToken, nil
 }
 
+// JSXNext returns the next token inside a JSX tag, that is after < and before >. Identifiers may contain dashes, as in data-id, and are always returned as IdentifierToken. Strings do not contain escape sequences and may span multiple lines.
+func (l *Lexer) JSXNext() (TokenType, []byte) {
+	l.prevLineTerminator = false
+	l.prevNumericLiteral = false
+
+	c := l.r.Peek(0)
+	switch c {
+	case ' ', '\t', '\v', '\f':
+		l.r.Move(1)
+		for l.consumeWhitespace() {
+		}
+		return WhitespaceToken, l.r.Shift()
+	case '\n', '\r':
+		l.r.Move(1)
+		for l.consumeLineTerminator() {
+		}
+		return LineTerminatorToken, l.r.Shift()
+	case '/':
+		if tt := l.consumeCommentToken(); tt != ErrorToken {
+			return tt, l.r.Shift()
+		}
+		l.r.Move(1)
+		return DivToken, l.r.Shift()
+	case '<':
+		l.r.Move(1)
+		return LtToken, l.r.Shift()
+	case '>':
+		l.r.Move(1)
+		return GtToken, l.r.Shift()
+	case '=':
+		l.r.Move(1)
+		return EqToken, l.r.Shift()
+	case ':':
+		l.r.Move(1)
+		return ColonToken, l.r.Shift()
+	case '.':
+		l.r.Move(1)
+		return DotToken, l.r.Shift()
+	case '{':
+		l.level++
+		l.r.Move(1)
+		return OpenBraceToken, l.r.Shift()
+	case '\'', '"':
+		l.r.Move(1)
+		for {
+			if c := l.r.Peek(0); c == '\'' || c == '"' {
+				l.r.Move(1)
+				if c == l.r.Lexeme()[0] {
+					return StringToken, l.r.Shift()
+				}
+			} else if c == 0 && l.r.Err() != nil {
+				l.err = parse.NewErrorLexer(l.r, "unterminated string literal")
+				return ErrorToken, nil
+			} else {
+				l.r.Move(1)
+			}
+		}
+	default:
+		if l.consumeIdentifierToken() {
+			for l.r.Peek(0) == '-' {
+				l.r.Move(1)
+				for l.consumeIdentifierContinue() {
+				}
+			}
+			return IdentifierToken, l.r.Shift()
+		} else if 0xC0 <= c {
+			if l.consumeWhitespace() {
+				for l.consumeWhitespace() {
+				}
+				return WhitespaceToken, l.r.Shift()
+			} else if l.consumeLineTerminator() {
+				for l.consumeLineTerminator() {
+				}
+				return LineTerminatorToken, l.r.Shift()
+			}
+		} else if c == 0 && l.r.Err() != nil {
+			return ErrorToken, nil
+		}
+	}
+
+	r, n := l.r.PeekRune(0)
+	l.err = parse.NewErrorLexer(l.r, "unexpected %s", parse.Printable(r))
+	l.r.Move(n)
+	return ErrorToken, l.r.Shift()
+}
+
+// JSXText returns the text of JSX children up to the next { or <, which may be empty. It is assumed that we just received the > of a JSX tag or the } of a JSX expression with JSXNext() or Next(). It returns ErrorToken at the end of the input.
+func (l *Lexer) JSXText() (TokenType, []byte) {
+	l.prevLineTerminator = false
+	l.prevNumericLiteral = false
+	for {
+		if c := l.r.Peek(0); c == '{' || c == '<' {
+			return JSXTextToken, l.r.Shift()
+		} else if c == 0 && l.r.Err() != nil {
+			return ErrorToken, nil
+		}
+		l.r.Move(1)
+	}
+}
+
 // Next returns the next Token. It returns ErrorToken when an error was encountered. Using Err() one can retrieve the error message.
 func (l *Lexer) Next() (TokenType, []byte) {
 	prevLineTerminator := l.prevLineTerminator
@@ -175,7 +279,7 @@ func (l *Lexer) Next() (TokenType, []byte) {
 		l.r.Move(1)
 		return OpenBracketToken, l.r.Shift()
 	case '<', '-':
-		if l.consumeHTMLLikeCommentToken(prevLineTerminator) {
+		if l.htmlComments && l.consumeHTMLLikeCommentToken(prevLineTerminator) {
 			return CommentToken, l.r.Shift()
 		} else if tt := l.consumeOperatorToken(); tt != ErrorToken {
 			return tt, l.r.Shift()
@@ -183,6 +287,9 @@ func (l *Lexer) Next() (TokenType, []byte) {
 	case '`':
 		l.templateLevels = append(l.templateLevels, l.level)
 		return l.consumeTemplateToken(), l.r.Shift()
+	case '@':
+		l.r.Move(1)
+		return AtToken, l.r.Shift()
 	case '#':
 		l.r.Move(1)
 		if l.consumeIdentifierToken() {
@@ -216,8 +323,9 @@ func (l *Lexer) Next() (TokenType, []byte) {
 		}
 	}
 
-	r, _ := l.r.PeekRune(0)
+	r, n := l.r.PeekRune(0)
 	l.err = parse.NewErrorLexer(l.r, "unexpected %s", parse.Printable(r))
+	l.r.Move(n)
 	return ErrorToken, l.r.Shift()
 }
 
@@ -513,6 +621,20 @@ func (l *Lexer) consumeIdentifierToken() bool {
 	return true
 }
 
+func (l *Lexer) consumeIdentifierContinue() bool {
+	c := l.r.Peek(0)
+	if identifierTable[c] {
+		l.r.Move(1)
+		return true
+	} else if 0xC0 <= c {
+		if r, n := l.r.PeekRune(0); r == '\u200C' || r == '\u200D' || unicode.IsOneOf(identifierContinue, r) {
+			l.r.Move(n)
+			return true
+		}
+	}
+	return false
+}
+
 func (l *Lexer) consumeNumericSeparator(f func() bool) bool {
 	if l.r.Peek(0) != '_' {
 		return false
@@ -561,8 +683,24 @@ func (l *Lexer) consumeNumericToken() TokenType {
 			l.r.Move(1)
 			return BigIntToken
 		} else if '0' <= l.r.Peek(0) && l.r.Peek(0) <= '9' {
-			l.err = parse.NewErrorLexer(l.r, "legacy octal numbers are not supported")
-			return ErrorToken
+			if !l.legacyOctal {
+				l.err = parse.NewErrorLexer(l.r, "legacy octal numbers are not supported")
+				return ErrorToken
+			}
+			octal := true
+			for c := l.r.Peek(0); '0' <= c && c <= '9'; c = l.r.Peek(0) {
+				if '8' <= c {
+					octal = false
+				}
+				l.r.Move(1)
+			}
+			if octal {
+				return LegacyOctalToken
+			} else if l.r.Peek(0) == 'n' {
+				l.err = parse.NewErrorLexer(l.r, "invalid number")
+				return ErrorToken
+			}
+			// decimal number with a leading zero such as 08, which may have a fraction or exponent
 		}
 	} else if first != '.' {
 		for l.consumeDigit() || l.consumeNumericSeparator(l.consumeDigit) {
@@ -26,6 +26,7 @@ func TestTokens(t *testing.T) {
 		{"/*comment*/ //comment", TTs{CommentToken, CommentToken}},
 		{"{ } ( ) [ ]", TTs{OpenBraceToken, CloseBraceToken, OpenParenToken, CloseParenToken, OpenBracketToken, CloseBracketToken}},
 		{". ; , < > <= ...", TTs{DotToken, SemicolonToken, CommaToken, LtToken, GtToken, LtEqToken, EllipsisToken}},
+		{"@a", TTs{AtToken, IdentifierToken}},
 		{">= == != === !==", TTs{GtEqToken, EqEqToken, NotEqToken, EqEqEqToken, NotEqEqToken}},
 		{"+ - * / % ** ++ --", TTs{AddToken, SubToken, MulToken, DivToken, ModToken, ExpToken, IncrToken, DecrToken}},
 		{"<< >> >>> & | ^", TTs{LtLtToken, GtGtToken, GtGtGtToken, BitAndToken, BitOrToken, BitXorToken}},
@@ -224,6 +225,91 @@ func TestRegExp(t *testing.T) {
 	test.T(t, token, ErrorToken)
 }
 
+func TestJSX(t *testing.T) {
+	var tokenTests = []struct {
+		js       string
+		expected []TokenType
+	}{
+		{"div className='a' data-x=\"b\n\"/>", TTs{IdentifierToken, IdentifierToken, EqToken, StringToken, IdentifierToken, EqToken, StringToken, DivToken, GtToken}},
+		{"svg:rect {", TTs{IdentifierToken, ColonToken, IdentifierToken, OpenBraceToken}},
+		{"A.B /* c */ x>", TTs{IdentifierToken, DotToken, IdentifierToken, CommentToken, IdentifierToken, GtToken}},
+		{"a='b", TTs{IdentifierToken, EqToken, ErrorToken}},
+		{"@", TTs{ErrorToken}},
+	}
+
+	for _, tt := range tokenTests {
+		t.Run(tt.js, func(t *testing.T) {
+			l := NewLexer(parse.NewInputString(tt.js))
+			tokens := []TokenType{}
+			for {
+				token, _ := l.JSXNext()
+				if token == ErrorToken {
+					if l.Err() != io.EOF {
+						tokens = append(tokens, token)
+					}
+					break
+				} else if token == WhitespaceToken {
+					continue
+				}
+				tokens = append(tokens, token)
+			}
+			test.T(t, tokens, tt.expected, "token types must match")
+		})
+	}
+
+	l := NewLexer(parse.NewInputString("text &amp; more\n{a}<b>"))
+	tt, data := l.JSXText()
+	test.T(t, tt, JSXTextToken)
+	test.String(t, string(data), "text &amp; more\n")
+	tt, _ = l.JSXNext()
+	test.T(t, tt, OpenBraceToken)
+
+	l = NewLexer(parse.NewInputString("<b>"))
+	tt, data = l.JSXText()
+	test.T(t, tt, JSXTextToken)
+	test.String(t, string(data), "")
+
+	tt, _ = NewLexer(parse.NewInputString("text")).JSXText()
+	test.T(t, tt, ErrorToken)
+}
+
+func TestGoalTokens(t *testing.T) {
+	var tokenTests = []struct {
+		js           string
+		htmlComments bool
+		legacyOctal  bool
+		expected     []TokenType
+	}{
+		{"010 0 08 08.5 09e1 0777", true, true, TTs{LegacyOctalToken, DecimalToken, DecimalToken, DecimalToken, DecimalToken, LegacyOctalToken}},
+		{"07.5", true, true, TTs{LegacyOctalToken, DecimalToken}},
+		{"09n", true, true, TTs{ErrorToken}},
+		{"010", true, false, TTs{ErrorToken}},
+		{"1<!--2\n", false, false, TTs{DecimalToken, LtToken, NotToken, DecrToken, DecimalToken, LineTerminatorToken}},
+		{"-->a\n", false, false, TTs{DecrToken, GtToken, IdentifierToken, LineTerminatorToken}},
+	}
+
+	for _, tt := range tokenTests {
+		t.Run(tt.js, func(t *testing.T) {
+			l := NewLexer(parse.NewInputString(tt.js))
+			l.htmlComments, l.legacyOctal = tt.htmlComments, tt.legacyOctal
+			tokens := []TokenType{}
+			for {
+				token, _ := l.Next()
+				if token == ErrorToken {
+					if l.Err() != io.EOF {
+						tokens = append(tokens, token)
+					}
+					break
+				} else if token == WhitespaceToken {
+					continue
+				}
+				tokens = append(tokens, token)
+			}
+			test.T(t, tokens, tt.expected, "token types must match")
+		})
+	}
+}
+
 func TestOffset(t *testing.T) {
 	z := parse.NewInputString(`var i=5;`)
 	l := NewLexer(z)
@@ -247,7 +333,7 @@ func TestLexerErrors(t *testing.T) {
 		js  string
 		err string
 	}{
-		{"@", "unexpected @"},
+		{"\\", "unexpected \\"},
 		{"\x00", "unexpected 0x00"},
 		{"\x7f", "unexpected 0x7F"},
 		{"\u200F", "unexpected U+200F"},
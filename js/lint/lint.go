@@ -0,0 +1,242 @@
+// Package lint is a framework for rules that check JavaScript code as parsed by the js package. Rules walk the AST and use its scopes to report diagnostics with a severity, a position, and an optional fix. The rules no-undef, no-unused-vars, no-debugger, and no-with are built in.
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/js"
+)
+
+// Severity is the severity of the diagnostics of a rule.
+type Severity uint8
+
+// Severity values.
+const (
+	Off Severity = iota // the rule is disabled
+	Warning
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Off:
+		return "off"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	return "Invalid(" + strconv.Itoa(int(s)) + ")"
+}
+
+// Edit replaces the source code in a span by a text.
+type Edit struct {
+	js.Span
+	Text []byte
+}
+
+// Diagnostic is a problem in the source code reported by a rule.
+type Diagnostic struct {
+	Rule     string
+	Severity Severity
+	Message  string
+	js.Span
+	Line, Column int    // position of the start of the span, starting at 1
+	Fix          []Edit // edits that fix the problem, can be nil
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s (%s)", d.Line, d.Column, d.Severity, d.Message, d.Rule)
+}
+
+// Rule is a lint rule. New is called for each AST that is linted and returns the visitor that is walked over the AST, or nil if the rule does not walk the AST. If the visitor implements Finisher, its Finish method is called after the walk.
+type Rule struct {
+	Name        string
+	Description string
+	Severity    Severity // default severity
+	New         func(c *Context) js.IVisitor
+}
+
+// Finisher is implemented by the visitors of rules that report diagnostics after walking the AST.
+type Finisher interface {
+	Finish()
+}
+
+var registry = map[string]*Rule{}
+
+// Register adds a rule to the registry so that it is run by Lint. It panics if a rule with the same name is already registered.
+func Register(rule *Rule) {
+	if _, ok := registry[rule.Name]; ok {
+		panic("lint: rule " + rule.Name + " is already registered")
+	}
+	registry[rule.Name] = rule
+}
+
+// Rules returns the registered rules sorted by name.
+func Rules() []*Rule {
+	rules := make([]*Rule, 0, len(registry))
+	for _, rule := range registry {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules
+}
+
+// RuleConfig is the configuration of a rule.
+type RuleConfig struct {
+	Severity Severity
+	Options  map[string]string // rule specific options, can be nil
+}
+
+// Config is the configuration of rules by name. Rules that are not configured run with their default severity and default options.
+type Config map[string]RuleConfig
+
+////////////////////////////////////////////////////////////////
+
+// Context is passed to a rule to report diagnostics for an AST.
+type Context struct {
+	AST     *js.AST
+	Src     []byte            // source code of the AST
+	Options map[string]string // options of the rule, can be nil
+
+	linter   *linter
+	rule     *Rule
+	severity Severity
+}
+
+// Scopes returns the scope tree of the AST, which is shared by all rules.
+func (c *Context) Scopes() *js.Scopes {
+	if c.linter.scopes == nil {
+		c.linter.scopes = js.AnalyzeScopes(c.AST)
+	}
+	return c.linter.scopes
+}
+
+// Option returns the value of an option of the rule, or def if it is not set.
+func (c *Context) Option(name, def string) string {
+	if value, ok := c.Options[name]; ok {
+		return value
+	}
+	return def
+}
+
+// Report adds a diagnostic for the source code in a span with an optional fix.
+func (c *Context) Report(span js.Span, message string, fix ...Edit) {
+	c.linter.diags = append(c.linter.diags, Diagnostic{
+		Rule:     c.rule.Name,
+		Severity: c.severity,
+		Message:  message,
+		Span:     span,
+		Fix:      fix,
+	})
+}
+
+type linter struct {
+	scopes *js.Scopes
+	diags  []Diagnostic
+}
+
+// Lint runs the registered rules that are not disabled by the configuration over the AST of the source, and returns their diagnostics sorted by position. The AST must not be parsed with js.Options.NoRefs for the diagnostics of identifiers to have their position. It returns an error if the configuration refers to a rule that is not registered.
+func Lint(src []byte, ast *js.AST, config Config) ([]Diagnostic, error) {
+	for name := range config {
+		if _, ok := registry[name]; !ok {
+			return nil, fmt.Errorf("unknown rule %s", name)
+		}
+	}
+
+	l := &linter{}
+	visitors := multiVisitor{}
+	for _, rule := range Rules() {
+		c := &Context{
+			AST:      ast,
+			Src:      src,
+			linter:   l,
+			rule:     rule,
+			severity: rule.Severity,
+		}
+		if ruleConfig, ok := config[rule.Name]; ok {
+			c.Options = ruleConfig.Options
+			c.severity = ruleConfig.Severity
+		}
+		if c.severity == Off {
+			continue
+		}
+		if v := rule.New(c); v != nil {
+			visitors = append(visitors, v)
+		}
+	}
+	js.Walk(visitors, ast)
+	for _, v := range visitors {
+		if finisher, ok := v.(Finisher); ok {
+			finisher.Finish()
+		}
+	}
+
+	sort.SliceStable(l.diags, func(i, j int) bool { return l.diags[i].Start < l.diags[j].Start })
+	for i := range l.diags {
+		l.diags[i].Line, l.diags[i].Column, _ = parse.Position(bytes.NewReader(src), l.diags[i].Start)
+	}
+	return l.diags, nil
+}
+
+// multiVisitor walks several visitors over the AST at once.
+type multiVisitor []js.IVisitor
+
+func (m multiVisitor) Enter(n js.INode) js.IVisitor {
+	next := make(multiVisitor, len(m))
+	entered := false
+	for i, v := range m {
+		if v != nil {
+			if next[i] = v.Enter(n); next[i] != nil {
+				entered = true
+			}
+		}
+	}
+	if !entered {
+		return nil
+	}
+	return next
+}
+
+func (m multiVisitor) Exit(n js.INode) {
+	for _, v := range m {
+		if v != nil {
+			v.Exit(n)
+		}
+	}
+}
+
+// Fix applies the fixes of the diagnostics to the source and returns the fixed source. Fixes that overlap with the fix of a previous diagnostic are skipped, so that linting and fixing again may fix more problems.
+func Fix(src []byte, diags []Diagnostic) []byte {
+	edits := []Edit{}
+	for _, diag := range diags {
+		if len(diag.Fix) == 0 {
+			continue
+		}
+		overlaps := false
+		for _, fix := range diag.Fix {
+			for _, edit := range edits {
+				if fix.Start < edit.End && edit.Start < fix.End || fix.Start == edit.Start {
+					overlaps = true
+				}
+			}
+		}
+		if !overlaps {
+			edits = append(edits, diag.Fix...)
+		}
+	}
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].Start < edits[j].Start })
+
+	b := make([]byte, 0, len(src))
+	start := 0
+	for _, edit := range edits {
+		b = append(b, src[start:edit.Start]...)
+		b = append(b, edit.Text...)
+		start = edit.End
+	}
+	return append(b, src[start:]...)
+}
@@ -0,0 +1,92 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/js"
+	"github.com/tdewolff/test"
+)
+
+func lint(t *testing.T, src string, config Config) []Diagnostic {
+	ast, err := js.Parse(parse.NewInputString(src), js.Options{})
+	test.Error(t, err)
+	diags, err := Lint([]byte(src), ast, config)
+	test.Error(t, err)
+	return diags
+}
+
+func TestRules(t *testing.T) {
+	var tests = []struct {
+		rule     string
+		js       string
+		options  map[string]string
+		expected string
+	}{
+		{"no-debugger", "a();\ndebugger;\nif (b) debugger", nil, "2:1: error: unexpected debugger statement (no-debugger), 3:8: error: unexpected debugger statement (no-debugger)"},
+		{"no-with", "with (a) { with (b) c }", nil, "1:1: error: unexpected with statement (no-with), 1:12: error: unexpected with statement (no-with)"},
+		{"no-undef", "var a = b + c; b = Math.max(a, typeof d, typeof e.f); parseInt(arguments)", nil, "1:9: error: b is not defined (no-undef), 1:13: error: c is not defined (no-undef), 1:16: error: b is not defined (no-undef), 1:49: error: e is not defined (no-undef)"},
+		{"no-undef", "import x from 'x'; window.alert(x, $)", map[string]string{"globals": "window, $"}, ""},
+		{"no-unused-vars", "var a = 1, b; function f(x, y, z) { var c = 2; c = 3; return y }", nil, "1:5: warning: a is declared but never used (no-unused-vars), 1:12: warning: b is declared but never used (no-unused-vars), 1:24: warning: f is declared but never used (no-unused-vars), 1:32: warning: z is declared but never used (no-unused-vars), 1:41: warning: c is declared but never used (no-unused-vars)"},
+		{"no-unused-vars", "function f(x, {y}) { try {} catch (e) {} }", map[string]string{"vars": "local", "args": "all", "caughtErrors": "none"}, "1:12: warning: x is declared but never used (no-unused-vars), 1:16: warning: y is declared but never used (no-unused-vars)"},
+		{"no-unused-vars", "(function f(x) {}); (class A {}); (x) => 1", map[string]string{"args": "none"}, ""},
+		{"no-unused-vars", "var a = 1; function f() { var b = 2; eval('a + b') } f()", nil, ""},
+		{"no-unused-vars", "var a, b, c; export {a, b as d}; export function f() {} export default function g() {}", nil, "1:11: warning: c is declared but never used (no-unused-vars)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			config := Config{}
+			for _, rule := range Rules() {
+				if rule.Name != tt.rule {
+					config[rule.Name] = RuleConfig{Severity: Off}
+				}
+			}
+			if tt.options != nil {
+				config[tt.rule] = RuleConfig{Severity: registry[tt.rule].Severity, Options: tt.options}
+			}
+
+			diags := []string{}
+			for _, diag := range lint(t, tt.js, config) {
+				diags = append(diags, diag.String())
+			}
+			test.String(t, strings.Join(diags, ", "), tt.expected)
+		})
+	}
+}
+
+func TestLint(t *testing.T) {
+	src := "function f(a) {\n\tdebugger;\n\twith (a) b()\n}\nf()"
+	diags := lint(t, src, Config{"no-with": {Severity: Warning}})
+	test.T(t, len(diags), 3)
+	test.String(t, diags[0].String(), "2:2: error: unexpected debugger statement (no-debugger)")
+	test.String(t, diags[1].String(), "3:2: warning: unexpected with statement (no-with)")
+	test.String(t, diags[2].String(), "3:11: error: b is not defined (no-undef)")
+	test.String(t, string(Fix([]byte(src), diags)), "function f(a) {\n\t\n\twith (a) b()\n}\nf()")
+
+	_, err := Lint([]byte(src), nil, Config{"no-such-rule": {}})
+	test.That(t, err != nil)
+}
+
+func TestRegister(t *testing.T) {
+	rule := &Rule{
+		Name: "no-alert",
+		New: func(c *Context) js.IVisitor {
+			for _, v := range c.Scopes().Undeclared() {
+				if string(v.Data) == "alert" {
+					for _, ref := range c.Scopes().Refs(v) {
+						c.Report(ref.Span, "unexpected alert", Edit{ref.Span, []byte("console.log")})
+					}
+				}
+			}
+			return nil
+		},
+	}
+	Register(rule)
+	defer delete(registry, rule.Name)
+
+	src := "alert(1); alert(2)"
+	diags := lint(t, src, Config{"no-alert": {Severity: Error}, "no-undef": {Severity: Off}})
+	test.T(t, len(diags), 2)
+	test.String(t, string(Fix([]byte(src), diags)), "console.log(1); console.log(2)")
+}
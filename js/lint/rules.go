@@ -0,0 +1,387 @@
+package lint
+
+import (
+	"strings"
+
+	"github.com/tdewolff/parse/v2/js"
+)
+
+func init() {
+	Register(&Rule{
+		Name:        "no-debugger",
+		Description: "disallow debugger statements",
+		Severity:    Error,
+		New:         newNoDebugger,
+	})
+	Register(&Rule{
+		Name:        "no-undef",
+		Description: "disallow the use of undeclared variables, except for the standard globals and the globals option, a comma separated list of names",
+		Severity:    Error,
+		New:         newNoUndef,
+	})
+	Register(&Rule{
+		Name:        "no-unused-vars",
+		Description: "disallow variables that are declared but never read, with the options vars (all or local), args (after-used, all, or none), and caughtErrors (all or none)",
+		Severity:    Warning,
+		New:         newNoUnusedVars,
+	})
+	Register(&Rule{
+		Name:        "no-with",
+		Description: "disallow with statements",
+		Severity:    Error,
+		New:         newNoWith,
+	})
+}
+
+// noDebugger reports debugger statements, and removes those that are in a statement list.
+type noDebugger struct {
+	c      *Context
+	inList map[*js.DebuggerStmt]bool
+}
+
+func newNoDebugger(c *Context) js.IVisitor {
+	return &noDebugger{c, map[*js.DebuggerStmt]bool{}}
+}
+
+func (r *noDebugger) Enter(n js.INode) js.IVisitor {
+	switch n := n.(type) {
+	case *js.BlockStmt:
+		r.addList(n.List)
+	case *js.CaseClause:
+		r.addList(n.List)
+	case *js.DebuggerStmt:
+		if r.inList[n] {
+			end := n.End
+			if end < len(r.c.Src) && r.c.Src[end] == ';' {
+				end++
+			}
+			r.c.Report(n.Span, "unexpected debugger statement", Edit{Span: js.Span{Start: n.Start, End: end}})
+		} else {
+			r.c.Report(n.Span, "unexpected debugger statement")
+		}
+	}
+	return r
+}
+
+func (r *noDebugger) Exit(n js.INode) {}
+
+func (r *noDebugger) addList(list []js.IStmt) {
+	for _, stmt := range list {
+		if debugger, ok := stmt.(*js.DebuggerStmt); ok {
+			r.inList[debugger] = true
+		}
+	}
+}
+
+// noWith reports with statements.
+type noWith struct {
+	c *Context
+}
+
+func newNoWith(c *Context) js.IVisitor {
+	return noWith{c}
+}
+
+func (r noWith) Enter(n js.INode) js.IVisitor {
+	if n, ok := n.(*js.WithStmt); ok {
+		r.c.Report(n.Span, "unexpected with statement")
+	}
+	return r
+}
+
+func (r noWith) Exit(n js.INode) {}
+
+////////////////////////////////////////////////////////////////
+
+// globals are the global variables of the ECMAScript standard.
+var globals = map[string]bool{
+	"AggregateError":       true,
+	"Array":                true,
+	"ArrayBuffer":          true,
+	"Atomics":              true,
+	"BigInt":               true,
+	"BigInt64Array":        true,
+	"BigUint64Array":       true,
+	"Boolean":              true,
+	"DataView":             true,
+	"Date":                 true,
+	"Error":                true,
+	"EvalError":            true,
+	"FinalizationRegistry": true,
+	"Float32Array":         true,
+	"Float64Array":         true,
+	"Function":             true,
+	"Infinity":             true,
+	"Int16Array":           true,
+	"Int32Array":           true,
+	"Int8Array":            true,
+	"Intl":                 true,
+	"Iterator":             true,
+	"JSON":                 true,
+	"Map":                  true,
+	"Math":                 true,
+	"NaN":                  true,
+	"Number":               true,
+	"Object":               true,
+	"Promise":              true,
+	"Proxy":                true,
+	"RangeError":           true,
+	"ReferenceError":       true,
+	"Reflect":              true,
+	"RegExp":               true,
+	"Set":                  true,
+	"SharedArrayBuffer":    true,
+	"String":               true,
+	"Symbol":               true,
+	"SyntaxError":          true,
+	"TypeError":            true,
+	"URIError":             true,
+	"Uint16Array":          true,
+	"Uint32Array":          true,
+	"Uint8Array":           true,
+	"Uint8ClampedArray":    true,
+	"WeakMap":              true,
+	"WeakRef":              true,
+	"WeakSet":              true,
+	"arguments":            true,
+	"decodeURI":            true,
+	"decodeURIComponent":   true,
+	"encodeURI":            true,
+	"encodeURIComponent":   true,
+	"escape":               true,
+	"eval":                 true,
+	"globalThis":           true,
+	"isFinite":             true,
+	"isNaN":                true,
+	"parseFloat":           true,
+	"parseInt":             true,
+	"undefined":            true,
+	"unescape":             true,
+}
+
+// noUndef reports the references to undeclared variables, except for standard globals, configured globals, and imported bindings. References in typeof expressions are allowed.
+type noUndef struct {
+	c       *Context
+	typeofs []js.Span // spans of typeof expressions of an identifier
+}
+
+func newNoUndef(c *Context) js.IVisitor {
+	return &noUndef{c: c}
+}
+
+func (r *noUndef) Enter(n js.INode) js.IVisitor {
+	if n, ok := n.(*js.UnaryExpr); ok && n.Op == js.TypeofToken {
+		if _, ok := n.X.(*js.Var); ok {
+			r.typeofs = append(r.typeofs, n.Span)
+		}
+	}
+	return r
+}
+
+func (r *noUndef) Exit(n js.INode) {}
+
+func (r *noUndef) Finish() {
+	allowed := map[string]bool{}
+	for _, name := range strings.Split(r.c.Option("globals", ""), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			allowed[name] = true
+		}
+	}
+	for _, name := range importedNames(r.c.AST) {
+		allowed[string(name)] = true
+	}
+
+	scopes := r.c.Scopes()
+	for _, v := range scopes.Undeclared() {
+		name := string(v.Data)
+		if globals[name] || allowed[name] {
+			continue
+		}
+	Refs:
+		for _, ref := range scopes.Refs(v) {
+			for _, span := range r.typeofs {
+				if span.Start <= ref.Start && ref.End == span.End {
+					continue Refs
+				}
+			}
+			r.c.Report(ref.Span, name+" is not defined")
+		}
+	}
+}
+
+// importedNames returns the local names of the bindings of the import statements, which are not variables of the scope tree.
+func importedNames(ast *js.AST) [][]byte {
+	names := [][]byte{}
+	for _, stmt := range ast.List {
+		if importStmt, ok := stmt.(*js.ImportStmt); ok {
+			if importStmt.Default != nil {
+				names = append(names, importStmt.Default)
+			}
+			for _, alias := range importStmt.List {
+				names = append(names, alias.Binding)
+			}
+		}
+	}
+	return names
+}
+
+////////////////////////////////////////////////////////////////
+
+// noUnusedVars reports variables that are never read. Exported variables, names of function and class expressions, and variables that are visible to a direct call to eval are not reported.
+type noUnusedVars struct {
+	c                  *Context
+	vars, args, caught string
+	skip               map[*js.Var]bool
+}
+
+func newNoUnusedVars(c *Context) js.IVisitor {
+	r := &noUnusedVars{
+		c:      c,
+		vars:   c.Option("vars", "all"),
+		args:   c.Option("args", "after-used"),
+		caught: c.Option("caughtErrors", "all"),
+		skip:   map[*js.Var]bool{},
+	}
+	scopes := c.Scopes()
+	for _, stmt := range c.AST.List {
+		if exportStmt, ok := stmt.(*js.ExportStmt); ok && exportStmt.Module == nil {
+			for _, alias := range exportStmt.List {
+				name := alias.Binding
+				if alias.Name != nil {
+					name = alias.Name
+				}
+				if v := scopes.Root.Lookup(name); v != nil {
+					r.skip[v] = true
+				}
+			}
+			switch decl := exportStmt.Decl.(type) {
+			case *js.VarDecl:
+				for _, item := range decl.List {
+					for _, v := range bindingVars(item.Binding, nil) {
+						r.skip[scopes.Resolve(v)] = true
+					}
+				}
+			case *js.FuncDecl:
+				if decl.Name != nil {
+					r.skip[scopes.Resolve(decl.Name)] = true
+				}
+			case *js.ClassDecl:
+				if decl.Name != nil {
+					r.skip[scopes.Resolve(decl.Name)] = true
+				}
+			}
+		}
+	}
+	for _, v := range scopes.Undeclared() {
+		if string(v.Data) == "eval" {
+			for _, ref := range scopes.Refs(v) {
+				for scope := ref.Scope; scope != nil; scope = scope.Parent {
+					for _, w := range scope.Scope.Declared {
+						r.skip[w] = true
+					}
+				}
+			}
+		}
+	}
+	r.check(scopes.Root)
+	return nil
+}
+
+func (r *noUnusedVars) check(scope *js.ScopeNode) {
+	if scope.Parent != nil || r.vars != "local" {
+		params := r.params(scope)
+		for _, v := range scope.Scope.Declared {
+			if r.skip[v] || v.Decl == js.ExprDecl || v.Decl == js.CatchDecl && r.caught == "none" || params[v] {
+				continue
+			}
+			r.skip[v] = true
+			r.checkVar(v)
+		}
+	}
+	for _, child := range scope.Children {
+		r.check(child)
+	}
+}
+
+// params returns the parameters of a function scope that are not reported because of the args option.
+func (r *noUnusedVars) params(scope *js.ScopeNode) map[*js.Var]bool {
+	var params *js.Params
+	switch n := scope.Node.(type) {
+	case *js.FuncDecl:
+		params = &n.Params
+	case *js.ArrowFunc:
+		params = &n.Params
+	case *js.MethodDecl:
+		params = &n.Params
+	default:
+		return nil
+	}
+
+	vars := []*js.Var{}
+	for _, item := range params.List {
+		vars = bindingVars(item.Binding, vars)
+	}
+	if params.Rest != nil {
+		vars = bindingVars(params.Rest, vars)
+	}
+
+	skip := map[*js.Var]bool{}
+	for i, v := range vars {
+		v = r.c.Scopes().Resolve(v)
+		if r.args == "none" {
+			skip[v] = true
+		} else if r.args == "after-used" {
+			for _, w := range vars[i+1:] {
+				if r.isUsed(r.c.Scopes().Resolve(w)) {
+					skip[v] = true
+					break
+				}
+			}
+		}
+	}
+	return skip
+}
+
+func (r *noUnusedVars) checkVar(v *js.Var) {
+	if r.isUsed(v) {
+		return
+	}
+	span := v.Span
+	for _, ref := range r.c.Scopes().Refs(v) {
+		if ref.IsDecl() {
+			span = ref.Span
+			break
+		}
+	}
+	r.c.Report(span, string(v.Data)+" is declared but never used")
+}
+
+func (r *noUnusedVars) isUsed(v *js.Var) bool {
+	for _, ref := range r.c.Scopes().Refs(v) {
+		if ref.IsRead() {
+			return true
+		}
+	}
+	return false
+}
+
+// bindingVars appends the variables that are bound by a binding.
+func bindingVars(binding js.IBinding, vars []*js.Var) []*js.Var {
+	switch b := binding.(type) {
+	case *js.Var:
+		vars = append(vars, b)
+	case *js.BindingArray:
+		for _, item := range b.List {
+			vars = bindingVars(item.Binding, vars)
+		}
+		vars = bindingVars(b.Rest, vars)
+	case *js.BindingObject:
+		for _, item := range b.List {
+			vars = bindingVars(item.Value.Binding, vars)
+		}
+		if b.Rest != nil {
+			vars = append(vars, b.Rest)
+		}
+	}
+	return vars
+}
@@ -0,0 +1,231 @@
+package js
+
+import (
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/buffer"
+)
+
+// DecodeString returns the value of a string literal in UTF-8, where b is the data of a StringToken including its quotes. Lone surrogates, which cannot be encoded in UTF-8, are replaced by U+FFFD. It returns an error for an invalid escape sequence, of which the position is relative to the string literal.
+func DecodeString(b []byte) ([]byte, error) {
+	if len(b) < 2 || b[0] != '"' && b[0] != '\'' || b[len(b)-1] != b[0] {
+		return nil, parse.NewError(buffer.NewReader(b), 0, "invalid string literal")
+	}
+	return decodeLiteral(b, 1, len(b)-1, false)
+}
+
+// DecodeTemplate returns the cooked value of a template literal or one of its parts in UTF-8, where b is the data of a TemplateToken including its delimiters, such as the Value of a TemplatePart and the Tail of a TemplateExpr. Line terminators \r\n and \r are normalized to \n and lone surrogates are replaced by U+FFFD. It returns an error for the first invalid escape sequence, of which the position is relative to the template. Invalid escape sequences are allowed in tagged templates, for which the cooked value is undefined and the raw value is given by RawTemplate.
+func DecodeTemplate(b []byte) ([]byte, error) {
+	start, end, ok := templateContents(b)
+	if !ok {
+		return nil, parse.NewError(buffer.NewReader(b), 0, "invalid template literal")
+	}
+	return decodeLiteral(b, start, end, true)
+}
+
+// RawTemplate returns the raw value of a template literal or one of its parts without its delimiters, where line terminators \r\n and \r are normalized to \n. This is the value of String.raw for the template.
+func RawTemplate(b []byte) []byte {
+	start, end, ok := templateContents(b)
+	if !ok {
+		return nil
+	}
+	raw := make([]byte, 0, end-start)
+	for i := start; i < end; i++ {
+		if b[i] == '\r' {
+			if i+1 < end && b[i+1] == '\n' {
+				i++
+			}
+			raw = append(raw, '\n')
+		} else {
+			raw = append(raw, b[i])
+		}
+	}
+	return raw
+}
+
+// templateContents returns the offsets of the contents of a template, which starts with ` or } and ends with ` or ${.
+func templateContents(b []byte) (int, int, bool) {
+	if len(b) < 2 || b[0] != '`' && b[0] != '}' {
+		return 0, 0, false
+	} else if b[len(b)-1] == '`' {
+		return 1, len(b) - 1, true
+	} else if 3 <= len(b) && b[len(b)-2] == '$' && b[len(b)-1] == '{' {
+		return 1, len(b) - 2, true
+	}
+	return 0, 0, false
+}
+
+func decodeLiteral(b []byte, start, end int, template bool) ([]byte, error) {
+	units, invalid := cookString(b[start:end], template)
+	if invalid != -1 {
+		msg := checkEscapes(b[start+invalid:end], template, false)
+		if msg == "" {
+			msg = "invalid escape sequence"
+		}
+		return nil, parse.NewError(buffer.NewReader(b), start+invalid, msg)
+	}
+	return []byte(string(utf16.Decode(units))), nil
+}
+
+// QuoteString returns the shortest literal for s with the given quote, which is either ' or " for a string literal, or ` for a template literal without substitutions. Any other quote is replaced by ". Only the quote, backslashes, line terminators that are not allowed in the literal, NUL characters, and ${ in template literals are escaped. Invalid UTF-8 is replaced by U+FFFD.
+func QuoteString(s string, quote byte) []byte {
+	if quote != '\'' && quote != '`' {
+		quote = '"'
+	}
+	b := make([]byte, 0, len(s)+2)
+	b = append(b, quote)
+	for i := 0; i < len(s); {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == rune(quote) || r == '\\':
+			b = append(b, '\\', byte(r))
+		case r == '\n' && quote != '`':
+			b = append(b, '\\', 'n')
+		case r == '\r':
+			// template literals normalize \r to \n
+			b = append(b, '\\', 'r')
+		case r == 0:
+			if i+1 < len(s) && '0' <= s[i+1] && s[i+1] <= '9' {
+				// \0 followed by a digit is an octal escape sequence
+				b = append(b, '\\', 'x', '0', '0')
+			} else {
+				b = append(b, '\\', '0')
+			}
+		case r == '$' && quote == '`' && i+1 < len(s) && s[i+1] == '{':
+			b = append(b, '\\', '$')
+		default:
+			var rb [utf8.UTFMax]byte
+			b = append(b, rb[:utf8.EncodeRune(rb[:], r)]...)
+		}
+		i += n
+	}
+	return append(b, quote)
+}
+
+// cookString decodes the escape sequences of the contents of a string or template literal into UTF-16 code units, so that lone surrogates are retained. It returns the offset of the first invalid escape sequence, which are only allowed in tagged templates, or -1 if all are valid.
+func cookString(b []byte, template bool) ([]uint16, int) {
+	units := make([]uint16, 0, len(b))
+	for i := 0; i < len(b); {
+		start := i
+		c := b[i]
+		if c == '\r' && template {
+			units = append(units, '\n')
+			if i+1 < len(b) && b[i+1] == '\n' {
+				i++
+			}
+			i++
+			continue
+		} else if c != '\\' || i+1 == len(b) {
+			r, n := utf8.DecodeRune(b[i:])
+			units = appendUTF16(units, r)
+			i += n
+			continue
+		}
+
+		i++
+		c = b[i]
+		i++
+		switch c {
+		case 'n':
+			units = append(units, '\n')
+		case 't':
+			units = append(units, '\t')
+		case 'r':
+			units = append(units, '\r')
+		case 'b':
+			units = append(units, '\b')
+		case 'f':
+			units = append(units, '\f')
+		case 'v':
+			units = append(units, '\v')
+		case '\n':
+			// line continuation
+		case '\r':
+			if i < len(b) && b[i] == '\n' {
+				i++
+			}
+		case 'x':
+			if len(b) < i+2 || !isHex(b[i]) || !isHex(b[i+1]) {
+				return nil, start
+			}
+			units = append(units, uint16(hexValue(b[i])<<4|hexValue(b[i+1])))
+			i += 2
+		case 'u':
+			r := 0
+			if i < len(b) && b[i] == '{' {
+				j := i + 1
+				for j < len(b) && isHex(b[j]) && r <= unicode.MaxRune {
+					r = r<<4 | int(hexValue(b[j]))
+					j++
+				}
+				if j == i+1 || len(b) <= j || b[j] != '}' || unicode.MaxRune < r {
+					return nil, start
+				}
+				i = j + 1
+			} else {
+				if len(b) < i+4 {
+					return nil, start
+				}
+				for _, c := range b[i : i+4] {
+					if !isHex(c) {
+						return nil, start
+					}
+					r = r<<4 | int(hexValue(c))
+				}
+				i += 4
+			}
+			units = appendUTF16(units, rune(r))
+		default:
+			if '0' <= c && c <= '7' {
+				if template && (c != '0' || i < len(b) && '0' <= b[i] && b[i] <= '9') {
+					return nil, start
+				}
+				// legacy octal escape of at most three digits with a value below 256
+				r := int(c - '0')
+				if i < len(b) && '0' <= b[i] && b[i] <= '7' {
+					r = r<<3 | int(b[i]-'0')
+					i++
+					if c <= '3' && i < len(b) && '0' <= b[i] && b[i] <= '7' {
+						r = r<<3 | int(b[i]-'0')
+						i++
+					}
+				}
+				units = append(units, uint16(r))
+			} else if (c == '8' || c == '9') && template {
+				return nil, start
+			} else if c == 0xE2 && i+1 < len(b) && b[i] == 0x80 && (b[i+1] == 0xA8 || b[i+1] == 0xA9) {
+				i += 2 // line continuation with U+2028 or U+2029
+			} else {
+				r, n := utf8.DecodeRune(b[i-1:])
+				units = appendUTF16(units, r)
+				i += n - 1
+			}
+		}
+	}
+	return units, -1
+}
+
+func appendUTF16(units []uint16, r rune) []uint16 {
+	if r < 0x10000 {
+		return append(units, uint16(r))
+	}
+	r1, r2 := utf16.EncodeRune(r)
+	return append(units, uint16(r1), uint16(r2))
+}
+
+// utf16String converts UTF-16 code units to a string, it returns false when there are lone surrogates, which cannot be encoded in UTF-8.
+func utf16String(units []uint16) (string, bool) {
+	for i := 0; i < len(units); i++ {
+		if utf16.IsSurrogate(rune(units[i])) {
+			if units[i] < 0xDC00 && i+1 < len(units) && 0xDC00 <= units[i+1] && units[i+1] < 0xE000 {
+				i++
+			} else {
+				return "", false
+			}
+		}
+	}
+	return string(utf16.Decode(units)), true
+}
@@ -0,0 +1,143 @@
+package js
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestDecodeString(t *testing.T) {
+	var tests = []struct {
+		lit      string
+		expected string
+	}{
+		{`""`, ""},
+		{`'abc'`, "abc"},
+		{`"a'b\"c"`, `a'b"c`},
+		{`'\n\t\r\b\f\v\0\\\''`, "\n\t\r\b\f\v\x00\\'"},
+		{`'\x41B\u{43}\u{1F600}'`, "ABC\U0001F600"},
+		{`'😀'`, "\U0001F600"},
+		{`'\ud83d'`, "�"},
+		{`'\101\7\08\8\9\a'`, "A\a\x008" + "89a"},
+		{"'a\\\nb\\\r\nc\\ d'", "abcd"},
+		{"'a b'", "a b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lit, func(t *testing.T) {
+			s, err := DecodeString([]byte(tt.lit))
+			test.Error(t, err)
+			test.String(t, string(s), tt.expected)
+		})
+	}
+}
+
+func TestDecodeStringError(t *testing.T) {
+	var tests = []struct {
+		lit    string
+		err    string
+		line   int
+		column int
+	}{
+		{`'\x4'`, "invalid hexadecimal escape sequence", 1, 2},
+		{`'a\u{110000}'`, "invalid unicode escape sequence", 1, 3},
+		{`'\u00G0'`, "invalid unicode escape sequence", 1, 2},
+		{`'abc"`, "invalid string literal", 1, 1},
+		{`abc`, "invalid string literal", 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lit, func(t *testing.T) {
+			_, err := DecodeString([]byte(tt.lit))
+			test.That(t, err != nil, "must return error")
+			test.String(t, err.(*parse.Error).Message, tt.err)
+			test.T(t, err.(*parse.Error).Line, tt.line)
+			test.T(t, err.(*parse.Error).Column, tt.column)
+		})
+	}
+}
+
+func TestDecodeTemplate(t *testing.T) {
+	var tests = []struct {
+		lit    string
+		cooked string
+		raw    string
+	}{
+		{"``", "", ""},
+		{"`abc${", "abc", "abc"},
+		{"}abc`", "abc", "abc"},
+		{"}a${", "a", "a"},
+		{"`a\\`b\\${c`", "a`b${c", "a\\`b\\${c"},
+		{"`a\r\nb\rc\\\r\nd`", "a\nb\ncd", "a\nb\nc\\\nd"},
+		{"`\\u{1F600}\\x41\\0`", "\U0001F600A\x00", "\\u{1F600}\\x41\\0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lit, func(t *testing.T) {
+			s, err := DecodeTemplate([]byte(tt.lit))
+			test.Error(t, err)
+			test.String(t, string(s), tt.cooked)
+			test.String(t, string(RawTemplate([]byte(tt.lit))), tt.raw)
+		})
+	}
+}
+
+func TestDecodeTemplateError(t *testing.T) {
+	var tests = []struct {
+		lit    string
+		err    string
+		line   int
+		column int
+	}{
+		{"`\\unicode`", "invalid unicode escape sequence", 1, 2},
+		{"`a\n\\01`", "octal escape sequences are not allowed in template strings", 2, 1},
+		{"}\\8${", "octal escape sequences are not allowed in template strings", 1, 2},
+		{"`abc", "invalid template literal", 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lit, func(t *testing.T) {
+			_, err := DecodeTemplate([]byte(tt.lit))
+			test.That(t, err != nil, "must return error")
+			test.String(t, err.(*parse.Error).Message, tt.err)
+			test.T(t, err.(*parse.Error).Line, tt.line)
+			test.T(t, err.(*parse.Error).Column, tt.column)
+		})
+	}
+}
+
+func TestQuoteString(t *testing.T) {
+	var tests = []struct {
+		s        string
+		quote    byte
+		expected string
+	}{
+		{"", '"', `""`},
+		{"abc", '\'', `'abc'`},
+		{`a'b"c\d`, '\'', `'a\'b"c\\d'`},
+		{`a'b"c\d`, '"', `"a'b\"c\\d"`},
+		{"a\nb\rc d", '"', `"a\nb\rc` + " " + `d"`},
+		{"a\nb\rc", '`', "`a\nb\\rc`"},
+		{"`${a}$b", '`', "`\\`\\${a}$b`"},
+		{"\x00a\x001", '"', `"\0a\x001"`},
+		{"\xffa", '"', "\"�a\""},
+		{"a", 'x', `"a"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			lit := QuoteString(tt.s, tt.quote)
+			test.String(t, string(lit), tt.expected)
+
+			// the literal is parsed and decoded to the original string
+			ast, err := Parse(parse.NewInputBytes(append([]byte("x = "), lit...)), Options{})
+			test.Error(t, err)
+			var s []byte
+			switch n := ast.List[0].(*ExprStmt).Value.(*BinaryExpr).Y.(type) {
+			case *LiteralExpr:
+				s, err = DecodeString(n.Data)
+			case *TemplateExpr:
+				s, err = DecodeTemplate(n.Tail)
+			}
+			test.Error(t, err)
+			test.String(t, string(s), strings.ToValidUTF8(tt.s, "\uFFFD"))
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package js
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// Mangle renames the declared variables to short names, where the variables that are referenced most get the shortest names. Names are assigned per scope, so that variables in sibling scopes reuse the same names, while avoiding the names of variables of enclosing scopes and of undeclared variables that are referenced inside the scope. Top-level declarations are only renamed when module is set and they are not exported, since the top-level declarations of a script are global variables that other scripts may use. Variables that are visible to a direct call to eval and variables that are referenced inside a with statement keep their names, since they may be referenced by name at runtime. A catch parameter and a var declaration of the same name in its catch clause also keep their names, since the initializer of the var declaration assigns to the catch parameter.
+func Mangle(ast *AST, module bool) {
+	scopes := AnalyzeScopes(ast)
+	m := &mangler{
+		scopes: scopes,
+		fixed:  dynamicVars(ast, scopes),
+		refs:   map[*ScopeNode]map[*Var]bool{},
+	}
+	for v := range catchVars(ast) {
+		m.fixed[v] = true
+	}
+	if module {
+		for _, v := range exportedVars(ast, scopes, true) {
+			m.fixed[v] = true
+		}
+	} else {
+		for _, v := range scopes.Root.Scope.Declared {
+			m.fixed[v] = true
+		}
+	}
+	m.collectRefs(scopes.Root)
+
+	used := map[string]bool{}
+	for _, name := range importedNames(ast) {
+		used[string(name)] = true
+	}
+	m.mangle(scopes.Root, used)
+}
+
+type mangler struct {
+	scopes *Scopes
+	fixed  map[*Var]bool                // variables that keep their name
+	refs   map[*ScopeNode]map[*Var]bool // variables referenced in a scope and its child scopes
+}
+
+// collectRefs collects the variables that are referenced in the scope and its child scopes.
+func (m *mangler) collectRefs(node *ScopeNode) map[*Var]bool {
+	refs := map[*Var]bool{}
+	for _, ref := range node.Refs {
+		refs[ref.Var] = true
+	}
+	for _, child := range node.Children {
+		for v := range m.collectRefs(child) {
+			refs[v] = true
+		}
+	}
+	m.refs[node] = refs
+	return refs
+}
+
+// mangle renames the variables declared in the scope, avoiding the used names, and continues with its child scopes. Enclosing scopes are renamed first so that their new names are avoided.
+func (m *mangler) mangle(node *ScopeNode, used map[string]bool) {
+	for v := range m.refs[node] {
+		if !isInScope(m.scopes.DeclScope(v), node) {
+			used[string(v.Data)] = true
+		}
+	}
+
+	vars := VarArray{}
+	seen := map[*Var]bool{}
+	for _, v := range node.Scope.Declared {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		if m.fixed[v] {
+			used[string(v.Data)] = true
+		} else {
+			vars = append(vars, v)
+		}
+	}
+	sort.SliceStable(vars, func(i, j int) bool {
+		return len(m.scopes.Refs(vars[i])) > len(m.scopes.Refs(vars[j]))
+	})
+
+	i := 0
+	for _, v := range vars {
+		name := mangledName(i)
+		for used[string(name)] || isReservedName(name) {
+			i++
+			name = mangledName(i)
+		}
+		i++
+		v.Data = name
+		used[string(name)] = true
+	}
+
+	for _, child := range node.Children {
+		m.mangle(child, map[string]bool{})
+	}
+}
+
+var mangleStartChars = []byte("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_$")
+var mangleChars = []byte("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_$0123456789")
+
+// mangledName returns the i-th name in the sequence a, b, ..., $, aa, ba, ..., of identifiers ordered by length.
+func mangledName(i int) []byte {
+	name := []byte{mangleStartChars[i%len(mangleStartChars)]}
+	i /= len(mangleStartChars)
+	for 0 < i {
+		i--
+		name = append(name, mangleChars[i%len(mangleChars)])
+		i /= len(mangleChars)
+	}
+	return name
+}
+
+// isReservedName returns true for the names that cannot be used as the name of a variable in strict mode code, including arguments and eval.
+func isReservedName(name []byte) bool {
+	if tt, ok := Keywords[string(name)]; ok {
+		switch tt {
+		case LetToken, StaticToken, ImplementsToken, InterfaceToken, PackageToken, PrivateToken, ProtectedToken, PublicToken:
+			return true
+		}
+		return IsReservedWord(tt)
+	}
+	return string(name) == "arguments" || string(name) == "eval"
+}
+
+// dynamicVars returns the variables that may be referenced by name at runtime, which are the variables that are visible to a direct call to eval and the variables that are referenced inside a with statement.
+func dynamicVars(ast *AST, scopes *Scopes) map[*Var]bool {
+	vars := map[*Var]bool{}
+	for _, v := range scopes.Undeclared() {
+		if string(v.Data) == "eval" {
+			for _, ref := range scopes.Refs(v) {
+				for scope := ref.Scope; scope != nil; scope = scope.Parent {
+					for _, w := range scope.Scope.Declared {
+						vars[w] = true
+					}
+				}
+			}
+		}
+	}
+	Walk(withVars(vars), ast)
+	return vars
+}
+
+// withVars collects the variables that are referenced in the body of with statements.
+type withVars map[*Var]bool
+
+func (vars withVars) Enter(n INode) IVisitor {
+	if n, ok := n.(*WithStmt); ok {
+		Walk(withBodyVars(vars), n.Body)
+		return nil
+	}
+	return vars
+}
+
+func (vars withVars) Exit(n INode) {}
+
+type withBodyVars map[*Var]bool
+
+func (vars withBodyVars) Enter(n INode) IVisitor {
+	if v, ok := n.(*Var); ok {
+		vars[resolveVar(v)] = true
+		return nil
+	}
+	return vars
+}
+
+func (vars withBodyVars) Exit(n INode) {}
+
+// catchVars returns the catch parameters that are redeclared by a var declaration in their catch clause and the variables of those var declarations, which must keep the same name since the var declaration declares a variable in the function scope while its initializer assigns to the catch parameter (Annex B.3.5).
+func catchVars(ast *AST) map[*Var]bool {
+	vars := map[*Var]bool{}
+	Walk(catchRedeclarations(vars), ast)
+	return vars
+}
+
+type catchRedeclarations map[*Var]bool
+
+func (vars catchRedeclarations) Enter(n INode) IVisitor {
+	if n, ok := n.(*TryStmt); ok && n.Catch != nil {
+		if param, ok := n.Binding.(*Var); ok {
+			h := &hoister{}
+			Walk(h, n.Catch)
+			if h.decl != nil {
+				for _, item := range h.decl.List {
+					if v := item.Binding.(*Var); bytes.Equal(v.Data, param.Data) {
+						vars[resolveVar(param)] = true
+						vars[resolveVar(v)] = true
+					}
+				}
+			}
+		}
+	}
+	return vars
+}
+
+func (vars catchRedeclarations) Exit(n INode) {}
+
+// exportedVars returns the top-level variables that are exported by export declarations and, if locals is set, by export statements without module.
+func exportedVars(ast *AST, scopes *Scopes, locals bool) []*Var {
+	vars := []*Var{}
+	for _, stmt := range ast.List {
+		exportStmt, ok := stmt.(*ExportStmt)
+		if !ok || exportStmt.Module != nil {
+			continue
+		}
+		if locals {
+			for _, alias := range exportStmt.List {
+				name := alias.Binding
+				if alias.Name != nil {
+					name = alias.Name
+				}
+				if v := scopes.Root.Scope.findDeclared(name, false); v != nil {
+					vars = append(vars, v)
+				}
+			}
+		}
+		if !exportStmt.Default {
+			switch decl := exportStmt.Decl.(type) {
+			case *VarDecl:
+				for _, item := range decl.List {
+					vars = bindingVars(item.Binding, vars)
+				}
+			case *FuncDecl:
+				if decl.Name != nil {
+					vars = append(vars, resolveVar(decl.Name))
+				}
+			case *ClassDecl:
+				if decl.Name != nil {
+					vars = append(vars, resolveVar(decl.Name))
+				}
+			}
+		}
+	}
+	return vars
+}
+
+// importedNames returns the local names of the bindings of the import statements.
+func importedNames(ast *AST) [][]byte {
+	names := [][]byte{}
+	for _, stmt := range ast.List {
+		if importStmt, ok := stmt.(*ImportStmt); ok {
+			if importStmt.Default != nil {
+				names = append(names, importStmt.Default)
+			}
+			for _, alias := range importStmt.List {
+				names = append(names, alias.Binding)
+			}
+		}
+	}
+	return names
+}
+
+////////////////////////////////////////////////////////////////
+
+// Rename renames a declared variable and all its references, and updates the export statements of a top-level variable so that its exported name stays the same. It returns an error and leaves the AST unchanged when the name is not a valid identifier, when the variable is undeclared, exported by its declaration, or may be referenced by name through eval or with, or when the new name conflicts with another variable. The name conflicts when it is declared in the same scope, when it is declared in a scope between a reference and the declaration so that the reference would refer to that variable instead, or when the variable would shadow an enclosing or undeclared variable of that name that is referenced in its scope. The scope tree remains valid after renaming.
+func (s *Scopes) Rename(v *Var, name []byte) error {
+	v = resolveVar(v)
+	decl := s.DeclScope(v)
+	if decl == nil {
+		return fmt.Errorf("cannot rename undeclared variable %s", v.Data)
+	} else if !AsIdentifierName(name) || isReservedName(name) {
+		return fmt.Errorf("invalid variable name %s", name)
+	} else if bytes.Equal(v.Data, name) {
+		return nil
+	}
+
+	ast := s.Root.Node.(*AST)
+	if dynamicVars(ast, s)[v] {
+		return fmt.Errorf("cannot rename variable %s that may be referenced by eval or with", v.Data)
+	} else if catchVars(ast)[v] {
+		return fmt.Errorf("cannot rename variable %s that is redeclared by var in a catch clause", v.Data)
+	} else if decl == s.Root {
+		for _, w := range exportedVars(ast, s, false) {
+			if w == v {
+				return fmt.Errorf("cannot rename exported declaration %s", v.Data)
+			}
+		}
+		for _, imported := range importedNames(ast) {
+			if bytes.Equal(imported, name) {
+				return fmt.Errorf("cannot rename %s to imported binding %s", v.Data, name)
+			}
+		}
+	}
+	if decl.Scope.findDeclared(name, false) != nil {
+		return fmt.Errorf("cannot rename %s to %s that is already declared", v.Data, name)
+	}
+	for _, ref := range s.Refs(v) {
+		for scope := ref.Scope; scope != nil && scope != decl; scope = scope.Parent {
+			if scope.Scope.findDeclared(name, false) != nil {
+				return fmt.Errorf("cannot rename %s to %s that is declared in an inner scope", v.Data, name)
+			}
+		}
+	}
+	if s.capturedVar(decl, v, name) != nil {
+		return fmt.Errorf("cannot rename %s to %s that is referenced in its scope", v.Data, name)
+	}
+
+	name = append([]byte{}, name...)
+	if decl == s.Root {
+		for _, stmt := range ast.List {
+			if exportStmt, ok := stmt.(*ExportStmt); ok && exportStmt.Module == nil {
+				for i, alias := range exportStmt.List {
+					if alias.Name != nil && bytes.Equal(alias.Name, v.Data) || alias.Name == nil && bytes.Equal(alias.Binding, v.Data) {
+						exportStmt.List[i].Name = name
+					}
+				}
+			}
+		}
+	}
+	v.Data = name
+	return nil
+}
+
+// capturedVar returns a variable with the given name, other than v, that is referenced in the scope or its child scopes but declared outside of the scope, or nil if there is none.
+func (s *Scopes) capturedVar(scope *ScopeNode, v *Var, name []byte) *Var {
+	var capture func(*ScopeNode) *Var
+	capture = func(node *ScopeNode) *Var {
+		for _, ref := range node.Refs {
+			if ref.Var != v && bytes.Equal(ref.Var.Data, name) && !isInScope(s.DeclScope(ref.Var), scope) {
+				return ref.Var
+			}
+		}
+		for _, child := range node.Children {
+			if w := capture(child); w != nil {
+				return w
+			}
+		}
+		return nil
+	}
+	return capture(scope)
+}
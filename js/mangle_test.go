@@ -0,0 +1,129 @@
+package js
+
+import (
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestMangle(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"function f(first, second) { return second + second + first }", "function f(b, a) { return a + a + b }"},
+		{"var x = 1; function f(y) { return x + y }", "var x = 1; function f(a) { return x + a }"},
+		{"function f(x) { var a = x; return a + b }", "function f(a) { var c = a; return c + b }"},
+		{"function f(x) { function g(y) { return x + y } function h(z) { return z } }", "function f(a) { function b(b) { return a + b } function c(a) { return a } }"},
+		{"function f() { let long = 1; { let inner = 2; g(long, inner) } }", "function f() { let a = 1; { let b = 2; g(a, b) } }"},
+		{"function f(x) { var y = {x}; var {z} = y; return z }", "function f(a) { var b = {x: a}; var {z: c} = b; return c }"},
+		{"function f(x, y) { return eval('x') } function g(x) { return x }", "function f(x, y) { return eval('x') } function g(a) { return a }"},
+		{"function f(x, y) { with (o) { return x } }", "function f(x, a) { with (o) { return x } }"},
+		{"function f(x) { try { g() } catch (err) { h(err, x) } }", "function f(a) { try { g() } catch (b) { h(b, a) } }"},
+		{"function f(x) { try { g() } catch (err) { var err = 1; h(err, x) } return err }", "function f(a) { try { g() } catch (err) { var err = 1; h(err, a) } return err }"},
+		{"function f(x) { try { g() } catch ([err]) { var y } var err; return err }", "function f(b) { try { g() } catch ([a]) { var c } var a; return a }"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+			Mangle(ast, false)
+
+			expected, err := Parse(parse.NewInputString(tt.expected), Options{})
+			test.Error(t, err)
+			test.String(t, ast.JS(), expected.JS())
+		})
+	}
+}
+
+func TestMangleModule(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"var x = 1; function f(y) { return x + y } f(x)", "var a = 1; function b(b) { return a + b } b(a)"},
+		{"import a from 'a'; let x = a; export {x as y}; export const z = 1; let w = 2", "import a from 'a'; let x = a; export {x as y}; export const z = 1; let b = 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{Goal: ModuleGoal})
+			test.Error(t, err)
+			Mangle(ast, true)
+
+			expected, err := Parse(parse.NewInputString(tt.expected), Options{Goal: ModuleGoal})
+			test.Error(t, err)
+			test.String(t, ast.JS(), expected.JS())
+		})
+	}
+}
+
+func TestMangledName(t *testing.T) {
+	test.String(t, string(mangledName(0)), "a")
+	test.String(t, string(mangledName(53)), "$")
+	test.String(t, string(mangledName(54)), "aa")
+	test.String(t, string(mangledName(108)), "ab")
+
+	names := map[string]bool{}
+	for i := 0; i < 10000; i++ {
+		name := mangledName(i)
+		test.That(t, AsIdentifierName(name), string(name))
+		test.That(t, !names[string(name)], string(name))
+		names[string(name)] = true
+	}
+	test.That(t, isReservedName([]byte("do")))
+	test.That(t, isReservedName([]byte("let")))
+	test.That(t, isReservedName([]byte("eval")))
+	test.That(t, !isReservedName([]byte("of")))
+}
+
+func TestRename(t *testing.T) {
+	var tests = []struct {
+		js       string
+		name     string
+		expected string
+	}{
+		{"var a = 1; function f() { return a + a }", "b", "var b = 1; function f() { return b + b }"},
+		{"var a = {a}; ({a} = a)", "b", "var b = {a: b}; ({a: b} = b)"},
+		{"var a = 1; a++", "a", "var a = 1; a++"},
+		{"var a; export {a}; export {a as c}", "b", "var b; export {b as a}; export {b as c}"},
+		{"var a = 1; var b", "b", "cannot rename a to b that is already declared"},
+		{"var a = 1; function f(b) { return a + b }", "b", "cannot rename a to b that is declared in an inner scope"},
+		{"var a = 1; function f() { return a + b }", "b", "cannot rename a to b that is referenced in its scope"},
+		{"var a = 1", "if", "invalid variable name if"},
+		{"var a = 1", "1a", "invalid variable name 1a"},
+		{"a = 1", "b", "cannot rename undeclared variable a"},
+		{"var a = 1; eval('a')", "b", "cannot rename variable a that may be referenced by eval or with"},
+		{"var a = 1; with (o) a", "b", "cannot rename variable a that may be referenced by eval or with"},
+		{"try { f() } catch (a) { var a = 1 } g(a)", "b", "cannot rename variable a that is redeclared by var in a catch clause"},
+		{"export var a = 1", "b", "cannot rename exported declaration a"},
+		{"import b from 'b'; var a = 1", "b", "cannot rename a to imported binding b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+			scopes := AnalyzeScopes(ast)
+
+			var v *Var
+			for _, ref := range scopes.Root.Refs {
+				if string(ref.Var.Data) == "a" {
+					v = ref.Var
+					break
+				}
+			}
+			test.That(t, v != nil)
+			src := ast.JS()
+			if err := scopes.Rename(v, []byte(tt.name)); err != nil {
+				test.String(t, err.Error(), tt.expected)
+				test.String(t, ast.JS(), src)
+				return
+			}
+
+			expected, err := Parse(parse.NewInputString(tt.expected), Options{})
+			test.Error(t, err)
+			test.String(t, ast.JS(), expected.JS())
+			test.T(t, scopes.Root.Lookup([]byte(tt.name)), v)
+		})
+	}
+}
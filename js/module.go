@@ -0,0 +1,315 @@
+package js
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tdewolff/parse/v2"
+)
+
+// RequestKind is the kind of a module request.
+type RequestKind uint8
+
+// RequestKind values.
+const (
+	ImportRequest        RequestKind = iota // import declaration
+	ExportRequest                           // export declaration with a from clause
+	DynamicImportRequest                    // import() call
+	RequireRequest                          // require() call
+)
+
+func (kind RequestKind) String() string {
+	switch kind {
+	case ImportRequest:
+		return "ImportRequest"
+	case ExportRequest:
+		return "ExportRequest"
+	case DynamicImportRequest:
+		return "DynamicImportRequest"
+	case RequireRequest:
+		return "RequireRequest"
+	}
+	return "Invalid(" + strconv.Itoa(int(kind)) + ")"
+}
+
+// ModuleRequest is a request for a module by an import or export declaration, a dynamic import, or a call to require.
+type ModuleRequest struct {
+	Kind       RequestKind
+	Specifier  string            // decoded module specifier, empty when it is not a constant string
+	Default    []byte            // local name of the default import, can be nil
+	Names      []Alias           // imported or re-exported names as in ImportStmt and ExportStmt, can be nil
+	Attributes []ImportAttribute // can be nil
+	Node       INode             // *ImportStmt, *ExportStmt, or *CallExpr
+	Span                         // span of the node
+}
+
+func (r ModuleRequest) String() string {
+	return r.Kind.String() + "(" + strconv.Quote(r.Specifier) + ")"
+}
+
+// ModuleRequests returns the modules that are requested by the AST in the order in which they appear in the source. Besides import declarations and export declarations with a from clause, this includes calls to import() and to require() when require is not declared. The specifier of a call is empty when its argument is not a constant string.
+func ModuleRequests(ast *AST) []ModuleRequest {
+	c := &requestCollector{}
+	Walk(c, ast)
+	return c.requests
+}
+
+type requestCollector struct {
+	requests []ModuleRequest
+}
+
+func (c *requestCollector) Enter(n INode) IVisitor {
+	switch n := n.(type) {
+	case *ImportStmt:
+		c.requests = append(c.requests, ModuleRequest{
+			Kind:       ImportRequest,
+			Specifier:  moduleSpecifier(n.Module),
+			Default:    n.Default,
+			Names:      n.List,
+			Attributes: n.Attributes,
+			Node:       n,
+			Span:       n.Span,
+		})
+	case *ExportStmt:
+		if n.Module != nil {
+			c.requests = append(c.requests, ModuleRequest{
+				Kind:       ExportRequest,
+				Specifier:  moduleSpecifier(n.Module),
+				Names:      n.List,
+				Attributes: n.Attributes,
+				Node:       n,
+				Span:       n.Span,
+			})
+		}
+	case *CallExpr:
+		var kind RequestKind
+		if lit, ok := n.X.(*LiteralExpr); ok && lit.TokenType == ImportToken && !n.Optional {
+			kind = DynamicImportRequest
+		} else if v, ok := n.X.(*Var); ok && string(v.Data) == "require" && resolveVar(v).Decl == NoDecl && !n.Optional && len(n.Args.List) == 1 && !n.Args.List[0].Rest {
+			kind = RequireRequest
+		} else {
+			return c
+		}
+		specifier := ""
+		if 0 < len(n.Args.List) && !n.Args.List[0].Rest {
+			if v := Eval(n.Args.List[0].Value); v.Type == StringValue {
+				specifier = v.Str
+			}
+		}
+		c.requests = append(c.requests, ModuleRequest{
+			Kind:      kind,
+			Specifier: specifier,
+			Node:      n,
+			Span:      n.Span,
+		})
+	}
+	return c
+}
+
+func (c *requestCollector) Exit(n INode) {}
+
+// moduleSpecifier returns the value of the string literal of a module specifier.
+func moduleSpecifier(module []byte) string {
+	if v := Eval(&LiteralExpr{StringToken, module, Span{}}); v.Type == StringValue {
+		return v.Str
+	}
+	return string(module[1 : len(module)-1])
+}
+
+////////////////////////////////////////////////////////////////
+
+// moduleExts are the extensions of the files that are parsed by ParseModuleGraph, in the order in which they are tried when resolving a specifier without extension.
+var moduleExts = []string{".js", ".mjs", ".cjs", ".jsx", ".ts", ".tsx", ".mts", ".cts"}
+
+// tsExts are the extensions of the TypeScript files that compile to a file with the given extension.
+var tsExts = map[string][]string{
+	".js":  {".ts", ".tsx"},
+	".jsx": {".tsx"},
+	".mjs": {".mts"},
+	".cjs": {".cts"},
+}
+
+// Module is a file in a module graph.
+type Module struct {
+	Path     string // path relative to the directory of the graph, separated by slashes
+	AST      *AST
+	Requests []ModuleRequest
+	Deps     []*Module // resolved module of each request, nil for packages and for specifiers that cannot be resolved
+}
+
+// ModuleGraph is the dependency graph of the modules in a directory.
+type ModuleGraph struct {
+	Modules []*Module // sorted by path
+
+	paths map[string]*Module
+}
+
+// ParseModuleGraph parses all JavaScript and TypeScript files in a directory and its subdirectories, except in node_modules, and resolves the relative specifiers of their module requests to the files in the directory. A specifier without extension resolves to the first file that exists with one of the extensions .js, .mjs, .cjs, .jsx, .ts, .tsx, .mts, or .cts, or to an index file of a directory, and a specifier of a .js file also resolves to the .ts or .tsx file of the same name. Files with the extensions .jsx and .tsx are parsed with Options.JSX, and TypeScript files with Options.TypeScript.
+func ParseModuleGraph(dir string, o Options) (*ModuleGraph, error) {
+	g := &ModuleGraph{
+		paths: map[string]*Module{},
+	}
+	err := filepath.Walk(dir, func(filename string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		} else if info.IsDir() {
+			if info.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		ext := filepath.Ext(filename)
+		if !isModuleExt(ext) {
+			return nil
+		}
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		fo := o
+		fo.JSX = o.JSX || ext == ".jsx" || ext == ".tsx"
+		fo.TypeScript = o.TypeScript || ext == ".ts" || ext == ".tsx" || ext == ".mts" || ext == ".cts"
+		ast, err := Parse(parse.NewInputBytes(src), fo)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+
+		rel, err := filepath.Rel(dir, filename)
+		if err != nil {
+			return err
+		}
+		m := &Module{
+			Path:     filepath.ToSlash(rel),
+			AST:      ast,
+			Requests: ModuleRequests(ast),
+		}
+		g.Modules = append(g.Modules, m)
+		g.paths[m.Path] = m
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(g.Modules, func(i, j int) bool { return g.Modules[i].Path < g.Modules[j].Path })
+	for _, m := range g.Modules {
+		m.Deps = make([]*Module, len(m.Requests))
+		for i, r := range m.Requests {
+			m.Deps[i] = g.Resolve(m, r.Specifier)
+		}
+	}
+	return g, nil
+}
+
+// Module returns the module with the given path relative to the directory of the graph, or nil if it does not exist.
+func (g *ModuleGraph) Module(p string) *Module {
+	return g.paths[p]
+}
+
+// Resolve returns the module that a specifier refers to when it is requested by m, or nil if it is not a relative path or absolute path, or if no such module exists. Absolute paths are relative to the directory of the graph.
+func (g *ModuleGraph) Resolve(m *Module, specifier string) *Module {
+	var p string
+	if strings.HasPrefix(specifier, "/") {
+		p = path.Clean(specifier[1:])
+	} else if strings.HasPrefix(specifier, "./") || strings.HasPrefix(specifier, "../") || specifier == "." || specifier == ".." {
+		p = path.Join(path.Dir(m.Path), specifier)
+	} else {
+		return nil
+	}
+
+	if dep := g.paths[p]; dep != nil {
+		return dep
+	}
+	if ext := path.Ext(p); tsExts[ext] != nil {
+		// TypeScript imports refer to the output file
+		for _, tsExt := range tsExts[ext] {
+			if dep := g.paths[p[:len(p)-len(ext)]+tsExt]; dep != nil {
+				return dep
+			}
+		}
+	}
+	for _, ext := range moduleExts {
+		if dep := g.paths[p+ext]; dep != nil {
+			return dep
+		}
+	}
+	for _, ext := range moduleExts {
+		if dep := g.paths[path.Join(p, "index"+ext)]; dep != nil {
+			return dep
+		}
+	}
+	return nil
+}
+
+// Cycles returns the import cycles of the graph, which are the groups of modules that depend on each other directly or indirectly, including modules that import themselves. The modules of a cycle and the cycles are sorted by path.
+func (g *ModuleGraph) Cycles() [][]*Module {
+	// Tarjan's strongly connected components algorithm
+	index := map[*Module]int{}
+	lowlink := map[*Module]int{}
+	onStack := map[*Module]bool{}
+	stack := []*Module{}
+	cycles := [][]*Module{}
+
+	var visit func(*Module)
+	visit = func(m *Module) {
+		index[m] = len(index)
+		lowlink[m] = index[m]
+		stack = append(stack, m)
+		onStack[m] = true
+
+		selfLoop := false
+		for _, dep := range m.Deps {
+			if dep == nil {
+				continue
+			} else if dep == m {
+				selfLoop = true
+			} else if _, ok := index[dep]; !ok {
+				visit(dep)
+				if lowlink[dep] < lowlink[m] {
+					lowlink[m] = lowlink[dep]
+				}
+			} else if onStack[dep] && index[dep] < lowlink[m] {
+				lowlink[m] = index[dep]
+			}
+		}
+
+		if lowlink[m] == index[m] {
+			i := len(stack) - 1
+			for stack[i] != m {
+				i--
+			}
+			cycle := append([]*Module{}, stack[i:]...)
+			for _, c := range cycle {
+				onStack[c] = false
+			}
+			stack = stack[:i]
+			if 1 < len(cycle) || selfLoop {
+				sort.Slice(cycle, func(i, j int) bool { return cycle[i].Path < cycle[j].Path })
+				cycles = append(cycles, cycle)
+			}
+		}
+	}
+	for _, m := range g.Modules {
+		if _, ok := index[m]; !ok {
+			visit(m)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0].Path < cycles[j][0].Path })
+	return cycles
+}
+
+func isModuleExt(ext string) bool {
+	for _, moduleExt := range moduleExts {
+		if ext == moduleExt {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package js
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestModuleRequests(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"import a, {b as c, d} from './a'", `ImportRequest("./a") a [b as c d]`},
+		{"import * as ns from 'x'; import 'y'", `ImportRequest("x") [* as ns], ImportRequest("y")`},
+		{"import data from './data.json' with {type: 'json'}", `ImportRequest("./data.json") data [] {type: 'json'}`},
+		{"export * from 'a'; export {b as c} from 'b'; export {d}", `ExportRequest("a") [*], ExportRequest("b") [b as c]`},
+		{"import('./a'); f(() => import(`./b`, {with: {}})); import(x)", `DynamicImportRequest("./a"), DynamicImportRequest("./b"), DynamicImportRequest("")`},
+		{"const a = require('a'); require('b' + '/c'); require(x)", `RequireRequest("a"), RequireRequest("b/c"), RequireRequest("")`},
+		{"require('a', 'b'); a.require('c'); function f(require) { require('d') }", ``},
+		{"import a from '\\x61'", `ImportRequest("a") a []`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+
+			requests := []string{}
+			for _, r := range ModuleRequests(ast) {
+				s := r.String()
+				if r.Default != nil {
+					s += " " + string(r.Default)
+				}
+				if r.Kind == ImportRequest && (r.Names != nil || r.Default != nil) || r.Kind == ExportRequest {
+					names := []string{}
+					for _, alias := range r.Names {
+						names = append(names, alias.String())
+					}
+					s += " [" + strings.Join(names, " ") + "]"
+				}
+				if r.Attributes != nil {
+					s += " " + importAttributesString(r.Attributes, false)[6:]
+				}
+				test.That(t, r.Node.Range() == r.Span)
+				requests = append(requests, s)
+			}
+			test.String(t, strings.Join(requests, ", "), tt.expected)
+		})
+	}
+}
+
+func TestModuleGraph(t *testing.T) {
+	g, err := ParseModuleGraph("testdata/modules", Options{})
+	test.Error(t, err)
+
+	paths := []string{}
+	for _, m := range g.Modules {
+		paths = append(paths, m.Path)
+	}
+	test.T(t, paths, []string{"a.js", "b.ts", "data.cjs", "lazy.js", "lib/index.js", "lib/self.js", "main.js"})
+
+	deps := func(p string) []string {
+		m := g.Module(p)
+		test.That(t, m != nil, p)
+		deps := []string{}
+		for _, dep := range m.Deps {
+			if dep == nil {
+				deps = append(deps, "")
+			} else {
+				deps = append(deps, dep.Path)
+			}
+		}
+		return deps
+	}
+	test.T(t, deps("main.js"), []string{"a.js", "lib/index.js", "data.cjs", "lazy.js", "", "", ""})
+	test.T(t, deps("a.js"), []string{"b.ts"})
+	test.T(t, deps("b.ts"), []string{"a.js"})
+	test.T(t, deps("lib/index.js"), []string{"lib/self.js"})
+	test.T(t, deps("lazy.js"), []string{"main.js"})
+	test.T(t, g.Resolve(g.Module("lib/index.js"), ".."), (*Module)(nil))
+	test.T(t, g.Resolve(g.Module("lib/index.js"), "../lib"), g.Module("lib/index.js"))
+
+	cycles := []string{}
+	for _, cycle := range g.Cycles() {
+		paths := []string{}
+		for _, m := range cycle {
+			paths = append(paths, m.Path)
+		}
+		cycles = append(cycles, strings.Join(paths, " "))
+	}
+	test.T(t, cycles, []string{"a.js b.ts", "lazy.js main.js", "lib/self.js"})
+
+	_, err = ParseModuleGraph("testdata/modules/node_modules/pkg", Options{})
+	test.That(t, err != nil)
+}
@@ -1,6 +1,7 @@
 package js
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -9,8 +10,41 @@ import (
 	"github.com/tdewolff/parse/v2/buffer"
 )
 
+// Goal is the goal symbol with which the source is parsed, which is either a script or a module.
+type Goal int
+
+// Goal values.
+const (
+	AnyGoal    Goal = iota // sloppy code that may contain import and export statements and top-level await, where strict mode reserved words are only reported with early errors
+	ScriptGoal             // sloppy code unless it has a use strict directive, with legacy octal numbers and without import and export statements
+	ModuleGoal             // strict code where await is reserved and HTML-like comments are not allowed
+)
+
 type Options struct {
-	WhileToFor bool
+	Goal          Goal // the goal symbol of the source, AnyGoal by default
+	WhileToFor    bool
+	ErrorRecovery bool // continue parsing after an error at the next statement, see ErrorList
+	Comments      bool // attach comments to the nodes, see AST.CommentMap
+	Tokens        bool // keep all tokens including whitespace and comments, see AST.Tokens
+	NoRefs        bool // do not record the span of every identifier, which saves memory, see Scopes.Refs
+	JSX           bool // parse JSX elements in expressions
+	TypeScript    bool // parse TypeScript and remove its types
+	AssertImports bool // parse import attributes with the legacy assert keyword besides with
+	EarlyErrors   bool // report the early errors of the static semantics, see Check
+}
+
+// ErrorList is a list of parse errors. It is returned by Parse when Options.ErrorRecovery is set, in which case statements that failed to parse are replaced by a BadStmt in the AST.
+type ErrorList []*parse.Error
+
+func (errs ErrorList) Error() string {
+	s := ""
+	for i, err := range errs {
+		if i != 0 {
+			s += "\n"
+		}
+		s += err.Error()
+	}
+	return s
 }
 
 // Parser is the state for the parser.
@@ -19,13 +53,27 @@ type Parser struct {
 	o   Options
 	err error
 
+	errs      ErrorList // errors that have been recovered from
+	errTT     TokenType // token type at the error
+	errOffset int       // offset of the error
+
+	comments []Comment // all comments, only when Options.Comments is set
+	tokens   TokenList // all tokens, only when Options.Tokens is set
+
 	data                   []byte
 	tt                     TokenType
+	end                    int // end offset of the previous token
 	prevLT                 bool
 	inFor                  bool
 	await, yield           bool
+	strict                 bool
 	assumeArrowFunc        bool
 	allowDirectivePrologue bool
+	paramProps             []*Var  // TypeScript parameter properties of the last parsed parameters
+	decorators             []IExpr // decorators of the class that follows
+	decoratorsStart        int
+	condLevel, conds       int // nesting level and number of the conditional consequents being parsed at that level, to disambiguate TypeScript arrow return types
+	refs                   []varRef
 
 	stmtLevel int
 	exprLevel int
@@ -36,26 +84,27 @@ type Parser struct {
 // Parse returns a JS AST tree of.
 func Parse(r *parse.Input, o Options) (*AST, error) {
 	ast := &AST{}
-	p := &Parser{
-		l:     NewLexer(r),
-		o:     o,
-		tt:    WhitespaceToken, // trick so that next() works
-		await: true,
-	}
+	p := newParser(r, o)
 
 	// process shebang
 	if r.Peek(0) == '#' && r.Peek(1) == '!' {
 		r.Move(2)
 		p.l.consumeSingleLineComment() // consume till end-of-line
-		ast.Comments = append(ast.Comments, r.Shift())
+		p.tt, p.data = CommentToken, r.Shift()
+		ast.Comments = append(ast.Comments, p.data)
+		p.addToken()
 	}
 
 	p.tt, p.data = p.l.Next()
+	p.addToken()
 	for p.tt == CommentToken || p.tt == CommentLineTerminatorToken {
 		ast.Comments = append(ast.Comments, p.data)
+		p.addComment()
 		p.tt, p.data = p.l.Next()
+		p.addToken()
 		if p.tt == WhitespaceToken || p.tt == LineTerminatorToken {
 			p.tt, p.data = p.l.Next()
+			p.addToken()
 		}
 	}
 	if p.tt == WhitespaceToken || p.tt == LineTerminatorToken {
@@ -63,35 +112,239 @@ func Parse(r *parse.Input, o Options) (*AST, error) {
 	}
 	// prevLT may be wrong but that is not a problem
 	ast.BlockStmt = p.parseModule()
+	ast.Strict = p.strict
+	ast.refs = p.refs
+	if p.o.Comments {
+		ast.CommentMap = newCommentMap(ast, p.l.r.Bytes(), p.comments)
+	}
+	if p.o.Tokens {
+		p.addSkipped(len(p.l.r.Bytes()))
+		ast.Tokens = p.tokens
+	}
+
+	if p.o.ErrorRecovery {
+		if p.err != nil {
+			p.addError()
+		}
+		if p.o.EarlyErrors {
+			p.errs = append(p.errs, check(ast, p.l.r.Bytes(), p.o.TypeScript)...)
+			sortErrors(p.errs)
+		}
+		if len(p.errs) != 0 {
+			return ast, p.errs
+		}
+		return ast, nil
+	}
 
 	if p.err == nil {
 		p.err = p.l.Err()
 	} else {
-		offset := p.l.r.Offset() - len(p.data)
-		p.err = parse.NewError(buffer.NewReader(p.l.r.Bytes()), offset, p.err.Error())
+		p.err = parse.NewError(buffer.NewReader(p.l.r.Bytes()), p.errOffset, p.err.Error())
 	}
 	if p.err == io.EOF {
 		p.err = nil
 	}
+	if p.err == nil && p.o.EarlyErrors {
+		if errs := check(ast, p.l.r.Bytes(), p.o.TypeScript); len(errs) != 0 {
+			p.err = errs[0]
+		}
+	}
 	return ast, p.err
 }
 
+func newParser(r *parse.Input, o Options) *Parser {
+	if o.EarlyErrors {
+		o.NoRefs = false // early errors at identifiers are reported at their references
+	}
+	p := &Parser{
+		l:         NewLexer(r),
+		o:         o,
+		tt:        WhitespaceToken, // trick so that next() works
+		await:     o.Goal != ScriptGoal,
+		strict:    o.Goal == ModuleGoal,
+		condLevel: -1,
+	}
+	p.l.htmlComments = o.Goal != ModuleGoal
+	p.l.legacyOctal = o.Goal == ScriptGoal
+	return p
+}
+
 ////////////////////////////////////////////////////////////////
 
 func (p *Parser) next() {
+	if p.err != nil {
+		// keep failing so that callers that overwrite the ErrorToken set by failAt stop parsing
+		p.tt = ErrorToken
+		return
+	}
 	p.prevLT = false
+	p.end = p.l.r.Offset()
 	p.tt, p.data = p.l.Next()
+	p.addToken()
 	for p.tt == WhitespaceToken || p.tt == LineTerminatorToken || p.tt == CommentToken || p.tt == CommentLineTerminatorToken {
 		if p.tt == LineTerminatorToken || p.tt == CommentLineTerminatorToken {
 			p.prevLT = true
 		}
+		if p.tt == CommentToken || p.tt == CommentLineTerminatorToken {
+			p.addComment()
+		}
 		p.tt, p.data = p.l.Next()
+		p.addToken()
+	}
+	if p.strict {
+		p.checkStrictToken()
+	}
+}
+
+// nextJSX moves to the next token inside a JSX tag.
+func (p *Parser) nextJSX() {
+	if p.err != nil {
+		p.tt = ErrorToken
+		return
+	}
+	p.prevLT = false
+	p.end = p.l.r.Offset()
+	p.tt, p.data = p.l.JSXNext()
+	p.addToken()
+	for p.tt == WhitespaceToken || p.tt == LineTerminatorToken || p.tt == CommentToken || p.tt == CommentLineTerminatorToken {
+		if p.tt == CommentToken || p.tt == CommentLineTerminatorToken {
+			p.addComment()
+		}
+		p.tt, p.data = p.l.JSXNext()
+		p.addToken()
+	}
+}
+
+// addComment adds the current comment token to the comments to be attached to the AST.
+func (p *Parser) addComment() {
+	if p.o.Comments {
+		p.comments = append(p.comments, Comment{p.data, p.tokenSpan()})
+	}
+}
+
+// addToken adds the current token to the tokens of the AST.
+func (p *Parser) addToken() {
+	if p.o.Tokens && len(p.data) != 0 {
+		p.addSkipped(p.offset())
+		p.tokens = append(p.tokens, Token{p.tt, p.data, p.tokenSpan()})
+	}
+}
+
+// addSkipped adds an error token for the source code up to offset that was skipped after an error.
+func (p *Parser) addSkipped(offset int) {
+	end := 0
+	if 0 < len(p.tokens) {
+		end = p.tokens[len(p.tokens)-1].End
+	}
+	if end < offset {
+		p.tokens = append(p.tokens, Token{ErrorToken, p.l.r.Bytes()[end:offset], Span{end, offset}})
+	}
+}
+
+// offset returns the byte offset of the start of the current token.
+func (p *Parser) offset() int {
+	return p.l.r.Offset() - len(p.data)
+}
+
+// span returns the span from start up to the end of the previous token.
+func (p *Parser) span(start int) Span {
+	return Span{start, p.end}
+}
+
+// tokenSpan returns the span of the current token.
+func (p *Parser) tokenSpan() Span {
+	return Span{p.offset(), p.l.r.Offset()}
+}
+
+// use adds a use of the variable, whose span is that of its first occurrence.
+func (p *Parser) use(name []byte, span Span) *Var {
+	p.checkIdentifier(name, span)
+	v := p.scope.Use(name)
+	if v.End == 0 {
+		v.Span = span
+	}
+	if !p.o.NoRefs {
+		p.refs = append(p.refs, varRef{v, span})
+	}
+	return v
+}
+
+// declare declares a new variable, whose span is that of its first occurrence.
+func (p *Parser) declare(decl DeclType, name []byte, span Span) (*Var, bool) {
+	p.checkIdentifier(name, span)
+	v, ok := p.scope.Declare(decl, name)
+	if ok {
+		if v.End == 0 {
+			v.Span = span
+		}
+		if !p.o.NoRefs {
+			p.refs = append(p.refs, varRef{v, span})
+		}
+	}
+	return v, ok
+}
+
+// checkIdentifier fails if the name of an identifier reference or binding is a reserved word in strict mode code or in modules.
+func (p *Parser) checkIdentifier(name []byte, span Span) {
+	if p.strict && (p.o.Goal != AnyGoal || p.o.EarlyErrors) {
+		// with AnyGoal these are reported only by Check, to keep accepting code that was accepted before strict mode was tracked
+		switch Keywords[string(name)] {
+		case LetToken, StaticToken, ImplementsToken, InterfaceToken, PackageToken, PrivateToken, ProtectedToken, PublicToken, YieldToken:
+			p.failAt(span.Start, "unexpected strict mode reserved word %s", name)
+			return
+		}
+	}
+	if p.o.Goal == ModuleGoal && bytes.Equal(name, []byte("await")) {
+		p.failAt(span.Start, "unexpected reserved word await in module")
+	}
+}
+
+// checkStrictToken fails if the current token is a legacy octal number or a decimal number with a leading zero, which are not allowed in strict mode code.
+func (p *Parser) checkStrictToken() {
+	if p.tt == LegacyOctalToken || p.tt == DecimalToken && 1 < len(p.data) && p.data[0] == '0' && '0' <= p.data[1] && p.data[1] <= '9' {
+		p.failMessage("legacy octal numbers are not allowed in strict mode")
+	}
+}
+
+// useStrict makes the code strict after a use strict directive, the current token is the first that follows the directive.
+func (p *Parser) useStrict() {
+	if !p.strict {
+		p.strict = true
+		p.checkStrictToken()
+	}
+}
+
+// exitStrict restores the strictness of the code that encloses a function and returns whether the function is strict. If a use strict directive made the function strict, its name and parameters are checked retroactively.
+func (p *Parser) exitStrict(parentStrict bool, name *Var, params *Params) bool {
+	strict := p.strict
+	if strict && !parentStrict {
+		if name != nil {
+			p.checkIdentifier(name.Data, name.Span)
+		}
+		vars := []*Var{}
+		for _, item := range params.List {
+			vars = bindingVars(item.Binding, vars)
+		}
+		if params.Rest != nil {
+			vars = bindingVars(params.Rest, vars)
+		}
+		for _, v := range vars {
+			p.checkIdentifier(v.Data, v.Span)
+		}
 	}
+	p.strict = parentStrict
+	return strict
 }
 
 func (p *Parser) failMessage(msg string, args ...interface{}) {
+	p.failAt(p.offset(), msg, args...)
+}
+
+// failAt is like failMessage but reports the error at the given offset, which may be before the current token.
+func (p *Parser) failAt(offset int, msg string, args ...interface{}) {
 	if p.err == nil {
 		p.err = fmt.Errorf(msg, args...)
+		p.errTT, p.errOffset = p.tt, offset
 		p.tt = ErrorToken
 	}
 }
@@ -131,10 +384,95 @@ func (p *Parser) fail(in string, expected ...TokenType) {
 		}
 
 		p.err = errors.New(msg)
+		p.errTT, p.errOffset = p.tt, p.offset()
 		p.tt = ErrorToken
 	}
 }
 
+// addError moves the current error to the list of recovered errors.
+func (p *Parser) addError() {
+	var err *parse.Error
+	if lexerErr, ok := p.l.Err().(*parse.Error); ok && p.errTT == ErrorToken {
+		err = lexerErr
+	} else {
+		err = parse.NewError(buffer.NewReader(p.l.r.Bytes()), p.errOffset, p.err.Error())
+	}
+	if len(p.errs) == 0 || p.errs[len(p.errs)-1] != err && (p.errs[len(p.errs)-1].Line != err.Line || p.errs[len(p.errs)-1].Column != err.Column) {
+		p.errs = append(p.errs, err)
+	}
+	p.err = nil
+}
+
+// parserState is the state of the parser at the start of a statement, which is restored after recovering from an error.
+type parserState struct {
+	scope                                        *Scope
+	inFor, await, yield, strict, assumeArrowFunc bool
+	stmtLevel, exprLevel                         int
+	level                                        int
+}
+
+func (p *Parser) state() parserState {
+	return parserState{p.scope, p.inFor, p.await, p.yield, p.strict, p.assumeArrowFunc, p.stmtLevel, p.exprLevel, p.level()}
+}
+
+// level returns the nesting level of parentheses and braces before the current token.
+func (p *Parser) level() int {
+	switch p.tt {
+	case OpenBraceToken, OpenParenToken, TemplateStartToken:
+		return p.l.level - 1
+	case CloseBraceToken, CloseParenToken, TemplateEndToken:
+		return p.l.level + 1
+	}
+	return p.l.level
+}
+
+// recover records the current error and skips tokens until the end of the statement that started at start, it returns a BadStmt for the skipped source. Inside a block it stops before the closing brace of the block.
+func (p *Parser) recover(state parserState, start int, inBlock bool) IStmt {
+	p.addError()
+	p.scope, p.inFor, p.await, p.yield, p.strict, p.assumeArrowFunc = state.scope, state.inFor, state.await, state.yield, state.strict, state.assumeArrowFunc
+	p.stmtLevel, p.exprLevel = state.stmtLevel, state.exprLevel
+	p.allowDirectivePrologue = false
+	p.decorators = nil
+	if p.tt == ErrorToken && p.l.err == nil {
+		p.tt = p.errTT // restore the token at which parsing failed
+	}
+
+	skipped := false // make sure we skip at least one token
+	for {
+		if p.tt == ErrorToken {
+			if p.l.err == nil {
+				break // EOF
+			} else if len(p.errs) == 0 || p.errs[len(p.errs)-1] != p.l.err {
+				p.errs = append(p.errs, p.l.err.(*parse.Error))
+			}
+			p.l.err = nil // continue lexing after the invalid token
+			p.l.r.Skip()
+		} else if skipped || start < p.offset() {
+			level := p.level()
+			if p.tt == SemicolonToken && level == state.level {
+				p.next()
+				break
+			} else if p.tt == CloseBraceToken && inBlock && level <= state.level {
+				break
+			} else if p.prevLT && (level == state.level || state.level < level && isStmtStart(p.tt)) {
+				break // also stop at unbalanced parentheses or braces
+			}
+		}
+		p.next()
+		skipped = true
+	}
+	return &BadStmt{p.l.r.Bytes()[start:p.end], p.span(start)}
+}
+
+// isStmtStart returns true for tokens that (almost) always start a statement.
+func isStmtStart(tt TokenType) bool {
+	switch tt {
+	case BreakToken, ClassToken, ConstToken, ContinueToken, DebuggerToken, DoToken, ExportToken, ForToken, FunctionToken, IfToken, ImportToken, LetToken, ReturnToken, SwitchToken, ThrowToken, TryToken, VarToken, WhileToken, WithToken:
+		return true
+	}
+	return false
+}
+
 func (p *Parser) consume(in string, tt TokenType) bool {
 	if p.tt != tt {
 		p.fail(in, tt)
@@ -175,30 +513,66 @@ func (p *Parser) exitScope(parent *Scope) {
 func (p *Parser) parseModule() (module BlockStmt) {
 	p.enterScope(&module.Scope, true)
 	p.allowDirectivePrologue = true
-	for {
-		switch p.tt {
-		case ErrorToken:
-			return
-		case ImportToken:
-			p.next()
-			if p.tt == OpenParenToken {
-				// could be an import call expression
-				left := &LiteralExpr{ImportToken, []byte("import")}
-				p.exprLevel++
-				suffix := p.parseExpressionSuffix(left, OpExpr, OpCall)
-				p.exprLevel--
-				module.List = append(module.List, &ExprStmt{suffix})
-			} else {
-				importStmt := p.parseImportStmt()
-				module.List = append(module.List, &importStmt)
+	for p.parseModuleItem(&module) {
+	}
+	return
+}
+
+// parseModuleItem parses the next statement of the module and appends it to its list, it returns false at the end of the input.
+func (p *Parser) parseModuleItem(module *BlockStmt) bool {
+	state, start, n := p.state(), p.offset(), len(module.List)
+	switch p.tt {
+	case ErrorToken:
+		if p.o.ErrorRecovery && p.l.err != nil {
+			p.fail("")
+			module.List = append(module.List, p.recover(state, start, false))
+			return true
+		}
+		module.End = p.l.r.Offset()
+		return false
+	case ImportToken:
+		p.allowDirectivePrologue = false
+		importSpan := p.tokenSpan()
+		p.next()
+		if p.tt == OpenParenToken {
+			// could be an import call expression
+			left := &LiteralExpr{ImportToken, []byte("import"), importSpan}
+			p.exprLevel++
+			suffix := p.parseExpressionSuffix(left, start, OpExpr, OpCall)
+			p.exprLevel--
+			module.List = append(module.List, &ExprStmt{suffix, p.span(start)})
+		} else {
+			if importStmt := p.parseImportStmt(start); importStmt != nil {
+				module.List = append(module.List, importStmt)
 			}
-		case ExportToken:
-			exportStmt := p.parseExportStmt()
-			module.List = append(module.List, &exportStmt)
-		default:
-			module.List = append(module.List, p.parseStmt(true))
+		}
+	case ExportToken:
+		p.allowDirectivePrologue = false
+		if exportStmt := p.parseExportStmt(); exportStmt != nil {
+			module.List = append(module.List, exportStmt)
+		}
+	case AtToken:
+		// decorators of a class declaration, possibly before export
+		p.allowDirectivePrologue = false
+		p.decoratorsStart, p.decorators = start, p.parseDecorators()
+		if p.tt == ExportToken && p.err == nil {
+			if exportStmt := p.parseExportStmt(); exportStmt != nil {
+				exportStmt.Start = start
+				module.List = append(module.List, exportStmt)
+			}
+		} else if stmt := p.parseStmt(true); stmt != nil {
+			module.List = append(module.List, stmt)
+		}
+	default:
+		if stmt := p.parseStmt(true); stmt != nil {
+			module.List = append(module.List, stmt)
 		}
 	}
+	if p.err != nil && p.o.ErrorRecovery {
+		// replace the statement that failed to parse, if any
+		module.List = append(module.List[:n], p.recover(state, start, false))
+	}
+	return true
 }
 
 func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
@@ -208,6 +582,27 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 		return nil
 	}
 
+	start := p.offset()
+	directive := p.allowDirectivePrologue
+	p.allowDirectivePrologue = false // nested statements are not directives
+	if p.tt == AtToken || p.decorators != nil {
+		if !allowDeclaration {
+			p.fail("statement")
+			return
+		} else if !p.parseClassDecorators("decorator") {
+			return
+		}
+	}
+	if p.o.TypeScript && allowDeclaration {
+		if tsStmt, ok := p.parseTSStmt(); ok {
+			// declarations without JavaScript equivalent return nil
+			if p.tt == SemicolonToken {
+				p.next()
+			}
+			p.stmtLevel--
+			return tsStmt
+		}
+	}
 	switch tt := p.tt; tt {
 	case OpenBraceToken:
 		stmt = p.parseBlockStmt("block statement")
@@ -217,7 +612,7 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 			return
 		}
 		p.next()
-		varDecl := p.parseVarDecl(tt, true)
+		varDecl := p.parseVarDecl(tt, true, start)
 		stmt = varDecl
 		if !p.prevLT && p.tt != SemicolonToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
 			if tt == ConstToken {
@@ -231,14 +626,14 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 		let := p.data
 		p.next()
 		if allowDeclaration && (IsIdentifier(p.tt) || p.tt == YieldToken || p.tt == AwaitToken || p.tt == OpenBracketToken || p.tt == OpenBraceToken) {
-			stmt = p.parseVarDecl(tt, false)
+			stmt = p.parseVarDecl(tt, false, start)
 			if !p.prevLT && p.tt != SemicolonToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
 				p.fail("let declaration")
 				return
 			}
 		} else {
 			// expression
-			stmt = &ExprStmt{p.parseIdentifierExpression(OpExpr, let)}
+			stmt = &ExprStmt{p.parseIdentifierExpression(OpExpr, let, start), p.span(start)}
 			if !p.prevLT && p.tt != SemicolonToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
 				p.fail("expression")
 				return
@@ -260,7 +655,7 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 			p.next()
 			elseBody = p.parseStmt(false)
 		}
-		stmt = &IfStmt{cond, body, elseBody}
+		stmt = &IfStmt{cond, body, elseBody, p.span(start)}
 	case ContinueToken, BreakToken:
 		tt := p.tt
 		p.next()
@@ -269,14 +664,14 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 			label = p.data
 			p.next()
 		}
-		stmt = &BranchStmt{tt, label}
+		stmt = &BranchStmt{tt, label, p.span(start)}
 	case ReturnToken:
 		p.next()
 		var value IExpr
 		if !p.prevLT && p.tt != SemicolonToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
 			value = p.parseExpression(OpExpr)
 		}
-		stmt = &ReturnStmt{value}
+		stmt = &ReturnStmt{value, p.span(start)}
 	case WithToken:
 		p.next()
 		if !p.consume("with statement", OpenParenToken) {
@@ -288,7 +683,7 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 		}
 
 		p.scope.Func.HasWith = true
-		stmt = &WithStmt{cond, p.parseStmt(false)}
+		stmt = &WithStmt{cond, p.parseStmt(false), p.span(start)}
 	case DoToken:
 		stmt = &DoWhileStmt{}
 		p.next()
@@ -299,10 +694,11 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 		if !p.consume("do-while statement", OpenParenToken) {
 			return
 		}
-		stmt = &DoWhileStmt{p.parseExpression(OpExpr), body}
+		cond := p.parseExpression(OpExpr)
 		if !p.consume("do-while statement", CloseParenToken) {
 			return
 		}
+		stmt = &DoWhileStmt{cond, body, p.span(start)}
 	case WhileToken:
 		p.next()
 		if !p.consume("while statement", OpenParenToken) {
@@ -319,11 +715,11 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 
 			block, ok := body.(*BlockStmt)
 			if !ok {
-				block = &BlockStmt{List: []IStmt{body}}
+				block = &BlockStmt{List: []IStmt{body}, Span: body.Range()}
 			}
-			stmt = &ForStmt{varDecl, cond, nil, block}
+			stmt = &ForStmt{varDecl, cond, nil, block, p.span(start)}
 		} else {
-			stmt = &WhileStmt{cond, body}
+			stmt = &WhileStmt{cond, body, p.span(start)}
 		}
 	case ForToken:
 		p.next()
@@ -342,8 +738,9 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 		p.inFor = true
 		if p.tt == VarToken || p.tt == LetToken || p.tt == ConstToken {
 			tt := p.tt
+			initStart := p.offset()
 			p.next()
-			varDecl := p.parseVarDecl(tt, true)
+			varDecl := p.parseVarDecl(tt, true, initStart)
 			if p.tt != SemicolonToken && (1 < len(varDecl.List) || varDecl.List[0].Default != nil) {
 				p.fail("for statement")
 				return
@@ -380,11 +777,7 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 				return
 			}
 			p.scope.MarkForStmt()
-			if p.tt == OpenBraceToken {
-				body.List = p.parseStmtList("")
-			} else if p.tt != SemicolonToken {
-				body.List = []IStmt{p.parseStmt(false)}
-			}
+			p.parseForBody(body)
 			if init == nil {
 				varDecl := &VarDecl{TokenType: VarToken, Scope: p.scope, InFor: true}
 				p.scope.Func.VarDecls = append(p.scope.Func.VarDecls, varDecl)
@@ -392,7 +785,7 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 			} else if varDecl, ok := init.(*VarDecl); ok {
 				varDecl.InFor = true
 			}
-			stmt = &ForStmt{init, cond, post, body}
+			stmt = &ForStmt{init, cond, post, body, p.span(start)}
 		} else if p.tt == InToken {
 			if await {
 				p.fail("for statement", OfToken)
@@ -404,15 +797,11 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 				return
 			}
 			p.scope.MarkForStmt()
-			if p.tt == OpenBraceToken {
-				body.List = p.parseStmtList("")
-			} else if p.tt != SemicolonToken {
-				body.List = []IStmt{p.parseStmt(false)}
-			}
+			p.parseForBody(body)
 			if varDecl, ok := init.(*VarDecl); ok {
 				varDecl.InForInOf = true
 			}
-			stmt = &ForInStmt{init, value, body}
+			stmt = &ForInStmt{init, value, body, p.span(start)}
 		} else if p.tt == OfToken {
 			p.next()
 			value := p.parseExpression(OpAssign)
@@ -420,15 +809,11 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 				return
 			}
 			p.scope.MarkForStmt()
-			if p.tt == OpenBraceToken {
-				body.List = p.parseStmtList("")
-			} else if p.tt != SemicolonToken {
-				body.List = []IStmt{p.parseStmt(false)}
-			}
+			p.parseForBody(body)
 			if varDecl, ok := init.(*VarDecl); ok {
 				varDecl.InForInOf = true
 			}
-			stmt = &ForOfStmt{await, init, value, body}
+			stmt = &ForOfStmt{await, init, value, body, p.span(start)}
 		} else {
 			p.fail("for statement", InToken, OfToken, SemicolonToken)
 			return
@@ -460,6 +845,7 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 				break
 			}
 
+			clauseStart := p.offset()
 			clause := p.tt
 			var list IExpr
 			if p.tt == CaseToken {
@@ -477,18 +863,23 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 
 			var stmts []IStmt
 			for p.tt != CaseToken && p.tt != DefaultToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
-				stmts = append(stmts, p.parseStmt(true))
+				if stmt := p.parseStmt(true); stmt != nil {
+					stmts = append(stmts, stmt)
+				}
 			}
-			switchStmt.List = append(switchStmt.List, CaseClause{clause, list, stmts})
+			switchStmt.List = append(switchStmt.List, CaseClause{clause, list, stmts, p.span(clauseStart)})
 		}
 		p.exitScope(parent)
+		switchStmt.Span = p.span(start)
 		stmt = switchStmt
 	case FunctionToken:
 		if !allowDeclaration {
 			p.fail("statement")
 			return
 		}
-		stmt = p.parseFuncDecl()
+		if funcDecl := p.parseFuncDecl(); funcDecl != nil {
+			stmt = funcDecl
+		}
 	case AsyncToken: // async function
 		if !allowDeclaration {
 			p.fail("statement")
@@ -497,10 +888,12 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 		async := p.data
 		p.next()
 		if p.tt == FunctionToken && !p.prevLT {
-			stmt = p.parseAsyncFuncDecl()
+			if funcDecl := p.parseAsyncFuncDecl(start); funcDecl != nil {
+				stmt = funcDecl
+			}
 		} else {
 			// expression
-			stmt = &ExprStmt{p.parseAsyncExpression(OpExpr, async)}
+			stmt = &ExprStmt{p.parseAsyncExpression(OpExpr, async, start), p.span(start)}
 			if !p.prevLT && p.tt != SemicolonToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
 				p.fail("expression")
 				return
@@ -518,7 +911,7 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 		if !p.prevLT {
 			value = p.parseExpression(OpExpr)
 		}
-		stmt = &ThrowStmt{value}
+		stmt = &ThrowStmt{value, p.span(start)}
 	case TryToken:
 		p.next()
 		body := p.parseBlockStmt("try statement")
@@ -531,11 +924,14 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 			if p.tt == OpenParenToken {
 				p.next()
 				binding = p.parseBinding(CatchDecl) // local to block scope of catch
+				p.parseTypeAnnotation()
 				if !p.consume("try-catch statement", CloseParenToken) {
 					return
 				}
 			}
+			catchStart := p.offset()
 			catch.List = p.parseStmtList("try-catch statement")
+			catch.Span = p.span(catchStart)
 			p.exitScope(parent)
 		} else if p.tt != FinallyToken {
 			p.fail("try statement", CatchToken, FinallyToken)
@@ -545,12 +941,12 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 			p.next()
 			finally = p.parseBlockStmt("try-finally statement")
 		}
-		stmt = &TryStmt{body, binding, catch, finally}
+		stmt = &TryStmt{body, binding, catch, finally, p.span(start)}
 	case DebuggerToken:
 		p.next()
-		stmt = &DebuggerStmt{}
+		stmt = &DebuggerStmt{p.span(start)}
 	case SemicolonToken, ErrorToken:
-		stmt = &EmptyStmt{}
+		stmt = &EmptyStmt{p.tokenSpan()}
 	default:
 		if p.isIdentifierReference(p.tt) {
 			// labelled statement or expression
@@ -558,10 +954,14 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 			p.next()
 			if p.tt == ColonToken {
 				p.next()
-				stmt = &LabelledStmt{label, p.parseStmt(true)} // allows illegal async function, generator function, let, const, or class declarations
+				body := p.parseStmt(true) // allows illegal async function, generator function, let, const, or class declarations
+				if body == nil && p.err == nil {
+					body = &EmptyStmt{Span{p.end, p.end}}
+				}
+				stmt = &LabelledStmt{label, body, p.span(start)}
 			} else {
 				// expression
-				stmt = &ExprStmt{p.parseIdentifierExpression(OpExpr, label)}
+				stmt = &ExprStmt{p.parseIdentifierExpression(OpExpr, label, start), p.span(start)}
 				if !p.prevLT && p.tt != SemicolonToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
 					p.fail("expression")
 					return
@@ -569,16 +969,18 @@ func (p *Parser) parseStmt(allowDeclaration bool) (stmt IStmt) {
 			}
 		} else {
 			// expression
-			stmt = &ExprStmt{p.parseExpression(OpExpr)}
+			stmt = &ExprStmt{p.parseExpression(OpExpr), p.span(start)}
 			if !p.prevLT && p.tt != SemicolonToken && p.tt != CloseBraceToken && p.tt != ErrorToken {
 				p.fail("expression")
 				return
 			}
-			if p.allowDirectivePrologue {
+			if directive {
 				if lit, ok := stmt.(*ExprStmt).Value.(*LiteralExpr); ok && lit.TokenType == StringToken {
-					stmt = &DirectivePrologueStmt{lit.Data}
-				} else {
-					p.allowDirectivePrologue = false
+					stmt = &DirectivePrologueStmt{lit.Data, lit.Span}
+					p.allowDirectivePrologue = true
+					if string(lit.Data) == `"use strict"` || string(lit.Data) == `'use strict'` {
+						p.useStrict()
+					}
 				}
 			}
 		}
@@ -595,14 +997,24 @@ func (p *Parser) parseStmtList(in string) (list []IStmt) {
 		return
 	}
 	for {
+		state, start, n := p.state(), p.offset(), len(list)
 		if p.tt == ErrorToken {
 			p.fail("")
+			if p.o.ErrorRecovery && p.l.err != nil {
+				list = append(list, p.recover(state, start, true))
+				continue
+			}
 			return
 		} else if p.tt == CloseBraceToken {
 			p.next()
 			break
 		}
-		list = append(list, p.parseStmt(true))
+		if stmt := p.parseStmt(true); stmt != nil {
+			list = append(list, stmt)
+		}
+		if p.err != nil && p.o.ErrorRecovery {
+			list = append(list[:n], p.recover(state, start, true))
+		}
 	}
 	return
 }
@@ -610,16 +1022,48 @@ func (p *Parser) parseStmtList(in string) (list []IStmt) {
 func (p *Parser) parseBlockStmt(in string) (blockStmt *BlockStmt) {
 	blockStmt = &BlockStmt{}
 	parent := p.enterScope(&blockStmt.Scope, false)
+	start := p.offset()
 	blockStmt.List = p.parseStmtList(in)
+	blockStmt.Span = p.span(start)
 	p.exitScope(parent)
 	return
 }
 
-func (p *Parser) parseImportStmt() (importStmt ImportStmt) {
+func (p *Parser) parseForBody(body *BlockStmt) {
+	start := p.offset()
+	if p.tt == OpenBraceToken {
+		body.List = p.parseStmtList("")
+	} else if p.tt != SemicolonToken {
+		body.List = []IStmt{p.parseStmt(false)}
+	} else {
+		body.Span = Span{p.end, p.end} // the semicolon ends the statement
+		return
+	}
+	body.Span = p.span(start)
+}
+
+func (p *Parser) parseImportStmt(start int) (importStmt *ImportStmt) {
 	// assume we're passed import
+	if p.o.Goal == ScriptGoal {
+		p.failAt(start, "import statement is only allowed in modules")
+		return
+	}
+	importStmt = &ImportStmt{}
+	hasTypes := false // TypeScript type-only specifiers have been removed
+	if p.o.TypeScript && p.isWord("type") {
+		if tt, _ := p.peek(); tt == OpenBraceToken || tt == MulToken || IsIdentifier(tt) && tt != FromToken {
+			// type-only import
+			p.skipTSStmt()
+			if p.tt == SemicolonToken {
+				p.next()
+			}
+			return nil
+		}
+	}
 	if p.tt == StringToken {
 		importStmt.Module = p.data
 		p.next()
+		importStmt.Attributes, importStmt.Assert = p.parseImportAttributes("import statement")
 	} else {
 		if IsIdentifier(p.tt) || p.tt == YieldToken {
 			importStmt.Default = p.data
@@ -629,6 +1073,7 @@ func (p *Parser) parseImportStmt() (importStmt ImportStmt) {
 			}
 		}
 		if p.tt == MulToken {
+			aliasStart := p.offset()
 			star := p.data
 			p.next()
 			if !p.consume("import statement", AsToken) {
@@ -638,11 +1083,21 @@ func (p *Parser) parseImportStmt() (importStmt ImportStmt) {
 				p.fail("import statement", IdentifierToken)
 				return
 			}
-			importStmt.List = []Alias{Alias{star, p.data}}
+			binding := p.data
 			p.next()
+			importStmt.List = []Alias{Alias{star, binding, p.span(aliasStart)}}
 		} else if p.tt == OpenBraceToken {
 			p.next()
 			for IsIdentifierName(p.tt) || p.tt == StringToken {
+				aliasStart := p.offset()
+				isType := false
+				if p.o.TypeScript && p.isWord("type") {
+					if tt, _ := p.peek(); IsIdentifierName(tt) || tt == StringToken {
+						isType = true
+						hasTypes = true
+						p.next()
+					}
+				}
 				tt := p.tt
 				var name, binding []byte = nil, p.data
 				p.next()
@@ -659,11 +1114,13 @@ func (p *Parser) parseImportStmt() (importStmt ImportStmt) {
 					p.fail("import statement", IdentifierToken, StringToken)
 					return
 				}
-				importStmt.List = append(importStmt.List, Alias{name, binding})
+				if !isType {
+					importStmt.List = append(importStmt.List, Alias{name, binding, p.span(aliasStart)})
+				}
 				if p.tt == CommaToken {
 					p.next()
 					if p.tt == CloseBraceToken {
-						importStmt.List = append(importStmt.List, Alias{})
+						importStmt.List = append(importStmt.List, Alias{Span: Span{p.end, p.end}})
 						break
 					}
 				}
@@ -672,7 +1129,7 @@ func (p *Parser) parseImportStmt() (importStmt ImportStmt) {
 				return
 			}
 		}
-		if importStmt.Default == nil && len(importStmt.List) == 0 {
+		if importStmt.Default == nil && len(importStmt.List) == 0 && !hasTypes {
 			p.fail("import statement", StringToken, IdentifierToken, MulToken, OpenBraceToken)
 			return
 		}
@@ -686,18 +1143,63 @@ func (p *Parser) parseImportStmt() (importStmt ImportStmt) {
 		}
 		importStmt.Module = p.data
 		p.next()
+		importStmt.Attributes, importStmt.Assert = p.parseImportAttributes("import statement")
+	}
+	if p.err != nil {
+		return
 	}
+	importStmt.Span = p.span(start)
 	if p.tt == SemicolonToken {
 		p.next()
 	}
+	if importStmt.Default == nil && len(importStmt.List) == 0 && hasTypes {
+		return nil // only imports types
+	}
 	return
 }
 
-func (p *Parser) parseExportStmt() (exportStmt ExportStmt) {
+func (p *Parser) parseExportStmt() (exportStmt *ExportStmt) {
 	// assume we're at export
+	hasTypes := false // TypeScript types or overload signatures have been removed
+	start := p.offset()
+	if p.o.Goal == ScriptGoal {
+		p.failMessage("export statement is only allowed in modules")
+		return nil
+	}
+	exportStmt = &ExportStmt{}
 	p.next()
+	if (p.tt == AtToken || p.decorators != nil) && p.tt != DefaultToken && !p.parseClassDecorators("export statement") {
+		return
+	}
+	if p.o.TypeScript {
+		if p.isWord("type") {
+			if tt, _ := p.peek(); tt == OpenBraceToken || tt == MulToken {
+				// type-only export
+				p.skipTSStmt()
+				if p.tt == SemicolonToken {
+					p.next()
+				}
+				return nil
+			}
+		}
+		if tsStmt, ok := p.parseTSStmt(); ok {
+			if p.tt == SemicolonToken {
+				p.next()
+			}
+			if tsStmt == nil {
+				if p.err != nil {
+					return
+				}
+				return nil
+			}
+			exportStmt.Decl = tsStmt.(IExpr)
+			exportStmt.Span = p.span(start)
+			return
+		}
+	}
 	if p.tt == MulToken || p.tt == OpenBraceToken {
 		if p.tt == MulToken {
+			aliasStart := p.offset()
 			star := p.data
 			p.next()
 			if p.tt == AsToken {
@@ -706,10 +1208,11 @@ func (p *Parser) parseExportStmt() (exportStmt ExportStmt) {
 					p.fail("export statement", IdentifierToken, StringToken)
 					return
 				}
-				exportStmt.List = []Alias{Alias{star, p.data}}
+				binding := p.data
 				p.next()
+				exportStmt.List = []Alias{Alias{star, binding, p.span(aliasStart)}}
 			} else {
-				exportStmt.List = []Alias{Alias{nil, star}}
+				exportStmt.List = []Alias{Alias{nil, star, p.span(aliasStart)}}
 			}
 			if p.tt != FromToken {
 				p.fail("export statement", FromToken)
@@ -718,6 +1221,15 @@ func (p *Parser) parseExportStmt() (exportStmt ExportStmt) {
 		} else {
 			p.next()
 			for IsIdentifierName(p.tt) || p.tt == StringToken {
+				aliasStart := p.offset()
+				isType := false
+				if p.o.TypeScript && p.isWord("type") {
+					if tt, _ := p.peek(); IsIdentifierName(tt) || tt == StringToken {
+						isType = true
+						hasTypes = true
+						p.next()
+					}
+				}
 				var name, binding []byte = nil, p.data
 				p.next()
 				if p.tt == AsToken {
@@ -730,11 +1242,13 @@ func (p *Parser) parseExportStmt() (exportStmt ExportStmt) {
 					binding = p.data
 					p.next()
 				}
-				exportStmt.List = append(exportStmt.List, Alias{name, binding})
+				if !isType {
+					exportStmt.List = append(exportStmt.List, Alias{name, binding, p.span(aliasStart)})
+				}
 				if p.tt == CommaToken {
 					p.next()
 					if p.tt == CloseBraceToken {
-						exportStmt.List = append(exportStmt.List, Alias{})
+						exportStmt.List = append(exportStmt.List, Alias{Span: Span{p.end, p.end}})
 						break
 					}
 				}
@@ -751,35 +1265,68 @@ func (p *Parser) parseExportStmt() (exportStmt ExportStmt) {
 			}
 			exportStmt.Module = p.data
 			p.next()
+			if exportStmt.Attributes, exportStmt.Assert = p.parseImportAttributes("export statement"); p.err != nil {
+				return
+			}
 		}
 	} else if p.tt == VarToken || p.tt == ConstToken || p.tt == LetToken {
 		tt := p.tt
+		declStart := p.offset()
 		p.next()
-		exportStmt.Decl = p.parseVarDecl(tt, false)
+		exportStmt.Decl = p.parseVarDecl(tt, false, declStart)
 	} else if p.tt == FunctionToken {
-		exportStmt.Decl = p.parseFuncDecl()
+		if funcDecl := p.parseFuncDecl(); funcDecl != nil {
+			exportStmt.Decl = funcDecl
+		} else {
+			hasTypes = true
+		}
 	} else if p.tt == AsyncToken { // async function
+		asyncStart := p.offset()
 		p.next()
 		if p.tt != FunctionToken || p.prevLT {
 			p.fail("export statement", FunctionToken)
 			return
 		}
-		exportStmt.Decl = p.parseAsyncFuncDecl()
+		if funcDecl := p.parseAsyncFuncDecl(asyncStart); funcDecl != nil {
+			exportStmt.Decl = funcDecl
+		} else {
+			hasTypes = true
+		}
 	} else if p.tt == ClassToken {
 		exportStmt.Decl = p.parseClassDecl()
 	} else if p.tt == DefaultToken {
 		exportStmt.Default = true
 		p.next()
-		if p.tt == FunctionToken {
-			exportStmt.Decl = p.parseFuncDeclDefault()
+		if (p.tt == AtToken || p.decorators != nil) && !p.parseClassDecorators("export statement") {
+			return
+		}
+		if p.o.TypeScript && p.isWord("abstract") {
+			if tt, prevLT := p.peek(); tt == ClassToken && !prevLT {
+				p.next()
+			}
+		}
+		if p.o.TypeScript && p.tt == InterfaceToken && p.isInterfaceDecl() {
+			p.parseTSStmt() // interface declarations are removed
+			hasTypes = true
+		} else if p.tt == FunctionToken {
+			if funcDecl := p.parseFuncDeclDefault(); funcDecl != nil {
+				exportStmt.Decl = funcDecl
+			} else {
+				hasTypes = true
+			}
 		} else if p.tt == AsyncToken { // async function or async arrow function
+			asyncStart := p.offset()
 			async := p.data
 			p.next()
 			if p.tt == FunctionToken && !p.prevLT {
-				exportStmt.Decl = p.parseAsyncFuncDeclDefault()
+				if funcDecl := p.parseAsyncFuncDeclDefault(asyncStart); funcDecl != nil {
+					exportStmt.Decl = funcDecl
+				} else {
+					hasTypes = true
+				}
 			} else {
 				// expression
-				exportStmt.Decl = p.parseAsyncExpression(OpExpr, async)
+				exportStmt.Decl = p.parseAsyncExpression(OpExpr, async, asyncStart)
 			}
 		} else if p.tt == ClassToken {
 			exportStmt.Decl = p.parseClassDeclDefault()
@@ -790,13 +1337,68 @@ func (p *Parser) parseExportStmt() (exportStmt ExportStmt) {
 		p.fail("export statement", MulToken, OpenBraceToken, VarToken, LetToken, ConstToken, FunctionToken, AsyncToken, ClassToken, DefaultToken)
 		return
 	}
+	exportStmt.Span = p.span(start)
 	if p.tt == SemicolonToken {
 		p.next()
 	}
+	if hasTypes && exportStmt.Decl == nil && len(exportStmt.List) == 0 {
+		return nil // only exports types
+	}
+	return
+}
+
+// parseImportAttributes parses the import attributes after the module specifier of import and export statements, it returns nil if there are none.
+func (p *Parser) parseImportAttributes(in string) (attributes []ImportAttribute, assert bool) {
+	if p.tt != WithToken && (!p.o.AssertImports || !p.isWord("assert") || p.prevLT) {
+		return
+	}
+	assert = p.tt != WithToken
+	p.next()
+	if !p.consume(in, OpenBraceToken) {
+		return
+	}
+	attributes = []ImportAttribute{}
+	keys := map[string]bool{}
+	for p.tt != CloseBraceToken {
+		start := p.offset()
+		if !IsIdentifierName(p.tt) && p.tt != StringToken {
+			p.fail(in, IdentifierToken, StringToken, CloseBraceToken)
+			return
+		}
+		key := p.data
+		name := key
+		if p.tt == StringToken {
+			if value, err := DecodeString(key); err == nil {
+				name = value
+			}
+		}
+		if keys[string(name)] {
+			p.failMessage("duplicate import attribute key %s", string(name))
+			return
+		}
+		keys[string(name)] = true
+		p.next()
+		if !p.consume(in, ColonToken) {
+			return
+		} else if p.tt != StringToken {
+			p.fail(in, StringToken)
+			return
+		}
+		value := p.data
+		p.next()
+		attributes = append(attributes, ImportAttribute{key, value, p.span(start)})
+		if p.tt == CommaToken {
+			p.next()
+		} else if p.tt != CloseBraceToken {
+			p.fail(in, CommaToken, CloseBraceToken)
+			return
+		}
+	}
+	p.next()
 	return
 }
 
-func (p *Parser) parseVarDecl(tt TokenType, canBeHoisted bool) (varDecl *VarDecl) {
+func (p *Parser) parseVarDecl(tt TokenType, canBeHoisted bool, start int) (varDecl *VarDecl) {
 	// assume we're past var, let or const
 	varDecl = &VarDecl{
 		TokenType: tt,
@@ -812,10 +1414,17 @@ func (p *Parser) parseVarDecl(tt TokenType, canBeHoisted bool) (varDecl *VarDecl
 	for {
 		// binding element, var declaration in for-in or for-of can never have a default
 		var bindingElement BindingElement
+		elementStart := p.offset()
 		parentInFor := p.inFor
 		p.inFor = false
 		bindingElement.Binding = p.parseBinding(declType)
 		p.inFor = parentInFor
+		if p.o.TypeScript {
+			if p.tt == NotToken && !p.prevLT {
+				p.next() // definite assignment assertion
+			}
+			p.parseTypeAnnotation()
+		}
 		if p.tt == EqToken {
 			p.next()
 			bindingElement.Default = p.parseExpression(OpAssign)
@@ -826,6 +1435,7 @@ func (p *Parser) parseVarDecl(tt TokenType, canBeHoisted bool) (varDecl *VarDecl
 			p.fail("const statement", EqToken)
 		}
 
+		bindingElement.Span = p.span(elementStart)
 		varDecl.List = append(varDecl.List, bindingElement)
 		if p.tt == CommaToken {
 			p.next()
@@ -833,23 +1443,46 @@ func (p *Parser) parseVarDecl(tt TokenType, canBeHoisted bool) (varDecl *VarDecl
 			break
 		}
 	}
+	varDecl.Span = p.span(start)
 	return
 }
 
 func (p *Parser) parseFuncParams(in string) (params Params) {
+	start := p.offset()
 	if !p.consume(in, OpenParenToken) {
 		return
 	}
 
+	var props []*Var // TypeScript parameter properties
 	for p.tt != CloseParenToken && p.tt != ErrorToken {
+		if p.o.TypeScript && p.tt == ThisToken {
+			// this parameter
+			p.next()
+			p.parseTypeAnnotation()
+			if p.tt == CommaToken {
+				p.next()
+			}
+			continue
+		}
+		isProp := false
+		for p.o.TypeScript && p.isParamModifier() {
+			isProp = true
+			p.next()
+		}
 		if p.tt == EllipsisToken {
 			// binding rest element
 			p.next()
 			params.Rest = p.parseBinding(ArgumentDecl)
+			p.parseTypeAnnotation()
 			p.consume(in, CloseParenToken)
+			params.Span = p.span(start)
+			p.paramProps = props
 			return
 		}
 		params.List = append(params.List, p.parseBindingElement(ArgumentDecl))
+		if v, ok := params.List[len(params.List)-1].Binding.(*Var); ok && isProp {
+			props = append(props, v)
+		}
 		if p.tt != CommaToken {
 			break
 		}
@@ -860,6 +1493,8 @@ func (p *Parser) parseFuncParams(in string) (params Params) {
 		return
 	}
 	p.next()
+	params.Span = p.span(start)
+	p.paramProps = props
 
 	// mark undeclared vars as arguments in `function f(a=b){var b}` where the b's are different vars
 	p.scope.MarkFuncArgs()
@@ -867,30 +1502,30 @@ func (p *Parser) parseFuncParams(in string) (params Params) {
 }
 
 func (p *Parser) parseFuncDecl() (funcDecl *FuncDecl) {
-	return p.parseAnyFunc(false, false, false)
+	return p.parseAnyFunc(false, false, false, p.offset())
 }
 
 func (p *Parser) parseFuncDeclDefault() (funcDecl *FuncDecl) {
-	return p.parseAnyFunc(false, true, false)
+	return p.parseAnyFunc(false, true, false, p.offset())
 }
 
-func (p *Parser) parseAsyncFuncDecl() (funcDecl *FuncDecl) {
-	return p.parseAnyFunc(true, false, false)
+func (p *Parser) parseAsyncFuncDecl(start int) (funcDecl *FuncDecl) {
+	return p.parseAnyFunc(true, false, false, start)
 }
 
-func (p *Parser) parseAsyncFuncDeclDefault() (funcDecl *FuncDecl) {
-	return p.parseAnyFunc(true, true, false)
+func (p *Parser) parseAsyncFuncDeclDefault(start int) (funcDecl *FuncDecl) {
+	return p.parseAnyFunc(true, true, false, start)
 }
 
 func (p *Parser) parseFuncExpr() (funcDecl *FuncDecl) {
-	return p.parseAnyFunc(false, false, true)
+	return p.parseAnyFunc(false, false, true, p.offset())
 }
 
-func (p *Parser) parseAsyncFuncExpr() (funcDecl *FuncDecl) {
-	return p.parseAnyFunc(true, false, true)
+func (p *Parser) parseAsyncFuncExpr(start int) (funcDecl *FuncDecl) {
+	return p.parseAnyFunc(true, false, true, start)
 }
 
-func (p *Parser) parseAnyFunc(async, exportDefault, expr bool) (funcDecl *FuncDecl) {
+func (p *Parser) parseAnyFunc(async, exportDefault, expr bool, start int) (funcDecl *FuncDecl) {
 	// assume we're at function
 	p.next()
 	funcDecl = &FuncDecl{}
@@ -901,10 +1536,12 @@ func (p *Parser) parseAnyFunc(async, exportDefault, expr bool) (funcDecl *FuncDe
 	}
 	var ok bool
 	var name []byte
+	var nameSpan Span
 	if expr && (IsIdentifier(p.tt) || p.tt == YieldToken || p.tt == AwaitToken) || !expr && p.isIdentifierReference(p.tt) {
 		name = p.data
+		nameSpan = p.tokenSpan()
 		if !expr {
-			funcDecl.Name, ok = p.scope.Declare(FunctionDecl, p.data)
+			funcDecl.Name, ok = p.declare(FunctionDecl, p.data, nameSpan)
 			if !ok {
 				p.failMessage("identifier %s has already been declared", string(p.data))
 				return
@@ -914,7 +1551,7 @@ func (p *Parser) parseAnyFunc(async, exportDefault, expr bool) (funcDecl *FuncDe
 	} else if !expr && !exportDefault {
 		p.fail("function declaration", IdentifierToken)
 		return
-	} else if p.tt != OpenParenToken {
+	} else if p.tt != OpenParenToken && (!p.o.TypeScript || p.tt != LtToken) {
 		p.fail("function declaration", IdentifierToken, OpenParenToken)
 		return
 	}
@@ -923,11 +1560,28 @@ func (p *Parser) parseAnyFunc(async, exportDefault, expr bool) (funcDecl *FuncDe
 	p.await, p.yield = funcDecl.Async, funcDecl.Generator
 
 	if expr && name != nil {
-		funcDecl.Name, _ = p.scope.Declare(ExprDecl, name) // cannot fail
+		funcDecl.Name, _ = p.declare(ExprDecl, name, nameSpan) // cannot fail
+	}
+	if p.o.TypeScript && p.tt == LtToken {
+		p.skipTypeParams()
 	}
 	funcDecl.Params = p.parseFuncParams("function declaration")
+	if p.o.TypeScript {
+		p.parseTypeAnnotation()
+		if !expr && p.tt != OpenBraceToken && p.err == nil {
+			// overload signature or declaration without body
+			p.await, p.yield = parentAwait, parentYield
+			p.exitScope(parent)
+			return nil
+		}
+	}
 	p.allowDirectivePrologue = true
+	parentStrict := p.strict
+	bodyStart := p.offset()
 	funcDecl.Body.List = p.parseStmtList("function declaration")
+	funcDecl.Body.Span = p.span(bodyStart)
+	funcDecl.Span = p.span(start)
+	funcDecl.Strict = p.exitStrict(parentStrict, funcDecl.Name, &funcDecl.Params)
 
 	p.await, p.yield = parentAwait, parentYield
 	p.exitScope(parent)
@@ -948,28 +1602,53 @@ func (p *Parser) parseClassExpr() (classDecl *ClassDecl) {
 
 func (p *Parser) parseAnyClass(exportDefault, expr bool) (classDecl *ClassDecl) {
 	// assume we're at class
+	start := p.offset()
+	decorators := p.decorators
+	if decorators != nil {
+		start = p.decoratorsStart
+		p.decorators = nil
+	}
+	parentStrict := p.strict
+	p.strict = true // all parts of a class are strict
 	p.next()
-	classDecl = &ClassDecl{}
+	classDecl = &ClassDecl{Decorators: decorators}
 	if IsIdentifier(p.tt) || p.tt == YieldToken || p.tt == AwaitToken {
 		if !expr {
 			var ok bool
-			classDecl.Name, ok = p.scope.Declare(LexicalDecl, p.data)
+			classDecl.Name, ok = p.declare(LexicalDecl, p.data, p.tokenSpan())
 			if !ok {
 				p.failMessage("identifier %s has already been declared", string(p.data))
 				return
 			}
 		} else {
 			//classDecl.Name, ok = p.scope.Declare(ExprDecl, p.data) // classes do not register vars
-			classDecl.Name = &Var{p.data, nil, 1, ExprDecl}
+			classDecl.Name = &Var{p.data, nil, 1, ExprDecl, p.tokenSpan()}
+			p.checkIdentifier(p.data, p.tokenSpan())
 		}
 		p.next()
 	} else if !expr && !exportDefault {
 		p.fail("class declaration", IdentifierToken)
 		return
 	}
+	if p.o.TypeScript && p.tt == LtToken {
+		p.skipTypeParams()
+	}
 	if p.tt == ExtendsToken {
 		p.next()
 		classDecl.Extends = p.parseExpression(OpLHS)
+		if p.o.TypeScript && p.tt == LtToken {
+			p.skipTypeArgs()
+		}
+	}
+	if p.o.TypeScript && p.tt == ImplementsToken {
+		p.next()
+		for {
+			p.skipType()
+			if p.tt != CommaToken {
+				break
+			}
+			p.next()
+		}
 	}
 
 	if !p.consume("class declaration", OpenBraceToken) {
@@ -987,50 +1666,162 @@ func (p *Parser) parseAnyClass(exportDefault, expr bool) (classDecl *ClassDecl)
 			break
 		}
 
-		classDecl.List = append(classDecl.List, p.parseClassElement())
+		if element, ok := p.parseClassElement(); ok {
+			classDecl.List = append(classDecl.List, element)
+		}
+	}
+	classDecl.Span = p.span(start)
+	p.strict = parentStrict
+	return
+}
+
+// parseDecorators parses a list of decorators, each is a variable or dotted name that is optionally called, or an expression between parentheses.
+func (p *Parser) parseDecorators() (decorators []IExpr) {
+	for p.tt == AtToken {
+		p.next()
+		start := p.offset()
+		var decorator IExpr
+		if p.tt == OpenParenToken {
+			p.next()
+			x := p.parseExpression(OpExpr)
+			if !p.consume("decorator", CloseParenToken) {
+				return
+			}
+			decorator = &GroupExpr{x, p.span(start)}
+		} else if IsIdentifier(p.tt) || p.tt == YieldToken || p.tt == AwaitToken {
+			decorator = p.use(p.data, p.tokenSpan())
+			p.next()
+			for p.tt == DotToken {
+				p.next()
+				if !IsIdentifierName(p.tt) && p.tt != PrivateIdentifierToken {
+					p.fail("decorator", IdentifierToken)
+					return
+				} else if p.tt != PrivateIdentifierToken {
+					p.tt = IdentifierToken
+				}
+				decorator = &DotExpr{decorator, LiteralExpr{p.tt, p.data, p.tokenSpan()}, OpMember, false, Span{start, p.l.r.Offset()}}
+				p.next()
+			}
+			if p.tt == OpenParenToken {
+				decorator = &CallExpr{decorator, p.parseArguments(), false, p.span(start)}
+			}
+		} else {
+			p.fail("decorator", IdentifierToken, OpenParenToken)
+			return
+		}
+		decorators = append(decorators, decorator)
 	}
 	return
 }
 
-func (p *Parser) parseClassElement() ClassElement {
+// parseClassDecorators parses the decorators of the class that follows, which are added to the class by parseAnyClass. Decorators may already have been parsed before an export statement. It returns false if no class follows.
+func (p *Parser) parseClassDecorators(in string) bool {
+	if p.tt == AtToken {
+		if p.decorators != nil {
+			// decorators both before and after export
+			p.decorators = nil
+			p.fail(in, ClassToken)
+			return false
+		}
+		p.decoratorsStart = p.offset()
+		p.decorators = p.parseDecorators()
+	}
+	if p.o.TypeScript && p.isWord("abstract") {
+		if tt, prevLT := p.peek(); tt == ClassToken && !prevLT {
+			p.next()
+		}
+	}
+	if p.tt != ClassToken {
+		p.fail(in, ClassToken)
+	}
+	if p.err != nil {
+		p.decorators = nil
+		return false
+	}
+	return true
+}
+
+// parseClassElement returns false if the element has been removed, which happens for TypeScript declarations without a JavaScript equivalent.
+func (p *Parser) parseClassElement() (ClassElement, bool) {
+	start := p.offset()
 	method := &MethodDecl{}
+	if p.tt == AtToken {
+		method.Decorators = p.parseDecorators()
+	}
 	var data []byte // either static, async, get, or set
+	var dataSpan Span
+	strip := false
+	if p.o.TypeScript {
+		strip = p.parseTSModifiers()
+	}
 	if p.tt == StaticToken {
 		method.Static = true
 		data = p.data
+		dataSpan = p.tokenSpan()
 		p.next()
 		if p.tt == OpenBraceToken {
-			return ClassElement{StaticBlock: p.parseBlockStmt("class static block")}
+			if method.Decorators != nil {
+				p.failMessage("decorators are not valid on a class static block")
+				return ClassElement{}, false
+			}
+			staticBlock := p.parseBlockStmt("class static block")
+			return ClassElement{StaticBlock: staticBlock, Span: p.span(start)}, true
+		}
+		if p.o.TypeScript && p.tt != OpenParenToken && p.tt != EqToken && p.tt != SemicolonToken && p.tt != CloseBraceToken {
+			if p.parseTSModifiers() {
+				strip = true
+			}
 		}
 	}
-	if p.tt == MulToken {
-		method.Generator = true
-		p.next()
-	} else if p.tt == AsyncToken {
-		data = p.data
-		p.next()
-		if !p.prevLT {
-			method.Async = true
-			if p.tt == MulToken {
-				method.Generator = true
-				data = nil
-				p.next()
+	if p.o.TypeScript && p.tt == OpenBracketToken && p.isIndexSignature() {
+		// index signature
+		p.skipBalanced(OpenBracketToken, CloseBracketToken)
+		p.parseTypeAnnotation()
+		return ClassElement{}, false
+	}
+	accessor := false
+	if p.isWord("accessor") {
+		if next, prevLT := p.peek(); !prevLT && (IsIdentifierName(next) || next == StringToken || IsNumeric(next) || next == OpenBracketToken || next == PrivateIdentifierToken) {
+			// auto-accessor, otherwise it is a field or method named accessor
+			accessor = true
+			data = nil
+			p.next()
+		}
+	}
+	if !accessor {
+		if p.tt == MulToken {
+			method.Generator = true
+			p.next()
+		} else if p.tt == AsyncToken {
+			data = p.data
+			dataSpan = p.tokenSpan()
+			p.next()
+			if !p.prevLT {
+				method.Async = true
+				if p.tt == MulToken {
+					method.Generator = true
+					data = nil
+					p.next()
+				}
 			}
+		} else if p.tt == GetToken {
+			method.Get = true
+			data = p.data
+			dataSpan = p.tokenSpan()
+			p.next()
+		} else if p.tt == SetToken {
+			method.Set = true
+			data = p.data
+			dataSpan = p.tokenSpan()
+			p.next()
 		}
-	} else if p.tt == GetToken {
-		method.Get = true
-		data = p.data
-		p.next()
-	} else if p.tt == SetToken {
-		method.Set = true
-		data = p.data
-		p.next()
 	}
 
 	isField := false
 	if data != nil && p.tt == OpenParenToken {
 		// (static) method name is: static, async, get, or set
-		method.Name.Literal = LiteralExpr{IdentifierToken, data}
+		method.Name.Literal = LiteralExpr{IdentifierToken, data, dataSpan}
+		method.Name.Span = dataSpan
 		if method.Async || method.Get || method.Set {
 			method.Async = false
 			method.Get = false
@@ -1038,32 +1829,55 @@ func (p *Parser) parseClassElement() ClassElement {
 		} else {
 			method.Static = false
 		}
-	} else if data != nil && (p.tt == EqToken || p.tt == SemicolonToken || p.tt == CloseBraceToken) {
+	} else if data != nil && (p.tt == EqToken || p.tt == SemicolonToken || p.tt == CloseBraceToken || p.o.TypeScript && (p.tt == ColonToken || p.tt == QuestionToken || p.tt == NotToken)) {
 		// (static) field name is: static, async, get, or set
-		method.Name.Literal = LiteralExpr{IdentifierToken, data}
+		method.Name.Literal = LiteralExpr{IdentifierToken, data, dataSpan}
+		method.Name.Span = dataSpan
 		if !method.Async && !method.Get && !method.Set {
 			method.Static = false
 		}
 		isField = true
 	} else {
 		if p.tt == PrivateIdentifierToken {
-			method.Name.Literal = LiteralExpr{p.tt, p.data}
+			method.Name.Literal = LiteralExpr{p.tt, p.data, p.tokenSpan()}
+			method.Name.Span = method.Name.Literal.Span
 			p.next()
 		} else {
 			method.Name = p.parsePropertyName("method or field definition")
 		}
+		if p.o.TypeScript {
+			if p.tt == QuestionToken || p.tt == NotToken && !p.prevLT {
+				p.next() // optional member or definite assignment assertion
+			}
+			if p.tt == LtToken {
+				p.skipTypeParams()
+			}
+		}
 		if (data == nil || method.Static) && p.tt != OpenParenToken {
 			isField = true
+		} else if accessor {
+			p.fail("accessor field")
+			return ClassElement{}, false
 		}
 	}
+	if isField && p.o.TypeScript && (p.tt == QuestionToken || p.tt == NotToken && !p.prevLT) {
+		p.next() // field named static, async, get, or set
+	}
 
 	if isField {
+		p.parseTypeAnnotation()
 		var init IExpr
 		if p.tt == EqToken {
 			p.next()
 			init = p.parseExpression(OpAssign)
 		}
-		return ClassElement{Field: Field{Static: method.Static, Name: method.Name, Init: init}}
+		field := Field{Decorators: method.Decorators, Static: method.Static, Accessor: accessor, Name: method.Name, Init: init, Span: p.span(start)}
+		return ClassElement{Field: field, Span: field.Span}, !strip
+	}
+
+	if method.Decorators != nil && !method.Static && method.Name.IsIdent([]byte("constructor")) {
+		p.failMessage("decorators are not valid on a class constructor")
+		return ClassElement{}, false
 	}
 
 	parent := p.enterScope(&method.Body.Scope, true)
@@ -1071,32 +1885,54 @@ func (p *Parser) parseClassElement() ClassElement {
 	p.await, p.yield = method.Async, method.Generator
 
 	method.Params = p.parseFuncParams("method definition")
+	props := p.paramProps
+	if p.o.TypeScript {
+		p.parseTypeAnnotation()
+		if strip || p.tt != OpenBraceToken && p.err == nil {
+			// abstract method or overload signature
+			if p.tt == OpenBraceToken {
+				p.parseStmtList("method definition")
+			}
+			p.await, p.yield = parentAwait, parentYield
+			p.exitScope(parent)
+			return ClassElement{}, false
+		}
+	}
 	p.allowDirectivePrologue = true
+	bodyStart := p.offset()
 	method.Body.List = p.parseStmtList("method definition")
+	if 0 < len(props) && method.Name.IsIdent([]byte("constructor")) {
+		method.Body.List = addParamProps(method.Body.List, props, Span{bodyStart, bodyStart})
+	}
+	method.Body.Span = p.span(bodyStart)
+	method.Strict = p.strict // class bodies are strict
+	method.Span = p.span(start)
 
 	p.await, p.yield = parentAwait, parentYield
 	p.exitScope(parent)
-	return ClassElement{Method: method}
+	return ClassElement{Method: method, Span: method.Span}, true
 }
 
 func (p *Parser) parsePropertyName(in string) (propertyName PropertyName) {
+	start := p.offset()
 	if IsIdentifierName(p.tt) {
-		propertyName.Literal = LiteralExpr{IdentifierToken, p.data}
+		propertyName.Literal = LiteralExpr{IdentifierToken, p.data, p.tokenSpan()}
 		p.next()
 	} else if p.tt == StringToken {
 		// reinterpret string as identifier or number if we can, except for empty strings
 		if isIdent := AsIdentifierName(p.data[1 : len(p.data)-1]); isIdent {
-			propertyName.Literal = LiteralExpr{IdentifierToken, p.data[1 : len(p.data)-1]}
+			propertyName.Literal = LiteralExpr{IdentifierToken, p.data[1 : len(p.data)-1], p.tokenSpan()}
 		} else if isNum := AsDecimalLiteral(p.data[1 : len(p.data)-1]); isNum {
-			propertyName.Literal = LiteralExpr{DecimalToken, p.data[1 : len(p.data)-1]}
+			propertyName.Literal = LiteralExpr{DecimalToken, p.data[1 : len(p.data)-1], p.tokenSpan()}
 		} else {
-			propertyName.Literal = LiteralExpr{p.tt, p.data}
+			propertyName.Literal = LiteralExpr{p.tt, p.data, p.tokenSpan()}
 		}
 		p.next()
 	} else if IsNumeric(p.tt) {
-		propertyName.Literal = LiteralExpr{p.tt, p.data}
+		propertyName.Literal = LiteralExpr{p.tt, p.data, p.tokenSpan()}
 		p.next()
 	} else if p.tt == OpenBracketToken {
+		propertyName.Literal.Span = Span{start, start}
 		p.next()
 		propertyName.Computed = p.parseExpression(OpAssign)
 		if !p.consume(in, CloseBracketToken) {
@@ -1106,24 +1942,34 @@ func (p *Parser) parsePropertyName(in string) (propertyName PropertyName) {
 		p.fail(in, IdentifierToken, StringToken, NumericToken, OpenBracketToken)
 		return
 	}
+	propertyName.Span = p.span(start)
 	return
 }
 
 func (p *Parser) parseBindingElement(decl DeclType) (bindingElement BindingElement) {
 	// binding element
+	start := p.offset()
 	bindingElement.Binding = p.parseBinding(decl)
+	if p.o.TypeScript && decl == ArgumentDecl {
+		if p.tt == QuestionToken {
+			p.next() // optional parameter
+		}
+		p.parseTypeAnnotation()
+	}
 	if p.tt == EqToken {
 		p.next()
 		bindingElement.Default = p.parseExpression(OpAssign)
 	}
+	bindingElement.Span = p.span(start)
 	return
 }
 
 func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 	// binding identifier or binding pattern
+	start := p.offset()
 	if p.isIdentifierReference(p.tt) {
 		var ok bool
-		binding, ok = p.scope.Declare(decl, p.data)
+		binding, ok = p.declare(decl, p.data, p.tokenSpan())
 		if !ok {
 			p.failMessage("identifier %s has already been declared", string(p.data))
 			return
@@ -1133,7 +1979,7 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 		p.next()
 		array := BindingArray{}
 		if p.tt == CommaToken {
-			array.List = append(array.List, BindingElement{})
+			array.List = append(array.List, BindingElement{Span: Span{p.offset(), p.offset()}})
 		}
 		last := 0
 		for p.tt != CloseBracketToken {
@@ -1141,7 +1987,7 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 			for p.tt == CommaToken {
 				p.next()
 				if p.tt == CommaToken {
-					array.List = append(array.List, BindingElement{})
+					array.List = append(array.List, BindingElement{Span: Span{p.offset(), p.offset()}})
 				}
 			}
 			// binding rest element
@@ -1167,6 +2013,7 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 			}
 		}
 		p.next() // always CloseBracketToken
+		array.Span = p.span(start)
 		binding = &array
 	} else if p.tt == OpenBraceToken {
 		p.next()
@@ -1180,7 +2027,7 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 					return
 				}
 				var ok bool
-				object.Rest, ok = p.scope.Declare(decl, p.data)
+				object.Rest, ok = p.declare(decl, p.data, p.tokenSpan())
 				if !ok {
 					p.failMessage("identifier %s has already been declared", string(p.data))
 					return
@@ -1193,10 +2040,12 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 				break
 			}
 
+			itemStart := p.offset()
 			item := BindingObjectItem{}
 			if p.isIdentifierReference(p.tt) {
 				name := p.data
-				item.Key = &PropertyName{LiteralExpr{IdentifierToken, p.data}, nil}
+				nameSpan := p.tokenSpan()
+				item.Key = &PropertyName{LiteralExpr{IdentifierToken, p.data, nameSpan}, nil, nameSpan}
 				p.next()
 				if p.tt == ColonToken {
 					// property name + : + binding element
@@ -1206,7 +2055,7 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 					// single name binding
 					var ok bool
 					item.Key.Literal.Data = parse.Copy(item.Key.Literal.Data) // copy so that renaming doesn't rename the key
-					item.Value.Binding, ok = p.scope.Declare(decl, name)
+					item.Value.Binding, ok = p.declare(decl, name, nameSpan)
 					if !ok {
 						p.failMessage("identifier %s has already been declared", string(name))
 						return
@@ -1215,6 +2064,7 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 						p.next()
 						item.Value.Default = p.parseExpression(OpAssign)
 					}
+					item.Value.Span = p.span(itemStart)
 				}
 			} else {
 				propertyName := p.parsePropertyName("object binding pattern")
@@ -1224,6 +2074,7 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 				}
 				item.Value = p.parseBindingElement(decl)
 			}
+			item.Span = p.span(itemStart)
 			object.List = append(object.List, item)
 
 			if p.tt == CommaToken {
@@ -1234,6 +2085,7 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 			}
 		}
 		p.next() // always CloseBracketToken
+		object.Span = p.span(start)
 		binding = &object
 	} else {
 		p.fail("binding")
@@ -1244,6 +2096,7 @@ func (p *Parser) parseBinding(decl DeclType) (binding IBinding) {
 
 func (p *Parser) parseArrayLiteral() (array ArrayExpr) {
 	// assume we're on [
+	start := p.offset()
 	p.next()
 	prevComma := true
 	for {
@@ -1255,27 +2108,30 @@ func (p *Parser) parseArrayLiteral() (array ArrayExpr) {
 			break
 		} else if p.tt == CommaToken {
 			if prevComma {
-				array.List = append(array.List, Element{})
+				array.List = append(array.List, Element{Span: Span{p.offset(), p.offset()}})
 			}
 			prevComma = true
 			p.next()
 		} else {
+			elementStart := p.offset()
 			spread := p.tt == EllipsisToken
 			if spread {
 				p.next()
 			}
-			array.List = append(array.List, Element{p.parseAssignmentExpression(), spread})
+			array.List = append(array.List, Element{p.parseAssignmentExpression(), spread, p.span(elementStart)})
 			prevComma = false
 			if spread && p.tt != CloseBracketToken {
 				p.assumeArrowFunc = false
 			}
 		}
 	}
+	array.Span = p.span(start)
 	return
 }
 
 func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 	// assume we're on {
+	start := p.offset()
 	p.next()
 	for {
 		if p.tt == ErrorToken {
@@ -1286,6 +2142,7 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 			break
 		}
 
+		propertyStart := p.offset()
 		property := Property{}
 		if p.tt == EllipsisToken {
 			p.next()
@@ -1297,12 +2154,14 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 		} else {
 			// try to parse as MethodDefinition, otherwise fall back to PropertyName:AssignExpr or IdentifierReference
 			var data []byte
+			var dataSpan Span
 			method := MethodDecl{}
 			if p.tt == MulToken {
 				p.next()
 				method.Generator = true
 			} else if p.tt == AsyncToken {
 				data = p.data
+				dataSpan = p.tokenSpan()
 				p.next()
 				if !p.prevLT {
 					method.Async = true
@@ -1312,22 +2171,26 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 						data = nil
 					}
 				} else {
-					method.Name.Literal = LiteralExpr{IdentifierToken, data}
+					method.Name.Literal = LiteralExpr{IdentifierToken, data, dataSpan}
+					method.Name.Span = dataSpan
 					data = nil
 				}
 			} else if p.tt == GetToken {
 				data = p.data
+				dataSpan = p.tokenSpan()
 				p.next()
 				method.Get = true
 			} else if p.tt == SetToken {
 				data = p.data
+				dataSpan = p.tokenSpan()
 				p.next()
 				method.Set = true
 			}
 
 			// PropertyName
 			if data != nil && !method.Generator && (p.tt == EqToken || p.tt == CommaToken || p.tt == CloseBraceToken || p.tt == ColonToken || p.tt == OpenParenToken) {
-				method.Name.Literal = LiteralExpr{IdentifierToken, data}
+				method.Name.Literal = LiteralExpr{IdentifierToken, data, dataSpan}
+				method.Name.Span = dataSpan
 				method.Async = false
 				method.Get = false
 				method.Set = false
@@ -1337,6 +2200,13 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 					return
 				}
 			}
+			if p.o.TypeScript && p.tt == LtToken {
+				p.skipTypeParams()
+				if p.tt != OpenParenToken {
+					p.fail("method definition", OpenParenToken)
+					return
+				}
+			}
 
 			if p.tt == OpenParenToken {
 				// MethodDefinition
@@ -1345,7 +2215,14 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 				p.await, p.yield = method.Async, method.Generator
 
 				method.Params = p.parseFuncParams("method definition")
+				p.parseTypeAnnotation()
+				p.allowDirectivePrologue = true
+				parentStrict := p.strict
+				bodyStart := p.offset()
 				method.Body.List = p.parseStmtList("method definition")
+				method.Body.Span = p.span(bodyStart)
+				method.Span = p.span(propertyStart)
+				method.Strict = p.exitStrict(parentStrict, nil, &method.Params)
 
 				p.await, p.yield = parentAwait, parentYield
 				p.exitScope(parent)
@@ -1366,13 +2243,13 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 				property.Name = &method.Name                                    // set key explicitly so after renaming the original is still known
 				if p.assumeArrowFunc {
 					var ok bool
-					property.Value, ok = p.scope.Declare(ArgumentDecl, name)
+					property.Value, ok = p.declare(ArgumentDecl, name, method.Name.Span)
 					if !ok {
-						property.Value = p.scope.Use(name)
+						property.Value = p.use(name, method.Name.Span)
 						p.assumeArrowFunc = false
 					}
 				} else {
-					property.Value = p.scope.Use(name)
+					property.Value = p.use(name, method.Name.Span)
 				}
 				if p.tt == EqToken {
 					p.next()
@@ -1383,6 +2260,7 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 				}
 			}
 		}
+		property.Span = p.span(propertyStart)
 		object.List = append(object.List, property)
 		if p.tt == CommaToken {
 			p.next()
@@ -1391,19 +2269,21 @@ func (p *Parser) parseObjectLiteral() (object ObjectExpr) {
 			return
 		}
 	}
+	object.Span = p.span(start)
 	return
 }
 
-func (p *Parser) parseTemplateLiteral(precLeft OpPrec) (template TemplateExpr) {
+func (p *Parser) parseTemplateLiteral(precLeft OpPrec, start int) (template TemplateExpr) {
 	// assume we're on 'Template' or 'TemplateStart'
 	template.Prec = OpMember
 	if precLeft < OpMember {
 		template.Prec = OpCall
 	}
 	for p.tt == TemplateStartToken || p.tt == TemplateMiddleToken {
+		partStart := p.offset()
 		tpl := p.data
 		p.next()
-		template.List = append(template.List, TemplatePart{tpl, p.parseExpression(OpExpr)})
+		template.List = append(template.List, TemplatePart{tpl, p.parseExpression(OpExpr), p.span(partStart)})
 	}
 	if p.tt != TemplateToken && p.tt != TemplateEndToken {
 		p.fail("template literal", TemplateToken)
@@ -1411,14 +2291,244 @@ func (p *Parser) parseTemplateLiteral(precLeft OpPrec) (template TemplateExpr) {
 	}
 	template.Tail = p.data
 	p.next() // TemplateEndToken
+	template.Span = p.span(start)
+	return
+}
+
+// parseJSXElement parses a JSX element or fragment that starts at start, it returns at the last > of the element without moving to the next token.
+func (p *Parser) parseJSXElement(start int) *JSXElement {
+	// assume we're at the token after <
+	elem := &JSXElement{}
+	if p.tt != GtToken {
+		if elem.Name = p.parseJSXElementName(); elem.Name == nil {
+			return nil
+		}
+		for p.tt != GtToken && p.tt != DivToken {
+			attr := p.parseJSXAttribute()
+			if p.err != nil {
+				return nil
+			}
+			elem.Attrs = append(elem.Attrs, attr)
+		}
+		if p.tt == DivToken {
+			p.nextJSX()
+			if p.tt != GtToken {
+				p.fail("JSX element", GtToken)
+				return nil
+			}
+			elem.SelfClosing = true
+			elem.Span = Span{start, p.l.r.Offset()}
+			return elem
+		}
+	}
+
+	// children
+	for {
+		textStart := p.l.r.Offset()
+		p.end = textStart
+		if p.tt, p.data = p.l.JSXText(); p.tt == ErrorToken {
+			p.fail("JSX element")
+			return nil
+		} else if len(p.data) != 0 {
+			p.addToken()
+			elem.Children = append(elem.Children, &JSXText{p.data, p.tokenSpan()})
+		}
+
+		p.nextJSX()
+		childStart := p.offset()
+		if p.tt == OpenBraceToken {
+			expr := p.parseJSXExpr(true)
+			if expr == nil {
+				return nil
+			}
+			elem.Children = append(elem.Children, expr)
+		} else if p.tt == LtToken {
+			p.nextJSX()
+			if p.tt == DivToken {
+				break // closing tag
+			}
+			child := p.parseJSXElement(childStart)
+			if child == nil {
+				return nil
+			}
+			elem.Children = append(elem.Children, child)
+		} else {
+			p.fail("JSX element", LtToken, OpenBraceToken)
+			return nil
+		}
+	}
+
+	// closing tag
+	p.nextJSX()
+	if p.tt != GtToken {
+		var name IExpr
+		if name = p.parseJSXElementName(); name == nil {
+			return nil
+		} else if elem.Name == nil || jsxNameString(name) != jsxNameString(elem.Name) {
+			p.failMessage("expected closing tag for %s", jsxNameString(elem.Name))
+			return nil
+		} else if p.tt != GtToken {
+			p.fail("JSX closing tag", GtToken)
+			return nil
+		}
+	} else if elem.Name != nil {
+		p.failMessage("expected closing tag for %s", jsxNameString(elem.Name))
+		return nil
+	}
+	elem.Span = Span{start, p.l.r.Offset()}
+	return elem
+}
+
+// parseJSXElementName parses the name of a JSX element, names that start with a lowercase letter or that contain a dash are intrinsic elements, others are references to components.
+func (p *Parser) parseJSXElementName() IExpr {
+	start := p.offset()
+	if p.tt != IdentifierToken {
+		p.fail("JSX element name", IdentifierToken)
+		return nil
+	}
+	name := p.data
+	p.nextJSX()
+	if p.tt == ColonToken {
+		p.nextJSX()
+		if p.tt != IdentifierToken {
+			p.fail("JSX element name", IdentifierToken)
+			return nil
+		}
+		name = append(append(append([]byte{}, name...), ':'), p.data...)
+		p.nextJSX()
+		return &JSXName{name, p.span(start)}
+	}
+
+	var left IExpr
+	if p.tt != DotToken && ('a' <= name[0] && name[0] <= 'z' || bytes.IndexByte(name, '-') != -1) {
+		return &JSXName{name, p.span(start)}
+	} else if bytes.Equal(name, []byte("this")) {
+		left = &LiteralExpr{ThisToken, name, p.span(start)}
+	} else if bytes.IndexByte(name, '-') != -1 {
+		p.fail("JSX element name", IdentifierToken)
+		return nil
+	} else {
+		left = p.use(name, p.span(start))
+	}
+	for p.tt == DotToken {
+		p.nextJSX()
+		if p.tt != IdentifierToken {
+			p.fail("JSX element name", IdentifierToken)
+			return nil
+		}
+		y := LiteralExpr{IdentifierToken, p.data, p.tokenSpan()}
+		p.nextJSX()
+		left = &DotExpr{left, y, OpMember, false, p.span(start)}
+	}
+	return left
+}
+
+func (p *Parser) parseJSXAttribute() (attr JSXAttribute) {
+	start := p.offset()
+	if p.tt == OpenBraceToken {
+		p.next()
+		if !p.consume("JSX spread attribute", EllipsisToken) {
+			return
+		}
+		attr.Value = p.parseExpression(OpAssign)
+		attr.Spread = true
+		if p.tt != CloseBraceToken {
+			p.fail("JSX spread attribute", CloseBraceToken)
+			return
+		}
+		p.nextJSX()
+		attr.Span = p.span(start)
+		return
+	} else if p.tt != IdentifierToken {
+		p.fail("JSX attribute", IdentifierToken)
+		return
+	}
+	attr.Name = p.data
+	p.nextJSX()
+	if p.tt == ColonToken {
+		p.nextJSX()
+		if p.tt != IdentifierToken {
+			p.fail("JSX attribute", IdentifierToken)
+			return
+		}
+		attr.Name = append(append(append([]byte{}, attr.Name...), ':'), p.data...)
+		p.nextJSX()
+	}
+	if p.tt == EqToken {
+		p.nextJSX()
+		valueStart := p.offset()
+		switch p.tt {
+		case StringToken:
+			attr.Value = &LiteralExpr{StringToken, p.data, p.tokenSpan()}
+		case OpenBraceToken:
+			if expr := p.parseJSXExpr(false); expr != nil {
+				attr.Value = expr
+			} else {
+				return
+			}
+		case LtToken:
+			p.nextJSX()
+			if elem := p.parseJSXElement(valueStart); elem != nil {
+				attr.Value = elem
+			} else {
+				return
+			}
+		default:
+			p.fail("JSX attribute", StringToken, OpenBraceToken)
+			return
+		}
+		p.nextJSX()
+	}
+	attr.Span = p.span(start)
 	return
 }
 
+// parseJSXExpr parses an expression in braces, which can be empty or a spread expression for children. It returns at the closing brace without moving to the next token.
+func (p *Parser) parseJSXExpr(child bool) *JSXExpr {
+	// assume we're at {
+	start := p.offset()
+	expr := &JSXExpr{}
+	p.next()
+	if child && p.tt == EllipsisToken {
+		expr.Spread = true
+		p.next()
+	}
+	if !child || expr.Spread || p.tt != CloseBraceToken {
+		parentInFor := p.inFor
+		p.inFor = false
+		expr.X = p.parseExpression(OpAssign)
+		p.inFor = parentInFor
+	}
+	if p.tt != CloseBraceToken {
+		p.fail("JSX expression", CloseBraceToken)
+		return nil
+	}
+	expr.Span = Span{start, p.l.r.Offset()}
+	return expr
+}
+
+// jsxNameString returns the name of a JSX element.
+func jsxNameString(name IExpr) string {
+	switch name := name.(type) {
+	case *JSXName:
+		return string(name.Data)
+	case *Var:
+		return string(name.Data)
+	case *LiteralExpr:
+		return string(name.Data)
+	case *DotExpr:
+		return jsxNameString(name.X) + "." + string(name.Y.Data)
+	}
+	return ""
+}
+
 func (p *Parser) parseArguments() (args Args) {
 	// assume we're on (
+	start := p.offset()
 	p.next()
 	args.List = make([]Arg, 0, 4)
 	for {
+		argStart := p.offset()
 		rest := p.tt == EllipsisToken
 		if rest {
 			p.next()
@@ -1430,16 +2540,31 @@ func (p *Parser) parseArguments() (args Args) {
 		args.List = append(args.List, Arg{
 			Value: p.parseExpression(OpAssign),
 			Rest:  rest,
+			Span:  p.span(argStart),
 		})
 		if p.tt == CommaToken {
 			p.next()
 		}
 	}
 	p.consume("arguments", CloseParenToken)
+	args.Span = p.span(start)
 	return
 }
 
-func (p *Parser) parseAsyncArrowFunc() (arrowFunc *ArrowFunc) {
+// isImportCallArgs returns true if the arguments are valid for an import call, which are a module specifier and optional options.
+func isImportCallArgs(args Args) bool {
+	if len(args.List) == 0 || 2 < len(args.List) {
+		return false
+	}
+	for _, arg := range args.List {
+		if arg.Rest {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Parser) parseAsyncArrowFunc(start int) (arrowFunc *ArrowFunc) {
 	// expect we're at Identifier or Yield or (
 	arrowFunc = &ArrowFunc{}
 	parent := p.enterScope(&arrowFunc.Body.Scope, true)
@@ -1447,9 +2572,11 @@ func (p *Parser) parseAsyncArrowFunc() (arrowFunc *ArrowFunc) {
 	p.await, p.yield = true, false
 
 	if IsIdentifier(p.tt) || !p.yield && p.tt == YieldToken {
-		ref, _ := p.scope.Declare(ArgumentDecl, p.data) // cannot fail
+		refSpan := p.tokenSpan()
+		ref, _ := p.declare(ArgumentDecl, p.data, refSpan) // cannot fail
 		p.next()
-		arrowFunc.Params.List = []BindingElement{{Binding: ref}}
+		arrowFunc.Params.List = []BindingElement{{Binding: ref, Span: refSpan}}
+		arrowFunc.Params.Span = refSpan
 	} else {
 		arrowFunc.Params = p.parseFuncParams("arrow function")
 
@@ -1461,22 +2588,24 @@ func (p *Parser) parseAsyncArrowFunc() (arrowFunc *ArrowFunc) {
 
 	p.await, p.yield = true, parentYield
 	arrowFunc.Async = true
-	arrowFunc.Body.List = p.parseArrowFuncBody()
+	p.parseArrowFuncBody(arrowFunc)
+	arrowFunc.Span = p.span(start)
 
 	p.await, p.yield = parentAwait, parentYield
 	p.exitScope(parent)
 	return
 }
 
-func (p *Parser) parseIdentifierArrowFunc(v *Var) (arrowFunc *ArrowFunc) {
+func (p *Parser) parseIdentifierArrowFunc(v *Var, start int) (arrowFunc *ArrowFunc) {
 	// expect we're at =>
 	arrowFunc = &ArrowFunc{}
 	parent := p.enterScope(&arrowFunc.Body.Scope, true)
 	parentAwait, parentYield := p.await, p.yield
 
+	paramSpan := Span{start, p.end}
 	if 1 < v.Uses {
 		v.Uses--
-		v, _ = p.scope.Declare(ArgumentDecl, parse.Copy(v.Data)) // cannot fail
+		v, _ = p.declare(ArgumentDecl, parse.Copy(v.Data), paramSpan) // cannot fail
 	} else {
 		// if v.Uses==1 it must be undeclared and be the last added
 		p.scope.Parent.Undeclared = p.scope.Parent.Undeclared[:len(p.scope.Parent.Undeclared)-1]
@@ -1485,15 +2614,17 @@ func (p *Parser) parseIdentifierArrowFunc(v *Var) (arrowFunc *ArrowFunc) {
 	}
 
 	p.await = false
-	arrowFunc.Params.List = []BindingElement{{v, nil}}
-	arrowFunc.Body.List = p.parseArrowFuncBody()
+	arrowFunc.Params.List = []BindingElement{{v, nil, paramSpan}}
+	arrowFunc.Params.Span = paramSpan
+	p.parseArrowFuncBody(arrowFunc)
+	arrowFunc.Span = p.span(start)
 
 	p.await, p.yield = parentAwait, parentYield
 	p.exitScope(parent)
 	return
 }
 
-func (p *Parser) parseArrowFuncBody() (list []IStmt) {
+func (p *Parser) parseArrowFuncBody(arrowFunc *ArrowFunc) {
 	// expect we're at arrow
 	if p.tt != ArrowToken {
 		p.fail("arrow function", ArrowToken)
@@ -1507,46 +2638,59 @@ func (p *Parser) parseArrowFuncBody() (list []IStmt) {
 	// mark undeclared vars as arguments in `function f(a=b){var b}` where the b's are different vars
 	p.scope.MarkFuncArgs()
 
+	start := p.offset()
+	body := &arrowFunc.Body
 	if p.tt == OpenBraceToken {
-		parentInFor := p.inFor
+		parentInFor, parentStrict := p.inFor, p.strict
 		p.inFor = false
 		p.yield = false
 		p.allowDirectivePrologue = true
-		list = p.parseStmtList("arrow function")
+		body.List = p.parseStmtList("arrow function")
 		p.inFor = parentInFor
+		arrowFunc.Strict = p.exitStrict(parentStrict, nil, &arrowFunc.Params)
 	} else {
-		list = []IStmt{&ReturnStmt{p.parseExpression(OpAssign)}}
+		body.List = []IStmt{&ReturnStmt{p.parseExpression(OpAssign), p.span(start)}}
+		arrowFunc.Strict = p.strict
 	}
-	return
+	body.Span = p.span(start)
 }
 
-func (p *Parser) parseIdentifierExpression(prec OpPrec, ident []byte) IExpr {
+func (p *Parser) parseIdentifierExpression(prec OpPrec, ident []byte, start int) IExpr {
 	var left IExpr
-	left = p.scope.Use(ident)
-	return p.parseExpressionSuffix(left, prec, OpPrimary)
+	left = p.use(ident, p.span(start))
+	return p.parseExpressionSuffix(left, start, prec, OpPrimary)
 }
 
-func (p *Parser) parseAsyncExpression(prec OpPrec, async []byte) IExpr {
+func (p *Parser) parseAsyncExpression(prec OpPrec, async []byte, start int) IExpr {
 	// assume we're at a token after async
 	var left IExpr
 	precLeft := OpPrimary
+	if p.o.TypeScript && !p.prevLT && prec <= OpAssign && p.tt == LtToken {
+		// generic async arrow function
+		state := p.save()
+		p.skipTypeParams()
+		if p.err == nil && p.tt == OpenParenToken {
+			return p.parseParenthesizedExpressionOrArrowFunc(prec, async, start)
+		}
+		p.restore(state)
+	}
 	if !p.prevLT && p.tt == FunctionToken {
 		// primary expression
-		left = p.parseAsyncFuncExpr()
+		left = p.parseAsyncFuncExpr(start)
 	} else if !p.prevLT && prec <= OpAssign && (p.tt == OpenParenToken || IsIdentifier(p.tt) || !p.yield && p.tt == YieldToken || p.tt == AwaitToken) {
 		// async arrow function expression
 		if p.tt == AwaitToken {
 			p.fail("arrow function")
 			return nil
 		} else if p.tt == OpenParenToken {
-			return p.parseParenthesizedExpressionOrArrowFunc(prec, async)
+			return p.parseParenthesizedExpressionOrArrowFunc(prec, async, start)
 		}
-		left = p.parseAsyncArrowFunc()
+		left = p.parseAsyncArrowFunc(start)
 		precLeft = OpAssign
 	} else {
-		left = p.scope.Use(async)
+		left = p.use(async, p.span(start))
 	}
-	return p.parseExpressionSuffix(left, prec, precLeft)
+	return p.parseExpressionSuffix(left, start, prec, precLeft)
 }
 
 // parseExpression parses an expression that has a precedence of prec or higher.
@@ -1563,29 +2707,33 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 		if p.tt == ErrorToken {
 			p.fail("regular expression")
 			return nil
+		} else if p.o.Tokens {
+			p.tokens = p.tokens[:len(p.tokens)-1] // replace the / or /= token
+			p.addToken()
 		}
 	}
 
 	var left IExpr
 	precLeft := OpPrimary
+	start := p.offset()
 
 	if IsIdentifier(p.tt) && p.tt != AsyncToken {
-		left = p.scope.Use(p.data)
+		left = p.use(p.data, p.tokenSpan())
 		p.next()
-		suffix := p.parseExpressionSuffix(left, prec, precLeft)
+		suffix := p.parseExpressionSuffix(left, start, prec, precLeft)
 		p.exprLevel--
 		return suffix
 	} else if IsNumeric(p.tt) {
-		left = &LiteralExpr{p.tt, p.data}
+		left = &LiteralExpr{p.tt, p.data, p.tokenSpan()}
 		p.next()
-		suffix := p.parseExpressionSuffix(left, prec, precLeft)
+		suffix := p.parseExpressionSuffix(left, start, prec, precLeft)
 		p.exprLevel--
 		return suffix
 	}
 
 	switch tt := p.tt; tt {
 	case StringToken, ThisToken, NullToken, TrueToken, FalseToken, RegExpToken:
-		left = &LiteralExpr{p.tt, p.data}
+		left = &LiteralExpr{p.tt, p.data, p.tokenSpan()}
 		p.next()
 	case OpenBracketToken:
 		parentInFor := p.inFor
@@ -1606,14 +2754,16 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 			p.next()
 			parentInFor := p.inFor
 			p.inFor = false
-			left = &GroupExpr{p.parseExpression(OpExpr)}
+			group := &GroupExpr{X: p.parseExpression(OpExpr)}
 			p.inFor = parentInFor
 			if !p.consume("expression", CloseParenToken) {
 				return nil
 			}
+			group.Span = p.span(start)
+			left = group
 			break
 		}
-		suffix := p.parseParenthesizedExpressionOrArrowFunc(prec, nil)
+		suffix := p.parseParenthesizedExpressionOrArrowFunc(prec, nil, start)
 		p.exprLevel--
 		return suffix
 	case NotToken, BitNotToken, TypeofToken, VoidToken, DeleteToken:
@@ -1622,7 +2772,7 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 			return nil
 		}
 		p.next()
-		left = &UnaryExpr{tt, p.parseExpression(OpUnary)}
+		left = &UnaryExpr{tt, p.parseExpression(OpUnary), p.span(start)}
 		precLeft = OpUnary
 	case AddToken:
 		if OpUnary < prec {
@@ -1630,7 +2780,7 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 			return nil
 		}
 		p.next()
-		left = &UnaryExpr{PosToken, p.parseExpression(OpUnary)}
+		left = &UnaryExpr{PosToken, p.parseExpression(OpUnary), p.span(start)}
 		precLeft = OpUnary
 	case SubToken:
 		if OpUnary < prec {
@@ -1638,7 +2788,7 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 			return nil
 		}
 		p.next()
-		left = &UnaryExpr{NegToken, p.parseExpression(OpUnary)}
+		left = &UnaryExpr{NegToken, p.parseExpression(OpUnary), p.span(start)}
 		precLeft = OpUnary
 	case IncrToken:
 		if OpUpdate < prec {
@@ -1646,7 +2796,7 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 			return nil
 		}
 		p.next()
-		left = &UnaryExpr{PreIncrToken, p.parseExpression(OpUnary)}
+		left = &UnaryExpr{PreIncrToken, p.parseExpression(OpUnary), p.span(start)}
 		precLeft = OpUnary
 	case DecrToken:
 		if OpUpdate < prec {
@@ -1654,19 +2804,19 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 			return nil
 		}
 		p.next()
-		left = &UnaryExpr{PreDecrToken, p.parseExpression(OpUnary)}
+		left = &UnaryExpr{PreDecrToken, p.parseExpression(OpUnary), p.span(start)}
 		precLeft = OpUnary
 	case AwaitToken:
 		// either accepted as IdentifierReference or as AwaitExpression
 		if p.await && prec <= OpUnary {
 			p.next()
-			left = &UnaryExpr{tt, p.parseExpression(OpUnary)}
+			left = &UnaryExpr{tt, p.parseExpression(OpUnary), p.span(start)}
 			precLeft = OpUnary
 		} else if p.await {
 			p.fail("expression")
 			return nil
 		} else {
-			left = p.scope.Use(p.data)
+			left = p.use(p.data, p.tokenSpan())
 			p.next()
 		}
 	case NewToken:
@@ -1676,10 +2826,10 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 			if !p.consume("new.target expression", TargetToken) {
 				return nil
 			}
-			left = &NewTargetExpr{}
+			left = &NewTargetExpr{p.span(start)}
 			precLeft = OpMember
 		} else {
-			newExpr := &NewExpr{p.parseExpression(OpNew), nil}
+			newExpr := &NewExpr{X: p.parseExpression(OpNew)}
 			if p.tt == OpenParenToken {
 				args := p.parseArguments()
 				if len(args.List) != 0 {
@@ -1689,18 +2839,22 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 			} else {
 				precLeft = OpNew
 			}
+			newExpr.Span = p.span(start)
 			left = newExpr
 		}
 	case ImportToken:
 		// OpMember < prec does never happen
-		left = &LiteralExpr{p.tt, p.data}
+		left = &LiteralExpr{p.tt, p.data, p.tokenSpan()}
 		p.next()
 		if p.tt == DotToken {
 			p.next()
 			if !p.consume("import.meta expression", MetaToken) {
 				return nil
+			} else if p.o.Goal == ScriptGoal {
+				p.failAt(start, "import.meta is only allowed in modules")
+				return nil
 			}
-			left = &ImportMetaExpr{}
+			left = &ImportMetaExpr{p.span(start)}
 			precLeft = OpMember
 		} else if p.tt != OpenParenToken {
 			p.fail("import expression", OpenParenToken)
@@ -1713,7 +2867,7 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 		}
 	case SuperToken:
 		// OpMember < prec does never happen
-		left = &LiteralExpr{p.tt, p.data}
+		left = &LiteralExpr{p.tt, p.data, p.tokenSpan()}
 		p.next()
 		if OpCall < prec && p.tt != DotToken && p.tt != OpenBracketToken {
 			p.fail("super expression", OpenBracketToken, DotToken)
@@ -1742,20 +2896,24 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 					yieldExpr.X = p.parseExpression(OpAssign)
 				}
 			}
+			yieldExpr.Span = p.span(start)
 			left = &yieldExpr
 			precLeft = OpAssign
 		} else if p.yield {
 			p.fail("expression")
 			return nil
 		} else {
-			left = p.scope.Use(p.data)
+			left = p.use(p.data, p.tokenSpan())
 			p.next()
 		}
 	case AsyncToken:
 		async := p.data
 		p.next()
-		left = p.parseAsyncExpression(prec, async)
-	case ClassToken:
+		left = p.parseAsyncExpression(prec, async, start)
+	case ClassToken, AtToken:
+		if tt == AtToken && !p.parseClassDecorators("expression") {
+			return nil
+		}
 		parentInFor := p.inFor
 		p.inFor = false
 		left = p.parseClassExpr()
@@ -1768,19 +2926,60 @@ func (p *Parser) parseExpression(prec OpPrec) IExpr {
 	case TemplateToken, TemplateStartToken:
 		parentInFor := p.inFor
 		p.inFor = false
-		template := p.parseTemplateLiteral(precLeft)
+		template := p.parseTemplateLiteral(precLeft, start)
 		left = &template
 		p.inFor = parentInFor
+	case LtToken:
+		if !p.o.JSX && p.o.TypeScript {
+			if prec <= OpAssign {
+				// generic arrow function
+				state := p.save()
+				p.skipTypeParams()
+				if p.err == nil && p.tt == OpenParenToken {
+					suffix := p.parseParenthesizedExpressionOrArrowFunc(prec, nil, start)
+					p.exprLevel--
+					return suffix
+				}
+				p.restore(state)
+			}
+			if OpUnary < prec {
+				p.fail("expression")
+				return nil
+			}
+			// type assertion
+			p.next()
+			p.skipType()
+			if !p.consumeGt() {
+				p.fail("type assertion", GtToken)
+				return nil
+			}
+			left = p.parseExpression(OpUnary)
+			precLeft = OpUnary
+			break
+		} else if !p.o.JSX {
+			p.fail("expression")
+			return nil
+		}
+		parentInFor := p.inFor
+		p.inFor = false
+		p.nextJSX()
+		elem := p.parseJSXElement(start)
+		p.inFor = parentInFor
+		if elem == nil {
+			return nil
+		}
+		left = elem
+		p.next()
 	default:
 		p.fail("expression")
 		return nil
 	}
-	suffix := p.parseExpressionSuffix(left, prec, precLeft)
+	suffix := p.parseExpressionSuffix(left, start, prec, precLeft)
 	p.exprLevel--
 	return suffix
 }
 
-func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr {
+func (p *Parser) parseExpressionSuffix(left IExpr, start int, prec, precLeft OpPrec) IExpr {
 	for i := 0; ; i++ {
 		if 1000 < p.exprLevel+i {
 			p.failMessage("too many nested expressions")
@@ -1796,10 +2995,12 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpAssign)}
+			left = &BinaryExpr{tt, left, p.parseExpression(OpAssign), p.span(start)}
 			precLeft = OpAssign
 		case LtToken, LtEqToken, GtToken, GtEqToken, InToken, InstanceofToken:
-			if OpCompare < prec || p.inFor && tt == InToken {
+			if p.o.TypeScript && tt == LtToken && OpCall <= precLeft && p.tryTypeArgs() {
+				continue // type arguments of call
+			} else if OpCompare < prec || p.inFor && tt == InToken {
 				return left
 			} else if precLeft < OpCompare {
 				// can only fail after a yield or arrow function expression
@@ -1807,7 +3008,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpShift)}
+			left = &BinaryExpr{tt, left, p.parseExpression(OpShift), p.span(start)}
 			precLeft = OpCompare
 		case EqEqToken, NotEqToken, EqEqEqToken, NotEqEqToken:
 			if OpEquals < prec {
@@ -1818,7 +3019,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpCompare)}
+			left = &BinaryExpr{tt, left, p.parseExpression(OpCompare), p.span(start)}
 			precLeft = OpEquals
 		case AndToken:
 			if OpAnd < prec {
@@ -1828,7 +3029,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpBitOr)}
+			left = &BinaryExpr{tt, left, p.parseExpression(OpBitOr), p.span(start)}
 			precLeft = OpAnd
 		case OrToken:
 			if OpOr < prec {
@@ -1838,7 +3039,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpAnd)}
+			left = &BinaryExpr{tt, left, p.parseExpression(OpAnd), p.span(start)}
 			precLeft = OpOr
 		case NullishToken:
 			if OpCoalesce < prec {
@@ -1848,7 +3049,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpBitOr)}
+			left = &BinaryExpr{tt, left, p.parseExpression(OpBitOr), p.span(start)}
 			precLeft = OpCoalesce
 		case DotToken:
 			// OpMember < prec does never happen
@@ -1868,7 +3069,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 			if p.tt != PrivateIdentifierToken {
 				p.tt = IdentifierToken
 			}
-			left = &DotExpr{left, LiteralExpr{p.tt, p.data}, exprPrec, false}
+			left = &DotExpr{left, LiteralExpr{p.tt, p.data, p.tokenSpan()}, exprPrec, false, Span{start, p.l.r.Offset()}}
 			p.next()
 			if precLeft < OpMember {
 				precLeft = OpCall
@@ -1877,7 +3078,10 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 			}
 		case OpenBracketToken:
 			// OpMember < prec does never happen
-			if precLeft < OpCall {
+			if precLeft < OpCall && p.prevLT {
+				// the left-hand side cannot be indexed, such as x++ or a type assertion, so that a semicolon is inserted
+				return left
+			} else if precLeft < OpCall {
 				p.fail("expression")
 				return nil
 			}
@@ -1888,18 +3092,20 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 			}
 			parentInFor := p.inFor
 			p.inFor = false
-			left = &IndexExpr{left, p.parseExpression(OpExpr), exprPrec, false}
+			indexExpr := &IndexExpr{left, p.parseExpression(OpExpr), exprPrec, false, Span{}}
 			p.inFor = parentInFor
 			if !p.consume("index expression", CloseBracketToken) {
 				return nil
 			}
+			indexExpr.Span = p.span(start)
+			left = indexExpr
 			if precLeft < OpMember {
 				precLeft = OpCall
 			} else {
 				precLeft = OpMember
 			}
 		case OpenParenToken:
-			if OpCall < prec {
+			if OpCall < prec || precLeft < OpCall && p.prevLT {
 				return left
 			} else if precLeft < OpCall {
 				p.fail("expression")
@@ -1907,18 +3113,25 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 			}
 			parentInFor := p.inFor
 			p.inFor = false
-			left = &CallExpr{left, p.parseArguments(), false}
+			args := p.parseArguments()
+			if literal, ok := left.(*LiteralExpr); ok && literal.TokenType == ImportToken && !isImportCallArgs(args) {
+				p.failMessage("import expression expects a module specifier and optional options")
+				return nil
+			}
+			left = &CallExpr{left, args, false, p.span(start)}
 			precLeft = OpCall
 			p.inFor = parentInFor
 		case TemplateToken, TemplateStartToken:
 			// OpMember < prec does never happen
-			if precLeft < OpCall {
+			if precLeft < OpCall && p.prevLT {
+				return left
+			} else if precLeft < OpCall {
 				p.fail("expression")
 				return nil
 			}
 			parentInFor := p.inFor
 			p.inFor = false
-			template := p.parseTemplateLiteral(precLeft)
+			template := p.parseTemplateLiteral(precLeft, start)
 			template.Tag = left
 			left = &template
 			if precLeft < OpMember {
@@ -1933,24 +3146,26 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 			}
 			p.next()
 			if p.tt == OpenParenToken {
-				left = &CallExpr{left, p.parseArguments(), true}
+				left = &CallExpr{left, p.parseArguments(), true, p.span(start)}
 			} else if p.tt == OpenBracketToken {
 				p.next()
-				left = &IndexExpr{left, p.parseExpression(OpExpr), OpCall, true}
+				indexExpr := &IndexExpr{left, p.parseExpression(OpExpr), OpCall, true, Span{}}
 				if !p.consume("optional chaining expression", CloseBracketToken) {
 					return nil
 				}
+				indexExpr.Span = p.span(start)
+				left = indexExpr
 			} else if p.tt == TemplateToken || p.tt == TemplateStartToken {
-				template := p.parseTemplateLiteral(precLeft)
+				template := p.parseTemplateLiteral(precLeft, start)
 				template.Prec = OpCall
 				template.Tag = left
 				template.Optional = true
 				left = &template
 			} else if IsIdentifierName(p.tt) {
-				left = &DotExpr{left, LiteralExpr{IdentifierToken, p.data}, OpCall, true}
+				left = &DotExpr{left, LiteralExpr{IdentifierToken, p.data, p.tokenSpan()}, OpCall, true, Span{start, p.l.r.Offset()}}
 				p.next()
 			} else if p.tt == PrivateIdentifierToken {
-				left = &DotExpr{left, LiteralExpr{p.tt, p.data}, OpCall, true}
+				left = &DotExpr{left, LiteralExpr{p.tt, p.data, p.tokenSpan()}, OpCall, true, Span{start, p.l.r.Offset()}}
 				p.next()
 			} else {
 				p.fail("optional chaining expression", IdentifierToken, OpenParenToken, OpenBracketToken, TemplateToken)
@@ -1965,7 +3180,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &UnaryExpr{PostIncrToken, left}
+			left = &UnaryExpr{PostIncrToken, left, p.span(start)}
 			precLeft = OpUpdate
 		case DecrToken:
 			if p.prevLT || OpUpdate < prec {
@@ -1975,7 +3190,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &UnaryExpr{PostDecrToken, left}
+			left = &UnaryExpr{PostDecrToken, left, p.span(start)}
 			precLeft = OpUpdate
 		case ExpToken:
 			if OpExp < prec {
@@ -1985,7 +3200,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpExp)}
+			left = &BinaryExpr{tt, left, p.parseExpression(OpExp), p.span(start)}
 			precLeft = OpExp
 		case MulToken, DivToken, ModToken:
 			if OpMul < prec {
@@ -1995,7 +3210,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpExp)}
+			left = &BinaryExpr{tt, left, p.parseExpression(OpExp), p.span(start)}
 			precLeft = OpMul
 		case AddToken, SubToken:
 			if OpAdd < prec {
@@ -2005,7 +3220,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpMul)}
+			left = &BinaryExpr{tt, left, p.parseExpression(OpMul), p.span(start)}
 			precLeft = OpAdd
 		case LtLtToken, GtGtToken, GtGtGtToken:
 			if OpShift < prec {
@@ -2015,7 +3230,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpAdd)}
+			left = &BinaryExpr{tt, left, p.parseExpression(OpAdd), p.span(start)}
 			precLeft = OpShift
 		case BitAndToken:
 			if OpBitAnd < prec {
@@ -2025,7 +3240,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpEquals)}
+			left = &BinaryExpr{tt, left, p.parseExpression(OpEquals), p.span(start)}
 			precLeft = OpBitAnd
 		case BitXorToken:
 			if OpBitXor < prec {
@@ -2035,7 +3250,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpBitAnd)}
+			left = &BinaryExpr{tt, left, p.parseExpression(OpBitAnd), p.span(start)}
 			precLeft = OpBitXor
 		case BitOrToken:
 			if OpBitOr < prec {
@@ -2045,7 +3260,7 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 			p.next()
-			left = &BinaryExpr{tt, left, p.parseExpression(OpBitXor)}
+			left = &BinaryExpr{tt, left, p.parseExpression(OpBitXor), p.span(start)}
 			precLeft = OpBitOr
 		case QuestionToken:
 			if OpAssign < prec {
@@ -2054,13 +3269,19 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				p.fail("expression")
 				return nil
 			}
+			parentCondLevel, parentConds := p.condLevel, p.conds
+			if p.condLevel != p.l.level {
+				p.condLevel, p.conds = p.l.level, 0
+			}
+			p.conds++
 			p.next()
 			ifExpr := p.parseExpression(OpAssign)
+			p.condLevel, p.conds = parentCondLevel, parentConds
 			if !p.consume("conditional expression", ColonToken) {
 				return nil
 			}
 			elseExpr := p.parseExpression(OpAssign)
-			left = &CondExpr{left, ifExpr, elseExpr}
+			left = &CondExpr{left, ifExpr, elseExpr, p.span(start)}
 			precLeft = OpAssign
 		case CommaToken:
 			if OpExpr < prec {
@@ -2069,9 +3290,10 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 			p.next()
 			if commaExpr, ok := left.(*CommaExpr); ok {
 				commaExpr.List = append(commaExpr.List, p.parseExpression(OpAssign))
+				commaExpr.Span = p.span(start)
 				i-- // adjust expression nesting limit
 			} else {
-				left = &CommaExpr{[]IExpr{left, p.parseExpression(OpAssign)}}
+				left = &CommaExpr{[]IExpr{left, p.parseExpression(OpAssign)}, p.span(start)}
 			}
 			precLeft = OpExpr
 		case ArrowToken:
@@ -2089,8 +3311,25 @@ func (p *Parser) parseExpressionSuffix(left IExpr, prec, precLeft OpPrec) IExpr
 				return nil
 			}
 
-			left = p.parseIdentifierArrowFunc(v)
+			left = p.parseIdentifierArrowFunc(v, start)
 			precLeft = OpAssign
+		case NotToken:
+			if !p.o.TypeScript || p.prevLT || precLeft < OpCall {
+				return left
+			}
+			p.next() // non-null assertion
+		case AsToken, IdentifierToken:
+			if !p.o.TypeScript || p.prevLT || tt == IdentifierToken && !p.isWord("satisfies") {
+				return left
+			} else if OpCompare < prec {
+				return left
+			} else if precLeft < OpCompare {
+				p.fail("expression")
+				return nil
+			}
+			p.next()
+			p.skipType()
+			precLeft = OpCompare
 		default:
 			return left
 		}
@@ -2102,34 +3341,42 @@ func (p *Parser) parseAssignmentExpression() IExpr {
 	if p.assumeArrowFunc && p.isIdentifierReference(p.tt) {
 		tt := p.tt
 		data := p.data
+		start := p.offset()
 		p.next()
-		if p.tt == EqToken || p.tt == CommaToken || p.tt == CloseParenToken || p.tt == CloseBraceToken || p.tt == CloseBracketToken {
+		span := p.span(start)
+		if p.o.TypeScript && p.tt == QuestionToken {
+			if next, _ := p.peek(); next == ColonToken || next == CommaToken || next == CloseParenToken || next == EqToken {
+				p.next() // optional parameter
+			}
+		}
+		if p.tt == EqToken || p.tt == CommaToken || p.tt == CloseParenToken || p.tt == CloseBraceToken || p.tt == CloseBracketToken || p.o.TypeScript && p.tt == ColonToken {
 			var ok bool
 			var left IExpr
-			left, ok = p.scope.Declare(ArgumentDecl, data)
+			left, ok = p.declare(ArgumentDecl, data, span)
 			if ok {
 				p.assumeArrowFunc = false
-				left = p.parseExpressionSuffix(left, OpAssign, OpPrimary)
+				left = p.parseExpressionSuffix(left, start, OpAssign, OpPrimary)
 				p.assumeArrowFunc = true
 				return left
 			}
 		}
 		p.assumeArrowFunc = false
 		if tt == AsyncToken {
-			return p.parseAsyncExpression(OpAssign, data)
+			return p.parseAsyncExpression(OpAssign, data, start)
 		}
-		return p.parseIdentifierExpression(OpAssign, data)
+		return p.parseIdentifierExpression(OpAssign, data, start)
 	} else if p.tt != OpenBracketToken && p.tt != OpenBraceToken {
 		p.assumeArrowFunc = false
 	}
 	return p.parseExpression(OpAssign)
 }
 
-func (p *Parser) parseParenthesizedExpressionOrArrowFunc(prec OpPrec, async []byte) IExpr {
+func (p *Parser) parseParenthesizedExpressionOrArrowFunc(prec OpPrec, async []byte, start int) IExpr {
 	var left IExpr
 	precLeft := OpPrimary
 
 	// expect to be at (
+	paramsStart := p.offset()
 	p.next()
 
 	isAsync := async != nil
@@ -2142,17 +3389,17 @@ func (p *Parser) parseParenthesizedExpressionOrArrowFunc(prec OpPrec, async []by
 
 	var list []IExpr
 	var rest IExpr
+	var restStart int
+	typed := false // has TypeScript type annotations
 	for p.tt != CloseParenToken && p.tt != ErrorToken {
 		if p.tt == EllipsisToken && p.assumeArrowFunc {
+			restStart = p.offset()
 			p.next()
 			if isAsync {
 				rest = p.parseAssignmentExpression()
-				if p.tt == CommaToken {
-					p.next()
-				}
 			} else if p.isIdentifierReference(p.tt) {
 				var ok bool
-				rest, ok = p.scope.Declare(ArgumentDecl, p.data)
+				rest, ok = p.declare(ArgumentDecl, p.data, p.tokenSpan())
 				if !ok {
 					p.failMessage("identifier %s has already been declared", string(p.data))
 					return nil
@@ -2168,10 +3415,29 @@ func (p *Parser) parseParenthesizedExpressionOrArrowFunc(prec OpPrec, async []by
 				p.fail("arrow function")
 				return nil
 			}
+			if p.o.TypeScript && p.tt == ColonToken {
+				typed = true
+				p.parseTypeAnnotation()
+			}
+			if isAsync && p.tt == CommaToken {
+				p.next()
+			}
 			break
 		}
 
-		list = append(list, p.parseAssignmentExpression())
+		item := p.parseAssignmentExpression()
+		if p.o.TypeScript && p.tt == ColonToken && p.assumeArrowFunc {
+			// parameter with type annotation
+			typed = true
+			p.parseTypeAnnotation()
+			if p.tt == EqToken {
+				p.next()
+				p.assumeArrowFunc = false
+				item = &BinaryExpr{EqToken, item, p.parseExpression(OpAssign), p.span(item.Range().Start)}
+				p.assumeArrowFunc = true
+			}
+		}
+		list = append(list, item)
 		if p.tt != CommaToken {
 			break
 		}
@@ -2182,21 +3448,29 @@ func (p *Parser) parseParenthesizedExpressionOrArrowFunc(prec OpPrec, async []by
 		return nil
 	}
 	p.next()
+	if p.o.TypeScript && p.tt == ColonToken && p.assumeArrowFunc && prec <= OpAssign && p.tryArrowReturnType() {
+		typed = true
+	}
 	isArrowFunc := p.tt == ArrowToken && p.assumeArrowFunc
 	p.assumeArrowFunc, p.inFor = parentAssumeArrowFunc, parentInFor
+	if typed && !isArrowFunc {
+		p.fail("arrow function", ArrowToken)
+		return nil
+	}
 
 	if isArrowFunc {
 		parentAwait, parentYield := p.await, p.yield
 		p.await = isAsync
 
 		// arrow function
-		arrowFunc.Params = Params{List: make([]BindingElement, len(list))}
+		arrowFunc.Params = Params{List: make([]BindingElement, len(list)), Span: p.span(paramsStart)}
 		for i, item := range list {
 			arrowFunc.Params.List[i] = p.exprToBindingElement(item) // can not fail when assumArrowFunc is set
 		}
 		arrowFunc.Async = isAsync
 		arrowFunc.Params.Rest = p.exprToBinding(rest)
-		arrowFunc.Body.List = p.parseArrowFuncBody()
+		p.parseArrowFuncBody(arrowFunc)
+		arrowFunc.Span = p.span(start)
 
 		p.await, p.yield = parentAwait, parentYield
 		p.exitScope(parent)
@@ -2217,24 +3491,25 @@ func (p *Parser) parseParenthesizedExpressionOrArrowFunc(prec OpPrec, async []by
 			// call expression
 			args := Args{}
 			for _, item := range list {
-				args.List = append(args.List, Arg{Value: item, Rest: false})
+				args.List = append(args.List, Arg{Value: item, Rest: false, Span: item.Range()})
 			}
 			if rest != nil {
-				args.List = append(args.List, Arg{Value: rest, Rest: true})
+				args.List = append(args.List, Arg{Value: rest, Rest: true, Span: Span{restStart, rest.Range().End}})
 			}
-			left = p.scope.Use(async)
-			left = &CallExpr{left, args, false}
+			args.Span = p.span(paramsStart)
+			left = p.use(async, Span{start, start + len(async)})
+			left = &CallExpr{left, args, false, p.span(start)}
 			precLeft = OpCall
 		} else {
 			// parenthesized expression
 			if 1 < len(list) {
-				left = &GroupExpr{&CommaExpr{list}}
+				left = &GroupExpr{&CommaExpr{list, Span{list[0].Range().Start, list[len(list)-1].Range().End}}, p.span(start)}
 			} else {
-				left = &GroupExpr{list[0]}
+				left = &GroupExpr{list[0], p.span(start)}
 			}
 		}
 	}
-	return p.parseExpressionSuffix(left, prec, precLeft)
+	return p.parseExpressionSuffix(left, start, prec, precLeft)
 }
 
 // exprToBinding converts a CoverParenthesizedExpressionAndArrowParameterList into FormalParameters
@@ -2243,7 +3518,7 @@ func (p *Parser) exprToBinding(expr IExpr) (binding IBinding) {
 	if v, ok := expr.(*Var); ok {
 		binding = v
 	} else if array, ok := expr.(*ArrayExpr); ok {
-		bindingArray := BindingArray{}
+		bindingArray := BindingArray{Span: array.Span}
 		for _, item := range array.List {
 			if item.Spread {
 				// can only BindingIdentifier or BindingPattern
@@ -2252,11 +3527,12 @@ func (p *Parser) exprToBinding(expr IExpr) (binding IBinding) {
 			}
 			var bindingElement BindingElement
 			bindingElement = p.exprToBindingElement(item.Value)
+			bindingElement.Span = item.Span
 			bindingArray.List = append(bindingArray.List, bindingElement)
 		}
 		binding = &bindingArray
 	} else if object, ok := expr.(*ObjectExpr); ok {
-		bindingObject := BindingObject{}
+		bindingObject := BindingObject{Span: object.Span}
 		for _, item := range object.List {
 			if item.Spread {
 				// can only be BindingIdentifier
@@ -2269,8 +3545,11 @@ func (p *Parser) exprToBinding(expr IExpr) (binding IBinding) {
 				bindingElement = p.exprToBindingElement(item.Value)
 			} else if item.Init != nil {
 				bindingElement.Default = item.Init
+				bindingElement.Span = Span{item.Value.Range().Start, item.Span.End}
+			} else {
+				bindingElement.Span = item.Value.Range()
 			}
-			bindingObject.List = append(bindingObject.List, BindingObjectItem{Key: item.Name, Value: bindingElement})
+			bindingObject.List = append(bindingObject.List, BindingObjectItem{Key: item.Name, Value: bindingElement, Span: item.Span})
 		}
 		binding = &bindingObject
 	}
@@ -2284,6 +3563,9 @@ func (p *Parser) exprToBindingElement(expr IExpr) (bindingElement BindingElement
 	} else {
 		bindingElement.Binding = p.exprToBinding(expr)
 	}
+	if expr != nil {
+		bindingElement.Span = expr.Range()
+	}
 	return
 }
 
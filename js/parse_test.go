@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/tdewolff/parse/v2"
 	"github.com/tdewolff/test"
@@ -117,6 +118,16 @@ func TestParse(t *testing.T) {
 		{"class A { get }", "Decl(class A Field(get))"},
 		{"class A { field static get method(){} }", "Decl(class A Field(field) Method(static get method Params() Stmt({ })))"},
 		{"class A { static { this.field = 5 } }", "Decl(class A Static(Stmt({ Stmt((this.field)=5) })))"},
+		{"@a class A {}", "Decl(@a class A)"},
+		{"@a @b.c @d(e) @(f+g) class A {}", "Decl(@a @(b.c) @(d(e)) @((f+g)) class A)"},
+		{"class A { @a field = 5; @b.c() static method() {} }", "Decl(class A Field(@a field = 5) Method(@((b.c)()) static method Params() Stmt({ })))"},
+		{"x = @a class {}", "Stmt(x=Decl(@a class))"},
+		{"@a export class A {}", "Stmt(export Decl(@a class A))"},
+		{"export @a class A {}", "Stmt(export Decl(@a class A))"},
+		{"export default @a class {}", "Stmt(export default Decl(@a class))"},
+		{"class A { @e accessor y = 2; static accessor #z; accessor 'w' }", "Decl(class A Field(@e accessor y = 2) Field(static accessor #z) Field(accessor w))"},
+		{"class A { accessor; accessor = 1; accessor() {} accessor\n x; static accessor }", "Decl(class A Field(accessor) Field(accessor = 1) Method(accessor Params() Stmt({ })) Field(accessor) Field(x) Field(static accessor))"},
+		{"class A { @a static constructor() {} @b 'constructor' = 1 }", "Decl(class A Method(@a static constructor Params() Stmt({ })) Field(@b constructor = 1))"},
 		//{"class A { get get get(){} }", "Decl(class A Definition(get) Method(get get Params() Stmt({ })))"}, // doesn't look like this should be supported
 		{"`tmpl`", "Stmt(`tmpl`)"},
 		{"`tmpl${x}`", "Stmt(`tmpl${x}`)"},
@@ -129,7 +140,12 @@ func TestParse(t *testing.T) {
 		{`import yield, {yield} from "pkg"`, `Stmt(import yield , { yield } from "pkg")`},
 		{`import {yield,} from "pkg"`, `Stmt(import { yield , } from "pkg")`},
 		{`import {"abc'def" as a} from "pkg"`, `Stmt(import { "abc'def" as a } from "pkg")`},
+		{`import a from "pkg" with {type: "json"}`, `Stmt(import a from "pkg" with {type: "json"})`},
+		{`import "pkg" with {"type": 'json', b: "c",}`, `Stmt(import "pkg" with {"type": 'json', b: "c"})`},
+		{`import {a} from "pkg" with {}`, `Stmt(import { a } from "pkg" with {})`},
 		{`export * from "pkg";`, `Stmt(export * from "pkg")`},
+		{`export * from "pkg" with {type: "json"}`, `Stmt(export * from "pkg" with {type: "json"})`},
+		{`export {a} from "pkg" with {type: "json"}`, `Stmt(export { a } from "pkg" with {type: "json"})`},
 		{`export * as for from "pkg"`, `Stmt(export * as for from "pkg")`},
 		{`export * as "abc'def" from "pkg"`, `Stmt(export * as "abc'def" from "pkg")`},
 		{`export {if, for as switch} from "pkg"`, `Stmt(export { if , for as switch } from "pkg")`},
@@ -283,6 +299,7 @@ func TestParse(t *testing.T) {
 		{"x = new import.meta", "Stmt(x=(new (import.meta)))"},
 		{"x = import(a)", "Stmt(x=(import(a)))"},
 		{"import('module')", "Stmt(import('module'))"},
+		{"x = import('module', {with: {type: 'json'}},)", "Stmt(x=(import('module', {with: {type: 'json'}})))"},
 		{"x = +a", "Stmt(x=(+a))"},
 		{"x = ++a", "Stmt(x=(++a))"},
 		{"x = -a", "Stmt(x=(-a))"},
@@ -334,6 +351,8 @@ func TestParse(t *testing.T) {
 		{"x = a+b+c", "Stmt(x=((a+b)+c))"},
 		{"x = a**b**c", "Stmt(x=(a**(b**c)))"},
 		{"a++ < b", "Stmt((a++)<b)"},
+		{"a++\n[b]", "Stmt(a++) Stmt([b])"},
+		{"a--\n(b)", "Stmt(a--) Stmt(b)"},
 		{"a&&b&&c", "Stmt((a&&b)&&c)"},
 		{"a||b||c", "Stmt((a||b)||c)"},
 		{"new new a(b)", "Stmt(new (new a(b)))"},
@@ -483,6 +502,16 @@ func TestParseError(t *testing.T) {
 		{"class A extends a b {}", "expected { instead of b in class declaration"},
 		{"class A{+", "expected Identifier, String, Numeric, or [ instead of + in method or field definition"},
 		{"class A{[a", "expected ] instead of EOF in method or field definition"},
+		{"@a", "expected class instead of EOF in decorator"},
+		{"@a function f(){}", "expected class instead of function in decorator"},
+		{"@1 class A {}", "expected Identifier or ( instead of 1 in decorator"},
+		{"@a.+ class A {}", "expected Identifier instead of + in decorator"},
+		{"@a export @b class A {}", "expected class instead of @ in export statement"},
+		{"if (a) @b class A {}", "unexpected @ in statement"},
+		{"class A { @a static {} }", "decorators are not valid on a class static block"},
+		{"class A { @a constructor() {} }", "decorators are not valid on a class constructor"},
+		{"class A { @a 'constructor'() {} }", "decorators are not valid on a class constructor"},
+		{"class A { accessor x() {} }", "unexpected ( in accessor field"},
 		{"var [...a", "expected ] instead of EOF in array binding pattern"},
 		{"var [a", "expected , or ] instead of EOF in array binding pattern"},
 		{"var [a]", "expected = instead of EOF in var statement"},
@@ -550,6 +579,18 @@ func TestParseError(t *testing.T) {
 		{"export {yield as", "expected Identifier or String instead of EOF in export statement"},
 		{"export {} from", "expected String instead of EOF in export statement"},
 		{"export {} from", "expected String instead of EOF in export statement"},
+		{"import a from 'b' with", "expected { instead of EOF in import statement"},
+		{"import a from 'b' with {type", "expected : instead of EOF in import statement"},
+		{"import a from 'b' with {type: json}", "expected String instead of json in import statement"},
+		{"import a from 'b' with {type: 'json' c: 'd'}", "expected , or } instead of c in import statement"},
+		{"import a from 'b' with {5: 'json'}", "expected Identifier, String, or } instead of 5 in import statement"},
+		{"import a from 'b' with {type: 'json', type: 'css'}", "duplicate import attribute key type"},
+		{"export * from 'b' with {type: 'json', 'type': 'css'}", "duplicate import attribute key type"},
+		{"export * from 'b' with {", "expected Identifier, String, or } instead of EOF in export statement"},
+		{"import a from 'b' assert {type: 'json'}", "unexpected { in expression"},
+		{"import()", "import expression expects a module specifier and optional options"},
+		{"x = import(a, b, c)", "import expression expects a module specifier and optional options"},
+		{"x = import(...a)", "import expression expects a module specifier and optional options"},
 		{"export async", "expected function instead of EOF in export statement"},
 
 		// no declarations
@@ -691,7 +732,7 @@ func TestParseError(t *testing.T) {
 
 		// other
 		{"\x00", "unexpected 0x00"},
-		{"@", "unexpected @"},
+		{"\\", "unexpected \\"},
 		{"\u200F", "unexpected U+200F"},
 		{"\u2010", "unexpected \u2010"},
 		{"a=\u2010", "unexpected \u2010 in expression"},
@@ -712,6 +753,45 @@ func TestParseError(t *testing.T) {
 	}
 }
 
+func TestParseErrorRecovery(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+		errs     []string
+	}{
+		{"a = ;\nb()", "Stmt(bad a = ;) Stmt(b())", []string{"unexpected ; in expression"}},
+		{"var x = {b: }\ny()", "Stmt(bad var x = {b: }) Stmt(y())", []string{"unexpected } in expression"}},
+		{"function f() { a = ; return 5 }\nc", "Decl(function f Params() Stmt({ Stmt(bad a = ;) Stmt(return 5) })) Stmt(c)", []string{"unexpected ; in expression"}},
+		{"if (a { b }\nvar c = 1", "Stmt(bad if (a { b }) Decl(var Binding(c = 1))", []string{"expected ) instead of { in if statement"}},
+		{"f(function(){ x = ; })", "Stmt(f(Decl(function Params() Stmt({ Stmt(bad x = ;) }))))", []string{"unexpected ; in expression"}},
+		{"a \\ b; c", "Stmt(a) Stmt(bad \\ b;) Stmt(c)", []string{"unexpected \\"}},
+		{"x = 'abc\nvar y", "Stmt(bad x = 'abc) Decl(var Binding(y))", []string{"unterminated string literal"}},
+		{"}} a;", "Stmt(bad }} a;)", []string{"unexpected } in expression"}},
+		{"import x; a=", "Stmt(bad import x;) Stmt(bad a=)", []string{"expected from instead of ; in import statement", "unexpected EOF in expression"}},
+		{"{a", "Stmt(bad {a)", []string{"unexpected EOF"}},
+		{"a; b", "Stmt(a) Stmt(b)", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{ErrorRecovery: true})
+			test.String(t, ast.String(), tt.expected)
+
+			errs := []string{}
+			if err != nil {
+				for _, e := range err.(ErrorList) {
+					errs = append(errs, e.Message)
+				}
+			}
+			test.T(t, len(errs), len(tt.errs), "number of errors")
+			for i, e := range tt.errs {
+				if i < len(errs) {
+					test.String(t, errs[i], e)
+				}
+			}
+		})
+	}
+}
+
 type ScopeVars struct {
 	bound, uses string
 	scopes      int
@@ -1057,6 +1137,329 @@ func TestScope(t *testing.T) {
 	test.T(t, ast.List[4].(*BlockStmt).List[0].(*BlockStmt).Scope.String(), "Scope{Declared: [], Undeclared: [Var{NoDecl d 1 2}]}")
 }
 
+type spanVisitor struct {
+	t     *testing.T
+	src   string
+	stack []Span
+}
+
+func (v *spanVisitor) Enter(n INode) IVisitor {
+	if _, ok := n.(*Var); ok {
+		return v // spans of variables are at their first occurrence
+	}
+	span := n.Range()
+	if span.Start < 0 || span.End < span.Start || len(v.src) < span.End {
+		v.t.Errorf("%T has invalid span %v", n, span)
+	} else if 0 < len(v.stack) {
+		parent := v.stack[len(v.stack)-1]
+		if span.Start < parent.Start || parent.End < span.End {
+			v.t.Errorf("%T span %v (%q) outside of parent span %v", n, span, v.src[span.Start:span.End], parent)
+		}
+	}
+	v.stack = append(v.stack, span)
+	return v
+}
+
+func (v *spanVisitor) Exit(n INode) {
+	if _, ok := n.(*Var); !ok {
+		v.stack = v.stack[:len(v.stack)-1]
+	}
+}
+
+func TestParseSpan(t *testing.T) {
+	var tests = []struct {
+		js    string
+		spans []string
+	}{
+		{"a = b + c;", []string{"a = b + c"}},
+		{"if (a) b; else { c }", []string{"if (a) b; else { c }"}},
+		{" x.y[z](1, ...w) ", []string{"x.y[z](1, ...w)"}},
+		{"for (let i = 0; i < 5; i++) ;\nwhile(a) b()", []string{"for (let i = 0; i < 5; i++)", "while(a) b()"}},
+		{"var [a, , b = 5] = c, {d: e} = f", []string{"var [a, , b = 5] = c, {d: e} = f"}},
+		{"async (a, b) => a; async x => x;(c) => { return c }", []string{"async (a, b) => a", "async x => x", "(c) => { return c }"}},
+		{"function f(a, ...b) { 'use strict' }\nclass A extends B { static x = 1; m() {} }", []string{"function f(a, ...b) { 'use strict' }", "class A extends B { static x = 1; m() {} }"}},
+		{"import a, {b as c} from 'd'; export {c}", []string{"import a, {b as c} from 'd'", "export {c}"}},
+		{"switch (a) { case 1: b; default: }\ntry {} catch (e) {} finally {}", []string{"switch (a) { case 1: b; default: }", "try {} catch (e) {} finally {}"}},
+		{"label: do a++; while (b)\n`x${y}z`; new A; (1, 2)", []string{"label: do a++; while (b)", "`x${y}z`", "new A", "(1, 2)"}},
+		{"a?.b?.[c]?.(d) ?? y ? z : !w", []string{"a?.b?.[c]?.(d) ?? y ? z : !w"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			if err != io.EOF {
+				test.Error(t, err)
+			}
+			test.T(t, ast.Range(), Span{0, len(tt.js)}, "module span")
+
+			spans := []string{}
+			for _, stmt := range ast.List {
+				span := stmt.Range()
+				spans = append(spans, tt.js[span.Start:span.End])
+			}
+			test.T(t, spans, tt.spans)
+
+			Walk(&spanVisitor{t: t, src: tt.js}, ast)
+		})
+	}
+
+	// line and column
+	src := "a = 1;\nif (b) {\n	c()\n}"
+	ast, err := Parse(parse.NewInputString(src), Options{})
+	if err != io.EOF {
+		test.Error(t, err)
+	}
+	call := ast.List[1].(*IfStmt).Body.(*BlockStmt).List[0].(*ExprStmt).Value
+	line, col, endLine, endCol := call.Range().Position([]byte(src))
+	test.T(t, []int{line, col, endLine, endCol}, []int{3, 2, 3, 5})
+	line, col, endLine, endCol = ast.List[1].Range().Position([]byte(src))
+	test.T(t, []int{line, col, endLine, endCol}, []int{2, 1, 4, 2})
+
+	// identifiers share their Var and thus the span of the first occurrence, the references have their own span unless with Options.NoRefs
+	src = "var a; if (a) a = 1"
+	for _, o := range []Options{{}, {NoRefs: true}} {
+		ast, err = Parse(parse.NewInputString(src), o)
+		test.Error(t, err)
+		a := ast.List[0].(*VarDecl).List[0].Binding.(*Var)
+		test.T(t, ast.List[1].(*IfStmt).Cond, IExpr(a))
+		test.T(t, a.Range(), Span{4, 5})
+		refs := []Span{}
+		for _, ref := range AnalyzeScopes(ast).Refs(a) {
+			refs = append(refs, ref.Span)
+		}
+		if !o.NoRefs {
+			test.T(t, refs, []Span{{4, 5}, {11, 12}, {14, 15}})
+		} else {
+			test.T(t, refs, []Span{{4, 5}, {4, 5}, {4, 5}})
+		}
+	}
+}
+
+func TestParseJSX(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"x = <div/>", "Stmt(x=<div />)"},
+		{"x = <></>", "Stmt(x=<></>)"},
+		{"x = <div className=\"a\" id={b} hidden>text {c}!</div>", "Stmt(x=<div className=\"a\" id={b} hidden>text {c}!</div>)"},
+		{"x = <A.B c={d} {...e}/>", "Stmt(x=<(A.B) c={d} {...e} />)"},
+		{"x = <this.A/>", "Stmt(x=<(this.A) />)"},
+		{"x = <my-el svg:rect xlink:href='y' data-z=<b/>></my-el>", "Stmt(x=<my-el svg:rect xlink:href='y' data-z=<b />></my-el>)"},
+		{"x = <a:b></a:b>", "Stmt(x=<a:b></a:b>)"},
+		{"x = <a>{/* empty */}{...b}<b>{c ? <d/> : e}</b></a>", "Stmt(x=<a>{}{...b}<b>{(c ? <d /> : e)}</b></a>)"},
+		{"x = <a>\n  text\n</a>", "Stmt(x=<a>\n  text\n</a>)"},
+		{"x = a < b ? <a /> : <b></b>", "Stmt(x=((a<b) ? <a /> : <b></b>))"},
+		{"for (<a/>; a in b;);", "Stmt(for <a /> ; (a in b) ; Stmt({ }))"},
+		{"f(<a/>, <b/>)", "Stmt(f(<a />, <b />))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{JSX: true})
+			if err != io.EOF {
+				test.Error(t, err)
+			}
+			test.String(t, ast.String(), tt.expected)
+			Walk(&spanVisitor{t: t, src: tt.js}, ast)
+		})
+	}
+
+	var errorTests = []struct {
+		js  string
+		err string
+	}{
+		{"x = <div>", "unexpected EOF in JSX element"},
+		{"x = <div></span>", "expected closing tag for div"},
+		{"x = <A.B></A>", "expected closing tag for A.B"},
+		{"x = <></div>", "expected closing tag for"},
+		{"x = <div></>", "expected closing tag for div"},
+		{"x = <div a=b/>", "expected String or { instead of b in JSX attribute"},
+		{"x = <div {a}/>", "expected ... instead of a in JSX spread attribute"},
+		{"x = <a-b.c/>", "expected Identifier instead of . in JSX element name"},
+		{"x = <div a={}/>", "unexpected } in expression"},
+		{"x = <div/", "expected > instead of EOF in JSX element"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.js, func(t *testing.T) {
+			_, err := Parse(parse.NewInputString(tt.js), Options{JSX: true})
+			test.That(t, err != io.EOF && err != nil)
+
+			e := err.Error()
+			if len(tt.err) < len(err.Error()) {
+				e = e[:len(tt.err)]
+			}
+			test.String(t, e, tt.err)
+		})
+	}
+
+	// JSX is opt-in
+	_, err := Parse(parse.NewInputString("x = <div/>"), Options{})
+	test.That(t, err != nil)
+}
+
+func TestParseAssertImports(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{`import a from "pkg" assert {type: "json"}`, `Stmt(import a from "pkg" assert {type: "json"})`},
+		{`import a from "pkg" with {type: "json"}`, `Stmt(import a from "pkg" with {type: "json"})`},
+		{`export * from "pkg" assert {type: "json"}`, `Stmt(export * from "pkg" assert {type: "json"})`},
+		{"import a from \"pkg\"\nassert(b)", `Stmt(import a from "pkg") Stmt(assert(b))`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{AssertImports: true})
+			if err != io.EOF {
+				test.Error(t, err)
+			}
+			test.String(t, ast.String(), tt.expected)
+		})
+	}
+}
+
+func TestParseGoal(t *testing.T) {
+	var tests = []struct {
+		js   string
+		goal Goal
+	}{
+		{"var static, let, yield, await; await(1)", ScriptGoal},
+		{"x = 010 + 08.5 + 09e1", ScriptGoal},
+		{"a <!-- b\n--> c", ScriptGoal},
+		{"function f(){ 'use strict' } var static", ScriptGoal},
+		{"{ 'use strict' } var static = 010", ScriptGoal},
+		{"x = 1; 'use strict'; var static", ScriptGoal},
+		{"x = { m(){ var static } }", ScriptGoal},
+		{"'use strict'; var a = 0.5", AnyGoal},
+		{"export var a; import b from 'b'; await c; x = import.meta", ModuleGoal},
+		{"a <!-- b", AnyGoal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			_, err := Parse(parse.NewInputString(tt.js), Options{Goal: tt.goal})
+			test.Error(t, err)
+		})
+	}
+}
+
+func TestParseGoalError(t *testing.T) {
+	var tests = []struct {
+		js     string
+		goal   Goal
+		err    string
+		column int
+	}{
+		{"'use strict'; var static", ScriptGoal, "unexpected strict mode reserved word static", 19},
+		{"'use strict'; x = 010", ScriptGoal, "legacy octal numbers are not allowed in strict mode", 19},
+		{"'use strict'\n08", ScriptGoal, "legacy octal numbers are not allowed in strict mode", 1},
+		{"function f(){ 'use strict'; return 07 }", ScriptGoal, "legacy octal numbers are not allowed in strict mode", 36},
+		{"x = { m(){ 'use strict'; return 010 } }", ScriptGoal, "legacy octal numbers are not allowed in strict mode", 33},
+		{"function static(){ 'use strict' }", ScriptGoal, "unexpected strict mode reserved word static", 10},
+		{"function f(a, yield){ 'use strict' }", ScriptGoal, "unexpected strict mode reserved word yield", 15},
+		{"(let) => { 'use strict' }", ScriptGoal, "unexpected strict mode reserved word let", 2},
+		{"class let {}", ScriptGoal, "unexpected strict mode reserved word let", 7},
+		{"x = class implements {}", ScriptGoal, "unexpected strict mode reserved word implements", 11},
+		{"class A { m(){ var interface } }", ScriptGoal, "unexpected strict mode reserved word interface", 20},
+		{"class A extends package {}", ScriptGoal, "unexpected strict mode reserved word package", 17},
+		{"var static", ModuleGoal, "unexpected strict mode reserved word static", 5},
+		{"function f(){ var await }", ModuleGoal, "unexpected reserved word await in module", 19},
+		{"x = 010", ModuleGoal, "legacy octal numbers are not supported in expression", 5},
+		{"import a from 'a'", ScriptGoal, "import statement is only allowed in modules", 1},
+		{"export var a", ScriptGoal, "export statement is only allowed in modules", 1},
+		{"x = import.meta", ScriptGoal, "import.meta is only allowed in modules", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			_, err := Parse(parse.NewInputString(tt.js), Options{Goal: tt.goal})
+			test.That(t, err != nil, "must return error")
+			test.String(t, err.(*parse.Error).Message, tt.err)
+			test.T(t, err.(*parse.Error).Column, tt.column)
+		})
+	}
+}
+
+func TestParseAnyGoalReservedWords(t *testing.T) {
+	// strict mode reserved words are only reported by the parser for an explicit goal or with early errors, and otherwise by Check
+	var tests = []string{
+		"'use strict'; var yield",
+		"'use strict'; var let",
+		"function f(){ 'use strict'; var static }",
+		"class A { m() { var yield } }",
+		"class let {}",
+		"function let(){ 'use strict' }",
+	}
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt), Options{})
+			test.Error(t, err)
+			test.That(t, Check(ast, []byte(tt)) != nil, "Check must return error")
+
+			_, err = Parse(parse.NewInputString(tt), Options{EarlyErrors: true})
+			test.That(t, err != nil, "must return error with early errors")
+		})
+	}
+}
+
+func TestParseGoalErrorTerminates(t *testing.T) {
+	// errors raised while declaring or using an identifier must stop parsing
+	var tests = []struct {
+		js     string
+		o      Options
+		err    string
+		column int
+	}{
+		{"\"use strict\"; a(b(yield)) => 1", Options{Goal: ScriptGoal}, "unexpected strict mode reserved word yield", 19},
+		{"a(b(yield)) => 1", Options{Goal: ModuleGoal}, "unexpected strict mode reserved word yield", 5},
+		{"class let q extends f {}", Options{EarlyErrors: true}, "unexpected strict mode reserved word let", 7},
+		{"class let q extends f {}; x = 1", Options{Goal: ScriptGoal, ErrorRecovery: true}, "unexpected strict mode reserved word let", 7},
+		{"x = <a b={yield}></a>", Options{Goal: ModuleGoal, JSX: true}, "unexpected strict mode reserved word yield", 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			done := make(chan error, 1)
+			go func() {
+				_, err := Parse(parse.NewInputString(tt.js), tt.o)
+				done <- err
+			}()
+			select {
+			case err := <-done:
+				test.That(t, err != nil, "must return error")
+				if errs, ok := err.(ErrorList); ok {
+					err = errs[0]
+				}
+				test.String(t, err.(*parse.Error).Message, tt.err)
+				test.T(t, err.(*parse.Error).Column, tt.column)
+			case <-time.After(5 * time.Second):
+				t.Fatal("parser does not terminate")
+			}
+		})
+	}
+}
+
+func TestParseStrict(t *testing.T) {
+	ast, err := Parse(parse.NewInputString("function f(){ g = () => 1 } function g(){ 'use strict'; h = () => 1 } x = { m(){} }; class A { m(){} }"), Options{Goal: ScriptGoal})
+	test.Error(t, err)
+	test.T(t, ast.Strict, false)
+	f := ast.List[0].(*FuncDecl)
+	test.T(t, f.Strict, false)
+	test.T(t, f.Body.List[0].(*ExprStmt).Value.(*BinaryExpr).Y.(*ArrowFunc).Strict, false)
+	g := ast.List[1].(*FuncDecl)
+	test.T(t, g.Strict, true)
+	test.T(t, g.Body.List[1].(*ExprStmt).Value.(*BinaryExpr).Y.(*ArrowFunc).Strict, true)
+	obj := ast.List[2].(*ExprStmt).Value.(*BinaryExpr).Y.(*ObjectExpr)
+	test.T(t, obj.List[0].Value.(*MethodDecl).Strict, false)
+	test.T(t, ast.List[3].(*ClassDecl).List[0].Method.Strict, true)
+
+	ast, err = Parse(parse.NewInputString("'use strict'; function f(){}"), Options{Goal: ScriptGoal})
+	test.Error(t, err)
+	test.T(t, ast.Strict, true)
+	test.T(t, ast.List[1].(*FuncDecl).Strict, true)
+
+	ast, err = Parse(parse.NewInputString("function f(){}"), Options{Goal: ModuleGoal})
+	test.Error(t, err)
+	test.T(t, ast.Strict, true)
+	test.T(t, ast.List[0].(*FuncDecl).Strict, true)
+}
+
 func TestParseInputError(t *testing.T) {
 	_, err := Parse(parse.NewInput(test.NewErrorReader(0)), Options{})
 	test.T(t, err, test.ErrPlain)
@@ -0,0 +1,1446 @@
+package js
+
+import (
+	"bytes"
+	"io"
+)
+
+// PrintOptions are the formatting options for the Printer. The zero value prints the same as the JS() methods of the nodes.
+type PrintOptions struct {
+	Pretty       bool   // print statements and class elements on separate lines and indent blocks
+	Indent       string // indentation for pretty printing, a tab if empty
+	Compact      bool   // leave out all whitespace and semicolons that are not needed, ignored when Pretty is set
+	NoSemicolons bool   // leave out semicolons at the end of statements where possible when pretty printing
+	Quote        byte   // quote for string literals, either ' or ", keeps the original quotes if zero
+	Comments     bool   // print the comments when printing an AST parsed with Options.Comments, comments within statements are printed inline, only legal comments are kept in compact style
+}
+
+type printStyle int
+
+const (
+	defaultStyle printStyle = iota
+	prettyStyle
+	compactStyle
+)
+
+const printBufferSize = 4096
+
+// Printer writes an AST back to JavaScript into an io.Writer, and optionally generates a source map that maps the output to the positions in the source the AST was parsed from. It buffers its output and writes to the writer in chunks.
+type Printer struct {
+	w     io.Writer
+	o     PrintOptions
+	style printStyle
+	err   error
+	buf   []byte
+
+	line, col int  // generated position
+	indent    int  // indentation level
+	soft      bool // space before the next write if needed to separate tokens
+	last      byte // last written byte
+	lastIdent bool // last token ends with identifier characters that are not identifier bytes, such as regular expression flags or an escape sequence
+
+	comments  CommentMap
+	commented INode // node of which the comments are printed by the caller on their own lines, other nodes get inline comments
+
+	// source map
+	sm      *SourceMap
+	source  int
+	lines   lineIndex
+	parent  Span // innermost node being printed that has a span
+	pending bool // map the next write to start
+	start   int
+	name    string
+	refs    map[*Var][]Span // spans of the occurrences of each variable in the source
+	nextRef map[*Var]int    // index of the next occurrence of each variable to be printed
+}
+
+// NewPrinter returns a new Printer that writes to w.
+func NewPrinter(w io.Writer, o PrintOptions) *Printer {
+	style := defaultStyle
+	if o.Pretty {
+		style = prettyStyle
+		if o.Indent == "" {
+			o.Indent = "\t"
+		}
+	} else if o.Compact {
+		style = compactStyle
+	}
+	return &Printer{
+		w:     w,
+		o:     o,
+		style: style,
+		buf:   make([]byte, 0, printBufferSize),
+	}
+}
+
+// SetSourceMap adds mappings to the source map while printing, where source is the index of the source in the source map as returned by SourceMap.AddSource, and src is the source code that was parsed. Identifiers are mapped to their own position with their name, unless the AST was parsed with Options.NoRefs in which case only the first occurrence of a variable is mapped.
+func (p *Printer) SetSourceMap(sm *SourceMap, source int, src []byte) {
+	p.sm = sm
+	p.source = source
+	p.lines = newLineIndex(src)
+}
+
+// Print writes the node to the writer, it returns the first error of the writer.
+func (p *Printer) Print(n INode) error {
+	if ast, ok := n.(*AST); ok {
+		if p.o.Comments {
+			p.comments = ast.CommentMap
+			p.commented = ast // comments at the end of the file
+		}
+		p.refs, p.nextRef = nil, nil
+		if p.sm != nil && 0 < len(ast.refs) {
+			p.refs, p.nextRef = map[*Var][]Span{}, map[*Var]int{}
+			for _, ref := range ast.refs {
+				p.refs[ref.v] = append(p.refs[ref.v], ref.Span)
+			}
+		}
+	}
+	p.print(n)
+	if p.style == prettyStyle && p.col != 0 {
+		if _, ok := n.(*AST); ok {
+			p.writeByte('\n')
+		}
+	}
+	p.flush()
+	return p.err
+}
+
+func (p *Printer) flush() {
+	if p.err == nil && 0 < len(p.buf) {
+		_, p.err = p.w.Write(p.buf)
+	}
+	p.buf = p.buf[:0]
+}
+
+// separate returns true if a space is needed between the last written byte and c to keep the tokens apart.
+func (p *Printer) separate(c byte) bool {
+	last := p.last
+	// a backslash starts an escape sequence in an identifier
+	if (0x80 <= last || identifierTable[last] || p.lastIdent) && (0x80 <= c || identifierTable[c] || c == '\\') {
+		return true
+	}
+	return (last == '+' || last == '-') && c == last || last == '/' && (c == '/' || c == '*') || last == '<' && (c == '!' || c == '<')
+}
+
+func (p *Printer) begin(c byte) {
+	if p.soft {
+		p.soft = false
+		if p.separate(c) {
+			p.buf = append(p.buf, ' ')
+			p.col++
+		}
+	}
+	if p.pending {
+		line, col := p.lines.position(p.start)
+		p.sm.AddMapping(p.line, p.col, p.source, line, col, p.name)
+		p.pending = false
+		p.name = ""
+	}
+}
+
+func (p *Printer) end() {
+	p.last = p.buf[len(p.buf)-1]
+	p.lastIdent = false
+	if printBufferSize <= len(p.buf) {
+		p.flush()
+	}
+}
+
+func (p *Printer) advance(c byte) {
+	if c == '\n' || c == '\r' {
+		p.line++
+		p.col = 0
+	} else if c < 0x80 || 0xC0 <= c && c < 0xF0 {
+		p.col++
+	} else if 0xF0 <= c {
+		p.col += 2
+	}
+}
+
+func (p *Printer) write(b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	p.begin(b[0])
+	for i, c := range b {
+		if c != '\r' || i+1 == len(b) || b[i+1] != '\n' {
+			p.advance(c)
+		}
+	}
+	p.buf = append(p.buf, b...)
+	p.end()
+}
+
+func (p *Printer) writeString(s string) {
+	if len(s) == 0 {
+		return
+	}
+	p.begin(s[0])
+	for i := 0; i < len(s); i++ {
+		p.advance(s[i]) // only used for ASCII without \r
+	}
+	p.buf = append(p.buf, s...)
+	p.end()
+}
+
+func (p *Printer) writeByte(c byte) {
+	p.begin(c)
+	p.advance(c)
+	p.buf = append(p.buf, c)
+	p.end()
+}
+
+// space writes a space, or only when needed in compact style.
+func (p *Printer) space() {
+	if p.style == compactStyle {
+		p.soft = true
+	} else {
+		p.writeByte(' ')
+	}
+}
+
+// comma writes a comma followed by a space except in compact style.
+func (p *Printer) comma() {
+	p.writeByte(',')
+	if p.style != compactStyle {
+		p.writeByte(' ')
+	}
+}
+
+// newline starts a new indented line when pretty printing.
+func (p *Printer) newline() {
+	p.writeByte('\n')
+	for i := 0; i < p.indent; i++ {
+		p.writeString(p.o.Indent)
+	}
+}
+
+// writeStringLiteral writes a string literal with the quote of the options.
+func (p *Printer) writeStringLiteral(b []byte) {
+	quote := p.o.Quote
+	if quote == 0 || len(b) < 2 || b[0] == quote {
+		p.write(b)
+		return
+	}
+	p.begin(quote)
+	p.buf = append(p.buf, quote)
+	p.advance(quote)
+	for i := 1; i < len(b)-1; i++ {
+		c := b[i]
+		if c == '\\' && i+2 < len(b) {
+			i++
+			if b[i] != b[0] {
+				p.buf = append(p.buf, '\\')
+				p.advance('\\')
+			}
+			c = b[i]
+		} else if c == quote {
+			p.buf = append(p.buf, '\\')
+			p.advance('\\')
+		}
+		p.buf = append(p.buf, c)
+		p.advance(c)
+	}
+	p.buf = append(p.buf, quote)
+	p.advance(quote)
+	p.end()
+}
+
+// mark maps the next write to the start of the span.
+func (p *Printer) mark(span Span) {
+	if p.sm != nil && span.Start < span.End {
+		p.pending = true
+		p.start = span.Start
+		p.name = ""
+	}
+}
+
+func (p *Printer) printVar(v *Var) {
+	if span := p.varSpan(v); p.sm != nil && span.Start < span.End && span.End <= len(p.lines.src) {
+		name := string(p.lines.src[span.Start:span.End])
+		if p.pending && p.start == span.Start {
+			p.name = name
+		} else if !p.pending && p.parent.Start <= span.Start && span.End <= p.parent.End {
+			p.pending = true
+			p.start = span.Start
+			p.name = name
+		}
+	}
+	name := v.Name()
+	p.write(name)
+	p.lastIdent = 0 < len(name) && name[len(name)-1] == '}'
+}
+
+// varSpan returns the span of the occurrence of the variable that is printed, which is the next occurrence in the node being printed. It returns the span of the first occurrence when the occurrences are unknown, which is when the AST was parsed with Options.NoRefs or when not printing an AST.
+func (p *Printer) varSpan(v *Var) Span {
+	spans := p.refs[v]
+	for i := p.nextRef[v]; i < len(spans); i++ {
+		if p.parent.Start <= spans[i].Start && spans[i].End <= p.parent.End {
+			p.nextRef[v] = i + 1
+			return spans[i]
+		}
+	}
+	return v.Span
+}
+
+// needsSemicolon returns true if the statement must be terminated by a semicolon when followed by another statement. Unless wrapped is set, the bodies of loops are not wrapped in braces and may need a semicolon.
+func needsSemicolon(stmt IStmt, wrapped bool) bool {
+	switch n := stmt.(type) {
+	case *BlockStmt, *EmptyStmt, *IfStmt, *SwitchStmt, *TryStmt, *FuncDecl, *ClassDecl:
+		return false
+	case *ForStmt:
+		return !wrapped && needsSemicolon(n.Body, wrapped)
+	case *ForInStmt:
+		return !wrapped && needsSemicolon(n.Body, wrapped)
+	case *ForOfStmt:
+		return !wrapped && needsSemicolon(n.Body, wrapped)
+	case *WhileStmt:
+		return n.Body == nil || !wrapped && needsSemicolon(n.Body, wrapped)
+	case *WithStmt:
+		return !wrapped && needsSemicolon(n.Body, wrapped)
+	case *LabelledStmt:
+		return needsSemicolon(n.Value, wrapped)
+	case *ExportStmt:
+		switch n.Decl.(type) {
+		case *FuncDecl, *ClassDecl:
+			return false
+		}
+	}
+	return true
+}
+
+// startsStmtHazard returns true if the statement starts with a token that would continue the previous statement without a semicolon.
+func startsStmtHazard(stmt IStmt) bool {
+	exprStmt, ok := stmt.(*ExprStmt)
+	if !ok {
+		return false
+	}
+	expr := exprStmt.Value
+	for {
+		switch n := expr.(type) {
+		case *GroupExpr, *ArrayExpr, *JSXElement, *ArrowFunc:
+			// arrow functions start with the parenthesized parameters or async
+			return true
+		case *LiteralExpr:
+			return n.TokenType == RegExpToken
+		case *TemplateExpr:
+			if n.Tag == nil {
+				return true
+			}
+			expr = n.Tag
+		case *UnaryExpr:
+			if n.Op != PostIncrToken && n.Op != PostDecrToken {
+				return n.Op == PosToken || n.Op == NegToken || n.Op == PreIncrToken || n.Op == PreDecrToken
+			}
+			expr = n.X
+		case *BinaryExpr:
+			expr = n.X
+		case *CondExpr:
+			expr = n.Cond
+		case *CommaExpr:
+			expr = n.List[0]
+		case *DotExpr:
+			expr = n.X
+		case *IndexExpr:
+			expr = n.X
+		case *CallExpr:
+			expr = n.X
+		default:
+			return false
+		}
+	}
+}
+
+// printStmts prints a list of statements, being the body of a block, module, or case clause.
+func (p *Printer) printStmts(list []IStmt, braces bool) {
+	switch p.style {
+	case defaultStyle:
+		if braces {
+			p.writeString("{ ")
+		}
+		for _, item := range list {
+			if _, isEmpty := item.(*EmptyStmt); !isEmpty {
+				p.printComments(item, false)
+				p.print(item)
+				p.writeByte(';')
+				p.printComments(item, true)
+				p.writeByte(' ')
+			}
+		}
+		if braces {
+			p.writeByte('}')
+		}
+	case prettyStyle:
+		if braces {
+			p.writeByte('{')
+			p.indent++
+		}
+		empty := true
+		for _, item := range list {
+			if _, isEmpty := item.(*EmptyStmt); isEmpty {
+				continue
+			}
+			if braces || !empty {
+				p.newline()
+			}
+			p.printPrettyStmt(item)
+			empty = false
+		}
+		if braces {
+			p.indent--
+			if !empty {
+				p.newline()
+			}
+			p.writeByte('}')
+		}
+	case compactStyle:
+		if braces {
+			p.writeByte('{')
+		}
+		var prev IStmt
+		for _, item := range list {
+			if _, isEmpty := item.(*EmptyStmt); isEmpty {
+				continue
+			}
+			if prev != nil && needsSemicolon(prev, false) {
+				p.writeByte(';')
+			}
+			p.printComments(item, false)
+			p.print(item)
+			p.printComments(item, true)
+			prev = item
+		}
+		if braces {
+			p.writeByte('}')
+		}
+	}
+}
+
+// printPrettyStmt prints a statement on its own line with a semicolon if needed.
+func (p *Printer) printPrettyStmt(stmt IStmt) {
+	p.printComments(stmt, false)
+	if p.o.NoSemicolons && startsStmtHazard(stmt) {
+		p.writeByte(';')
+	}
+	p.print(stmt)
+	if !p.o.NoSemicolons && needsSemicolon(stmt, true) {
+		p.writeByte(';')
+	}
+	p.printComments(stmt, true)
+}
+
+// printFileComments prints the comments at the end of the file, each on their own line.
+func (p *Printer) printFileComments(comments []Comment) {
+	for _, c := range comments {
+		if p.style == compactStyle && !c.IsLegal() {
+			continue
+		}
+		if p.col != 0 {
+			p.writeByte('\n')
+		}
+		p.write(c.Data)
+		if !c.IsMultiLine() {
+			p.writeByte('\n')
+		}
+	}
+}
+
+// printComments prints the leading or trailing comments of a statement or class element.
+func (p *Printer) printComments(n INode, trailing bool) {
+	if p.comments == nil {
+		return
+	}
+	p.commented = n
+	g, ok := p.comments[n]
+	if !ok {
+		return
+	}
+	comments := g.Leading
+	if trailing {
+		comments = g.Trailing
+	}
+	for _, c := range comments {
+		if p.style == compactStyle && !c.IsLegal() {
+			continue
+		}
+		if trailing {
+			if p.style != compactStyle {
+				p.writeByte(' ')
+			}
+			p.write(c.Data)
+			if !c.IsMultiLine() && p.style != prettyStyle {
+				p.writeByte('\n') // the newline that follows is written by the caller when pretty printing
+			}
+		} else {
+			p.write(c.Data)
+			if p.style == prettyStyle {
+				p.newline()
+			} else if !c.IsMultiLine() {
+				p.writeByte('\n')
+			} else if p.style == defaultStyle {
+				p.writeByte(' ')
+			}
+		}
+	}
+}
+
+// printInlineComments prints the leading or trailing comments of a node that is not printed on its own line, such as an expression. Single-line comments are printed as multi-line comments, since a line terminator may end the statement.
+func (p *Printer) printInlineComments(comments []Comment, trailing bool) {
+	for _, c := range comments {
+		if p.style == compactStyle && !c.IsLegal() {
+			continue
+		}
+		if trailing {
+			p.space()
+		}
+		if c.IsMultiLine() {
+			p.write(c.Data)
+		} else {
+			text := bytes.ReplaceAll(singleLineCommentText(c.Data), []byte("*/"), []byte("* /"))
+			p.writeString("/*")
+			p.write(text)
+			if 0 < len(text) && text[len(text)-1] != ' ' && text[len(text)-1] != '\t' {
+				p.writeByte(' ')
+			}
+			p.writeString("*/")
+		}
+		if !trailing {
+			p.space()
+		}
+	}
+}
+
+// singleLineCommentText returns the text of a single-line or HTML-like comment without its delimiter.
+func singleLineCommentText(b []byte) []byte {
+	if bytes.HasPrefix(b, []byte("<!--")) {
+		return b[4:]
+	} else if bytes.HasPrefix(b, []byte("-->")) {
+		return b[3:]
+	}
+	return b[2:]
+}
+
+// printBody prints the body of an if or do-while statement in braces.
+func (p *Printer) printBody(n IStmt) {
+	if _, ok := n.(*BlockStmt); ok {
+		p.print(n)
+	} else if p.style == defaultStyle {
+		p.writeString("{ ")
+		p.print(n)
+		p.writeString(" }")
+	} else {
+		p.printStmts([]IStmt{n}, true)
+	}
+}
+
+// printLoopBody prints the body of a loop or with statement, which is put in braces only when pretty printing.
+func (p *Printer) printLoopBody(n IStmt) {
+	if p.style == prettyStyle {
+		p.printBody(n)
+	} else {
+		p.print(n)
+	}
+}
+
+func (p *Printer) printAliases(list []Alias) {
+	if p.style == defaultStyle {
+		p.writeString(" {")
+		for i, item := range list {
+			if i != 0 {
+				p.writeString(" ,")
+			}
+			if item.Binding != nil {
+				p.writeByte(' ')
+				p.print(&item)
+			}
+		}
+		p.writeString(" }")
+		return
+	}
+
+	p.space()
+	p.writeByte('{')
+	if len(list) == 1 && list[0].Binding == nil {
+		p.writeByte('}')
+		return
+	}
+	p.space()
+	for i, item := range list {
+		if item.Binding == nil {
+			continue // trailing comma
+		} else if i != 0 {
+			p.comma()
+		}
+		p.print(&item)
+	}
+	p.space()
+	p.writeByte('}')
+}
+
+func (p *Printer) printModule(module []byte) {
+	p.space()
+	p.writeStringLiteral(module)
+}
+
+func (p *Printer) printImportAttributes(list []ImportAttribute, assert bool) {
+	if list == nil {
+		return
+	}
+	p.space()
+	if assert {
+		p.writeString("assert")
+	} else {
+		p.writeString("with")
+	}
+	p.space()
+	p.writeByte('{')
+	if p.style == prettyStyle && len(list) != 0 {
+		p.writeByte(' ')
+	}
+	for i := range list {
+		if i != 0 {
+			p.comma()
+		}
+		p.print(&list[i])
+	}
+	if p.style == prettyStyle && len(list) != 0 {
+		p.writeByte(' ')
+	}
+	p.writeByte('}')
+}
+
+func (p *Printer) printDecorators(list []IExpr) {
+	for _, decorator := range list {
+		p.writeByte('@')
+		if isDecoratorExpr(decorator) {
+			p.print(decorator)
+		} else {
+			p.writeByte('(')
+			p.print(decorator)
+			p.writeByte(')')
+		}
+		p.space()
+	}
+}
+
+func (p *Printer) print(n INode) {
+	if v, ok := n.(*Var); ok {
+		p.printVar(v)
+		return
+	}
+
+	inline := p.comments != nil && n != p.commented
+	if inline {
+		p.printInlineComments(p.comments.Leading(n), false)
+	}
+
+	parent := p.parent
+	if span := n.Range(); span.Start < span.End {
+		p.mark(span)
+		p.parent = span
+	}
+	defer func() {
+		p.parent = parent
+		if inline {
+			p.printInlineComments(p.comments.Trailing(n), true)
+		}
+	}()
+
+	switch n := n.(type) {
+	case *AST:
+		p.printStmts(n.List, false)
+		if p.comments != nil {
+			p.printFileComments(p.comments.Trailing(n))
+		}
+	case *BlockStmt:
+		p.printStmts(n.List, n.Scope.Parent != nil)
+	case *EmptyStmt:
+		p.writeByte(';')
+	case *ExprStmt:
+		p.print(n.Value)
+	case *IfStmt:
+		p.writeString("if")
+		p.space()
+		p.writeByte('(')
+		p.print(n.Cond)
+		p.writeByte(')')
+		p.space()
+		p.printBody(n.Body)
+		if n.Else != nil {
+			p.space()
+			p.writeString("else")
+			p.space()
+			if _, ok := n.Else.(*IfStmt); ok && p.style != defaultStyle {
+				p.print(n.Else)
+			} else {
+				p.printBody(n.Else)
+			}
+		}
+	case *DoWhileStmt:
+		p.writeString("do")
+		p.space()
+		p.printBody(n.Body)
+		p.space()
+		p.writeString("while")
+		p.space()
+		p.writeByte('(')
+		p.print(n.Cond)
+		p.writeByte(')')
+	case *WhileStmt:
+		p.writeString("while")
+		p.space()
+		p.writeByte('(')
+		p.print(n.Cond)
+		p.writeByte(')')
+		p.space()
+		if n.Body != nil {
+			p.printLoopBody(n.Body)
+		}
+	case *ForStmt:
+		p.writeString("for")
+		p.space()
+		p.writeByte('(')
+		if v, ok := n.Init.(*VarDecl); !ok && n.Init != nil || ok && len(v.List) != 0 {
+			p.print(n.Init)
+		} else if p.style == defaultStyle {
+			p.writeByte(' ')
+		}
+		p.writeByte(';')
+		if n.Cond != nil {
+			p.space()
+			p.print(n.Cond)
+		} else if p.style == defaultStyle {
+			p.writeByte(' ')
+		}
+		p.writeByte(';')
+		if n.Post != nil {
+			p.space()
+			p.print(n.Post)
+		} else if p.style == defaultStyle {
+			p.writeByte(' ')
+		}
+		p.writeByte(')')
+		p.space()
+		p.printLoopBody(n.Body)
+	case *ForInStmt:
+		p.writeString("for")
+		p.space()
+		p.writeByte('(')
+		p.print(n.Init)
+		p.space()
+		p.writeString("in")
+		p.space()
+		p.print(n.Value)
+		p.writeByte(')')
+		p.space()
+		p.printLoopBody(n.Body)
+	case *ForOfStmt:
+		p.writeString("for")
+		if n.Await {
+			p.space()
+			p.writeString("await")
+		}
+		p.space()
+		p.writeByte('(')
+		p.print(n.Init)
+		p.space()
+		p.writeString("of")
+		p.space()
+		p.print(n.Value)
+		p.writeByte(')')
+		p.space()
+		p.printLoopBody(n.Body)
+	case *CaseClause:
+		if p.style == defaultStyle {
+			p.writeByte(' ')
+		}
+		if n.Cond != nil {
+			p.writeString("case")
+			p.space()
+			p.print(n.Cond)
+		} else {
+			p.writeString("default")
+		}
+		p.writeByte(':')
+		if p.style == defaultStyle {
+			for _, item := range n.List {
+				p.writeByte(' ')
+				p.printComments(item, false)
+				p.print(item)
+				p.writeByte(';')
+				p.printComments(item, true)
+			}
+		} else if p.style == prettyStyle {
+			p.indent++
+			for _, item := range n.List {
+				if _, isEmpty := item.(*EmptyStmt); !isEmpty {
+					p.newline()
+					p.printPrettyStmt(item)
+				}
+			}
+			p.indent--
+		} else {
+			p.printStmts(n.List, false)
+		}
+	case *SwitchStmt:
+		p.writeString("switch")
+		p.space()
+		p.writeByte('(')
+		p.print(n.Init)
+		p.writeByte(')')
+		p.space()
+		p.writeByte('{')
+		p.indent++
+		for i := range n.List {
+			if p.style == prettyStyle {
+				p.newline()
+			} else if p.style == compactStyle && 0 < i && 0 < len(n.List[i-1].List) && needsSemicolon(n.List[i-1].List[len(n.List[i-1].List)-1], false) {
+				p.writeByte(';')
+			}
+			p.print(&n.List[i])
+		}
+		p.indent--
+		if p.style == prettyStyle {
+			p.newline()
+		} else if p.style == defaultStyle {
+			p.writeByte(' ')
+		}
+		p.writeByte('}')
+	case *BranchStmt:
+		p.write(n.Type.Bytes())
+		if n.Label != nil {
+			p.writeByte(' ')
+			p.write(n.Label)
+		}
+	case *ReturnStmt:
+		p.writeString("return")
+		if n.Value != nil {
+			p.space()
+			p.print(n.Value)
+		}
+	case *WithStmt:
+		p.writeString("with")
+		p.space()
+		p.writeByte('(')
+		p.print(n.Cond)
+		p.writeByte(')')
+		p.space()
+		p.printLoopBody(n.Body)
+	case *LabelledStmt:
+		p.write(n.Label)
+		p.writeByte(':')
+		p.space()
+		p.print(n.Value)
+	case *ThrowStmt:
+		p.writeString("throw")
+		p.space()
+		p.print(n.Value)
+	case *TryStmt:
+		p.writeString("try")
+		p.space()
+		p.print(n.Body)
+		if n.Catch != nil {
+			p.space()
+			p.writeString("catch")
+			if n.Binding != nil {
+				if p.style == prettyStyle {
+					p.writeByte(' ')
+				}
+				p.writeByte('(')
+				p.print(n.Binding)
+				p.writeByte(')')
+			}
+			p.space()
+			p.print(n.Catch)
+		}
+		if n.Finally != nil {
+			p.space()
+			p.writeString("finally")
+			p.space()
+			p.print(n.Finally)
+		}
+	case *DebuggerStmt:
+		p.writeString("debugger")
+	case *BadStmt:
+		p.write(n.Data)
+	case *Alias:
+		if n.Name != nil {
+			p.write(n.Name)
+			p.writeByte(' ')
+			p.writeString("as")
+			p.writeByte(' ')
+		}
+		p.write(n.Binding)
+	case *ImportAttribute:
+		if 0 < len(n.Key) && (n.Key[0] == '"' || n.Key[0] == '\'') {
+			p.writeStringLiteral(n.Key)
+		} else {
+			p.write(n.Key)
+		}
+		p.writeByte(':')
+		p.space()
+		p.writeStringLiteral(n.Value)
+	case *ImportStmt:
+		p.writeString("import")
+		if n.Default != nil {
+			p.writeByte(' ')
+			p.write(n.Default)
+			if len(n.List) != 0 {
+				if p.style == defaultStyle {
+					p.writeString(" ,")
+				} else {
+					p.writeByte(',')
+				}
+			}
+		}
+		if len(n.List) == 1 && len(n.List[0].Name) == 1 && n.List[0].Name[0] == '*' {
+			p.space()
+			p.print(&n.List[0])
+		} else if 0 < len(n.List) {
+			p.printAliases(n.List)
+		}
+		if n.Default != nil || len(n.List) != 0 {
+			p.space()
+			p.writeString("from")
+		}
+		p.printModule(n.Module)
+		p.printImportAttributes(n.Attributes, n.Assert)
+	case *ExportStmt:
+		p.writeString("export")
+		if n.Decl != nil {
+			if n.Default {
+				p.writeByte(' ')
+				p.writeString("default")
+			}
+			p.space()
+			p.print(n.Decl)
+			return
+		} else if len(n.List) == 1 && (len(n.List[0].Name) == 1 && n.List[0].Name[0] == '*' || n.List[0].Name == nil && len(n.List[0].Binding) == 1 && n.List[0].Binding[0] == '*') {
+			p.space()
+			p.print(&n.List[0])
+		} else if 0 < len(n.List) {
+			p.printAliases(n.List)
+		}
+		if n.Module != nil {
+			p.space()
+			p.writeString("from")
+			p.printModule(n.Module)
+			p.printImportAttributes(n.Attributes, n.Assert)
+		}
+	case *DirectivePrologueStmt:
+		p.write(n.Value)
+	case *PropertyName:
+		if n.Computed != nil {
+			p.writeByte('[')
+			p.print(n.Computed)
+			p.writeByte(']')
+		} else if n.Literal.TokenType == StringToken {
+			p.writeStringLiteral(n.Literal.Data)
+		} else {
+			p.write(n.Literal.Data)
+		}
+	case *BindingArray:
+		p.writeByte('[')
+		for i := range n.List {
+			if i != 0 {
+				if p.style == defaultStyle {
+					p.writeByte(',')
+				} else {
+					p.comma()
+				}
+			}
+			p.print(&n.List[i])
+		}
+		if n.Rest != nil {
+			if len(n.List) != 0 {
+				p.writeByte(',')
+			}
+			if p.style != compactStyle && (p.style == defaultStyle || len(n.List) != 0) {
+				p.writeByte(' ')
+			}
+			p.writeString("...")
+			p.print(n.Rest)
+		} else if 0 < len(n.List) && n.List[len(n.List)-1].Binding == nil {
+			p.writeByte(',') // trailing elision
+		}
+		p.writeByte(']')
+	case *BindingObjectItem:
+		if n.Key != nil {
+			if v, ok := n.Value.Binding.(*Var); !ok || !n.Key.IsIdent(v.Name()) {
+				p.print(n.Key)
+				p.writeByte(':')
+				p.space()
+			}
+		}
+		p.print(&n.Value)
+	case *BindingObject:
+		p.writeByte('{')
+		if p.style == defaultStyle {
+			for i := range n.List {
+				if i != 0 {
+					p.writeByte(',')
+				}
+				p.writeByte(' ')
+				p.print(&n.List[i])
+			}
+			if n.Rest != nil {
+				if len(n.List) != 0 {
+					p.writeByte(',')
+				}
+				p.writeString(" ...")
+				p.write(n.Rest.Data)
+			}
+			p.writeString(" }")
+			return
+		}
+		if len(n.List) != 0 || n.Rest != nil {
+			p.space()
+		}
+		for i := range n.List {
+			if i != 0 {
+				p.comma()
+			}
+			p.print(&n.List[i])
+		}
+		if n.Rest != nil {
+			if len(n.List) != 0 {
+				p.comma()
+			}
+			p.writeString("...")
+			p.print(n.Rest)
+		}
+		if len(n.List) != 0 || n.Rest != nil {
+			p.space()
+		}
+		p.writeByte('}')
+	case *BindingElement:
+		if n.Binding == nil {
+			return
+		}
+		p.print(n.Binding)
+		if n.Default != nil {
+			p.space()
+			p.writeByte('=')
+			p.space()
+			p.print(n.Default)
+		}
+	case *VarDecl:
+		p.write(n.TokenType.Bytes())
+		for i := range n.List {
+			if i != 0 {
+				p.writeByte(',')
+			}
+			if i == 0 || p.style != compactStyle {
+				p.space()
+			}
+			p.print(&n.List[i])
+		}
+	case *Params:
+		p.writeByte('(')
+		for i := range n.List {
+			if i != 0 {
+				p.comma()
+			}
+			p.print(&n.List[i])
+		}
+		if n.Rest != nil {
+			if len(n.List) != 0 {
+				p.comma()
+			}
+			p.writeString("...")
+			p.print(n.Rest)
+		}
+		p.writeByte(')')
+	case *FuncDecl:
+		if n.Async {
+			p.writeString("async")
+			p.writeByte(' ')
+		}
+		p.writeString("function")
+		if n.Generator {
+			p.writeByte('*')
+		}
+		if n.Name != nil {
+			p.space()
+			p.print(n.Name)
+		}
+		if p.style != compactStyle && (p.style == defaultStyle || n.Name == nil) {
+			p.writeByte(' ')
+		}
+		p.print(&n.Params)
+		p.space()
+		p.print(&n.Body)
+	case *MethodDecl:
+		p.printDecorators(n.Decorators)
+		if n.Static {
+			p.writeString("static")
+			p.space()
+		}
+		if n.Async {
+			p.writeString("async")
+			p.space()
+		}
+		if n.Generator {
+			p.writeByte('*')
+			if p.style == defaultStyle {
+				p.writeByte(' ')
+			}
+		}
+		if n.Get {
+			p.writeString("get")
+			p.space()
+		}
+		if n.Set {
+			p.writeString("set")
+			p.space()
+		}
+		p.print(&n.Name)
+		if p.style == defaultStyle {
+			p.writeByte(' ')
+		}
+		p.print(&n.Params)
+		p.space()
+		p.print(&n.Body)
+	case *Field:
+		p.printDecorators(n.Decorators)
+		if n.Static {
+			p.writeString("static")
+			p.space()
+		}
+		if n.Accessor {
+			p.writeString("accessor")
+			p.space()
+		}
+		p.print(&n.Name)
+		if n.Init != nil {
+			p.space()
+			p.writeByte('=')
+			p.space()
+			p.print(n.Init)
+		}
+	case *ClassElement:
+		if n.StaticBlock != nil {
+			p.writeString("static")
+			p.space()
+			p.print(n.StaticBlock)
+		} else if n.Method != nil {
+			p.print(n.Method)
+		} else {
+			p.print(&n.Field)
+		}
+	case *ClassDecl:
+		p.printDecorators(n.Decorators)
+		p.writeString("class")
+		if n.Name != nil {
+			p.space()
+			p.print(n.Name)
+		}
+		if n.Extends != nil {
+			p.space()
+			p.writeString("extends")
+			p.space()
+			p.print(n.Extends)
+		}
+		p.space()
+		p.writeByte('{')
+		switch p.style {
+		case defaultStyle:
+			p.writeByte(' ')
+			for i := range n.List {
+				elem := classElementNode(&n.List[i])
+				p.printComments(elem, false)
+				p.print(&n.List[i])
+				p.writeByte(';')
+				p.printComments(elem, true)
+				p.writeByte(' ')
+			}
+		case prettyStyle:
+			p.indent++
+			for i := range n.List {
+				elem := classElementNode(&n.List[i])
+				p.newline()
+				p.printComments(elem, false)
+				p.print(&n.List[i])
+				if n.List[i].StaticBlock == nil && n.List[i].Method == nil {
+					p.writeByte(';')
+				}
+				p.printComments(elem, true)
+			}
+			p.indent--
+			if len(n.List) != 0 {
+				p.newline()
+			}
+		case compactStyle:
+			for i := range n.List {
+				if 0 < i && n.List[i-1].StaticBlock == nil && n.List[i-1].Method == nil {
+					p.writeByte(';')
+				}
+				elem := classElementNode(&n.List[i])
+				p.printComments(elem, false)
+				p.print(&n.List[i])
+				p.printComments(elem, true)
+			}
+		}
+		p.writeByte('}')
+	case *LiteralExpr:
+		if n.TokenType == StringToken {
+			p.writeStringLiteral(n.Data)
+		} else {
+			p.write(n.Data)
+			p.lastIdent = n.TokenType == RegExpToken || (n.TokenType == IdentifierToken || n.TokenType == PrivateIdentifierToken) && n.Data[len(n.Data)-1] == '}'
+		}
+	case *Element:
+		if n.Value != nil {
+			if n.Spread {
+				p.writeString("...")
+			}
+			p.print(n.Value)
+		}
+	case *ArrayExpr:
+		p.writeByte('[')
+		for i := range n.List {
+			if i != 0 {
+				p.comma()
+			}
+			p.print(&n.List[i])
+		}
+		if 0 < len(n.List) && n.List[len(n.List)-1].Value == nil {
+			p.writeByte(',')
+		}
+		p.writeByte(']')
+	case *Property:
+		if n.Name != nil {
+			if v, ok := n.Value.(*Var); !ok || !n.Name.IsIdent(v.Name()) {
+				if _, ok := n.Value.(*MethodDecl); !ok || p.style == defaultStyle {
+					p.print(n.Name)
+					p.writeByte(':')
+					p.space()
+				}
+			}
+		} else if n.Spread {
+			p.writeString("...")
+		}
+		p.print(n.Value)
+		if n.Init != nil {
+			p.space()
+			p.writeByte('=')
+			p.space()
+			p.print(n.Init)
+		}
+	case *ObjectExpr:
+		p.writeByte('{')
+		if p.style == prettyStyle && len(n.List) != 0 {
+			p.writeByte(' ')
+		}
+		for i := range n.List {
+			if i != 0 {
+				p.comma()
+			}
+			p.print(&n.List[i])
+		}
+		if p.style == prettyStyle && len(n.List) != 0 {
+			p.writeByte(' ')
+		}
+		p.writeByte('}')
+	case *TemplatePart:
+		p.write(n.Value)
+		p.print(n.Expr)
+	case *TemplateExpr:
+		if n.Tag != nil {
+			p.print(n.Tag)
+			if n.Optional {
+				p.writeString("?.")
+			}
+		}
+		for i := range n.List {
+			p.print(&n.List[i])
+		}
+		p.write(n.Tail)
+	case *GroupExpr:
+		p.writeByte('(')
+		p.print(n.X)
+		p.writeByte(')')
+	case *IndexExpr:
+		p.print(n.X)
+		if n.Optional {
+			p.writeString("?.")
+		}
+		p.writeByte('[')
+		p.print(n.Y)
+		p.writeByte(']')
+	case *DotExpr:
+		p.print(n.X)
+		if n.Optional {
+			p.writeString("?.")
+		} else {
+			if lit, ok := n.X.(*LiteralExpr); ok && lit.TokenType == DecimalToken && isIntegerLiteral(lit.Data) {
+				p.writeByte(' ') // 1 .toString()
+			}
+			p.writeByte('.')
+		}
+		p.print(&n.Y)
+	case *NewTargetExpr:
+		p.writeString("new.target")
+	case *ImportMetaExpr:
+		p.writeString("import.meta")
+	case *Arg:
+		if n.Rest {
+			p.writeString("...")
+		}
+		p.print(n.Value)
+	case *Args:
+		for i := range n.List {
+			if i != 0 {
+				p.comma()
+			}
+			p.print(&n.List[i])
+		}
+	case *NewExpr:
+		p.writeString("new")
+		p.space()
+		p.print(n.X)
+		p.writeByte('(')
+		if n.Args != nil {
+			p.print(n.Args)
+		}
+		p.writeByte(')')
+	case *CallExpr:
+		p.print(n.X)
+		if n.Optional {
+			p.writeString("?.")
+		}
+		p.writeByte('(')
+		p.print(&n.Args)
+		p.writeByte(')')
+	case *UnaryExpr:
+		if n.Op == PostIncrToken || n.Op == PostDecrToken {
+			p.print(n.X)
+			p.write(n.Op.Bytes())
+		} else {
+			p.write(n.Op.Bytes())
+			if IsIdentifierName(n.Op) {
+				p.space()
+			}
+			p.soft = p.style != defaultStyle // separate + +a and - -a
+			p.print(n.X)
+		}
+	case *BinaryExpr:
+		p.print(n.X)
+		p.space()
+		p.write(n.Op.Bytes())
+		p.space()
+		p.print(n.Y)
+	case *CondExpr:
+		p.print(n.Cond)
+		p.space()
+		p.writeByte('?')
+		p.space()
+		p.print(n.X)
+		p.space()
+		p.writeByte(':')
+		p.space()
+		p.print(n.Y)
+	case *YieldExpr:
+		p.writeString("yield")
+		if n.X != nil {
+			if n.Generator {
+				p.writeByte('*')
+			}
+			p.space()
+			p.print(n.X)
+		}
+	case *ArrowFunc:
+		if n.Async {
+			p.writeString("async")
+			p.space()
+		}
+		p.print(&n.Params)
+		p.space()
+		p.writeString("=>")
+		p.space()
+		p.print(&n.Body)
+	case *CommaExpr:
+		for i, item := range n.List {
+			if i != 0 {
+				if p.style == prettyStyle {
+					p.comma()
+				} else {
+					p.writeByte(',')
+				}
+			}
+			p.print(item)
+		}
+	case *JSXElement:
+		p.writeByte('<')
+		if n.Name != nil {
+			p.print(n.Name)
+		}
+		for i := range n.Attrs {
+			p.writeByte(' ')
+			p.print(&n.Attrs[i])
+		}
+		if n.SelfClosing {
+			p.space()
+			p.writeString("/>")
+			break
+		}
+		p.writeByte('>')
+		for _, item := range n.Children {
+			p.print(item)
+		}
+		p.writeString("</")
+		if n.Name != nil {
+			p.print(n.Name)
+		}
+		p.writeByte('>')
+	case *JSXName:
+		p.write(n.Data)
+	case *JSXAttribute:
+		if n.Spread {
+			p.writeString("{...")
+			p.print(n.Value)
+			p.writeByte('}')
+			break
+		}
+		p.write(n.Name)
+		if n.Value != nil {
+			p.writeByte('=')
+			if lit, ok := n.Value.(*LiteralExpr); ok {
+				p.write(lit.Data) // JSX strings have no escapes and cannot be requoted
+			} else {
+				p.print(n.Value)
+			}
+		}
+	case *JSXExpr:
+		p.writeByte('{')
+		if n.Spread {
+			p.writeString("...")
+		}
+		if n.X != nil {
+			p.print(n.X)
+		}
+		p.writeByte('}')
+	case *JSXText:
+		p.write(n.Data)
+	}
+}
+
+// classElementNode returns the node of the class element that comments are attached to.
+func classElementNode(elem *ClassElement) INode {
+	if elem.StaticBlock != nil {
+		return elem.StaticBlock
+	} else if elem.Method != nil {
+		return elem.Method
+	}
+	return &elem.Field
+}
+
+// isIntegerLiteral returns true for decimal literals without a fraction or exponent.
+func isIntegerLiteral(b []byte) bool {
+	for _, c := range b {
+		if (c < '0' || '9' < c) && c != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,168 @@
+package js
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestPrinter(t *testing.T) {
+	pretty := PrintOptions{Pretty: true, Indent: "  "}
+	compact := PrintOptions{Compact: true}
+	var tests = []struct {
+		js       string
+		o        PrintOptions
+		expected string
+	}{
+		{"a = b", PrintOptions{}, "a = b; "},
+		{"1 .toString()", PrintOptions{}, "1 .toString(); "},
+
+		// pretty
+		{"a = b; c()", pretty, "a = b;\nc();\n"},
+		{"if (a) b; else if (c) d; else { e }", pretty, "if (a) {\n  b;\n} else if (c) {\n  d;\n} else {\n  e;\n}\n"},
+		{"for (;;) a; while (b) {}", pretty, "for (;;) {\n  a;\n}\nwhile (b) {}\n"},
+		{"function f(a, ...b) { return a }", pretty, "function f(a, ...b) {\n  return a;\n}\n"},
+		{"switch (a) { case 1: b; break; default: c }", pretty, "switch (a) {\n  case 1:\n    b;\n    break;\n  default:\n    c;\n}\n"},
+		{"class A extends B { x = 1; get y() { return 2 } }", pretty, "class A extends B {\n  x = 1;\n  get y() {\n    return 2;\n  }\n}\n"},
+		{"import a, { b as c, d } from 'x'", pretty, "import a, { b as c, d } from 'x';\n"},
+		{"import a from 'x' with {type: 'json'}", pretty, "import a from 'x' with { type: 'json' };\n"},
+		{"x = {a, b: 1, c() {}}, [1, , 2]", pretty, "x = { a, b: 1, c() {} }, [1, , 2];\n"},
+		{"var {a, ...b} = c, [d, , ...e] = f", pretty, "var { a, ...b } = c, [d, , ...e] = f;\n"},
+		{"@a class A { @b x; @c.d() m() {} }", pretty, "@a class A {\n  @b x;\n  @c.d() m() {}\n}\n"},
+		{"try { a } catch (e) {} finally {}", pretty, "try {\n  a;\n} catch (e) {} finally {}\n"},
+		{"a = b", PrintOptions{Pretty: true}, "a = b;\n"},
+		{"{ a }", PrintOptions{Pretty: true}, "{\n\ta;\n}\n"},
+
+		// compact
+		{"a = b; c()", compact, "a=b;c()"},
+		{"if (a) b; else if (c) d; else { e }", compact, "if(a){b}else if(c){d}else{e}"},
+		{"for (;;) a\nb", compact, "for(;;){a}b"},
+		{"while (a) b\nc", compact, "while(a)b;c"},
+		{"function f(a, b) { return a in b } f()", compact, "function f(a,b){return a in b}f()"},
+		{"a = b + +c - -d + ++e", compact, "a=b+ +c- -d+ ++e"},
+		{"a++ + b; a / /re/; /re/ in b", compact, "a++ +b;a/ /re/;/re/ in b"},
+		{"a < !--b", compact, "a< !--b"},
+		{"x = typeof a, void 0, new A", compact, "x=typeof a,void 0,new A()"},
+		{"switch (a) { case 'b': c; default: d }", compact, "switch(a){case'b':c;default:d}"},
+		{"class A { x = 1; y; m() {} static { z } }", compact, "class A{x=1;y;m(){}static{z}}"},
+		{"class A { static accessor x = 1; accessor [y]; accessor }", compact, "class A{static accessor x=1;accessor[y];accessor}"},
+		{"import { a as b } from 'x'; export * from 'y'", compact, "import{a as b}from'x';export*from'y'"},
+		{"import a from 'x' with {'type': 'json'}; export * from 'y' with {type: 'json'}", compact, "import a from'x'with{'type':'json'};export*from'y'with{type:'json'}"},
+		{"var {a, b: c} = d, [e, , ...f] = g", compact, "var{a,b:c}=d,[e,,...f]=g"},
+		{"async function* f() { yield* a } x = async (a) => a", compact, "async function*f(){yield*a}x=async(a)=>{return a}"},
+		{"1 .toString(); 1.5.toFixed()", compact, "1 .toString();1.5.toFixed()"},
+		{"@a @b.c(d) @(e[0]) class A { @f x = 1; @g m() {} }", compact, "@a@b.c(d)@(e[0])class A{@f x=1;@g m(){}}"},
+		{"var \\u0061 = 1; for (var a\\u{62} in c) x.d\\u{65} in e", compact, "var \\u0061=1;for(var a\\u{62} in c){x.d\\u{65} in e}"},
+
+		// semicolons
+		{"a; (b); [c]; `d`; +e; /f/.test(g); h", PrintOptions{Pretty: true, NoSemicolons: true}, "a\n;(b)\n;[c]\n;`d`\n;+e\n;/f/.test(g)\nh\n"},
+		{"a(); class A { x = 1 }", PrintOptions{Pretty: true, NoSemicolons: true}, "a()\nclass A {\n\tx = 1;\n}\n"},
+		{"a\n;({a}) => a", PrintOptions{Pretty: true, NoSemicolons: true}, "a\n;({ a }) => {\n\treturn a\n}\n"},
+		{"async x => x;(c) => c", PrintOptions{Pretty: true, NoSemicolons: true}, ";async (x) => {\n\treturn x\n}\n;(c) => {\n\treturn c\n}\n"},
+
+		// quotes
+		{`x = 'it\'s', "say \"hi\"", 'a"b', ""`, PrintOptions{Quote: '"'}, `x = "it's","say \"hi\"","a\"b",""; `},
+		{`x = 'it\'s', "say \"hi\"", 'a"b', "\\"`, PrintOptions{Quote: '\''}, `x = 'it\'s','say "hi"','a"b','\\'; `},
+		{`x = {"a-b": 1}; import "y"`, PrintOptions{Quote: '\''}, `x = {'a-b': 1}; import 'y'; `},
+		{`"use strict"; x = "a"`, PrintOptions{Quote: '\''}, `"use strict"; x = 'a'; `},
+		{`import "x" with {"type": "json"}`, PrintOptions{Quote: '\''}, `import 'x' with {'type': 'json'}; `},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+
+			buf := &bytes.Buffer{}
+			test.Error(t, NewPrinter(buf, tt.o).Print(ast))
+			test.String(t, buf.String(), tt.expected)
+
+			_, err = Parse(parse.NewInputBytes(buf.Bytes()), Options{})
+			test.Error(t, err, "reparse")
+		})
+	}
+}
+
+func TestPrinterJSX(t *testing.T) {
+	var tests = []struct {
+		js       string
+		o        PrintOptions
+		expected string
+	}{
+		{"x = <div a='b' {...c}>d {e}</div>", PrintOptions{}, "x = <div a='b' {...c}>d {e}</div>; "},
+		{"x = <div a='b' {...c}>d {e}</div>", PrintOptions{Quote: '"'}, "x = <div a='b' {...c}>d {e}</div>; "},
+		{"x = <a><b /> </a>", PrintOptions{Compact: true}, "x=<a><b/> </a>"},
+		{"x = a < <b/>", PrintOptions{Compact: true}, "x=a< <b/>"},
+		{"a; <b/>", PrintOptions{Pretty: true, NoSemicolons: true}, "a\n;<b />\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{JSX: true})
+			test.Error(t, err)
+
+			buf := &bytes.Buffer{}
+			test.Error(t, NewPrinter(buf, tt.o).Print(ast))
+			test.String(t, buf.String(), tt.expected)
+
+			_, err = Parse(parse.NewInputBytes(buf.Bytes()), Options{JSX: true})
+			test.Error(t, err, "reparse")
+		})
+	}
+}
+
+func TestPrinterRoundTrip(t *testing.T) {
+	src := "function f(a, b = 1, ...c) { for (var i = 0; i < a; i++) if (i % 2) continue; else b += i\n return b }\nvar x = f(1, 2) + -f(-3), y = [x, , 'y'], {z = 5, ...w} = {z: x ? y : null}\nlbl: while (x--) { switch (x) { case 1: break lbl; default: y = x => x * 2 } }\ny;\n(a) => a\n"
+	ast, err := Parse(parse.NewInputString(src), Options{})
+	test.Error(t, err)
+
+	for _, o := range []PrintOptions{{Pretty: true}, {Pretty: true, NoSemicolons: true}, {Compact: true}} {
+		buf := &bytes.Buffer{}
+		test.Error(t, NewPrinter(buf, o).Print(ast))
+
+		ast2, err := Parse(parse.NewInputBytes(buf.Bytes()), Options{})
+		test.Error(t, err)
+
+		buf2 := &bytes.Buffer{}
+		test.Error(t, NewPrinter(buf2, o).Print(ast2))
+		test.String(t, buf2.String(), buf.String())
+	}
+}
+
+func TestPrinterSourceMap(t *testing.T) {
+	src := "if (a) b = c\nelse {\n\td(e)\n}\n"
+	ast, err := Parse(parse.NewInputString(src), Options{})
+	test.Error(t, err)
+
+	sm := NewSourceMap("")
+	buf := &bytes.Buffer{}
+	p := NewPrinter(buf, PrintOptions{Compact: true})
+	p.SetSourceMap(sm, sm.AddSource("in.js", nil), []byte(src))
+	test.Error(t, p.Print(ast))
+	test.String(t, buf.String(), "if(a){b=c}else{d(e)}")
+
+	gen := []string{}
+	for _, seg := range decodeMappings(sm.Mappings()) {
+		test.T(t, seg.genLine, 0)
+		gen = append(gen, buf.String()[seg.genCol:seg.genCol+1])
+	}
+	test.T(t, gen, []string{"i", "a", "b", "c", "{", "d", "e"})
+}
+
+func BenchmarkPrinter(b *testing.B) {
+	src := bytes.Repeat([]byte("function f(a, b) { for (var i = 0; i < a.length; i++) { b += a[i] * 2 } return b }\n"), 100)
+	ast, err := Parse(parse.NewInputBytes(src), Options{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	for _, o := range []PrintOptions{{}, {Pretty: true}, {Compact: true}} {
+		b.Run("", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				NewPrinter(buf, o).Print(ast)
+			}
+		})
+	}
+}
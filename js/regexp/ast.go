@@ -0,0 +1,388 @@
+package regexp
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+// Flags are the flags of a regular expression.
+type Flags uint16
+
+// Flags values.
+const (
+	HasIndices  Flags = 1 << iota // d
+	Global                        // g
+	IgnoreCase                    // i
+	Multiline                     // m
+	DotAll                        // s
+	Unicode                       // u
+	UnicodeSets                   // v
+	Sticky                        // y
+)
+
+var flagChars = []byte("dgimsuvy")
+
+// String returns the flags in the order dgimsuvy.
+func (f Flags) String() string {
+	s := []byte{}
+	for i, c := range flagChars {
+		if f&(1<<uint(i)) != 0 {
+			s = append(s, c)
+		}
+	}
+	return string(s)
+}
+
+////////////////////////////////////////////////////////////////
+
+// Span is the range of byte offsets of a node in the source that was parsed.
+type Span struct {
+	Start, End int
+}
+
+// Range returns the range of byte offsets of the node in the source.
+func (s Span) Range() Span {
+	return s
+}
+
+// Node is an interface for the nodes of a pattern. String returns the source of the node in the pattern.
+type Node interface {
+	String() string
+	Range() Span
+}
+
+// RegExp is a parsed regular expression.
+type RegExp struct {
+	Body   Disjunction
+	Flags  Flags
+	Groups int // number of capturing groups
+}
+
+// Pattern returns the source of the pattern.
+func (n RegExp) Pattern() string {
+	return n.Body.String()
+}
+
+// String returns the regular expression literal.
+func (n RegExp) String() string {
+	pattern := n.Body.String()
+	if pattern == "" {
+		pattern = "(?:)" // an empty pattern would start a comment
+	}
+	return "/" + pattern + "/" + n.Flags.String()
+}
+
+// Disjunction is a list of alternatives separated by |.
+type Disjunction struct {
+	List []Alternative
+	Span
+}
+
+func (n Disjunction) String() string {
+	s := ""
+	for i, item := range n.List {
+		if i != 0 {
+			s += "|"
+		}
+		s += item.String()
+	}
+	return s
+}
+
+// Alternative is a sequence of terms.
+type Alternative struct {
+	List []Node
+	Span
+}
+
+func (n Alternative) String() string {
+	s := ""
+	for _, item := range n.List {
+		s += item.String()
+	}
+	return s
+}
+
+// Char is a single character, either written literally or by an escape sequence.
+type Char struct {
+	Value rune
+	Raw   []byte // source of the character, generated from Value if nil
+	Span
+}
+
+func (n Char) String() string {
+	if n.Raw != nil && (n.Raw[0] == '\\' || !isLineTerminator(n.Value) && n.Value != '/') {
+		return string(n.Raw)
+	}
+	return escapeChar(n.Value, "^$\\.*+?()[]{}|/")
+}
+
+// classString returns the source of the character inside a character class.
+func (n Char) classString() string {
+	if n.Raw != nil && (n.Raw[0] == '\\' || !isLineTerminator(n.Value)) {
+		return string(n.Raw)
+	}
+	return escapeChar(n.Value, "\\[]-^/(){}|")
+}
+
+// Dot is the . that matches any character.
+type Dot struct {
+	Span
+}
+
+func (n Dot) String() string {
+	return "."
+}
+
+// AssertionKind is the kind of an assertion.
+type AssertionKind int
+
+// AssertionKind values.
+const (
+	StartAssertion           AssertionKind = iota // ^
+	EndAssertion                                  // $
+	WordBoundaryAssertion                         // \b
+	NotWordBoundaryAssertion                      // \B
+)
+
+// Assertion is one of the assertions ^, $, \b, or \B.
+type Assertion struct {
+	Kind AssertionKind
+	Span
+}
+
+func (n Assertion) String() string {
+	switch n.Kind {
+	case StartAssertion:
+		return "^"
+	case EndAssertion:
+		return "$"
+	case WordBoundaryAssertion:
+		return `\b`
+	}
+	return `\B`
+}
+
+// Lookaround is a lookahead or lookbehind assertion.
+type Lookaround struct {
+	Behind   bool
+	Negative bool
+	Body     Disjunction
+	Span
+}
+
+func (n Lookaround) String() string {
+	s := "(?"
+	if n.Behind {
+		s += "<"
+	}
+	if n.Negative {
+		s += "!"
+	} else {
+		s += "="
+	}
+	return s + n.Body.String() + ")"
+}
+
+// Group is a capturing group, possibly named, or a non-capturing group that may add or remove the flags i, m, and s.
+type Group struct {
+	Capturing bool
+	Name      []byte // can be nil
+	Index     int    // index of a capturing group, starting at 1
+	Add       Flags  // modifiers of a non-capturing group
+	Remove    Flags
+	Body      Disjunction
+	Span
+}
+
+func (n Group) String() string {
+	s := "("
+	if n.Capturing {
+		if n.Name != nil {
+			s += "?<" + string(n.Name) + ">"
+		}
+	} else {
+		s += "?" + n.Add.String()
+		if n.Remove != 0 {
+			s += "-" + n.Remove.String()
+		}
+		s += ":"
+	}
+	return s + n.Body.String() + ")"
+}
+
+// Quantifier repeats its body at least Min and at most Max times, where Max is -1 if unbounded.
+type Quantifier struct {
+	Min, Max int
+	Lazy     bool
+	Body     Node
+	Span
+}
+
+func (n Quantifier) String() string {
+	s := n.Body.String()
+	if n.Min == 0 && n.Max == -1 {
+		s += "*"
+	} else if n.Min == 1 && n.Max == -1 {
+		s += "+"
+	} else if n.Min == 0 && n.Max == 1 {
+		s += "?"
+	} else if n.Min == n.Max {
+		s += "{" + strconv.Itoa(n.Min) + "}"
+	} else if n.Max == -1 {
+		s += "{" + strconv.Itoa(n.Min) + ",}"
+	} else {
+		s += "{" + strconv.Itoa(n.Min) + "," + strconv.Itoa(n.Max) + "}"
+	}
+	if n.Lazy {
+		s += "?"
+	}
+	return s
+}
+
+// Backreference refers to a capturing group by index or by name.
+type Backreference struct {
+	Index int    // zero for named references
+	Name  []byte // can be nil
+	Span
+}
+
+func (n Backreference) String() string {
+	if n.Name != nil {
+		return `\k<` + string(n.Name) + ">"
+	}
+	return `\` + strconv.Itoa(n.Index)
+}
+
+// CharClassEscape is one of the character class escapes \d, \D, \s, \S, \w, or \W.
+type CharClassEscape struct {
+	Kind byte // d, D, s, S, w, or W
+	Span
+}
+
+func (n CharClassEscape) String() string {
+	return `\` + string(n.Kind)
+}
+
+// PropertyEscape is a Unicode property escape such as \p{Letter} or \P{Script=Greek}.
+type PropertyEscape struct {
+	Negated bool
+	Name    []byte
+	Value   []byte // can be nil
+	Span
+}
+
+func (n PropertyEscape) String() string {
+	s := `\p{`
+	if n.Negated {
+		s = `\P{`
+	}
+	s += string(n.Name)
+	if n.Value != nil {
+		s += "=" + string(n.Value)
+	}
+	return s + "}"
+}
+
+// ClassOp is the operation of a character class that combines its items.
+type ClassOp int
+
+// ClassOp values.
+const (
+	UnionOp        ClassOp = iota
+	IntersectionOp         // &&, only with the v flag
+	SubtractionOp          // --, only with the v flag
+)
+
+// Class is a character class. With the v flag the items can be nested classes and strings, and be combined by intersection or subtraction.
+type Class struct {
+	Negated bool
+	Op      ClassOp
+	List    []Node
+	Span
+}
+
+func (n Class) String() string {
+	s := "["
+	if n.Negated {
+		s += "^"
+	}
+	for i, item := range n.List {
+		if i != 0 && n.Op == IntersectionOp {
+			s += "&&"
+		} else if i != 0 && n.Op == SubtractionOp {
+			s += "--"
+		}
+		if c, ok := item.(*Char); ok {
+			s += c.classString()
+		} else {
+			s += item.String()
+		}
+	}
+	return s + "]"
+}
+
+// ClassRange is a range of characters in a character class.
+type ClassRange struct {
+	From, To Char
+	Span
+}
+
+func (n ClassRange) String() string {
+	return n.From.classString() + "-" + n.To.classString()
+}
+
+// ClassStrings is a list of strings in a character class written as \q{abc|def}, only with the v flag.
+type ClassStrings struct {
+	List [][]Char
+	Span
+}
+
+func (n ClassStrings) String() string {
+	s := `\q{`
+	for i, str := range n.List {
+		if i != 0 {
+			s += "|"
+		}
+		for _, c := range str {
+			s += c.classString()
+		}
+	}
+	return s + "}"
+}
+
+////////////////////////////////////////////////////////////////
+
+func isLineTerminator(r rune) bool {
+	return r == '\n' || r == '\r' || r == '\u2028' || r == '\u2029'
+}
+
+// escapeChar returns the source of a character that is escaped if it is in syntax or is not printable.
+func escapeChar(r rune, syntax string) string {
+	switch r {
+	case '\t':
+		return `\t`
+	case '\n':
+		return `\n`
+	case '\v':
+		return `\v`
+	case '\f':
+		return `\f`
+	case '\r':
+		return `\r`
+	case '\u2028', '\u2029':
+		return `\u` + strconv.FormatInt(int64(r), 16)
+	}
+	if 0xD800 <= r && r <= 0xDFFF {
+		return `\u` + strconv.FormatInt(int64(r), 16) // lone surrogate
+	} else if r < 0x20 || r == 0x7F {
+		return `\x` + string("0123456789abcdef"[r>>4]) + string("0123456789abcdef"[r&0xF])
+	} else if r < utf8.RuneSelf {
+		for i := 0; i < len(syntax); i++ {
+			if byte(r) == syntax[i] {
+				return `\` + string(r)
+			}
+		}
+	}
+	return string(r)
+}
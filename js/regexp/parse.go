@@ -0,0 +1,1037 @@
+// Package regexp is a parser and validator for the pattern and flags of JavaScript regular expressions, following the specification at https://tc39.es/ecma262/#sec-patterns including the web compatibility syntax of Annex B.
+package regexp
+
+import (
+	"bytes"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Error is an early error of a regular expression, at a byte offset in the source that was parsed.
+type Error struct {
+	Message string
+	Offset  int
+}
+
+func (e *Error) Error() string {
+	return e.Message + " at offset " + strconv.Itoa(e.Offset)
+}
+
+// ParseFlags parses the flags of a regular expression.
+func ParseFlags(b []byte) (Flags, error) {
+	return parseFlags(b, 0)
+}
+
+func parseFlags(b []byte, offset int) (Flags, error) {
+	flags := Flags(0)
+	for i, c := range b {
+		j := bytes.IndexByte(flagChars, c)
+		if j == -1 {
+			return 0, &Error{"invalid regular expression flag", offset + i}
+		}
+		flag := Flags(1 << uint(j))
+		if flags&flag != 0 {
+			return 0, &Error{"duplicate regular expression flag", offset + i}
+		}
+		flags |= flag
+		if flags&Unicode != 0 && flags&UnicodeSets != 0 {
+			return 0, &Error{"regular expression flags u and v cannot be combined", offset + i}
+		}
+	}
+	return flags, nil
+}
+
+// Parse parses a pattern with the given flags and returns the first early error, if any. Offsets are byte offsets in the pattern.
+func Parse(pattern []byte, flags Flags) (*RegExp, error) {
+	if flags&Unicode != 0 && flags&UnicodeSets != 0 {
+		return nil, &Error{"regular expression flags u and v cannot be combined", 0}
+	}
+	return parse(pattern, 0, len(pattern), flags)
+}
+
+// ParseLiteral parses a regular expression literal such as /ab+c/gi, which is the data of a RegExpToken of the js lexer. Offsets are byte offsets in the literal.
+func ParseLiteral(b []byte) (*RegExp, error) {
+	if len(b) == 0 || b[0] != '/' {
+		return nil, &Error{"expected regular expression literal", 0}
+	}
+	inClass := false
+	for i := 1; i < len(b); i++ {
+		switch b[i] {
+		case '\\':
+			i++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '/':
+			if !inClass {
+				flags, err := parseFlags(b[i+1:], i+1)
+				if err != nil {
+					return nil, err
+				}
+				return parse(b, 1, i, flags)
+			}
+		case '\n', '\r':
+			return nil, &Error{"unterminated regular expression literal", i}
+		}
+	}
+	return nil, &Error{"unterminated regular expression literal", len(b)}
+}
+
+////////////////////////////////////////////////////////////////
+
+// altPos is the position of an alternative in a disjunction, the list of positions from the root determines which named groups can have the same name.
+type altPos struct {
+	disj, alt int
+}
+
+type groupName struct {
+	name []byte
+	path []altPos
+}
+
+type parser struct {
+	src      []byte
+	pos, end int
+	u, v     bool // Unicode mode by the u or v flag, and the v flag
+	named    bool // the pattern has named groups
+	groups   int  // number of capturing groups in the pattern
+	index    int  // number of capturing groups parsed so far
+	names    []groupName
+	refs     []*Backreference // named backreferences
+	path     []altPos
+	disjs    int
+	err      *Error
+}
+
+func parse(src []byte, start, end int, flags Flags) (*RegExp, error) {
+	p := &parser{
+		src: src,
+		pos: start,
+		end: end,
+		u:   flags&(Unicode|UnicodeSets) != 0,
+		v:   flags&UnicodeSets != 0,
+	}
+	p.countGroups()
+	body := p.parseDisjunction()
+	if p.pos < p.end {
+		p.fail(p.pos, "unmatched ')'") // only ) stops the outer disjunction
+	}
+	for _, ref := range p.refs {
+		if !p.hasName(ref.Name) {
+			p.fail(ref.Start, "invalid named reference")
+		}
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &RegExp{body, flags, p.groups}, nil
+}
+
+func (p *parser) fail(offset int, msg string) {
+	if p.err == nil {
+		p.err = &Error{msg, offset}
+	}
+	p.pos = p.end // stop parsing
+}
+
+func (p *parser) eof() bool {
+	return p.end <= p.pos
+}
+
+// at returns true if the pattern continues with s.
+func (p *parser) at(s string) bool {
+	return p.pos+len(s) <= p.end && string(p.src[p.pos:p.pos+len(s)]) == s
+}
+
+// peek returns the byte at offset i from the current position, or zero at the end of the pattern.
+func (p *parser) peek(i int) byte {
+	if p.pos+i < p.end {
+		return p.src[p.pos+i]
+	}
+	return 0
+}
+
+// countGroups counts the capturing groups of the whole pattern, which is needed to tell backreferences apart from legacy octal escapes.
+func (p *parser) countGroups() {
+	depth := 0 // class nesting
+	for i := p.pos; i < p.end; i++ {
+		switch p.src[i] {
+		case '\\':
+			i++
+		case '[':
+			if depth == 0 || p.v {
+				depth++
+			}
+		case ']':
+			if 0 < depth {
+				depth--
+			}
+		case '(':
+			if depth != 0 {
+				break
+			} else if i+1 < p.end && p.src[i+1] == '?' {
+				if i+3 < p.end && p.src[i+2] == '<' && p.src[i+3] != '=' && p.src[i+3] != '!' {
+					p.groups++
+					p.named = true
+				}
+			} else {
+				p.groups++
+			}
+		}
+	}
+}
+
+func (p *parser) hasName(name []byte) bool {
+	for _, item := range p.names {
+		if bytes.Equal(item.name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// addName adds the name of a group, a name can only be used again in another alternative of a common disjunction.
+func (p *parser) addName(name []byte, offset int) {
+	path := append([]altPos{}, p.path...)
+	for _, item := range p.names {
+		if bytes.Equal(item.name, name) {
+			i := 0
+			for i < len(path) && i < len(item.path) && path[i] == item.path[i] {
+				i++
+			}
+			if i == len(path) || i == len(item.path) || path[i].disj != item.path[i].disj {
+				p.fail(offset, "duplicate capture group name")
+				return
+			}
+		}
+	}
+	p.names = append(p.names, groupName{name, path})
+}
+
+func (p *parser) parseDisjunction() Disjunction {
+	start := p.pos
+	p.disjs++
+	disj := p.disjs
+	n := Disjunction{}
+	for {
+		p.path = append(p.path, altPos{disj, len(n.List)})
+		n.List = append(n.List, p.parseAlternative())
+		p.path = p.path[:len(p.path)-1]
+		if p.eof() || p.src[p.pos] != '|' {
+			break
+		}
+		p.pos++
+	}
+	n.Span = Span{start, p.pos}
+	return n
+}
+
+func (p *parser) parseAlternative() Alternative {
+	start := p.pos
+	n := Alternative{}
+	for !p.eof() && p.src[p.pos] != '|' && p.src[p.pos] != ')' {
+		if term := p.parseTerm(); term != nil {
+			n.List = append(n.List, term)
+		}
+	}
+	n.Span = Span{start, p.pos}
+	return n
+}
+
+func (p *parser) parseTerm() Node {
+	start := p.pos
+	var atom Node
+	quantifiable := true
+	switch c := p.src[p.pos]; c {
+	case '^':
+		p.pos++
+		atom, quantifiable = &Assertion{StartAssertion, Span{start, p.pos}}, false
+	case '$':
+		p.pos++
+		atom, quantifiable = &Assertion{EndAssertion, Span{start, p.pos}}, false
+	case '.':
+		p.pos++
+		atom = &Dot{Span{start, p.pos}}
+	case '(':
+		atom, quantifiable = p.parseGroup()
+	case '[':
+		atom = p.parseClass()
+	case '\\':
+		atom, quantifiable = p.parseAtomEscape()
+	case '*', '+', '?':
+		p.fail(start, "nothing to repeat")
+		return nil
+	case '{', '}', ']':
+		if c == '{' && p.isBracedQuantifier() {
+			p.fail(start, "nothing to repeat")
+			return nil
+		} else if p.u {
+			p.fail(start, "lone quantifier brackets")
+			return nil
+		}
+		atom = p.parseChar()
+	default:
+		atom = p.parseChar()
+	}
+	if p.err != nil {
+		return nil
+	}
+
+	if min, max, lazy, ok := p.parseQuantifier(); ok {
+		if !quantifiable {
+			p.fail(start, "nothing to repeat")
+			return nil
+		}
+		atom = &Quantifier{min, max, lazy, atom, Span{start, p.pos}}
+	}
+	return atom
+}
+
+// isBracedQuantifier returns true if the pattern continues with a quantifier of the form {n}, {n,}, or {n,m}.
+func (p *parser) isBracedQuantifier() bool {
+	i := p.pos + 1
+	digits := func() bool {
+		start := i
+		for i < p.end && '0' <= p.src[i] && p.src[i] <= '9' {
+			i++
+		}
+		return start < i
+	}
+	if !digits() {
+		return false
+	} else if i < p.end && p.src[i] == ',' {
+		i++
+		digits()
+	}
+	return i < p.end && p.src[i] == '}'
+}
+
+func (p *parser) parseQuantifier() (min, max int, lazy, ok bool) {
+	if p.eof() {
+		return
+	}
+	start := p.pos
+	switch p.src[p.pos] {
+	case '*':
+		min, max = 0, -1
+	case '+':
+		min, max = 1, -1
+	case '?':
+		min, max = 0, 1
+	case '{':
+		if !p.isBracedQuantifier() {
+			if p.u {
+				p.fail(start, "incomplete quantifier")
+			}
+			return
+		}
+		p.pos++
+		min = p.parseDecimal()
+		max = min
+		if p.src[p.pos] == ',' {
+			p.pos++
+			max = -1
+			if p.src[p.pos] != '}' {
+				max = p.parseDecimal()
+				if max < min {
+					p.fail(start, "numbers out of order in {} quantifier")
+					return
+				}
+			}
+		}
+	default:
+		return
+	}
+	p.pos++
+	if !p.eof() && p.src[p.pos] == '?' {
+		p.pos++
+		lazy = true
+	}
+	ok = true
+	return
+}
+
+// parseDecimal parses a decimal number, which saturates at the maximum of an int32.
+func (p *parser) parseDecimal() int {
+	n := 0
+	for !p.eof() && '0' <= p.src[p.pos] && p.src[p.pos] <= '9' {
+		if n < 1<<31-1 {
+			n = n*10 + int(p.src[p.pos]-'0')
+			if 1<<31-1 < n {
+				n = 1<<31 - 1
+			}
+		}
+		p.pos++
+	}
+	return n
+}
+
+// parseChar parses a literal character.
+func (p *parser) parseChar() *Char {
+	start := p.pos
+	r, n := utf8.DecodeRune(p.src[p.pos:p.end])
+	p.pos += n
+	return &Char{r, p.src[start:p.pos], Span{start, p.pos}}
+}
+
+func (p *parser) parseGroup() (Node, bool) {
+	// assume we're at (
+	start := p.pos
+	p.pos++
+	var n Node
+	var body *Disjunction
+	quantifiable := true
+	if !p.eof() && p.src[p.pos] == '?' {
+		p.pos++
+		if p.at("=") || p.at("!") || p.at("<=") || p.at("<!") {
+			lookaround := &Lookaround{}
+			if p.src[p.pos] == '<' {
+				lookaround.Behind = true
+				p.pos++
+			}
+			lookaround.Negative = p.src[p.pos] == '!'
+			p.pos++
+			n, body = lookaround, &lookaround.Body
+			quantifiable = !p.u && !lookaround.Behind // Annex B allows quantified lookaheads
+		} else if p.at("<") {
+			p.pos++
+			nameStart := p.pos
+			name := p.parseGroupName()
+			if p.err != nil {
+				return nil, false
+			}
+			p.addName(name, nameStart)
+			p.index++
+			group := &Group{Capturing: true, Name: name, Index: p.index}
+			n, body = group, &group.Body
+		} else {
+			group := &Group{}
+			group.Add = p.parseModifiers()
+			if p.at("-") {
+				p.pos++
+				group.Remove = p.parseModifiers()
+				if group.Remove == 0 || group.Add&group.Remove != 0 {
+					p.fail(start, "invalid group")
+					return nil, false
+				}
+			}
+			if !p.at(":") {
+				p.fail(start, "invalid group")
+				return nil, false
+			}
+			p.pos++
+			n, body = group, &group.Body
+		}
+	} else {
+		p.index++
+		group := &Group{Capturing: true, Index: p.index}
+		n, body = group, &group.Body
+	}
+
+	*body = p.parseDisjunction()
+	if p.eof() {
+		p.fail(start, "unterminated group")
+		return nil, false
+	}
+	p.pos++
+	switch n := n.(type) {
+	case *Lookaround:
+		n.Span = Span{start, p.pos}
+	case *Group:
+		n.Span = Span{start, p.pos}
+	}
+	return n, quantifiable
+}
+
+// parseModifiers parses the flags i, m, and s of a modifiers group.
+func (p *parser) parseModifiers() Flags {
+	flags := Flags(0)
+	for !p.eof() {
+		var flag Flags
+		switch p.src[p.pos] {
+		case 'i':
+			flag = IgnoreCase
+		case 'm':
+			flag = Multiline
+		case 's':
+			flag = DotAll
+		default:
+			return flags
+		}
+		if flags&flag != 0 {
+			p.fail(p.pos, "repeated flag in group modifiers")
+			return flags
+		}
+		flags |= flag
+		p.pos++
+	}
+	return flags
+}
+
+// parseGroupName parses the name of a group or named reference up to and including the closing >, escape sequences in the name are decoded.
+func (p *parser) parseGroupName() []byte {
+	start := p.pos
+	name := []byte{}
+	for !p.eof() && p.src[p.pos] != '>' {
+		var r rune
+		if p.src[p.pos] == '\\' {
+			p.pos++
+			if p.eof() || p.src[p.pos] != 'u' {
+				p.fail(start, "invalid capture group name")
+				return nil
+			}
+			var ok bool
+			if r, ok = p.parseUnicodeEscape(true); !ok {
+				p.fail(start, "invalid capture group name")
+				return nil
+			}
+		} else {
+			var n int
+			r, n = utf8.DecodeRune(p.src[p.pos:p.end])
+			p.pos += n
+		}
+		if len(name) == 0 && !isIDStart(r) || 0 < len(name) && !isIDContinue(r) {
+			p.fail(start, "invalid capture group name")
+			return nil
+		}
+		name = append(name, string(r)...)
+	}
+	if p.eof() || len(name) == 0 {
+		p.fail(start, "invalid capture group name")
+		return nil
+	}
+	p.pos++
+	return name
+}
+
+func (p *parser) parseAtomEscape() (Node, bool) {
+	// assume we're at \
+	start := p.pos
+	p.pos++
+	if p.eof() {
+		p.fail(start, `\ at end of pattern`)
+		return nil, false
+	}
+	switch c := p.src[p.pos]; c {
+	case 'b', 'B':
+		p.pos++
+		kind := WordBoundaryAssertion
+		if c == 'B' {
+			kind = NotWordBoundaryAssertion
+		}
+		return &Assertion{kind, Span{start, p.pos}}, false
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		digitsStart := p.pos
+		if index := p.parseDecimal(); index <= p.groups {
+			return &Backreference{Index: index, Span: Span{start, p.pos}}, true
+		} else if p.u {
+			p.fail(start, "invalid backreference")
+			return nil, false
+		}
+		p.pos = digitsStart
+		return p.parseLegacyEscape(start), true
+	case 'k':
+		if p.u || p.named {
+			p.pos++
+			if !p.at("<") {
+				p.fail(start, "invalid named reference")
+				return nil, false
+			}
+			p.pos++
+			name := p.parseGroupName()
+			if p.err != nil {
+				return nil, false
+			}
+			ref := &Backreference{Name: name, Span: Span{start, p.pos}}
+			p.refs = append(p.refs, ref)
+			return ref, true
+		}
+	case 'd', 'D', 's', 'S', 'w', 'W', 'p', 'P':
+		if n := p.parseCharClassEscape(start); n != nil || p.err != nil {
+			return n, true
+		}
+	}
+	return p.parseCharEscape(start, false), true
+}
+
+// parseCharClassEscape parses \d, \D, \s, \S, \w, \W, and property escapes in Unicode mode, it returns nil if the escape is not a character class escape.
+func (p *parser) parseCharClassEscape(start int) Node {
+	// assume we're past \
+	switch c := p.src[p.pos]; c {
+	case 'd', 'D', 's', 'S', 'w', 'W':
+		p.pos++
+		return &CharClassEscape{c, Span{start, p.pos}}
+	case 'p', 'P':
+		if p.u {
+			return p.parsePropertyEscape(start)
+		}
+	}
+	return nil
+}
+
+func (p *parser) parsePropertyEscape(start int) Node {
+	// assume we're at p or P
+	n := &PropertyEscape{Negated: p.src[p.pos] == 'P'}
+	p.pos++
+	if !p.at("{") {
+		p.fail(start, "invalid property name")
+		return nil
+	}
+	p.pos++
+	word := func() []byte {
+		wordStart := p.pos
+		for !p.eof() && (identifierChar(p.src[p.pos]) && p.src[p.pos] != '$') {
+			p.pos++
+		}
+		return p.src[wordStart:p.pos]
+	}
+	n.Name = word()
+	if p.at("=") {
+		p.pos++
+		n.Value = word()
+	}
+	if !p.at("}") || len(n.Name) == 0 || n.Value != nil && len(n.Value) == 0 {
+		p.fail(start, "invalid property name")
+		return nil
+	}
+	p.pos++
+	n.Span = Span{start, p.pos}
+
+	valid := false
+	if n.Value != nil {
+		switch string(n.Name) {
+		case "General_Category", "gc":
+			valid = generalCategories[string(n.Value)]
+		case "Script", "sc", "Script_Extensions", "scx":
+			valid = isScript(n.Value)
+		}
+	} else {
+		valid = generalCategories[string(n.Name)] || binaryProperties[string(n.Name)] || p.v && !n.Negated && stringProperties[string(n.Name)]
+	}
+	if !valid {
+		p.fail(start, "invalid property name")
+		return nil
+	}
+	return n
+}
+
+// parseCharEscape parses a character escape, which is an identity escape for unknown characters outside of Unicode mode.
+func (p *parser) parseCharEscape(start int, inClass bool) *Char {
+	// assume we're past \
+	c := p.src[p.pos]
+	r := rune(c)
+	switch c {
+	case 'f':
+		r = '\f'
+	case 'n':
+		r = '\n'
+	case 'r':
+		r = '\r'
+	case 't':
+		r = '\t'
+	case 'v':
+		r = '\v'
+	case 'c':
+		if next := p.peek(1); 'a' <= next|0x20 && next|0x20 <= 'z' || inClass && !p.u && ('0' <= next && next <= '9' || next == '_') {
+			p.pos += 2
+			return &Char{rune(next % 32), p.src[start:p.pos], Span{start, p.pos}}
+		} else if p.u {
+			p.fail(start, "invalid unicode escape")
+			return nil
+		}
+		// Annex B: the backslash is a literal character
+		return &Char{'\\', p.src[start:p.pos], Span{start, p.pos}}
+	case '0':
+		if next := p.peek(1); next < '0' || '9' < next {
+			r = 0
+		} else if p.u {
+			p.fail(start, "invalid decimal escape")
+			return nil
+		} else {
+			return p.parseLegacyEscape(start)
+		}
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		// only inside classes
+		if p.u {
+			p.fail(start, "invalid class escape")
+			return nil
+		}
+		return p.parseLegacyEscape(start)
+	case 'x':
+		if isHex(p.peek(1)) && isHex(p.peek(2)) {
+			r = rune(hexValue(p.src[p.pos+1 : p.pos+3]))
+			p.pos += 2
+		} else if p.u {
+			p.fail(start, "invalid escape")
+			return nil
+		}
+	case 'u':
+		pos := p.pos
+		var ok bool
+		if r, ok = p.parseUnicodeEscape(p.u); ok {
+			return &Char{r, p.src[start:p.pos], Span{start, p.pos}}
+		} else if p.u {
+			p.fail(start, "invalid unicode escape")
+			return nil
+		}
+		p.pos = pos
+		r = 'u'
+	case 'b':
+		// only inside classes
+		r = '\b'
+	case '-':
+		if !inClass && p.u {
+			p.fail(start, "invalid escape")
+			return nil
+		}
+	case 'k':
+		if p.u || p.named {
+			p.fail(start, "invalid escape") // only in classes, \k is a named reference elsewhere
+			return nil
+		}
+	default:
+		var n int
+		r, n = utf8.DecodeRune(p.src[p.pos:p.end])
+		if p.u && !bytes.ContainsRune([]byte("^$\\.*+?()[]{}|/"), r) && (!p.v || !inClass || !bytes.ContainsRune([]byte("&-!#%,:;<=>@`~"), r)) {
+			p.fail(start, "invalid escape")
+			return nil
+		}
+		p.pos += n - 1
+	}
+	p.pos++
+	return &Char{r, p.src[start:p.pos], Span{start, p.pos}}
+}
+
+// parseLegacyEscape parses an octal escape of at most 0377, or an identity escape for 8 and 9.
+func (p *parser) parseLegacyEscape(start int) *Char {
+	// assume we're past \ at a digit
+	r := rune(0)
+	for i := 0; i < 3 && !p.eof() && '0' <= p.src[p.pos] && p.src[p.pos] <= '7'; i++ {
+		next := r*8 + rune(p.src[p.pos]-'0')
+		if 0377 < next {
+			break
+		}
+		r = next
+		p.pos++
+	}
+	if p.pos == start+1 {
+		// 8 or 9
+		r = rune(p.src[p.pos])
+		p.pos++
+	}
+	return &Char{r, p.src[start:p.pos], Span{start, p.pos}}
+}
+
+// parseUnicodeEscape parses \uXXXX or in Unicode mode \u{X...}, and in Unicode mode combines escaped surrogate pairs. It does not move if the escape is invalid.
+func (p *parser) parseUnicodeEscape(unicodeMode bool) (rune, bool) {
+	// assume we're at u
+	if unicodeMode && p.peek(1) == '{' {
+		i := p.pos + 2
+		r := 0
+		for i < p.end && isHex(p.src[i]) {
+			r = r*16 + hexValue(p.src[i:i+1])
+			if 0x10FFFF < r {
+				return 0, false
+			}
+			i++
+		}
+		if i == p.pos+2 || p.end <= i || p.src[i] != '}' {
+			return 0, false
+		}
+		p.pos = i + 1
+		return rune(r), true
+	}
+	if p.end < p.pos+5 || !isHex(p.src[p.pos+1]) || !isHex(p.src[p.pos+2]) || !isHex(p.src[p.pos+3]) || !isHex(p.src[p.pos+4]) {
+		return 0, false
+	}
+	r := rune(hexValue(p.src[p.pos+1 : p.pos+5]))
+	p.pos += 5
+	if unicodeMode && 0xD800 <= r && r <= 0xDBFF && p.at(`\u`) && p.pos+6 <= p.end {
+		if trail := p.src[p.pos+2 : p.pos+6]; isHex(trail[0]) && isHex(trail[1]) && isHex(trail[2]) && isHex(trail[3]) {
+			if low := rune(hexValue(trail)); 0xDC00 <= low && low <= 0xDFFF {
+				p.pos += 6
+				return (r-0xD800)<<10 + (low - 0xDC00) + 0x10000, true
+			}
+		}
+	}
+	return r, true
+}
+
+func (p *parser) parseClass() Node {
+	// assume we're at [
+	start := p.pos
+	p.pos++
+	n := &Class{}
+	if p.at("^") {
+		n.Negated = true
+		p.pos++
+	}
+	if p.v {
+		p.parseClassSet(n)
+	} else {
+		p.parseClassRanges(n)
+	}
+	if p.err != nil {
+		return nil
+	} else if p.eof() {
+		p.fail(start, "unterminated character class")
+		return nil
+	}
+	p.pos++
+	n.Span = Span{start, p.pos}
+	if n.Negated && mayContainStrings(n) {
+		p.fail(start, "negated character class may contain strings")
+		return nil
+	}
+	return n
+}
+
+// parseClassRanges parses the characters, ranges, and escapes of a class without the v flag.
+func (p *parser) parseClassRanges(n *Class) {
+	for !p.eof() && p.src[p.pos] != ']' {
+		atom := p.parseClassAtom()
+		if p.err != nil {
+			return
+		} else if !p.at("-") || p.peek(1) == ']' || p.end <= p.pos+1 {
+			n.List = append(n.List, atom)
+			continue
+		}
+		dashStart := p.pos
+		p.pos++
+		to := p.parseClassAtom()
+		if p.err != nil {
+			return
+		}
+		from, ok := atom.(*Char)
+		toChar, ok2 := to.(*Char)
+		if ok && ok2 {
+			if toChar.Value < from.Value {
+				p.fail(from.Start, "range out of order in character class")
+				return
+			}
+			n.List = append(n.List, &ClassRange{*from, *toChar, Span{from.Start, toChar.End}})
+		} else if p.u {
+			p.fail(atom.Range().Start, "invalid character class")
+			return
+		} else {
+			// Annex B: a class escape in a range is a union with the dash
+			dash := &Char{'-', p.src[dashStart : dashStart+1], Span{dashStart, dashStart + 1}}
+			n.List = append(n.List, atom, dash, to)
+		}
+	}
+}
+
+func (p *parser) parseClassAtom() Node {
+	start := p.pos
+	if p.src[p.pos] != '\\' {
+		return p.parseChar()
+	}
+	p.pos++
+	if p.eof() {
+		p.fail(start, `\ at end of pattern`)
+		return nil
+	} else if n := p.parseCharClassEscape(start); n != nil || p.err != nil {
+		return n
+	} else if p.src[p.pos] == 'B' && p.u {
+		p.fail(start, "invalid class escape")
+		return nil
+	}
+	return p.parseCharEscape(start, true)
+}
+
+// parseClassSet parses the contents of a class with the v flag, which is a union of operands and ranges, or an intersection or subtraction of operands.
+func (p *parser) parseClassSet(n *Class) {
+	for !p.eof() && p.src[p.pos] != ']' {
+		if 0 < len(n.List) {
+			if p.at("&&") || p.at("--") {
+				op := IntersectionOp
+				if p.src[p.pos] == '-' {
+					op = SubtractionOp
+				}
+				if n.Op == UnionOp && 1 < len(n.List) || n.Op != UnionOp && n.Op != op {
+					p.fail(p.pos, "invalid set operation in character class")
+					return
+				} else if _, ok := n.List[0].(*ClassRange); ok {
+					p.fail(p.pos, "invalid set operation in character class")
+					return
+				}
+				n.Op = op
+				p.pos += 2
+				if p.eof() || p.src[p.pos] == ']' || p.src[p.pos] == '&' && op == IntersectionOp {
+					p.fail(p.pos, "invalid set operation in character class")
+					return
+				}
+			} else if n.Op != UnionOp {
+				p.fail(p.pos, "invalid set operation in character class")
+				return
+			}
+		}
+
+		operand := p.parseClassSetOperand()
+		if p.err != nil {
+			return
+		}
+		if from, ok := operand.(*Char); ok && n.Op == UnionOp && p.at("-") && !p.at("--") {
+			p.pos++
+			to := p.parseClassSetOperand()
+			if p.err != nil {
+				return
+			}
+			toChar, ok := to.(*Char)
+			if !ok {
+				p.fail(to.Range().Start, "invalid character class")
+				return
+			} else if toChar.Value < from.Value {
+				p.fail(from.Start, "range out of order in character class")
+				return
+			}
+			operand = &ClassRange{*from, *toChar, Span{from.Start, toChar.End}}
+		}
+		n.List = append(n.List, operand)
+	}
+}
+
+func (p *parser) parseClassSetOperand() Node {
+	start := p.pos
+	if p.eof() {
+		p.fail(start, "unterminated character class")
+		return nil
+	}
+	switch c := p.src[p.pos]; c {
+	case '[':
+		return p.parseClass()
+	case '\\':
+		p.pos++
+		if p.eof() {
+			p.fail(start, `\ at end of pattern`)
+			return nil
+		} else if n := p.parseCharClassEscape(start); n != nil || p.err != nil {
+			return n
+		} else if p.src[p.pos] == 'q' {
+			return p.parseClassStrings(start)
+		}
+		p.pos = start
+	}
+	return p.parseClassSetCharacter()
+}
+
+// parseClassSetCharacter parses a character of a class with the v flag, syntax characters must be escaped and reserved double punctuators are not allowed.
+func (p *parser) parseClassSetCharacter() *Char {
+	start := p.pos
+	c := p.src[p.pos]
+	if c == '\\' {
+		p.pos++
+		if p.eof() {
+			p.fail(start, `\ at end of pattern`)
+			return nil
+		}
+		return p.parseCharEscape(start, true)
+	} else if bytes.IndexByte([]byte("()[]{}/-|"), c) != -1 {
+		p.fail(start, "invalid character in character class")
+		return nil
+	} else if bytes.IndexByte([]byte("&!#$%*+,.:;<=>?@^`~"), c) != -1 && p.peek(1) == c {
+		p.fail(start, "invalid set operation in character class")
+		return nil
+	}
+	return p.parseChar()
+}
+
+func (p *parser) parseClassStrings(start int) Node {
+	// assume we're at q
+	p.pos++
+	if !p.at("{") {
+		p.fail(start, "invalid escape")
+		return nil
+	}
+	p.pos++
+	n := &ClassStrings{List: [][]Char{{}}}
+	for !p.eof() && p.src[p.pos] != '}' {
+		if p.src[p.pos] == '|' {
+			n.List = append(n.List, []Char{})
+			p.pos++
+			continue
+		}
+		c := p.parseClassSetCharacter()
+		if p.err != nil {
+			return nil
+		}
+		n.List[len(n.List)-1] = append(n.List[len(n.List)-1], *c)
+	}
+	if p.eof() {
+		p.fail(start, "invalid escape")
+		return nil
+	}
+	p.pos++
+	n.Span = Span{start, p.pos}
+	return n
+}
+
+// mayContainStrings returns true if a class item can match strings of a length other than one character.
+func mayContainStrings(n Node) bool {
+	switch n := n.(type) {
+	case *ClassStrings:
+		for _, str := range n.List {
+			if len(str) != 1 {
+				return true
+			}
+		}
+	case *PropertyEscape:
+		return n.Value == nil && stringProperties[string(n.Name)]
+	case *Class:
+		if n.Op == UnionOp {
+			for _, item := range n.List {
+				if mayContainStrings(item) {
+					return true
+				}
+			}
+			return false
+		} else if n.Op == SubtractionOp {
+			return mayContainStrings(n.List[0])
+		}
+		for _, item := range n.List {
+			if !mayContainStrings(item) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+////////////////////////////////////////////////////////////////
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c|0x20 && c|0x20 <= 'f'
+}
+
+func hexValue(b []byte) int {
+	n := 0
+	for _, c := range b {
+		if c <= '9' {
+			n = n*16 + int(c-'0')
+		} else {
+			n = n*16 + int(c|0x20-'a'+10)
+		}
+	}
+	return n
+}
+
+func identifierChar(c byte) bool {
+	return 'a' <= c|0x20 && c|0x20 <= 'z' || '0' <= c && c <= '9' || c == '_' || c == '$'
+}
+
+func isIDStart(r rune) bool {
+	if r < utf8.RuneSelf {
+		return identifierChar(byte(r)) && (r < '0' || '9' < r)
+	}
+	return unicode.IsLetter(r) || unicode.Is(unicode.Nl, r) || unicode.Is(unicode.Other_ID_Start, r)
+}
+
+func isIDContinue(r rune) bool {
+	if r < utf8.RuneSelf {
+		return identifierChar(byte(r))
+	}
+	return isIDStart(r) || unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue) || r == '\u200C' || r == '\u200D'
+}
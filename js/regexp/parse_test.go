@@ -0,0 +1,214 @@
+package regexp
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		re string
+	}{
+		{`//`},
+		{`/abc/`},
+		{`/a|b|/`},
+		{`/^a$/m`},
+		{`/a*b+c?d{2}e{2,}f{2,3}g*?h+?i??j{2}?/`},
+		{`/./s`},
+		{`/(a)(?:b)(?<name>c)\1\k<name>/`},
+		{`/(?<$a_1>x)|(?<$a_1>y)/`},
+		{`/(?<a>x)\k<a>/`},
+		{`/(?i:a)(?-m:b)(?s-i:c)/`},
+		{`/(?=a)(?!b)(?<=c)(?<!d)/`},
+		{`/\b\B\d\D\s\S\w\W/`},
+		{`/\f\n\r\t\v\0\x41A\cA\//`},
+		{`/\u{1F600}😀/u`},
+		{`/\p{L}\P{Letter}\p{Script=Greek}\p{sc=Latn}\p{gc=Lu}\p{ASCII_Hex_Digit}/u`},
+		{`/[abc][^a-z][\d-][a-][-a][\b\-\]]/`},
+		{`/[\p{L}\u{10000}-\u{10FFFF}]/u`},
+		{`/[\p{L}&&\p{Script=Greek}][\w--[a-z]][[a-z]\q{abc|d}]/v`},
+		{`/[\p{RGI_Emoji}--\q{x}][^\q{a|b}][\&\-\!]/v`},
+		{`/[^[^a]]/v`},
+
+		// Annex B
+		{`/]{}/`},
+		{`/a{/`},
+		{`/a{1,/`},
+		{`/(?=a)*/`},
+		{`/\c/`},
+		{`/[\c1\c_]/`},
+		{`/\1(a)/`},
+		{`/\2(a)\8\9/`},
+		{`/\012\0\08\377\400/`},
+		{`/\k\p{L}\u{1}\x1\e/`},
+		{`/[\d-a][a-\w]/`},
+		{`/[\1\7\8]/`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.re, func(t *testing.T) {
+			re, err := ParseLiteral([]byte(tt.re))
+			test.Error(t, err)
+			if tt.re == "//" {
+				test.String(t, re.String(), "/(?:)/")
+			} else {
+				test.String(t, re.String(), tt.re)
+			}
+		})
+	}
+}
+
+func TestParseNodes(t *testing.T) {
+	re, err := Parse([]byte(`a(?<b>c|d)*?[^e-f\d]\1`), IgnoreCase)
+	test.Error(t, err)
+	test.T(t, re.Groups, 1)
+	test.T(t, re.Flags, IgnoreCase)
+	test.T(t, len(re.Body.List), 1)
+
+	list := re.Body.List[0].List
+	test.T(t, len(list), 4)
+	test.T(t, list[0].(*Char).Value, 'a')
+	test.T(t, list[0].Range(), Span{0, 1})
+
+	quantifier := list[1].(*Quantifier)
+	test.T(t, quantifier.Min, 0)
+	test.T(t, quantifier.Max, -1)
+	test.T(t, quantifier.Lazy, true)
+	test.T(t, quantifier.Range(), Span{1, 12})
+	group := quantifier.Body.(*Group)
+	test.T(t, group.Capturing, true)
+	test.String(t, string(group.Name), "b")
+	test.T(t, group.Index, 1)
+	test.T(t, len(group.Body.List), 2)
+
+	class := list[2].(*Class)
+	test.T(t, class.Negated, true)
+	test.T(t, class.List[0].(*ClassRange).From.Value, 'e')
+	test.T(t, class.List[0].(*ClassRange).To.Value, 'f')
+	test.T(t, class.List[1].(*CharClassEscape).Kind, byte('d'))
+	test.T(t, list[3].(*Backreference).Index, 1)
+
+	_, err = Parse([]byte(`\x41\u{42}\103\cJ`), Unicode)
+	test.T(t, err.(*Error).Message, "invalid backreference")
+	re, err = Parse([]byte(`\x41\u{42}\cJ`), Unicode)
+	test.Error(t, err)
+	values := []rune{}
+	for _, item := range re.Body.List[0].List {
+		values = append(values, item.(*Char).Value)
+	}
+	test.T(t, string(values), "AB\n")
+}
+
+func TestParseGenerated(t *testing.T) {
+	var tests = []struct {
+		n        Node
+		expected string
+	}{
+		{&Char{Value: 'a'}, `a`},
+		{&Char{Value: '/'}, `\/`},
+		{&Char{Value: '*'}, `\*`},
+		{&Char{Value: '\n'}, `\n`},
+		{&Char{Value: 0}, `\x00`},
+		{&Char{Value: '\u2028'}, `\u2028`},
+		{&Char{Value: ' '}, ` `},
+		{&Char{Value: 0xD800}, `\ud800`},
+		{&Class{List: []Node{&Char{Value: ']'}, &Char{Value: '-'}, &Char{Value: '*'}}}, `[\]\-*]`},
+		{&Quantifier{Min: 2, Max: 2, Body: &Dot{}}, `.{2}`},
+		{&Group{Add: IgnoreCase, Remove: Multiline | DotAll, Body: Disjunction{List: []Alternative{{List: []Node{&Char{Value: 'a'}}}}}}, `(?i-ms:a)`},
+		{&Char{Value: '/', Raw: []byte("/")}, `\/`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			test.String(t, tt.n.String(), tt.expected)
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	var tests = []struct {
+		re     string
+		err    string
+		offset int
+	}{
+		{`/a`, "unterminated regular expression literal", 2},
+		{"/a\n/", "unterminated regular expression literal", 2},
+		{`/a/x`, "invalid regular expression flag", 3},
+		{`/a/gg`, "duplicate regular expression flag", 4},
+		{`/a/uv`, "regular expression flags u and v cannot be combined", 4},
+		{`/*/`, "nothing to repeat", 1},
+		{`/a**/`, "nothing to repeat", 3},
+		{`/^*/`, "nothing to repeat", 1},
+		{`/\b+/`, "nothing to repeat", 1},
+		{`/(?<=a)?/`, "nothing to repeat", 1},
+		{`/(?=a)*/u`, "nothing to repeat", 1},
+		{`/{1}/`, "nothing to repeat", 1},
+		{`/a{2,1}/`, "numbers out of order in {} quantifier", 2},
+		{`/a)/`, "unmatched ')'", 2},
+		{`/(a/`, "unterminated group", 1},
+		{`/(?a)/`, "invalid group", 1},
+		{`/(?ii:a)/`, "repeated flag in group modifiers", 4},
+		{`/(?i-i:a)/`, "invalid group", 1},
+		{`/(?-:a)/`, "invalid group", 1},
+		{`/(?<1a>x)/`, "invalid capture group name", 4},
+		{`/(?<a>x)(?<a>y)/`, "duplicate capture group name", 11},
+		{`/(?:(?<a>x)|y)(?<a>z)/`, "duplicate capture group name", 17},
+		{`/(?<a>x)\k<b>/`, "invalid named reference", 8},
+		{`/(?<a>x)\k/`, "invalid named reference", 8},
+		{`/\k<a>/u`, "invalid named reference", 1},
+		{`/[b-a]/`, "range out of order in character class", 2},
+		{`/[\d-a]/u`, "invalid character class", 2},
+		{`/\/`, "unterminated regular expression literal", 3},
+		{`/]/u`, "lone quantifier brackets", 1},
+		{`/a{/u`, "incomplete quantifier", 2},
+		{`/\1/u`, "invalid backreference", 1},
+		{`/\01/u`, "invalid decimal escape", 1},
+		{`/\c/u`, "invalid unicode escape", 1},
+		{`/\x1/u`, "invalid escape", 1},
+		{`/\u{110000}/u`, "invalid unicode escape", 1},
+		{`/\e/u`, "invalid escape", 1},
+		{`/\-/u`, "invalid escape", 1},
+		{`/[\1]/u`, "invalid class escape", 2},
+		{`/[\B]/u`, "invalid class escape", 2},
+		{`/(?<a>x)[\k]/`, "invalid escape", 9},
+		{`/\p{Foo}/u`, "invalid property name", 1},
+		{`/\p{Script=Foo}/u`, "invalid property name", 1},
+		{`/\p{Foo=Greek}/u`, "invalid property name", 1},
+		{`/\p{L/u`, "invalid property name", 1},
+		{`/\p{RGI_Emoji}/u`, "invalid property name", 1},
+		{`/\P{RGI_Emoji}/v`, "invalid property name", 1},
+		{`/[a-z&&b]/v`, "invalid set operation in character class", 5},
+		{`/[a&&b--c]/v`, "invalid set operation in character class", 6},
+		{`/[ab&&c]/v`, "invalid set operation in character class", 4},
+		{`/[a&&&b]/v`, "invalid set operation in character class", 5},
+		{`/[a&&]/v`, "invalid set operation in character class", 5},
+		{`/[(]/v`, "invalid character in character class", 2},
+		{`/[a!!]/v`, "invalid set operation in character class", 3},
+		{`/[^\q{ab}]/v`, "negated character class may contain strings", 1},
+		{`/[^\p{RGI_Emoji}]/v`, "negated character class may contain strings", 1},
+		{`/[\q{a]/v`, "invalid character in character class", 6},
+		{`/\q{a}/v`, "invalid escape", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.re, func(t *testing.T) {
+			_, err := ParseLiteral([]byte(tt.re))
+			test.That(t, err != nil, "must return error")
+			test.String(t, err.(*Error).Message, tt.err)
+			test.T(t, err.(*Error).Offset, tt.offset)
+		})
+	}
+
+	_, err := Parse([]byte("[a"), 0)
+	test.String(t, err.Error(), "unterminated character class at offset 0")
+	_, err = Parse([]byte("a"), Unicode|UnicodeSets)
+	test.That(t, err != nil)
+	_, err = ParseLiteral([]byte("a/"))
+	test.That(t, err != nil)
+	test.String(t, (&Error{"invalid escape", 5}).Error(), "invalid escape at offset 5")
+}
+
+func TestParseFlags(t *testing.T) {
+	flags, err := ParseFlags([]byte("ygmdsvi"))
+	test.Error(t, err)
+	test.T(t, flags, HasIndices|Global|IgnoreCase|Multiline|DotAll|UnicodeSets|Sticky)
+	test.String(t, flags.String(), "dgimsvy")
+}
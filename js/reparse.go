@@ -0,0 +1,280 @@
+package js
+
+import (
+	"io"
+
+	"github.com/tdewolff/parse/v2"
+)
+
+// TextEdit is a change of the source code that replaces the Deleted bytes at Offset by the Inserted bytes.
+type TextEdit struct {
+	Offset, Deleted int
+	Inserted        []byte
+}
+
+// Apply returns the source code with the edit applied.
+func (e TextEdit) Apply(src []byte) []byte {
+	b := make([]byte, 0, len(src)-e.Deleted+len(e.Inserted))
+	b = append(b, src[:e.Offset]...)
+	b = append(b, e.Inserted...)
+	return append(b, src[e.Offset+e.Deleted:]...)
+}
+
+// Reparse parses the input, which is the source code of the AST after applying the edit, by reusing the top-level statements of the AST that are not affected by the edit. Only the statements from the one before the edit up to the first statement after the edit are parsed again, the offsets of the statements that follow are shifted, and the global scope is updated. The reused statements are moved to the returned AST so that the given AST must not be used anymore. The AST must have been parsed with the same options. It falls back to parsing the whole input when the NoRefs, Comments, Tokens, or ErrorRecovery option is set, when the edit is in the first two statements or in the directive prologue, when the edit changes how the following statements or strict mode code are parsed, or when the parsed statements have an error. The returned AST and error are the same as those returned by Parse.
+func Reparse(ast *AST, r *parse.Input, edit TextEdit, o Options) (*AST, error) {
+	delta := len(edit.Inserted) - edit.Deleted
+	editEnd := edit.Offset + edit.Deleted
+	if o.NoRefs || o.Comments || o.Tokens || o.ErrorRecovery || edit.Offset < 0 || edit.Deleted < 0 || ast.End < editEnd || r.Len() != ast.End+delta {
+		return Parse(r, o)
+	}
+	list := ast.List
+	for _, stmt := range list {
+		if stmt == nil {
+			return Parse(r, o)
+		}
+	}
+
+	// the statements in list[start:end] are parsed again, where the statement before the edit is included since it may continue into the edit
+	start := 0
+	for start < len(list) && list[start].Range().End < edit.Offset {
+		start++
+	}
+	start--
+	if start < 1 {
+		return Parse(r, o)
+	} else if _, ok := list[start-1].(*DirectivePrologueStmt); ok {
+		return Parse(r, o)
+	}
+	end := start + 1
+	for end < len(list) && list[end].Range().Start <= editEnd {
+		end++
+	}
+	regionStart, regionEnd := list[start].Range().Start, ast.End
+	if end < len(list) {
+		regionEnd = list[end].Range().Start
+	}
+
+	// the global scope contains the variables that are used and declared by the reused statements
+	uses := map[*Var]int{}
+	prefixRefs, suffixRefs := []varRef{}, []varRef{}
+	for i, ref := range ast.refs {
+		if regionStart <= ref.Start && ref.Start < regionEnd {
+			continue
+		}
+		if i+1 == len(ast.refs) || ast.refs[i+1].Span != ref.Span {
+			// the preceding reference with the same span is replaced by the declaration of an arrow function parameter
+			uses[resolveVar(ref.v)]++
+		}
+		if ref.Start < regionStart {
+			prefixRefs = append(prefixRefs, ref)
+		} else {
+			ref.Start += delta
+			ref.End += delta
+			suffixRefs = append(suffixRefs, ref)
+		}
+	}
+	decls := &globalDecls{vars: map[*Var]bool{}, varDecls: map[*VarDecl]bool{}}
+	for _, stmt := range list[:start] {
+		Walk(decls, stmt)
+	}
+	for _, stmt := range list[end:] {
+		Walk(decls, stmt)
+	}
+
+	reparsed := &AST{
+		Comments: ast.Comments,
+		Strict:   ast.Strict,
+	}
+	scope := &reparsed.Scope
+	scope.Func = scope
+	scope.IsGlobalOrFunc = ast.Scope.IsGlobalOrFunc
+	scope.HasWith = decls.hasWith
+	seen := map[*Var]bool{}
+	for _, v := range ast.Scope.Declared {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		if decls.vars[v] {
+			scope.Declared = append(scope.Declared, v)
+		} else if 0 < uses[v] {
+			// only declared by the statements that are parsed again
+			v.Decl = NoDecl
+			scope.Undeclared = append(scope.Undeclared, v)
+		}
+		v.Uses = uint16(uses[v])
+	}
+	for _, v := range ast.Scope.Undeclared {
+		if !seen[v] && 0 < uses[v] {
+			seen[v] = true
+			scope.Undeclared = append(scope.Undeclared, v)
+			v.Uses = uint16(uses[v])
+		}
+	}
+	suffixVarDecls := []*VarDecl{}
+	for _, varDecl := range ast.Scope.VarDecls {
+		if decls.varDecls[varDecl] {
+			if varDecl.Start < regionStart {
+				scope.VarDecls = append(scope.VarDecls, varDecl)
+			} else {
+				suffixVarDecls = append(suffixVarDecls, varDecl)
+			}
+		}
+	}
+
+	// parse the statements until the start of the first reused statement after the edit
+	p := newParser(r, o)
+	p.scope = scope
+	p.strict = ast.Strict
+	r.Move(regionStart)
+	r.Skip()
+	p.next()
+
+	reparsed.List = append(make([]IStmt, 0, len(list)+1), list[:start]...)
+	next := regionEnd + delta
+	for p.err == nil && (end == len(list) || p.offset() < next) && p.parseModuleItem(&reparsed.BlockStmt) {
+	}
+	if p.err != nil || p.l.Err() != nil && p.l.Err() != io.EOF || p.strict != ast.Strict || end < len(list) && p.offset() != next {
+		r.Reset()
+		return Parse(r, o)
+	}
+	if end < len(list) {
+		reparsed.End = ast.End + delta
+	}
+	reparsed.List = append(reparsed.List, list[end:]...)
+	reparsed.refs = append(append(prefixRefs, p.refs...), suffixRefs...)
+	scope.VarDecls = append(scope.VarDecls, suffixVarDecls...)
+
+	rebase := &rebaser{
+		from:   regionEnd,
+		delta:  delta,
+		scopes: [2]*Scope{&ast.Scope, ast.Scope.Func},
+		scope:  scope,
+		vars:   map[*Var]bool{},
+	}
+	for _, stmt := range list[:start] {
+		Walk(rebase, stmt)
+	}
+	for _, stmt := range list[end:] {
+		Walk(rebase, stmt)
+	}
+
+	// recount the uses of the global variables and set the span of their first occurrence
+	uses = map[*Var]int{}
+	spans := map[*Var]Span{}
+	for i, ref := range reparsed.refs {
+		if i+1 == len(reparsed.refs) || reparsed.refs[i+1].Span != ref.Span {
+			v := resolveVar(ref.v)
+			if _, ok := spans[v]; !ok {
+				spans[v] = ref.Span
+			}
+			uses[v]++
+		}
+	}
+	for _, vars := range []VarArray{scope.Declared, scope.Undeclared} {
+		for _, v := range vars {
+			v.Uses = uint16(uses[v])
+			if span, ok := spans[v]; ok {
+				v.Span = span
+			}
+		}
+	}
+
+	if o.EarlyErrors {
+		if errs := check(reparsed, r.Bytes(), o.TypeScript); len(errs) != 0 {
+			return reparsed, errs[0]
+		}
+	}
+	return reparsed, nil
+}
+
+// globalDecls collects the variables and the variable declarations of the statements that may belong to the global scope, and whether they contain a with statement outside of functions.
+type globalDecls struct {
+	vars     map[*Var]bool
+	varDecls map[*VarDecl]bool
+	hasWith  bool
+}
+
+func (d *globalDecls) Enter(n INode) IVisitor {
+	switch n := n.(type) {
+	case *VarDecl:
+		// variables declared in block scopes are not in the global scope and are ignored by the caller
+		d.varDecls[n] = true
+		for _, item := range n.List {
+			for _, v := range bindingVars(item.Binding, nil) {
+				d.vars[v] = true
+			}
+		}
+	case *FuncDecl:
+		if n.Name != nil {
+			d.vars[resolveVar(n.Name)] = true
+		}
+		return nil
+	case *ClassDecl:
+		if n.Name != nil {
+			d.vars[resolveVar(n.Name)] = true
+		}
+		return nil
+	case *ArrowFunc, *MethodDecl:
+		return nil
+	case *WithStmt:
+		d.hasWith = true
+	}
+	return d
+}
+
+func (d *globalDecls) Exit(n INode) {}
+
+// rebaser moves the reused statements to the new global scope and shifts the offsets of the statements that follow the edit.
+type rebaser struct {
+	from, delta int
+	scopes      [2]*Scope // previous global scope and the one referred to by its child scopes
+	scope       *Scope
+	vars        map[*Var]bool
+}
+
+func (r *rebaser) Enter(n INode) IVisitor {
+	switch n := n.(type) {
+	case *Var:
+		if r.vars[n] {
+			return nil
+		}
+		r.vars[n] = true
+	case *BlockStmt:
+		r.rescope(&n.Scope)
+	case *SwitchStmt:
+		r.rescope(&n.Scope)
+	case *VarDecl:
+		if n.Scope == r.scopes[0] || n.Scope == r.scopes[1] {
+			n.Scope = r.scope
+		}
+	case *ClassDecl:
+		// class elements are not visited by Walk
+		for i := range n.List {
+			n.List[i].shift(r.from, r.delta)
+		}
+	}
+	if n, ok := n.(interface{ shift(int, int) }); ok {
+		n.shift(r.from, r.delta)
+	}
+	return r
+}
+
+func (r *rebaser) Exit(n INode) {}
+
+func (r *rebaser) rescope(scope *Scope) {
+	if scope.Parent == r.scopes[0] || scope.Parent == r.scopes[1] {
+		scope.Parent = r.scope
+	}
+	if scope.Func == r.scopes[0] || scope.Func == r.scopes[1] {
+		scope.Func = r.scope
+	}
+}
+
+// shift moves the span by delta if it starts at or after from.
+func (s *Span) shift(from, delta int) {
+	if from <= s.Start {
+		s.Start += delta
+		s.End += delta
+	}
+}
@@ -0,0 +1,143 @@
+package js
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+// spanList returns all spans of the nodes in source order of the fields, skipping scopes and variable links.
+func spanList(v reflect.Value, spans []Span) []Span {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			spans = spanList(v.Elem(), spans)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			spans = spanList(v.Index(i), spans)
+		}
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(Span{}) {
+			return append(spans, Span{int(v.Field(0).Int()), int(v.Field(1).Int())})
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Type().Field(i); field.PkgPath == "" && field.Name != "Link" && field.Type != reflect.TypeOf(Scope{}) && field.Type != reflect.TypeOf(&Scope{}) {
+				spans = spanList(v.Field(i), spans)
+			}
+		}
+	}
+	return spans
+}
+
+// globalScope returns the names and uses of the global variables, and the spans of their references.
+func globalScope(ast *AST) string {
+	scopes := AnalyzeScopes(ast)
+	vars := []string{}
+	for _, v := range ast.Scope.Declared {
+		vars = append(vars, fmt.Sprintf("declared %s=%d %v %v", v.Data, v.Uses, v.Span, scopes.Refs(v)))
+	}
+	for _, v := range ast.Scope.Undeclared {
+		vars = append(vars, fmt.Sprintf("undeclared %s=%d %v %v", v.Data, v.Uses, v.Span, scopes.Refs(v)))
+	}
+	sort.Strings(vars)
+	return strings.Join(vars, "\n")
+}
+
+func TestReparse(t *testing.T) {
+	var tests = []struct {
+		src      string
+		offset   int
+		deleted  int
+		inserted string
+		reused   bool // the last statement is reused
+	}{
+		{"a = 1; b = 2; c = 3; d = 4", 18, 1, "30", true},
+		{"a = 1; b = 2; c = 3; d = 4; e = 5", 19, 0, " + c", true},
+		{"a = 1; b = 2; c = 3; d = 4", 13, 0, "x = y; ", true},
+		{"a = 1; b = 2; c = 3; d = 4", 26, 0, "; e = 5", false},
+		{"a = 1; b = 2; c = 3;\n\nd = 4", 21, 0, "e = d;", true},
+		{"var x = 1; f(); y(); function f() { return z }", 16, 3, "g()", true},
+		{"let a = 1; b(); var c = 2; c + a; d", 16, 11, "", true},
+		{"let a = 1; b(); c(); z + a; d", 16, 4, "let z = 2; ", true},
+		{"x; y; f(); function f() {}", 6, 3, "f(1)", true},
+		{"x; y; { var a = 1 } a; b", 12, 1, "b", true},
+		{"x; y; { var a = 1 } a; b", 6, 14, "", true},
+		{"x; y; with (o) {} z; w", 6, 12, "", true},
+		{"x; y; z; w", 6, 0, "with (o) {}", true},
+		{"x; y; z = (a) => a + b; w", 17, 1, "c", true},
+		{"x; y; z = function (a) { var b; return a + b }; b", 20, 0, "c, ", true},
+		{"x; y; class A { m() { return A } }; new A", 6, 0, "let B = A; ", true},
+		{"import a from 'a'; export let b = a; c(); export {b as d}", 37, 3, "c(a)", true},
+		{"x; y; label: for (;;) { break label }; z", 24, 11, "continue label", true},
+		{"x;\ny;\na = b;\n(c)\nd", 11, 1, "", false}, // (c) becomes a call
+		{"x; y; z; w", 0, 1, "v", false},
+		{"x; y; z; w", 3, 1, "v", false},
+		{"\"use strict\"; 'a'; x; y", 15, 1, "b", false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d/%d/%s", tt.src, tt.offset, tt.deleted, tt.inserted), func(t *testing.T) {
+			edit := TextEdit{tt.offset, tt.deleted, []byte(tt.inserted)}
+			src := edit.Apply([]byte(tt.src))
+
+			ast, err := Parse(parse.NewInputString(tt.src), Options{})
+			test.Error(t, err)
+			last := ast.List[len(ast.List)-1]
+
+			reparsed, err := Reparse(ast, parse.NewInputBytes(src), edit, Options{})
+			test.Error(t, err)
+			expected, err := Parse(parse.NewInputBytes(src), Options{})
+			test.Error(t, err)
+
+			test.T(t, reparsed.List[len(reparsed.List)-1] == last, tt.reused, "reused")
+			test.T(t, reparsed.String(), expected.String())
+			test.T(t, fmt.Sprint(spanList(reflect.ValueOf(reparsed), nil)), fmt.Sprint(spanList(reflect.ValueOf(expected), nil)))
+			test.T(t, globalScope(reparsed), globalScope(expected))
+			test.T(t, reparsed.Scope.HasWith, expected.Scope.HasWith, "with")
+			test.T(t, len(reparsed.Scope.VarDecls), len(expected.Scope.VarDecls), "var declarations")
+		})
+	}
+
+	// with Options.NoRefs the whole input is parsed again
+	edit := TextEdit{18, 1, []byte("30")}
+	src := "a = 1; b = 2; c = 3; d = 4"
+	ast, err := Parse(parse.NewInputString(src), Options{NoRefs: true})
+	test.Error(t, err)
+	last := ast.List[len(ast.List)-1]
+	reparsed, err := Reparse(ast, parse.NewInputBytes(edit.Apply([]byte(src))), edit, Options{NoRefs: true})
+	test.Error(t, err)
+	test.T(t, reparsed.List[len(reparsed.List)-1] == last, false, "reused")
+	test.String(t, reparsed.String(), "Stmt(a=1) Stmt(b=2) Stmt(c=30) Stmt(d=4)")
+}
+
+func TestReparseError(t *testing.T) {
+	var tests = []struct {
+		src      string
+		offset   int
+		deleted  int
+		inserted string
+	}{
+		{"a = 1; b = 2; c = 3; d = 4", 18, 1, ")"},
+		{"let a; b; c; d", 10, 1, "let a"},
+		{"a; b; c; let d", 6, 1, "var d"},
+		{"a; b; c; function f(){}", 6, 1, "let f"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d/%d/%s", tt.src, tt.offset, tt.deleted, tt.inserted), func(t *testing.T) {
+			edit := TextEdit{tt.offset, tt.deleted, []byte(tt.inserted)}
+			src := edit.Apply([]byte(tt.src))
+
+			ast, err := Parse(parse.NewInputString(tt.src), Options{})
+			test.Error(t, err)
+			_, err = Reparse(ast, parse.NewInputBytes(src), edit, Options{})
+			_, expected := Parse(parse.NewInputBytes(src), Options{})
+			test.That(t, expected != nil, "expected error")
+			test.T(t, fmt.Sprint(err), fmt.Sprint(expected))
+		})
+	}
+}
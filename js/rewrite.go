@@ -0,0 +1,447 @@
+package js
+
+import "fmt"
+
+// IRewriter represents an AST rewriter.
+// Each INode encountered by `Rewrite` is passed to `Enter`, which returns the node that takes its place and the IRewriter for its children. Returning a nil node removes it, returning a nil IRewriter skips its children.
+// `Exit` is called upon the exit of a node and returns the node that takes its place, or nil to remove it
+type IRewriter interface {
+	Enter(n INode) (INode, IRewriter)
+	Exit(n INode) INode
+}
+
+// Rewrite traverses an AST in the same order as Walk and replaces the nodes returned by the rewriter, it returns the node that replaces n.
+// A replacement must be of the same kind as the node it replaces, that is a statement, expression, or binding, or a node of the same type for nodes that are not one of those.
+// Removed nodes are deleted from lists, removed statements that are not in a list are replaced by an empty statement, and other removed nodes set their field to nil, which is only valid for fields that can be nil.
+// Nodes that are stored by value, such as the parameters of a function, are copied from their replacement and cannot be removed
+func Rewrite(r IRewriter, n INode) INode {
+	if n == nil {
+		return nil
+	}
+
+	n, child := r.Enter(n)
+	if n == nil || child == nil {
+		return n
+	}
+
+	switch n := n.(type) {
+	case *AST:
+		rewriteBlockStmt(child, &n.BlockStmt)
+	case *Var:
+		// no children
+	case *BlockStmt:
+		n.List = rewriteStmtList(child, n.List)
+	case *EmptyStmt:
+		// no children
+	case *ExprStmt:
+		n.Value = rewriteExpr(child, n.Value)
+	case *IfStmt:
+		n.Cond = rewriteExpr(child, n.Cond)
+		n.Body = rewriteBody(child, n.Body)
+		n.Else = rewriteStmt(child, n.Else)
+	case *DoWhileStmt:
+		n.Body = rewriteBody(child, n.Body)
+		n.Cond = rewriteExpr(child, n.Cond)
+	case *WhileStmt:
+		n.Cond = rewriteExpr(child, n.Cond)
+		n.Body = rewriteBody(child, n.Body)
+	case *ForStmt:
+		n.Init = rewriteExpr(child, n.Init)
+		n.Cond = rewriteExpr(child, n.Cond)
+		n.Post = rewriteExpr(child, n.Post)
+		n.Body = rewriteBlock(child, n.Body, false)
+	case *ForInStmt:
+		n.Init = rewriteExpr(child, n.Init)
+		n.Value = rewriteExpr(child, n.Value)
+		n.Body = rewriteBlock(child, n.Body, false)
+	case *ForOfStmt:
+		n.Init = rewriteExpr(child, n.Init)
+		n.Value = rewriteExpr(child, n.Value)
+		n.Body = rewriteBlock(child, n.Body, false)
+	case *CaseClause:
+		n.Cond = rewriteExpr(child, n.Cond)
+		n.List = rewriteStmtList(child, n.List)
+	case *SwitchStmt:
+		n.Init = rewriteExpr(child, n.Init)
+		j := 0
+		for i := range n.List {
+			if clause := Rewrite(child, &n.List[i]); clause != nil {
+				n.List[j] = *clause.(*CaseClause)
+				j++
+			}
+		}
+		n.List = n.List[:j]
+	case *BranchStmt:
+		// no children
+	case *ReturnStmt:
+		n.Value = rewriteExpr(child, n.Value)
+	case *WithStmt:
+		n.Cond = rewriteExpr(child, n.Cond)
+		n.Body = rewriteBody(child, n.Body)
+	case *LabelledStmt:
+		n.Value = rewriteBody(child, n.Value)
+	case *ThrowStmt:
+		n.Value = rewriteExpr(child, n.Value)
+	case *TryStmt:
+		n.Body = rewriteBlock(child, n.Body, false)
+		n.Binding = rewriteBinding(child, n.Binding)
+		n.Catch = rewriteBlock(child, n.Catch, true)
+		n.Finally = rewriteBlock(child, n.Finally, true)
+	case *DebuggerStmt:
+		// no children
+	case *BadStmt:
+		// no children
+	case *Alias:
+		// no children
+	case *ImportAttribute:
+		// no children
+	case *ImportStmt:
+		n.List = rewriteAliases(child, n.List)
+		n.Attributes = rewriteImportAttributes(child, n.Attributes)
+	case *ExportStmt:
+		n.List = rewriteAliases(child, n.List)
+		n.Decl = rewriteExpr(child, n.Decl)
+		n.Attributes = rewriteImportAttributes(child, n.Attributes)
+	case *DirectivePrologueStmt:
+		// no children
+	case *PropertyName:
+		if literal := Rewrite(child, &n.Literal); literal != nil {
+			n.Literal = *literal.(*LiteralExpr)
+		}
+		n.Computed = rewriteExpr(child, n.Computed)
+	case *BindingArray:
+		n.List = rewriteBindingElements(child, n.List)
+		n.Rest = rewriteBinding(child, n.Rest)
+	case *BindingObjectItem:
+		n.Key = rewritePropertyName(child, n.Key)
+		rewriteBindingElement(child, &n.Value)
+	case *BindingObject:
+		j := 0
+		for i := range n.List {
+			if item := Rewrite(child, &n.List[i]); item != nil {
+				n.List[j] = *item.(*BindingObjectItem)
+				j++
+			}
+		}
+		n.List = n.List[:j]
+		n.Rest = rewriteVar(child, n.Rest)
+	case *BindingElement:
+		n.Binding = rewriteBinding(child, n.Binding)
+		n.Default = rewriteExpr(child, n.Default)
+	case *VarDecl:
+		n.List = rewriteBindingElements(child, n.List)
+	case *Params:
+		n.List = rewriteBindingElements(child, n.List)
+		n.Rest = rewriteBinding(child, n.Rest)
+	case *FuncDecl:
+		n.Name = rewriteVar(child, n.Name)
+		rewriteParams(child, &n.Params)
+		rewriteBlockStmt(child, &n.Body)
+	case *MethodDecl:
+		n.Decorators = rewriteExprList(child, n.Decorators)
+		rewritePropertyNameValue(child, &n.Name)
+		rewriteParams(child, &n.Params)
+		rewriteBlockStmt(child, &n.Body)
+	case *Field:
+		n.Decorators = rewriteExprList(child, n.Decorators)
+		rewritePropertyNameValue(child, &n.Name)
+		n.Init = rewriteExpr(child, n.Init)
+	case *ClassDecl:
+		n.Decorators = rewriteExprList(child, n.Decorators)
+		n.Name = rewriteVar(child, n.Name)
+		n.Extends = rewriteExpr(child, n.Extends)
+		j := 0
+		for i := range n.List {
+			if rewriteClassElement(child, &n.List[i]) {
+				n.List[j] = n.List[i]
+				j++
+			}
+		}
+		n.List = n.List[:j]
+	case *ClassElement:
+		rewriteClassElement(child, n)
+	case *LiteralExpr:
+		// no children
+	case *Element:
+		n.Value = rewriteExpr(child, n.Value)
+	case *ArrayExpr:
+		j := 0
+		for i := range n.List {
+			if elem := Rewrite(child, &n.List[i]); elem != nil {
+				n.List[j] = *elem.(*Element)
+				j++
+			}
+		}
+		n.List = n.List[:j]
+	case *Property:
+		n.Name = rewritePropertyName(child, n.Name)
+		n.Value = rewriteExpr(child, n.Value)
+		n.Init = rewriteExpr(child, n.Init)
+	case *ObjectExpr:
+		j := 0
+		for i := range n.List {
+			if prop := Rewrite(child, &n.List[i]); prop != nil {
+				n.List[j] = *prop.(*Property)
+				j++
+			}
+		}
+		n.List = n.List[:j]
+	case *TemplatePart:
+		n.Expr = rewriteExpr(child, n.Expr)
+	case *TemplateExpr:
+		n.Tag = rewriteExpr(child, n.Tag)
+		j := 0
+		for i := range n.List {
+			if part := Rewrite(child, &n.List[i]); part != nil {
+				n.List[j] = *part.(*TemplatePart)
+				j++
+			}
+		}
+		n.List = n.List[:j]
+	case *GroupExpr:
+		n.X = rewriteExpr(child, n.X)
+	case *IndexExpr:
+		n.X = rewriteExpr(child, n.X)
+		n.Y = rewriteExpr(child, n.Y)
+	case *DotExpr:
+		n.X = rewriteExpr(child, n.X)
+		if y := Rewrite(child, &n.Y); y != nil {
+			n.Y = *y.(*LiteralExpr)
+		}
+	case *NewTargetExpr:
+		// no children
+	case *ImportMetaExpr:
+		// no children
+	case *Arg:
+		n.Value = rewriteExpr(child, n.Value)
+	case *Args:
+		j := 0
+		for i := range n.List {
+			if arg := Rewrite(child, &n.List[i]); arg != nil {
+				n.List[j] = *arg.(*Arg)
+				j++
+			}
+		}
+		n.List = n.List[:j]
+	case *NewExpr:
+		n.X = rewriteExpr(child, n.X)
+		if n.Args != nil {
+			args, _ := Rewrite(child, n.Args).(*Args)
+			n.Args = args
+		}
+	case *CallExpr:
+		n.X = rewriteExpr(child, n.X)
+		if args := Rewrite(child, &n.Args); args != nil {
+			n.Args = *args.(*Args)
+		}
+	case *UnaryExpr:
+		n.X = rewriteExpr(child, n.X)
+	case *BinaryExpr:
+		n.X = rewriteExpr(child, n.X)
+		n.Y = rewriteExpr(child, n.Y)
+	case *CondExpr:
+		n.Cond = rewriteExpr(child, n.Cond)
+		n.X = rewriteExpr(child, n.X)
+		n.Y = rewriteExpr(child, n.Y)
+	case *YieldExpr:
+		n.X = rewriteExpr(child, n.X)
+	case *ArrowFunc:
+		rewriteParams(child, &n.Params)
+		rewriteBlockStmt(child, &n.Body)
+	case *CommaExpr:
+		n.List = rewriteExprList(child, n.List)
+	case *JSXElement:
+		n.Name = rewriteExpr(child, n.Name)
+		j := 0
+		for i := range n.Attrs {
+			if attr := Rewrite(child, &n.Attrs[i]); attr != nil {
+				n.Attrs[j] = *attr.(*JSXAttribute)
+				j++
+			}
+		}
+		n.Attrs = n.Attrs[:j]
+		n.Children = rewriteExprList(child, n.Children)
+	case *JSXAttribute:
+		n.Value = rewriteExpr(child, n.Value)
+	case *JSXExpr:
+		n.X = rewriteExpr(child, n.X)
+	case *JSXName:
+		// no children
+	case *JSXText:
+		// no children
+	}
+	return child.Exit(n)
+}
+
+func rewriteStmt(r IRewriter, stmt IStmt) IStmt {
+	if stmt == nil {
+		return nil
+	} else if n := Rewrite(r, stmt); n != nil {
+		return n.(IStmt)
+	}
+	return nil
+}
+
+// rewriteBody rewrites a statement that cannot be nil, a removed statement is replaced by an empty statement.
+func rewriteBody(r IRewriter, stmt IStmt) IStmt {
+	if stmt == nil {
+		return nil
+	}
+	end := stmt.Range().End
+	if stmt = rewriteStmt(r, stmt); stmt == nil {
+		return &EmptyStmt{Span{end, end}}
+	}
+	return stmt
+}
+
+func rewriteStmtList(r IRewriter, list []IStmt) []IStmt {
+	j := 0
+	for _, stmt := range list {
+		if stmt = rewriteStmt(r, stmt); stmt != nil {
+			list[j] = stmt
+			j++
+		}
+	}
+	return list[:j]
+}
+
+// rewriteBlock rewrites a block statement field, a removed block that is not optional is replaced by an empty block.
+func rewriteBlock(r IRewriter, block *BlockStmt, optional bool) *BlockStmt {
+	if block == nil {
+		return nil
+	}
+	end := block.End
+	if n := Rewrite(r, block); n != nil {
+		return n.(*BlockStmt)
+	} else if !optional {
+		return &BlockStmt{Span: Span{end, end}}
+	}
+	return nil
+}
+
+func rewriteBlockStmt(r IRewriter, block *BlockStmt) {
+	if n := Rewrite(r, block); n != nil {
+		*block = *n.(*BlockStmt)
+	}
+}
+
+func rewriteExpr(r IRewriter, expr IExpr) IExpr {
+	if expr == nil {
+		return nil
+	} else if n := Rewrite(r, expr); n != nil {
+		return n.(IExpr)
+	}
+	return nil
+}
+
+func rewriteExprList(r IRewriter, list []IExpr) []IExpr {
+	j := 0
+	for _, expr := range list {
+		if expr = rewriteExpr(r, expr); expr != nil {
+			list[j] = expr
+			j++
+		}
+	}
+	return list[:j]
+}
+
+func rewriteBinding(r IRewriter, binding IBinding) IBinding {
+	if binding == nil {
+		return nil
+	} else if n := Rewrite(r, binding); n != nil {
+		return n.(IBinding)
+	}
+	return nil
+}
+
+func rewriteVar(r IRewriter, v *Var) *Var {
+	if v == nil {
+		return nil
+	} else if n := Rewrite(r, v); n != nil {
+		return n.(*Var)
+	}
+	return nil
+}
+
+func rewritePropertyName(r IRewriter, name *PropertyName) *PropertyName {
+	if name == nil {
+		return nil
+	} else if n := Rewrite(r, name); n != nil {
+		return n.(*PropertyName)
+	}
+	return nil
+}
+
+func rewritePropertyNameValue(r IRewriter, name *PropertyName) {
+	if n := Rewrite(r, name); n != nil {
+		*name = *n.(*PropertyName)
+	}
+}
+
+func rewriteParams(r IRewriter, params *Params) {
+	if n := Rewrite(r, params); n != nil {
+		*params = *n.(*Params)
+	}
+}
+
+func rewriteBindingElement(r IRewriter, elem *BindingElement) {
+	if n := Rewrite(r, elem); n != nil {
+		*elem = *n.(*BindingElement)
+	}
+}
+
+func rewriteBindingElements(r IRewriter, list []BindingElement) []BindingElement {
+	j := 0
+	for i := range list {
+		if elem := Rewrite(r, &list[i]); elem != nil {
+			list[j] = *elem.(*BindingElement)
+			j++
+		}
+	}
+	return list[:j]
+}
+
+func rewriteAliases(r IRewriter, list []Alias) []Alias {
+	j := 0
+	for i := range list {
+		if alias := Rewrite(r, &list[i]); alias != nil {
+			list[j] = *alias.(*Alias)
+			j++
+		}
+	}
+	return list[:j]
+}
+
+func rewriteImportAttributes(r IRewriter, list []ImportAttribute) []ImportAttribute {
+	j := 0
+	for i := range list {
+		if attr := Rewrite(r, &list[i]); attr != nil {
+			list[j] = *attr.(*ImportAttribute)
+			j++
+		}
+	}
+	return list[:j]
+}
+
+// rewriteClassElement rewrites the static block, method, or field of a class element, it returns false if it has been removed.
+func rewriteClassElement(r IRewriter, item *ClassElement) bool {
+	var elem INode = &item.Field
+	if item.StaticBlock != nil {
+		elem = item.StaticBlock
+	} else if item.Method != nil {
+		elem = item.Method
+	}
+	switch elem := Rewrite(r, elem).(type) {
+	case nil:
+		return false
+	case *BlockStmt:
+		*item = ClassElement{StaticBlock: elem, Span: item.Span}
+	case *MethodDecl:
+		*item = ClassElement{Method: elem, Span: item.Span}
+	case *Field:
+		*item = ClassElement{Field: *elem, Span: item.Span}
+	default:
+		panic(fmt.Sprintf("cannot replace class element by %T", elem))
+	}
+	return true
+}
@@ -0,0 +1,151 @@
+package js
+
+import (
+	"io"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+type rewriter struct {
+	enter func(INode) INode
+	exit  func(INode) INode
+}
+
+func (r *rewriter) Enter(n INode) (INode, IRewriter) {
+	if r.enter != nil {
+		n = r.enter(n)
+	}
+	return n, r
+}
+
+func (r *rewriter) Exit(n INode) INode {
+	if r.exit != nil {
+		return r.exit(n)
+	}
+	return n
+}
+
+func TestRewrite(t *testing.T) {
+	replaceX := func(n INode) INode {
+		if v, ok := n.(*Var); ok && string(v.Data) == "x" {
+			return &LiteralExpr{DecimalToken, []byte("5"), v.Span}
+		}
+		return n
+	}
+	removeDebugger := func(n INode) INode {
+		if _, ok := n.(*DebuggerStmt); ok {
+			return nil
+		}
+		return n
+	}
+	removeZero := func(n INode) INode {
+		switch n := n.(type) {
+		case *Arg:
+			if lit, ok := n.Value.(*LiteralExpr); ok && string(lit.Data) == "0" {
+				return nil
+			}
+		case *Element:
+			if lit, ok := n.Value.(*LiteralExpr); ok && string(lit.Data) == "0" {
+				return nil
+			}
+		}
+		return n
+	}
+	foldAdd := func(n INode) INode {
+		if expr, ok := n.(*BinaryExpr); ok && expr.Op == AddToken {
+			x, okX := expr.X.(*LiteralExpr)
+			y, okY := expr.Y.(*LiteralExpr)
+			if okX && okY && len(x.Data) == 1 && len(y.Data) == 1 {
+				return &LiteralExpr{DecimalToken, []byte{x.Data[0] + y.Data[0] - '0'}, expr.Span}
+			}
+		}
+		return n
+	}
+	renameA := func(n INode) INode {
+		if name, ok := n.(*PropertyName); ok && name.IsIdent([]byte("a")) {
+			return &PropertyName{Literal: LiteralExpr{IdentifierToken, []byte("b"), name.Span}, Span: name.Span}
+		}
+		return n
+	}
+	removeFields := func(n INode) INode {
+		if _, ok := n.(*Field); ok {
+			return nil
+		}
+		return n
+	}
+
+	var tests = []struct {
+		js       string
+		r        *rewriter
+		expected string
+	}{
+		{"a = x + 1; f(x, ...x); var b = [x, {x}]", &rewriter{enter: replaceX}, "a = 5 + 1; f(5, ...5); var b = [5, {x: 5}]"},
+		{"a = x + 1; f(x, ...x); var b = [x, {x}]", &rewriter{exit: replaceX}, "a = 5 + 1; f(5, ...5); var b = [5, {x: 5}]"},
+		{"debugger; if (a) debugger; else { debugger; b }", &rewriter{enter: removeDebugger}, "if (a); else { b }"},
+		{"for (;;) debugger; lbl: debugger", &rewriter{exit: removeDebugger}, "for (;;); lbl: ;"},
+		{"f(0, a, 0); b = [0, c]", &rewriter{exit: removeZero}, "f(a); b = [c]"},
+		{"a = 1 + 2 + 3 + b", &rewriter{exit: foldAdd}, "a = 6 + b"},
+		{"x = {a: 1}; class A { a() {} }", &rewriter{exit: renameA}, "x = {b: 1}; class A { b() {} }"},
+		{"class A { x = 1; m() {} static {} }", &rewriter{exit: removeFields}, "class A { m() {} static {} }"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+			test.T(t, Rewrite(tt.r, ast), INode(ast))
+
+			expected, err := Parse(parse.NewInputString(tt.expected), Options{})
+			if err != io.EOF {
+				test.Error(t, err)
+			}
+			test.String(t, ast.JS(), expected.JS())
+		})
+	}
+
+	// replacing a node in Enter visits the children of the replacement
+	ast, err := Parse(parse.NewInputString("a(b)"), Options{})
+	test.Error(t, err)
+	entered := []string{}
+	Rewrite(&rewriter{enter: func(n INode) INode {
+		entered = append(entered, n.String())
+		if stmt, ok := n.(*ExprStmt); ok {
+			return &ExprStmt{&GroupExpr{stmt.Value, stmt.Span}, stmt.Span}
+		}
+		return n
+	}}, ast)
+	test.T(t, entered, []string{"Stmt(a(b))", "Stmt({ Stmt(a(b)) })", "Stmt(a(b))", "((a(b)))", "(a(b))", "a", "(b)", "b", "b"})
+	test.String(t, ast.JS(), "(a(b)); ")
+}
+
+type skipRewriter struct {
+	exited []string
+}
+
+func (r *skipRewriter) Enter(n INode) (INode, IRewriter) {
+	if _, ok := n.(*FuncDecl); ok {
+		return n, nil
+	}
+	return n, r
+}
+
+func (r *skipRewriter) Exit(n INode) INode {
+	r.exited = append(r.exited, n.String())
+	return n
+}
+
+func TestRewriteSkip(t *testing.T) {
+	ast, err := Parse(parse.NewInputString("function f() { a }"), Options{})
+	test.Error(t, err)
+
+	r := &skipRewriter{}
+	Rewrite(r, ast)
+	test.T(t, r.exited, []string{"Stmt({ Decl(function f Params() Stmt({ Stmt(a) })) })", ast.String()}) // function is not exited
+}
+
+func TestRewriteNilNode(t *testing.T) {
+	for _, n := range astNodes {
+		test.T(t, Rewrite(&rewriter{}, n), n)
+	}
+}
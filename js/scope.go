@@ -0,0 +1,372 @@
+package js
+
+// RefFlags specifies how a variable is accessed by a reference.
+type RefFlags uint8
+
+// RefFlags values.
+const (
+	ReadRef  RefFlags = 1 << iota // the value of the variable is read
+	WriteRef                      // a value is assigned to the variable
+	DeclRef                       // the variable is declared, as in a binding of a var statement or a function parameter
+)
+
+func (flags RefFlags) String() string {
+	s := ""
+	for i, name := range []string{"Read", "Write", "Decl"} {
+		if flags&(1<<i) != 0 {
+			if s != "" {
+				s += "|"
+			}
+			s += name
+		}
+	}
+	if s == "" {
+		return "None"
+	}
+	return s
+}
+
+// varRef is an occurrence of a variable in the source as recorded by the parser.
+type varRef struct {
+	v *Var
+	Span
+}
+
+// Ref is a reference to a variable, which is an occurrence of its identifier in the source.
+type Ref struct {
+	Var   *Var       // variable that is referenced, its Link is always nil
+	Scope *ScopeNode // scope in which the reference occurs
+	Flags RefFlags
+	Span  // span of the identifier, or the span of the first occurrence of the variable when unknown, which is the case when the AST was parsed with Options.NoRefs
+}
+
+// IsRead returns true if the reference reads the value of the variable.
+func (r Ref) IsRead() bool {
+	return r.Flags&ReadRef != 0
+}
+
+// IsWrite returns true if the reference assigns a value to the variable.
+func (r Ref) IsWrite() bool {
+	return r.Flags&WriteRef != 0
+}
+
+// IsDecl returns true if the reference is a declaration of the variable.
+func (r Ref) IsDecl() bool {
+	return r.Flags&DeclRef != 0
+}
+
+func (r Ref) String() string {
+	return "Ref{" + string(r.Var.Data) + " " + r.Flags.String() + "}"
+}
+
+// ScopeNode is a scope in the scope tree built by AnalyzeScopes.
+type ScopeNode struct {
+	Scope    *Scope
+	Node     INode      // node that introduces the scope: *AST, *BlockStmt, *SwitchStmt, *FuncDecl, *MethodDecl, *ArrowFunc, *ForStmt, *ForInStmt, *ForOfStmt, or *TryStmt for the catch clause
+	Parent   *ScopeNode // nil for the global scope
+	Children []*ScopeNode
+	Refs     []*Ref // references that occur in this scope, excluding those in child scopes
+}
+
+// IsFunc returns true for function scopes and the global scope, which are the scopes of var declarations.
+func (s *ScopeNode) IsFunc() bool {
+	return s.Scope.Func == s.Scope
+}
+
+// Lookup returns the variable with the given name that is visible in the scope, or nil if no such variable is declared. Variables of enclosing scopes are found when they are not shadowed.
+func (s *ScopeNode) Lookup(name []byte) *Var {
+	for ; s != nil; s = s.Parent {
+		if v := s.Scope.findDeclared(name, false); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// Scopes is the scope tree of an AST together with all references to its variables.
+type Scopes struct {
+	Root *ScopeNode
+
+	scopes     map[*Scope]*ScopeNode
+	decls      map[*Var]*ScopeNode
+	refs       map[*Var][]*Ref
+	undeclared []*Var
+}
+
+// AnalyzeScopes builds the scope tree of an AST and resolves all identifiers to their variables. For ASTs returned by Parse with Options.NoRefs, the spans of the references are those of the first occurrences of their variables. Imports and exports refer to bindings by name and do not have references, and references inside a with statement may refer to properties of its object instead.
+func AnalyzeScopes(ast *AST) *Scopes {
+	a := &scopeAnalyzer{
+		Scopes: &Scopes{
+			scopes: map[*Scope]*ScopeNode{},
+			decls:  map[*Var]*ScopeNode{},
+			refs:   map[*Var][]*Ref{},
+		},
+		occurrences: newVarOccurrences(ast.refs),
+	}
+	Walk(a, ast)
+	return a.Scopes
+}
+
+// Scope returns the node in the scope tree of a scope of the AST, or nil if it is not part of the AST.
+func (s *Scopes) Scope(scope *Scope) *ScopeNode {
+	return s.scopes[scope]
+}
+
+// Resolve returns the variable that is referred to by v. Identifiers of the same variable may be represented by different Vars that are linked together by the parser, they all resolve to the same Var.
+func (s *Scopes) Resolve(v *Var) *Var {
+	return resolveVar(v)
+}
+
+// DeclScope returns the scope in which the variable is declared, or nil if it is undeclared.
+func (s *Scopes) DeclScope(v *Var) *ScopeNode {
+	return s.decls[resolveVar(v)]
+}
+
+// Refs returns the references to the variable in the order in which they appear in the source, including its declarations.
+func (s *Scopes) Refs(v *Var) []*Ref {
+	return s.refs[resolveVar(v)]
+}
+
+// Undeclared returns the variables that are used but not declared, such as globals that are defined by the environment, in the order of their first reference.
+func (s *Scopes) Undeclared() []*Var {
+	return s.undeclared
+}
+
+// Shadows returns the variable of an enclosing scope that is shadowed by the declaration of v, or nil if it does not shadow any variable.
+func (s *Scopes) Shadows(v *Var) *Var {
+	v = resolveVar(v)
+	if scope := s.decls[v]; scope != nil {
+		return scope.Parent.Lookup(v.Data)
+	}
+	return nil
+}
+
+func resolveVar(v *Var) *Var {
+	for v.Link != nil {
+		v = v.Link
+	}
+	return v
+}
+
+// isAssignment returns true for the assignment operators, including compound assignments such as +=.
+func isAssignment(tt TokenType) bool {
+	switch tt {
+	case EqToken, MulEqToken, DivEqToken, ModEqToken, ExpEqToken, AddEqToken, SubEqToken, LtLtEqToken, GtGtEqToken, GtGtGtEqToken, BitAndEqToken, BitXorEqToken, BitOrEqToken, AndEqToken, OrEqToken, NullishEqToken:
+		return true
+	}
+	return false
+}
+
+////////////////////////////////////////////////////////////////
+
+type scopeFrame struct {
+	node         INode
+	mode         RefFlags // access of the variables in the node when it is an assignment target or binding
+	index        int      // number of children visited
+	scope, inner *ScopeNode
+}
+
+// child returns the access mode and scope of the next child c of the frame's node. Children are in the inner scope of the node, except for a few children such as the discriminant of a switch statement.
+func (f *scopeFrame) child(c INode) (RefFlags, *ScopeNode) {
+	mode, scope := RefFlags(0), f.inner
+	switch n := f.node.(type) {
+	case *BinaryExpr:
+		if f.index == 0 && isAssignment(n.Op) {
+			if f.mode&WriteRef != 0 {
+				mode = f.mode // default value in a destructuring assignment
+			} else if n.Op == EqToken {
+				mode = WriteRef
+			} else {
+				mode = ReadRef | WriteRef
+			}
+		}
+	case *UnaryExpr:
+		if n.Op == PreIncrToken || n.Op == PreDecrToken || n.Op == PostIncrToken || n.Op == PostDecrToken {
+			mode = ReadRef | WriteRef
+		}
+	case *GroupExpr, *ArrayExpr, *ObjectExpr, *Element, *BindingArray, *BindingObject:
+		mode = f.mode
+	case *Property:
+		if n.Name == nil && f.index == 0 || n.Name != nil && f.index == 1 {
+			mode = f.mode
+		}
+	case *BindingObjectItem:
+		if _, ok := c.(*BindingElement); ok {
+			mode = f.mode
+		}
+	case *BindingElement:
+		if f.index == 0 && n.Binding != nil {
+			mode = f.mode
+			if n.Default != nil && mode&DeclRef != 0 {
+				mode |= WriteRef
+			}
+		}
+	case *VarDecl:
+		mode = DeclRef
+		if elem, ok := c.(*BindingElement); ok && (elem.Default != nil || n.InForInOf) {
+			mode |= WriteRef
+		}
+	case *Params:
+		mode = DeclRef | WriteRef
+	case *FuncDecl:
+		if f.index == 0 && n.Name != nil {
+			mode = DeclRef | WriteRef
+			if n.Body.Scope.findDeclared(n.Name.Data, false) != n.Name {
+				scope = f.scope // name of a function declaration
+			}
+		}
+	case *ClassDecl:
+		if f.index == 0 && n.Name != nil {
+			mode = DeclRef | WriteRef
+		}
+	case *MethodDecl:
+		if _, ok := c.(*PropertyName); ok {
+			scope = f.scope
+		}
+	case *TryStmt:
+		if c == INode(n.Body) || c == INode(n.Finally) {
+			scope = f.scope
+		} else if _, ok := c.(*BlockStmt); !ok {
+			mode = DeclRef | WriteRef // catch binding
+		}
+	case *SwitchStmt:
+		if f.index == 0 {
+			scope = f.scope
+		}
+	case *ForInStmt, *ForOfStmt:
+		if f.index == 0 {
+			mode = WriteRef
+		}
+	}
+	return mode, scope
+}
+
+type scopeAnalyzer struct {
+	*Scopes
+	stack       []scopeFrame
+	occurrences varOccurrences
+}
+
+func (a *scopeAnalyzer) Enter(n INode) IVisitor {
+	if len(a.stack) == 0 {
+		scope := &n.(*AST).BlockStmt.Scope
+		a.Root = a.addScope(scope, n, nil)
+		a.stack = append(a.stack, scopeFrame{node: n, scope: a.Root, inner: a.Root})
+		return a
+	}
+
+	parent := &a.stack[len(a.stack)-1]
+	mode, scope := parent.child(n)
+	parent.index++
+	if try, ok := parent.node.(*TryStmt); ok && scope == nil {
+		// add the catch scope after the scope of the try block
+		parent.inner = a.addBodyScope(try.Catch, try, parent.scope)
+		scope = parent.inner
+	}
+	if v, ok := n.(*Var); ok {
+		if v != nil {
+			a.addRef(v, mode, scope, parent.node.Range())
+		}
+		return nil
+	}
+
+	inner := scope
+	switch n := n.(type) {
+	case *BlockStmt:
+		if n.Scope.Func != nil {
+			inner = a.addScope(&n.Scope, n, scope)
+		}
+	case *SwitchStmt:
+		inner = a.addScope(&n.Scope, n, scope)
+	case *FuncDecl:
+		inner = a.addScope(&n.Body.Scope, n, scope)
+	case *MethodDecl:
+		inner = a.addScope(&n.Body.Scope, n, scope)
+	case *ArrowFunc:
+		inner = a.addScope(&n.Body.Scope, n, scope)
+	case *ForStmt:
+		inner = a.addBodyScope(n.Body, n, scope)
+	case *ForInStmt:
+		inner = a.addBodyScope(n.Body, n, scope)
+	case *ForOfStmt:
+		inner = a.addBodyScope(n.Body, n, scope)
+	case *TryStmt:
+		inner = nil // added when entering the catch binding or body
+	}
+	a.stack = append(a.stack, scopeFrame{n, mode, 0, scope, inner})
+	return a
+}
+
+func (a *scopeAnalyzer) Exit(n INode) {
+	a.stack = a.stack[:len(a.stack)-1]
+}
+
+// addScope adds the scope to the scope tree, unless it was already added.
+func (a *scopeAnalyzer) addScope(scope *Scope, n INode, parent *ScopeNode) *ScopeNode {
+	if s, ok := a.scopes[scope]; ok {
+		return s
+	}
+	s := &ScopeNode{Scope: scope, Node: n, Parent: parent}
+	if parent != nil {
+		parent.Children = append(parent.Children, s)
+	}
+	a.scopes[scope] = s
+	for _, v := range scope.Declared {
+		a.decls[v] = s
+	}
+	return s
+}
+
+// addBodyScope adds the scope of a body that also contains the declarations in the head of a for statement or catch clause.
+func (a *scopeAnalyzer) addBodyScope(body *BlockStmt, n INode, parent *ScopeNode) *ScopeNode {
+	if body == nil || body.Scope.Func == nil {
+		return parent
+	}
+	return a.addScope(&body.Scope, n, parent)
+}
+
+// addRef adds a reference to v, its span is that of the next occurrence of the variable.
+func (a *scopeAnalyzer) addRef(v *Var, flags RefFlags, scope *ScopeNode, parent Span) {
+	decl := resolveVar(v)
+	if flags == 0 {
+		flags = ReadRef
+	}
+	ref := &Ref{decl, scope, flags, a.occurrences.span(v, parent)}
+	if _, ok := a.refs[decl]; !ok && decl.Decl == NoDecl {
+		a.undeclared = append(a.undeclared, decl)
+	}
+	a.refs[decl] = append(a.refs[decl], ref)
+	scope.Refs = append(scope.Refs, ref)
+}
+
+// varOccurrences are the occurrences of the variables in the source as recorded by the parser, which are matched in walking order to the Vars of the AST.
+type varOccurrences struct {
+	list map[*Var][]varRef // occurrences per resolved variable
+	next map[*Var]int      // index of the next unused occurrence
+}
+
+func newVarOccurrences(refs []varRef) varOccurrences {
+	o := varOccurrences{map[*Var][]varRef{}, map[*Var]int{}}
+	for _, ref := range refs {
+		v := resolveVar(ref.v)
+		o.list[v] = append(o.list[v], ref)
+	}
+	return o
+}
+
+// span returns the span of the next occurrence of v within the span of its parent node, or the span of its first occurrence when unknown.
+func (o varOccurrences) span(v *Var, parent Span) Span {
+	decl := resolveVar(v)
+	list := o.list[decl]
+	i := o.next[decl]
+	for i < len(list) && list[i].Start < parent.Start {
+		i++
+	}
+	span := v.Span
+	if i < len(list) && list[i].End <= parent.End {
+		span = list[i].Span
+		i++
+	}
+	o.next[decl] = i
+	return span
+}
@@ -0,0 +1,184 @@
+package js
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+// findVar returns the first declared variable with the given name in the scope tree, or the undeclared variable.
+func findVar(scopes *Scopes, s *ScopeNode, name string) *Var {
+	for _, v := range s.Scope.Declared {
+		if string(v.Data) == name {
+			return v
+		}
+	}
+	for _, child := range s.Children {
+		if v := findVar(scopes, child, name); v != nil {
+			return v
+		}
+	}
+	if s == scopes.Root {
+		for _, v := range scopes.Undeclared() {
+			if string(v.Data) == name {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+func scopeTree(s *ScopeNode) string {
+	names := []string{}
+	for _, v := range s.Scope.Declared {
+		names = append(names, string(v.Data))
+	}
+	str := fmt.Sprintf("%T[%s]", s.Node, strings.Join(names, " "))
+	for _, child := range s.Children {
+		str += " (" + scopeTree(child) + ")"
+	}
+	return str
+}
+
+func TestScopeRefs(t *testing.T) {
+	var tests = []struct {
+		js       string
+		name     string
+		expected string
+	}{
+		{"var a = 1; a++; b = a + c; a += 2", "a", "4:Write|Decl 11:Read|Write 20:Read 27:Read|Write"},
+		{"let a; a = a; --a", "a", "4:Decl 7:Write 11:Read 16:Read|Write"},
+		{"[a, {b: a = a, ...a}] = c; ({a} = c); ({a = 1} = c)", "a", "1:Write 8:Write 12:Read 18:Write 29:Write 40:Write"},
+		{"a.b = 1; a[0]++; [a.c] = d; f(a)", "a", "0:Read 9:Read 18:Read 30:Read"},
+		{"for (a in b); for (a of b); for (var c in a);", "a", "5:Write 19:Write 42:Read"},
+		{"for (let a in b) a; for (const [a] of b) a", "a", "9:Write|Decl 17:Read"},
+		{"function f(a, b = a, ...c) { f(a, c) }", "a", "11:Write|Decl 18:Read 31:Read"},
+		{"function f(a, b = a, ...c) { f(a, c) }", "f", "9:Write|Decl 29:Read"},
+		{"var [a, {b: a = 1}] = c", "a", "5:Write|Decl 12:Write|Decl"},
+		{"var {a = 1} = b, {a: [a]} = b", "a", "5:Write|Decl 22:Write|Decl"},
+		{"try {} catch (a) { a }", "a", "14:Write|Decl 19:Read"},
+		{"x = function a() { a }; class b { m() { b } }", "a", "13:Write|Decl 19:Read"},
+		{"x = function a() { a }; class b { m() { b } }", "b", "30:Write|Decl 40:Read"},
+		{"(a, b) => a; (a)", "a", "1:Write|Decl 10:Read"},
+		{"(a, b); a", "a", "1:Read 8:Read"},
+		{"a => ({a}); ({a}) => a", "a", "0:Write|Decl 7:Read"},
+		{"{ a } { var a }", "a", "2:Read 12:Decl"},
+		{"x = {a}; y = {[a]: a}", "a", "5:Read 15:Read 19:Read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+
+			scopes := AnalyzeScopes(ast)
+			v := findVar(scopes, scopes.Root, tt.name)
+			test.That(t, v != nil, "variable not found")
+
+			refs := []string{}
+			for _, ref := range scopes.Refs(v) {
+				test.T(t, tt.js[ref.Start:ref.End], tt.name)
+				refs = append(refs, fmt.Sprintf("%d:%v", ref.Start, ref.Flags))
+			}
+			test.String(t, strings.Join(refs, " "), tt.expected)
+		})
+	}
+}
+
+func TestScopeTree(t *testing.T) {
+	var tests = []struct {
+		js       string
+		expected string
+	}{
+		{"a", "*js.AST[]"},
+		{"var a; { let b; var c } { }", "*js.AST[a c] (*js.BlockStmt[b]) (*js.BlockStmt[])"},
+		{"function f(a) { let b }", "*js.AST[f] (*js.FuncDecl[a b])"},
+		{"x = function f(a) {}; y = a => { let b }", "*js.AST[] (*js.FuncDecl[f a]) (*js.ArrowFunc[a b])"},
+		{"class A { m(a) {} static { let b } }", "*js.AST[A] (*js.MethodDecl[a]) (*js.BlockStmt[b])"},
+		{"for (let i; ;) { let j } for (var k in a);", "*js.AST[k] (*js.ForStmt[i j]) (*js.ForInStmt[])"},
+		{"try { let a } catch ({b}) { let c } finally { let d }", "*js.AST[] (*js.BlockStmt[a]) (*js.TryStmt[b c]) (*js.BlockStmt[d])"},
+		{"switch (a) { case 1: let b }", "*js.AST[] (*js.SwitchStmt[b])"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.js), Options{})
+			test.Error(t, err)
+
+			scopes := AnalyzeScopes(ast)
+			test.String(t, scopeTree(scopes.Root), tt.expected)
+			test.T(t, scopes.Scope(&ast.BlockStmt.Scope), scopes.Root)
+		})
+	}
+}
+
+func TestScopeResolve(t *testing.T) {
+	js := "var a, b; function f(a, c = a) { switch (c) { case 1: let b; b; d } { a = window } }"
+	ast, err := Parse(parse.NewInputString(js), Options{})
+	test.Error(t, err)
+
+	scopes := AnalyzeScopes(ast)
+	root := scopes.Root
+	f := root.Children[0]
+	sw := f.Children[0]
+	block := f.Children[1]
+	test.T(t, f.IsFunc(), true)
+	test.T(t, sw.IsFunc(), false)
+
+	// the function name is in the global scope, the parameter default in the function scope
+	fn := ast.List[1].(*FuncDecl)
+	test.T(t, scopes.Refs(fn.Name)[0].Scope, root)
+	test.T(t, scopes.Refs(fn.Params.List[1].Binding.(*Var))[0].Scope, f)
+	test.T(t, len(f.Refs), 4) // a, c, a, and the discriminant c
+	test.T(t, len(sw.Refs), 3)
+
+	// lookup and shadowing
+	a := root.Lookup([]byte("a"))
+	innerA := f.Lookup([]byte("a"))
+	test.That(t, a != nil && innerA != nil && a != innerA)
+	test.T(t, block.Lookup([]byte("a")), innerA)
+	test.T(t, scopes.Shadows(innerA), a)
+	test.T(t, scopes.Shadows(a), (*Var)(nil))
+	test.T(t, scopes.Shadows(sw.Lookup([]byte("b"))), root.Lookup([]byte("b")))
+	test.T(t, root.Lookup([]byte("c")), (*Var)(nil))
+	test.T(t, scopes.DeclScope(innerA), f)
+	test.T(t, scopes.Resolve(innerA), innerA)
+
+	// undeclared variables
+	undeclared := []string{}
+	for _, v := range scopes.Undeclared() {
+		undeclared = append(undeclared, string(v.Data))
+		test.T(t, scopes.DeclScope(v), (*ScopeNode)(nil))
+	}
+	test.T(t, undeclared, []string{"d", "window"})
+
+	// write to the parameter in a nested block
+	refs := scopes.Refs(innerA)
+	test.T(t, len(refs), 3)
+	test.T(t, refs[2].Scope, block)
+	test.T(t, refs[2].IsWrite(), true)
+	test.T(t, refs[2].IsRead(), false)
+	test.T(t, refs[2].IsDecl(), false)
+	test.String(t, refs[2].String(), "Ref{a Write}")
+}
+
+func TestScopeTypeScript(t *testing.T) {
+	// occurrences in backtracked and removed code are not references
+	js := "f<a>(b); function g(c: number): void; function g(c) { c }"
+	ast, err := Parse(parse.NewInputString(js), Options{TypeScript: true})
+	test.Error(t, err)
+
+	scopes := AnalyzeScopes(ast)
+	c := findVar(scopes, scopes.Root, "c")
+	refs := []int{}
+	for _, ref := range scopes.Refs(c) {
+		refs = append(refs, ref.Start)
+	}
+	test.T(t, refs, []int{49, 54})
+}
+
+func TestRefFlags(t *testing.T) {
+	test.String(t, RefFlags(0).String(), "None")
+	test.String(t, (ReadRef | WriteRef | DeclRef).String(), "Read|Write|Decl")
+}
@@ -0,0 +1,186 @@
+package js
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+const base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// SourceMap is a source map (revision 3) that maps positions in the generated code back to positions in the original sources. Lines and columns are zero-based and columns count UTF-16 code units, as required by the specification.
+type SourceMap struct {
+	File           string
+	SourceRoot     string
+	Sources        []string
+	SourcesContent []*string // nil for sources without content
+	Names          []string
+
+	names    map[string]int
+	mappings []byte
+
+	// previous segment, segment fields are relative to the previous segment
+	genLine, genCol, source, line, col, name int
+	empty                                    bool // no segment on the current generated line
+}
+
+// NewSourceMap returns a new source map for the generated file.
+func NewSourceMap(file string) *SourceMap {
+	return &SourceMap{
+		File:  file,
+		names: map[string]int{},
+		empty: true,
+	}
+}
+
+// AddSource adds an original source file and returns its index. Content can be nil in which case the content is not included in the source map.
+func (sm *SourceMap) AddSource(filename string, content []byte) int {
+	sm.Sources = append(sm.Sources, filename)
+	if content != nil {
+		s := string(content)
+		sm.SourcesContent = append(sm.SourcesContent, &s)
+	} else {
+		sm.SourcesContent = append(sm.SourcesContent, nil)
+	}
+	return len(sm.Sources) - 1
+}
+
+// AddMapping maps the generated position to the position in the source with the given index, name can be empty. Mappings must be added in the order of the generated positions.
+func (sm *SourceMap) AddMapping(genLine, genCol, source, line, col int, name string) {
+	if genLine < sm.genLine || genLine == sm.genLine && !sm.empty && genCol <= sm.genCol {
+		return // out of order or same generated position
+	}
+	for sm.genLine < genLine {
+		sm.mappings = append(sm.mappings, ';')
+		sm.genLine++
+		sm.genCol = 0
+		sm.empty = true
+	}
+	if !sm.empty {
+		sm.mappings = append(sm.mappings, ',')
+	}
+	sm.mappings = appendVLQ(sm.mappings, genCol-sm.genCol)
+	sm.mappings = appendVLQ(sm.mappings, source-sm.source)
+	sm.mappings = appendVLQ(sm.mappings, line-sm.line)
+	sm.mappings = appendVLQ(sm.mappings, col-sm.col)
+	if name != "" {
+		i, ok := sm.names[name]
+		if !ok {
+			i = len(sm.Names)
+			sm.names[name] = i
+			sm.Names = append(sm.Names, name)
+		}
+		sm.mappings = appendVLQ(sm.mappings, i-sm.name)
+		sm.name = i
+	}
+	sm.genCol, sm.source, sm.line, sm.col = genCol, source, line, col
+	sm.empty = false
+}
+
+// Mappings returns the encoded mappings.
+func (sm *SourceMap) Mappings() string {
+	return string(sm.mappings)
+}
+
+// MarshalJSON returns the source map in JSON.
+func (sm *SourceMap) MarshalJSON() ([]byte, error) {
+	v := struct {
+		Version        int       `json:"version"`
+		File           string    `json:"file,omitempty"`
+		SourceRoot     string    `json:"sourceRoot,omitempty"`
+		Sources        []string  `json:"sources"`
+		SourcesContent []*string `json:"sourcesContent,omitempty"`
+		Names          []string  `json:"names"`
+		Mappings       string    `json:"mappings"`
+	}{3, sm.File, sm.SourceRoot, sm.Sources, nil, sm.Names, string(sm.mappings)}
+	if v.Sources == nil {
+		v.Sources = []string{}
+	}
+	if v.Names == nil {
+		v.Names = []string{}
+	}
+	for _, content := range sm.SourcesContent {
+		if content != nil {
+			v.SourcesContent = sm.SourcesContent
+			break
+		}
+	}
+	return json.Marshal(v)
+}
+
+// WriteTo writes the source map in JSON to w.
+func (sm *SourceMap) WriteTo(w io.Writer) (int64, error) {
+	b, err := sm.MarshalJSON()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// appendVLQ appends the base64 VLQ encoding of i.
+func appendVLQ(b []byte, i int) []byte {
+	u := uint(i) << 1
+	if i < 0 {
+		u = uint(-i)<<1 | 1
+	}
+	for {
+		digit := u & 0x1F
+		u >>= 5
+		if u != 0 {
+			digit |= 0x20
+		}
+		b = append(b, base64Chars[digit])
+		if u == 0 {
+			return b
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////
+
+// lineIndex converts byte offsets in a source to zero-based lines and UTF-16 columns.
+type lineIndex struct {
+	src    []byte
+	starts []int // offsets of the start of each line
+}
+
+func newLineIndex(src []byte) lineIndex {
+	starts := []int{0}
+	for i := 0; i < len(src); i++ {
+		if c := src[i]; c == '\n' {
+			starts = append(starts, i+1)
+		} else if c == '\r' {
+			if i+1 < len(src) && src[i+1] == '\n' {
+				i++
+			}
+			starts = append(starts, i+1)
+		} else if c == 0xE2 && i+2 < len(src) && src[i+1] == 0x80 && (src[i+2] == 0xA8 || src[i+2] == 0xA9) {
+			// U+2028 and U+2029
+			i += 2
+			starts = append(starts, i+1)
+		}
+	}
+	return lineIndex{src, starts}
+}
+
+func (l lineIndex) position(offset int) (line, col int) {
+	if len(l.src) < offset {
+		offset = len(l.src)
+	}
+	line = sort.Search(len(l.starts), func(i int) bool { return offset < l.starts[i] }) - 1
+	return line, utf16Len(l.src[l.starts[line]:offset])
+}
+
+// utf16Len returns the number of UTF-16 code units of the UTF-8 encoded b.
+func utf16Len(b []byte) int {
+	n := 0
+	for _, c := range b {
+		if c < 0x80 || 0xC0 <= c && c < 0xF0 {
+			n++ // ASCII or the first byte of a 2 or 3 byte encoding
+		} else if 0xF0 <= c {
+			n += 2 // surrogate pair
+		}
+	}
+	return n
+}
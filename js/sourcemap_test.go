@@ -0,0 +1,140 @@
+package js
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestVLQ(t *testing.T) {
+	var tests = []struct {
+		i        int
+		expected string
+	}{
+		{0, "A"},
+		{1, "C"},
+		{-1, "D"},
+		{15, "e"},
+		{16, "gB"},
+		{-16, "hB"},
+		{123, "2H"},
+		{1 << 20, "ggggC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			test.String(t, string(appendVLQ(nil, tt.i)), tt.expected)
+			i, n := decodeVLQ(tt.expected)
+			test.T(t, i, tt.i)
+			test.T(t, n, len(tt.expected))
+		})
+	}
+}
+
+func decodeVLQ(s string) (int, int) {
+	u, shift := 0, uint(0)
+	for n := 0; n < len(s); n++ {
+		digit := strings.IndexByte(base64Chars, s[n])
+		u |= (digit & 0x1F) << shift
+		shift += 5
+		if digit&0x20 == 0 {
+			if u&1 == 1 {
+				return -(u >> 1), n + 1
+			}
+			return u >> 1, n + 1
+		}
+	}
+	return 0, 0
+}
+
+type segment struct {
+	genLine, genCol, source, line, col, name int
+}
+
+func decodeMappings(mappings string) []segment {
+	segs := []segment{}
+	prev, prevName := segment{}, 0
+	for genLine, line := range strings.Split(mappings, ";") {
+		prev.genCol = 0
+		for _, s := range strings.Split(line, ",") {
+			if s == "" {
+				continue
+			}
+			fields := []int{}
+			for 0 < len(s) {
+				i, n := decodeVLQ(s)
+				fields = append(fields, i)
+				s = s[n:]
+			}
+			seg := segment{genLine, prev.genCol + fields[0], prev.source + fields[1], prev.line + fields[2], prev.col + fields[3], -1}
+			if len(fields) == 5 {
+				seg.name = prevName + fields[4]
+				prevName = seg.name
+			}
+			segs = append(segs, seg)
+			prev = seg
+		}
+	}
+	return segs
+}
+
+func TestSourceMap(t *testing.T) {
+	src := "function add(a, b) {\n\treturn a + b;\n}\n\nvar x = add(1, 2), y = [x, 'é', x]\nif (x)\n  y.push({z: x})\n"
+	ast, err := Parse(parse.NewInputString(src), Options{})
+	test.Error(t, err)
+
+	sm := NewSourceMap("out.js")
+	source := sm.AddSource("in.js", []byte(src))
+	buf := &bytes.Buffer{}
+	p := NewPrinter(buf, PrintOptions{})
+	p.SetSourceMap(sm, source, []byte(src))
+	test.Error(t, p.Print(ast))
+	test.String(t, buf.String(), ast.JS())
+
+	srcLines := strings.Split(src, "\n")
+	genLines := strings.Split(buf.String(), "\n")
+	segs := decodeMappings(sm.Mappings())
+	test.That(t, 10 < len(segs), "number of mappings")
+	names := map[string]bool{}
+	refs := map[[2]int]string{}
+	for _, seg := range segs {
+		test.T(t, seg.source, source)
+		gen := string([]rune(genLines[seg.genLine])[seg.genCol:])
+		orig := string([]rune(srcLines[seg.line])[seg.col:])
+		if gen[0] != orig[0] {
+			t.Errorf("mapping of %q to %q", gen, orig)
+		}
+		if seg.name != -1 {
+			test.That(t, strings.HasPrefix(orig, sm.Names[seg.name]), "name at original position")
+			names[sm.Names[seg.name]] = true
+			refs[[2]int{seg.line, seg.col}] = sm.Names[seg.name]
+		}
+	}
+	test.T(t, len(names), 5, "names") // add, a, b, x, y
+
+	// references other than the declaration are mapped to their own position
+	test.String(t, refs[[2]int{1, 8}], "a")
+	test.String(t, refs[[2]int{4, 32}], "x")
+	test.String(t, refs[[2]int{5, 4}], "x")
+	test.String(t, refs[[2]int{6, 13}], "x")
+
+	b, err := sm.MarshalJSON()
+	test.Error(t, err)
+	test.String(t, string(b), `{"version":3,"file":"out.js","sources":["in.js"],"sourcesContent":["function add(a, b) {\n\treturn a + b;\n}\n\nvar x = add(1, 2), y = [x, 'é', x]\nif (x)\n  y.push({z: x})\n"],"names":["add","a","b","x","y"],"mappings":"`+sm.Mappings()+`"}`)
+}
+
+func TestSourceMapAddMapping(t *testing.T) {
+	sm := NewSourceMap("")
+	sm.AddSource("in.js", nil)
+	sm.AddMapping(1, 2, 0, 3, 4, "")
+	sm.AddMapping(1, 2, 0, 5, 6, "") // same generated position
+	sm.AddMapping(1, 5, 0, 3, 1, "a")
+	test.String(t, sm.Mappings(), ";EAGI,GAAHA")
+
+	buf := &bytes.Buffer{}
+	_, err := sm.WriteTo(buf)
+	test.Error(t, err)
+	test.String(t, buf.String(), `{"version":3,"sources":["in.js"],"names":["a"],"mappings":";EAGI,GAAHA"}`)
+}
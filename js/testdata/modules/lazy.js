@@ -0,0 +1 @@
+export default () => import('/main.js');
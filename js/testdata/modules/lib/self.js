@@ -0,0 +1,2 @@
+import './self.js';
+export const self = 1;
@@ -0,0 +1,7 @@
+import a, {b as c} from './a';
+export * from "./lib/index.js";
+const d = require('./data.cjs');
+import('./lazy' + '.js');
+import(x);
+require('fs');
+import 'react';
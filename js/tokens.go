@@ -0,0 +1,94 @@
+package js
+
+import (
+	"sort"
+)
+
+// Token is a token of the source code, which includes whitespace, line terminators, and comments.
+type Token struct {
+	TokenType
+	Data []byte
+	Span
+}
+
+// IsTrivia returns true for whitespace, line terminators, and comments, which are not part of the syntax.
+func (t Token) IsTrivia() bool {
+	return t.TokenType == WhitespaceToken || t.TokenType == LineTerminatorToken || t.TokenType == CommentToken || t.TokenType == CommentLineTerminatorToken
+}
+
+// TokenList is the list of all tokens of the source code, which is kept in AST.Tokens when Options.Tokens is set. Concatenating the data of the tokens gives back the source code byte for byte, where source code that was skipped after an error is kept as an ErrorToken. The tokens of a node are those within its span, and the trivia before and after the node are found with Leading and Trailing.
+type TokenList []Token
+
+// Bytes returns the source code of the tokens.
+func (ts TokenList) Bytes() []byte {
+	b := []byte{}
+	for _, t := range ts {
+		b = append(b, t.Data...)
+	}
+	return b
+}
+
+// index returns the index of the first token that starts at or after offset.
+func (ts TokenList) index(offset int) int {
+	return sort.Search(len(ts), func(i int) bool { return offset <= ts[i].Start })
+}
+
+// Node returns the tokens of the node.
+func (ts TokenList) Node(n INode) TokenList {
+	span := n.Range()
+	return ts[ts.index(span.Start):ts.index(span.End)]
+}
+
+// Leading returns the whitespace, line terminators, and comments between the previous token and the node.
+func (ts TokenList) Leading(n INode) TokenList {
+	end := ts.index(n.Range().Start)
+	start := end
+	for 0 < start && ts[start-1].IsTrivia() {
+		start--
+	}
+	return ts[start:end]
+}
+
+// Trailing returns the whitespace and comments after the node on the same line, including the line terminator that ends the line.
+func (ts TokenList) Trailing(n INode) TokenList {
+	start := ts.index(n.Range().End)
+	end := start
+	for end < len(ts) && ts[end].IsTrivia() {
+		end++
+		if ts[end-1].TokenType == LineTerminatorToken || ts[end-1].TokenType == CommentLineTerminatorToken {
+			break
+		}
+	}
+	return ts[start:end]
+}
+
+// Reprint returns the source code of the tokens where the source code of the changed nodes is replaced by their JS output, so that the formatting and comments of all other source code are preserved. Changed nodes must have the span of the source code they replace, a node that replaces another must be given the span of the replaced node. Changed nodes that are inside another changed node are printed as part of that node.
+func (ts TokenList) Reprint(changed ...INode) []byte {
+	nodes := append([]INode{}, changed...)
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i].Range(), nodes[j].Range()
+		return a.Start < b.Start || a.Start == b.Start && b.End < a.End
+	})
+
+	b := []byte{}
+	offset := 0 // end of the source code that has been written
+	i := 0
+	for _, n := range nodes {
+		span := n.Range()
+		if span.Start < offset {
+			continue // inside the previous node
+		}
+		for ; i < len(ts) && ts[i].Start < span.Start; i++ {
+			b = append(b, ts[i].Data...)
+		}
+		b = append(b, n.JS()...)
+		offset = span.End
+		for i < len(ts) && ts[i].Start < span.End {
+			i++
+		}
+	}
+	for ; i < len(ts); i++ {
+		b = append(b, ts[i].Data...)
+	}
+	return b
+}
@@ -0,0 +1,105 @@
+package js
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func tokenTypes(ts TokenList) string {
+	types := []string{}
+	for _, t := range ts {
+		types = append(types, t.TokenType.String())
+	}
+	return strings.Join(types, " ")
+}
+
+func TestParseTokens(t *testing.T) {
+	var tests = []struct {
+		js string
+		o  Options
+	}{
+		{"", Options{}},
+		{"a = 1; // one\n/* two */ b\n", Options{}},
+		{"#!/usr/bin/env node\n/* a */ // b\nc", Options{}},
+		{"x = /a b/g.test(y) / 2; y /= /=/", Options{}},
+		{"x = `a ${ b /* c */ } d ${ `e` }`", Options{}},
+		{"<!-- a\nx --> b\n", Options{}},
+		{"x = <div a='b' {...c}>\n  d {e} <br/></div>", Options{JSX: true}},
+		{"let x: Array<Array<number>> = f<string>( y )", Options{TypeScript: true}},
+		{"function f() {\n\treturn /* a */ 1 // b\n}\n", Options{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			tt.o.Tokens = true
+			ast, err := Parse(parse.NewInputString(tt.js), tt.o)
+			test.Error(t, err)
+			test.String(t, string(ast.Tokens.Bytes()), tt.js)
+			for i, token := range ast.Tokens {
+				test.String(t, string(token.Data), tt.js[token.Start:token.End])
+				if 0 < i {
+					test.T(t, token.Start, ast.Tokens[i-1].End)
+				}
+			}
+		})
+	}
+
+	// tokens are only kept when requested
+	ast, err := Parse(parse.NewInputString("a = 1"), Options{})
+	test.Error(t, err)
+	test.T(t, len(ast.Tokens), 0)
+}
+
+func TestParseTokensError(t *testing.T) {
+	var tests = []struct {
+		js string
+		o  Options
+	}{
+		{"a = 1;\nb = #;\nc = 3", Options{}},
+		{"a = 1;\nb = #;\nc = 3", Options{ErrorRecovery: true}},
+		{"a = 1;\nb = );\nc = 3", Options{ErrorRecovery: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.js, func(t *testing.T) {
+			tt.o.Tokens = true
+			ast, err := Parse(parse.NewInputString(tt.js), tt.o)
+			test.That(t, err != nil, "error")
+			test.String(t, string(ast.Tokens.Bytes()), tt.js)
+		})
+	}
+}
+
+func TestTokenList(t *testing.T) {
+	src := "a = 1; // one\n\n/* two */ let b = /re/ // three\nc"
+	ast, err := Parse(parse.NewInputString(src), Options{Tokens: true})
+	test.Error(t, err)
+	test.T(t, tokenTypes(ast.Tokens), "Identifier Whitespace = Whitespace Decimal ; Whitespace Comment LineTerminator Comment Whitespace let Whitespace Identifier Whitespace = Whitespace RegExp Whitespace Comment LineTerminator Identifier")
+
+	stmt := ast.List[1]
+	test.String(t, string(ast.Tokens.Node(stmt).Bytes()), "let b = /re/")
+	test.String(t, string(ast.Tokens.Leading(stmt).Bytes()), " // one\n\n/* two */ ")
+	test.String(t, string(ast.Tokens.Trailing(stmt).Bytes()), " // three\n")
+	test.String(t, string(ast.Tokens.Leading(ast.List[0]).Bytes()), "")
+	test.String(t, string(ast.Tokens.Trailing(ast.List[2]).Bytes()), "")
+}
+
+func TestReprint(t *testing.T) {
+	src := "a  =  1; // one\n/* two */ let b = f( a,\n  c ) ; // three\n"
+	ast, err := Parse(parse.NewInputString(src), Options{Tokens: true})
+	test.Error(t, err)
+	test.String(t, string(ast.Tokens.Reprint()), src)
+
+	// change the value of a and the arguments of the call
+	assign := ast.List[0].(*ExprStmt).Value.(*BinaryExpr)
+	one := assign.Y.(*LiteralExpr)
+	one.Data = []byte("2")
+	call := ast.List[1].(*VarDecl).List[0].Default.(*CallExpr)
+	call.Args.List = call.Args.List[:1]
+	test.String(t, string(ast.Tokens.Reprint(call, one)), "a  =  2; // one\n/* two */ let b = f(a) ; // three\n")
+
+	// replace the variable declaration by an expression statement, nested changed nodes are printed with their parent
+	stmt := &ExprStmt{Value: call, Span: ast.List[1].Range()}
+	test.String(t, string(ast.Tokens.Reprint(call, stmt)), "a  =  1; // one\n/* two */ f(a) ; // three\n")
+}
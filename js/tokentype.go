@@ -19,6 +19,7 @@ const (
 	TemplateEndToken
 	RegExpToken
 	PrivateIdentifierToken
+	JSXTextToken
 )
 
 // Numeric token values.
@@ -29,6 +30,7 @@ const (
 	OctalToken
 	HexadecimalToken
 	BigIntToken
+	LegacyOctalToken // 0 followed by octal digits, only in sloppy scripts
 )
 
 // Punctuator token values.
@@ -47,6 +49,7 @@ const (
 	ColonToken                  // :
 	ArrowToken                  // =>
 	EllipsisToken               // ...
+	AtToken                     // @
 )
 
 // Operator token values.
@@ -359,6 +362,8 @@ func (tt TokenType) Bytes() []byte {
 		return []byte("RegExp")
 	case PrivateIdentifierToken:
 		return []byte("PrivateIdentifier")
+	case JSXTextToken:
+		return []byte("JSXText")
 	case NumericToken:
 		return []byte("Numeric")
 	case DecimalToken:
@@ -371,6 +376,8 @@ func (tt TokenType) Bytes() []byte {
 		return []byte("Hexadecimal")
 	case BigIntToken:
 		return []byte("BigInt")
+	case LegacyOctalToken:
+		return []byte("LegacyOctal")
 	case PunctuatorToken:
 		return []byte("Punctuator")
 	case OpenBraceToken:
@@ -399,6 +406,8 @@ func (tt TokenType) Bytes() []byte {
 		return []byte("=>")
 	case EllipsisToken:
 		return []byte("...")
+	case AtToken:
+		return []byte("@")
 	}
 	return nil
 }
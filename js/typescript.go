@@ -0,0 +1,798 @@
+package js
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/tdewolff/parse/v2"
+)
+
+// tsState is a position in the token stream to which the parser can backtrack, which is needed to disambiguate some of the TypeScript syntax.
+type tsState struct {
+	lexer          Lexer
+	templateLevels []int
+	offset         int
+	tt             TokenType
+	data           []byte
+	end            int
+	prevLT         bool
+	comments       int
+	refs           int
+	strict         bool
+	err            error
+}
+
+func (p *Parser) save() tsState {
+	return tsState{*p.l, append([]int(nil), p.l.templateLevels...), p.l.r.Offset(), p.tt, p.data, p.end, p.prevLT, len(p.comments), len(p.refs), p.strict, p.err}
+}
+
+func (p *Parser) restore(s tsState) {
+	r := p.l.r
+	r.Rewind(s.offset - r.Offset() + r.Pos())
+	r.Skip()
+	*p.l = s.lexer
+	p.l.templateLevels = s.templateLevels
+	p.tt, p.data, p.end, p.prevLT = s.tt, s.data, s.end, s.prevLT
+	p.comments = p.comments[:s.comments]
+	p.refs = p.refs[:s.refs]
+	p.strict = s.strict
+	p.err = s.err
+}
+
+// peek returns the token after the current token and whether a line terminator precedes it, without moving to it.
+func (p *Parser) peek() (TokenType, bool) {
+	state := p.save()
+	p.next()
+	tt, prevLT := p.tt, p.prevLT
+	p.restore(state)
+	return tt, prevLT
+}
+
+// isWord returns true if the current token is the identifier s, which is used for TypeScript keywords that are not reserved.
+func (p *Parser) isWord(s string) bool {
+	return p.tt == IdentifierToken && string(p.data) == s
+}
+
+// consumeGt consumes the > that closes type parameters or arguments, tokens that start with > such as >> are split.
+func (p *Parser) consumeGt() bool {
+	switch p.tt {
+	case GtToken:
+		p.next()
+		return true
+	case GtGtToken:
+		p.tt = GtToken
+	case GtGtGtToken:
+		p.tt = GtGtToken
+	case GtEqToken:
+		p.tt = EqToken
+	case GtGtEqToken:
+		p.tt = GtEqToken
+	case GtGtGtEqToken:
+		p.tt = GtGtEqToken
+	default:
+		return false
+	}
+	p.data = p.data[1:]
+	p.end = p.offset()
+	p.prevLT = false
+	return true
+}
+
+////////////////////////////////////////////////////////////////
+
+// parseTypeAnnotation skips a type annotation such as  : number  when parsing TypeScript.
+func (p *Parser) parseTypeAnnotation() {
+	if p.o.TypeScript && p.tt == ColonToken {
+		p.next()
+		p.skipType()
+	}
+}
+
+// skipType skips a TypeScript type.
+func (p *Parser) skipType() {
+	p.skipUnionType()
+	if p.tt == ExtendsToken && !p.prevLT {
+		// conditional type
+		p.next()
+		p.skipUnionType()
+		if !p.consume("type", QuestionToken) {
+			return
+		}
+		p.skipType()
+		if !p.consume("type", ColonToken) {
+			return
+		}
+		p.skipType()
+	}
+}
+
+func (p *Parser) skipUnionType() {
+	if p.tt == BitOrToken || p.tt == BitAndToken {
+		p.next()
+	}
+	for {
+		p.skipPrimaryType()
+		if p.tt != BitOrToken && p.tt != BitAndToken {
+			return
+		}
+		p.next()
+	}
+}
+
+func (p *Parser) skipPrimaryType() {
+	switch {
+	case p.isWord("keyof") || p.isWord("unique") || p.isWord("readonly") || p.isWord("infer"):
+		if tt, prevLT := p.peek(); !prevLT && isTypeStart(tt) {
+			p.next()
+			p.skipPrimaryType()
+			return
+		}
+		p.next()
+	case p.isWord("asserts"):
+		if tt, prevLT := p.peek(); !prevLT && IsIdentifierName(tt) {
+			// asserts x is T
+			p.next()
+			p.next()
+			if p.isWord("is") && !p.prevLT {
+				p.next()
+				p.skipType()
+			}
+			return
+		}
+		p.next()
+	case p.isWord("abstract") || p.tt == NewToken:
+		if p.tt != NewToken {
+			if tt, _ := p.peek(); tt != NewToken {
+				p.skipTypeReference()
+				break
+			}
+			p.next()
+		}
+		// constructor type
+		p.next()
+		p.skipFuncType()
+		return
+	case p.tt == LtToken:
+		// generic function type
+		p.skipFuncType()
+		return
+	case p.tt == OpenParenToken:
+		// parenthesized or function type
+		p.skipBalanced(OpenParenToken, CloseParenToken)
+		if p.tt == ArrowToken {
+			p.next()
+			p.skipType()
+			return
+		}
+	case p.tt == OpenBracketToken:
+		p.skipBalanced(OpenBracketToken, CloseBracketToken)
+	case p.tt == OpenBraceToken:
+		p.skipBalanced(OpenBraceToken, CloseBraceToken)
+	case p.tt == TemplateToken || p.tt == StringToken || IsNumeric(p.tt):
+		p.next()
+	case p.tt == TemplateStartToken:
+		for p.tt == TemplateStartToken || p.tt == TemplateMiddleToken {
+			p.next()
+			p.skipType()
+		}
+		if !p.consume("type", TemplateEndToken) {
+			return
+		}
+	case p.tt == SubToken:
+		p.next()
+		if !IsNumeric(p.tt) {
+			p.fail("type", DecimalToken)
+			return
+		}
+		p.next()
+	case p.tt == TypeofToken:
+		p.next()
+		if p.tt == ImportToken {
+			p.skipImportType()
+		} else {
+			p.skipTypeReference()
+		}
+	case p.tt == ImportToken:
+		p.skipImportType()
+	case IsIdentifierName(p.tt):
+		p.skipTypeReference()
+		if p.isWord("is") && !p.prevLT {
+			// type predicate
+			p.next()
+			p.skipType()
+			return
+		}
+	default:
+		p.fail("type")
+		return
+	}
+	for p.tt == OpenBracketToken && !p.prevLT {
+		// array or indexed access type
+		p.skipBalanced(OpenBracketToken, CloseBracketToken)
+	}
+}
+
+// skipTypeReference skips a possibly qualified type name with type arguments, as in  A.B<C>.
+func (p *Parser) skipTypeReference() {
+	if !IsIdentifierName(p.tt) {
+		p.fail("type", IdentifierToken)
+		return
+	}
+	p.next()
+	for p.tt == DotToken {
+		p.next()
+		if !IsIdentifierName(p.tt) {
+			p.fail("type", IdentifierToken)
+			return
+		}
+		p.next()
+	}
+	if p.tt == LtToken && !p.prevLT {
+		p.skipTypeArgs()
+	}
+}
+
+// skipImportType skips an import type, as in  import('a').B<C>.
+func (p *Parser) skipImportType() {
+	// assume we're at import
+	p.next()
+	if p.tt != OpenParenToken {
+		p.fail("type", OpenParenToken)
+		return
+	}
+	p.skipBalanced(OpenParenToken, CloseParenToken)
+	for p.tt == DotToken {
+		p.next()
+		if !IsIdentifierName(p.tt) {
+			p.fail("type", IdentifierToken)
+			return
+		}
+		p.next()
+	}
+	if p.tt == LtToken && !p.prevLT {
+		p.skipTypeArgs()
+	}
+}
+
+// skipFuncType skips the type parameters, parameters, and return type of a function or constructor type.
+func (p *Parser) skipFuncType() {
+	if p.tt == LtToken {
+		p.skipTypeParams()
+	}
+	if p.tt != OpenParenToken {
+		p.fail("function type", OpenParenToken)
+		return
+	}
+	p.skipBalanced(OpenParenToken, CloseParenToken)
+	if !p.consume("function type", ArrowToken) {
+		return
+	}
+	p.skipType()
+}
+
+// skipTypeArgs skips type arguments, as in  <string, T[]>.
+func (p *Parser) skipTypeArgs() {
+	// assume we're at <
+	p.next()
+	for {
+		p.skipType()
+		if p.tt != CommaToken {
+			break
+		}
+		p.next()
+	}
+	if !p.consumeGt() {
+		p.fail("type arguments", GtToken)
+	}
+}
+
+// skipTypeParams skips type parameters, as in  <T extends U = V, const K>.
+func (p *Parser) skipTypeParams() {
+	// assume we're at <
+	p.next()
+	for p.tt != GtToken {
+		for p.tt == ConstToken || p.tt == InToken || p.isWord("out") {
+			if tt, _ := p.peek(); !IsIdentifier(tt) {
+				break
+			}
+			p.next()
+		}
+		if !IsIdentifier(p.tt) {
+			p.fail("type parameters", IdentifierToken)
+			return
+		}
+		p.next()
+		if p.tt == ExtendsToken {
+			p.next()
+			p.skipType()
+		}
+		if p.tt == EqToken {
+			p.next()
+			p.skipType()
+		}
+		if p.tt != CommaToken {
+			break
+		}
+		p.next()
+	}
+	if !p.consumeGt() {
+		p.fail("type parameters", GtToken)
+	}
+}
+
+// skipBalanced skips tokens up to and including the close token that matches the current open token.
+func (p *Parser) skipBalanced(open, close TokenType) {
+	level := 0
+	for {
+		if p.tt == open {
+			level++
+		} else if p.tt == close {
+			level--
+		} else if p.tt == ErrorToken {
+			p.fail("type", close)
+			return
+		}
+		p.next()
+		if level == 0 {
+			return
+		}
+	}
+}
+
+// isTypeStart returns true for tokens that can start a type.
+func isTypeStart(tt TokenType) bool {
+	switch tt {
+	case OpenParenToken, OpenBracketToken, OpenBraceToken, StringToken, TemplateToken, TemplateStartToken, SubToken, LtToken:
+		return true
+	}
+	return IsIdentifierName(tt) || IsNumeric(tt)
+}
+
+// tryTypeArgs skips the type arguments of a call as in  f<T>(x), it returns false and does not move if the < is a less-than operator instead.
+func (p *Parser) tryTypeArgs() bool {
+	state := p.save()
+	p.skipTypeArgs()
+	if p.err == nil && (p.tt == OpenParenToken || p.tt == TemplateToken || p.tt == TemplateStartToken) {
+		return true
+	}
+	p.restore(state)
+	return false
+}
+
+// isInterfaceDecl returns true if the current interface keyword starts an interface declaration.
+func (p *Parser) isInterfaceDecl() bool {
+	tt, prevLT := p.peek()
+	return !prevLT && IsIdentifier(tt)
+}
+
+// tryArrowReturnType skips the return type of an arrow function as in  (a): T => a, it returns false and does not move if the colon is not followed by a type and an arrow. In the consequent of a conditional expression as in  a ? (b): T => c : d, the colon is only a return type if the colons of the alternates still follow the arrow function.
+func (p *Parser) tryArrowReturnType() bool {
+	state := p.save()
+	inCond := p.condLevel == p.level()
+	p.next()
+	p.skipType()
+	if p.err == nil && p.tt == ArrowToken {
+		if !inCond {
+			return true
+		}
+		arrow := p.save()
+		if p.hasCondAlternates(p.conds) {
+			p.restore(arrow)
+			return true
+		}
+	}
+	p.restore(state)
+	return false
+}
+
+// hasCondAlternates returns true if the tokens that follow contain the colons of n conditional expressions that are being parsed, it moves the parser.
+func (p *Parser) hasCondAlternates(n int) bool {
+	level, conds := 0, 0
+	for {
+		p.next()
+		switch p.tt {
+		case OpenParenToken, OpenBracketToken, OpenBraceToken, TemplateStartToken:
+			level++
+		case CloseParenToken, CloseBracketToken, CloseBraceToken, TemplateEndToken:
+			if level == 0 {
+				return false
+			}
+			level--
+		case QuestionToken:
+			if level == 0 {
+				conds++
+			}
+		case ColonToken:
+			if level == 0 {
+				if conds == 0 {
+					if n--; n == 0 {
+						return true
+					}
+				} else {
+					conds--
+				}
+			}
+		case CommaToken, SemicolonToken:
+			if level == 0 {
+				return false
+			}
+		case ErrorToken:
+			return false
+		}
+	}
+}
+
+// isIndexSignature returns true if the current [ starts an index signature as in  [key: string]: T.
+func (p *Parser) isIndexSignature() bool {
+	state := p.save()
+	p.next()
+	ok := IsIdentifier(p.tt)
+	p.next()
+	ok = ok && p.tt == ColonToken
+	p.restore(state)
+	return ok
+}
+
+// parseTSModifiers skips the TypeScript modifiers of a class member, it returns true if the member must be removed, which is the case for abstract members and declared fields.
+func (p *Parser) parseTSModifiers() (strip bool) {
+	for p.tt == PublicToken || p.tt == PrivateToken || p.tt == ProtectedToken || p.isWord("readonly") || p.isWord("override") || p.isWord("abstract") || p.isWord("declare") {
+		if tt, _ := p.peek(); !IsIdentifierName(tt) && tt != StringToken && !IsNumeric(tt) && tt != OpenBracketToken && tt != PrivateIdentifierToken && tt != MulToken {
+			break // the modifier is the name of the member
+		}
+		strip = strip || p.isWord("abstract") || p.isWord("declare")
+		p.next()
+	}
+	return
+}
+
+// isParamModifier returns true if the current token is the modifier of a constructor parameter property, as in  constructor(private a) {}.
+func (p *Parser) isParamModifier() bool {
+	if p.tt == PublicToken || p.tt == PrivateToken || p.tt == ProtectedToken || p.isWord("readonly") || p.isWord("override") {
+		tt, _ := p.peek()
+		return p.isIdentifierReference(tt) || tt == OpenBracketToken || tt == OpenBraceToken || IsIdentifierName(tt) && tt != InToken && tt != InstanceofToken
+	}
+	return false
+}
+
+// addParamProps adds the assignments of constructor parameter properties as in  this.a = a  to the start of the constructor body, or after the call to super.
+func addParamProps(list []IStmt, props []*Var, span Span) []IStmt {
+	i := 0
+	for i < len(list) {
+		if _, ok := list[i].(*DirectivePrologueStmt); !ok {
+			break
+		}
+		i++
+	}
+	for j, stmt := range list {
+		if exprStmt, ok := stmt.(*ExprStmt); ok {
+			if call, ok := exprStmt.Value.(*CallExpr); ok {
+				if lit, ok := call.X.(*LiteralExpr); ok && lit.TokenType == SuperToken {
+					i = j + 1
+					break
+				}
+			}
+		}
+	}
+
+	stmts := make([]IStmt, 0, len(list)+len(props))
+	stmts = append(stmts, list[:i]...)
+	for _, v := range props {
+		v.Uses++
+		this := &LiteralExpr{ThisToken, []byte("this"), span}
+		dot := &DotExpr{this, LiteralExpr{IdentifierToken, parse.Copy(v.Data), span}, OpMember, false, span}
+		stmts = append(stmts, &ExprStmt{&BinaryExpr{EqToken, dot, v, span}, span})
+	}
+	return append(stmts, list[i:]...)
+}
+
+////////////////////////////////////////////////////////////////
+
+// parseTSStmt parses the TypeScript declarations that can start a statement, it returns false without moving if the statement is not a TypeScript declaration. Declarations without a JavaScript equivalent are skipped and return a nil statement, enums are converted to a variable declaration.
+func (p *Parser) parseTSStmt() (IStmt, bool) {
+	start := p.offset()
+	switch {
+	case p.tt == EnumToken:
+		if enum := p.parseTSEnum(start); enum != nil {
+			return enum, true
+		}
+		return nil, true
+	case p.tt == ConstToken:
+		if tt, _ := p.peek(); tt == EnumToken {
+			p.next()
+			if enum := p.parseTSEnum(start); enum != nil {
+				return enum, true
+			}
+			return nil, true
+		}
+	case p.tt == InterfaceToken:
+		if p.isInterfaceDecl() {
+			p.next()
+			p.next()
+			if p.tt == LtToken {
+				p.skipTypeParams()
+			}
+			if p.tt == ExtendsToken {
+				p.next()
+				for {
+					p.skipType()
+					if p.tt != CommaToken {
+						break
+					}
+					p.next()
+				}
+			}
+			if p.tt != OpenBraceToken {
+				p.fail("interface declaration", OpenBraceToken)
+				return nil, true
+			}
+			p.skipBalanced(OpenBraceToken, CloseBraceToken)
+			return nil, true
+		}
+	case p.isWord("type"):
+		if tt, prevLT := p.peek(); !prevLT && IsIdentifier(tt) {
+			p.next()
+			p.next()
+			if p.tt == LtToken {
+				p.skipTypeParams()
+			}
+			if !p.consume("type alias", EqToken) {
+				return nil, true
+			}
+			p.skipType()
+			return nil, true
+		}
+	case p.isWord("declare"):
+		if tt, prevLT := p.peek(); !prevLT && IsIdentifierName(tt) {
+			p.next()
+			p.skipTSStmt()
+			return nil, true
+		}
+	case p.isWord("abstract"):
+		if tt, prevLT := p.peek(); !prevLT && tt == ClassToken {
+			p.next()
+			return p.parseClassDecl(), true
+		}
+	case p.isWord("namespace") || p.isWord("module"):
+		if tt, prevLT := p.peek(); !prevLT && (IsIdentifier(tt) || tt == StringToken) {
+			p.failMessage("TypeScript namespaces are not supported")
+			return nil, true
+		}
+	}
+	return nil, false
+}
+
+// skipTSStmt skips an ambient declaration up to the end of the statement.
+func (p *Parser) skipTSStmt() {
+	level := 0
+	for {
+		switch p.tt {
+		case ErrorToken:
+			return
+		case OpenParenToken, OpenBracketToken, OpenBraceToken:
+			level++
+		case CloseParenToken, CloseBracketToken, CloseBraceToken:
+			if level == 0 {
+				return
+			}
+			level--
+		case SemicolonToken:
+			if level == 0 {
+				return
+			}
+		}
+		p.next()
+		if level == 0 && p.prevLT {
+			return
+		}
+	}
+}
+
+// parseTSEnum parses an enum declaration and converts it to JavaScript, as in  var E = (function (E) { E[E["A"] = 0] = "A"; return E })(E || {}).
+func (p *Parser) parseTSEnum(start int) *VarDecl {
+	// assume we're at enum
+	p.next()
+	if !IsIdentifier(p.tt) {
+		p.fail("enum declaration", IdentifierToken)
+		return nil
+	}
+	name, nameSpan := p.data, p.tokenSpan()
+	p.next()
+
+	varDecl := &VarDecl{TokenType: VarToken, Scope: p.scope}
+	p.scope.Func.VarDecls = append(p.scope.Func.VarDecls, varDecl)
+	v, ok := p.declare(VariableDecl, name, nameSpan)
+	if !ok {
+		p.failMessage("identifier %s has already been declared", string(name))
+		return nil
+	}
+
+	bodyStart := p.offset()
+	if !p.consume("enum declaration", OpenBraceToken) {
+		return nil
+	}
+	f := &FuncDecl{}
+	parent := p.enterScope(&f.Body.Scope, true)
+	parentAwait, parentYield := p.await, p.yield
+	p.await, p.yield = false, false
+
+	param, _ := p.declare(ArgumentDecl, name, nameSpan) // cannot fail
+	f.Params = Params{List: []BindingElement{{Binding: param, Span: nameSpan}}, Span: nameSpan}
+
+	value, known := int64(0), true // value of the next member
+	members := [][]byte{}          // names of the members that can be referenced by the initializers
+	for p.tt != CloseBraceToken {
+		memberStart := p.offset()
+		keySpan := p.tokenSpan()
+		var key, memberName []byte
+		member := p.data
+		if p.tt == StringToken {
+			key = p.data
+			if s, err := DecodeString(p.data); err == nil && AsIdentifierName(s) {
+				memberName = s
+			}
+		} else if IsIdentifierName(p.tt) {
+			key = []byte("\"" + string(p.data) + "\"")
+			memberName = p.data
+		} else {
+			p.fail("enum declaration", IdentifierToken, StringToken)
+			return nil
+		}
+		p.next()
+
+		var init IExpr
+		isString := false
+		if p.tt == EqToken {
+			p.next()
+			init = p.parseEnumInit(param, members)
+			known = false
+			switch expr := init.(type) {
+			case *LiteralExpr:
+				if expr.TokenType == StringToken {
+					isString = true
+				} else if IsNumeric(expr.TokenType) {
+					value, known = parseEnumValue(expr.Data)
+				}
+			case *TemplateExpr:
+				isString = expr.Tag == nil && len(expr.List) == 0
+			case *UnaryExpr:
+				if lit, ok := expr.X.(*LiteralExpr); ok && expr.Op == NegToken && IsNumeric(lit.TokenType) {
+					value, known = parseEnumValue(lit.Data)
+					value = -value
+				}
+			}
+			value++
+		} else if known {
+			init = &LiteralExpr{DecimalToken, []byte(strconv.FormatInt(value, 10)), Span{p.end, p.end}}
+			value++
+		} else {
+			p.failMessage("enum member %s must have an initializer", string(member))
+			return nil
+		}
+
+		if memberName != nil {
+			members = append(members, memberName)
+		}
+
+		// E[E["A"] = 0] = "A" or E["A"] = "a"
+		span := p.span(memberStart)
+		prop := &IndexExpr{p.use(name, nameSpan), &LiteralExpr{StringToken, key, keySpan}, OpMember, false, span}
+		if isString {
+			f.Body.List = append(f.Body.List, &ExprStmt{&BinaryExpr{EqToken, prop, init, span}, span})
+		} else {
+			index := &BinaryExpr{EqToken, prop, init, span}
+			reverse := &IndexExpr{p.use(name, nameSpan), index, OpMember, false, span}
+			f.Body.List = append(f.Body.List, &ExprStmt{&BinaryExpr{EqToken, reverse, &LiteralExpr{StringToken, key, keySpan}, span}, span})
+		}
+
+		if p.tt == CommaToken {
+			p.next()
+		} else if p.tt != CloseBraceToken {
+			p.fail("enum declaration", CommaToken, CloseBraceToken)
+			return nil
+		}
+	}
+	closeSpan := p.tokenSpan()
+	p.next()
+	f.Body.List = append(f.Body.List, &ReturnStmt{p.use(name, nameSpan), closeSpan})
+	f.Body.Span = p.span(bodyStart)
+	f.Span = p.span(start)
+
+	p.await, p.yield = parentAwait, parentYield
+	p.exitScope(parent)
+
+	span := p.span(start)
+	arg := &BinaryExpr{OrToken, p.use(name, nameSpan), &ObjectExpr{Span: Span{p.end, p.end}}, span}
+	call := &CallExpr{&GroupExpr{f, span}, Args{[]Arg{{Value: arg, Span: span}}, span}, false, span}
+	varDecl.List = []BindingElement{{Binding: v, Default: call, Span: span}}
+	varDecl.Span = span
+	return varDecl
+}
+
+// parseEnumInit parses the initializer of an enum member, where references to the earlier members are qualified by the enum as in  E.A.
+func (p *Parser) parseEnumInit(enum *Var, members [][]byte) IExpr {
+	// declare the members in a scope around the initializer so that references to them are resolved while parsing
+	scope := &Scope{}
+	parent := p.enterScope(scope, false)
+	vars := map[*Var]bool{}
+	for _, member := range members {
+		if v, ok := scope.Declare(LexicalDecl, member); ok {
+			vars[v] = true
+		}
+	}
+	// the spans of the references are needed for the member expressions, also when references are not recorded
+	refs, noRefs := len(p.refs), p.o.NoRefs
+	p.o.NoRefs = false
+	init := p.parseExpression(OpAssign)
+	p.o.NoRefs = noRefs
+	p.exitScope(parent)
+
+	r := &enumRewriter{enum: enum, members: vars}
+	for i := refs; i < len(p.refs); i++ {
+		if ref := p.refs[i]; vars[resolveVar(ref.v)] {
+			p.refs[i].v = enum
+			if i+1 == len(p.refs) || p.refs[i+1].Span != ref.Span {
+				// the preceding reference with the same span is replaced by the declaration of an arrow function parameter
+				r.spans = append(r.spans, ref.Span)
+			}
+		}
+	}
+	if noRefs {
+		p.refs = p.refs[:refs]
+	}
+	if p.err != nil {
+		return init
+	}
+	return Rewrite(r, init).(IExpr)
+}
+
+// enumRewriter replaces the references to enum members by member expressions of the enum, the spans are those of the references in source order.
+type enumRewriter struct {
+	enum    *Var
+	members map[*Var]bool
+	spans   []Span
+}
+
+func (r *enumRewriter) Enter(n INode) (INode, IRewriter) {
+	switch n := n.(type) {
+	case *Var:
+		if r.members[resolveVar(n)] {
+			span := n.Span
+			if 0 < len(r.spans) {
+				span, r.spans = r.spans[0], r.spans[1:]
+			}
+			r.enum.Uses++
+			return &DotExpr{r.enum, LiteralExpr{IdentifierToken, n.Data, span}, OpMember, false, span}, nil
+		}
+	case *BlockStmt:
+		// scopes of functions in the initializer refer to the enum instead
+		hasEnum := false
+		for _, v := range n.Scope.Undeclared {
+			hasEnum = hasEnum || v == r.enum
+		}
+		undeclared := n.Scope.Undeclared[:0]
+		for _, v := range n.Scope.Undeclared {
+			if r.members[resolveVar(v)] {
+				if hasEnum {
+					continue
+				}
+				v, hasEnum = r.enum, true
+			}
+			undeclared = append(undeclared, v)
+		}
+		n.Scope.Undeclared = undeclared
+	}
+	return n, r
+}
+
+func (r *enumRewriter) Exit(n INode) INode {
+	return n
+}
+
+// parseEnumValue returns the value of an integer literal, and false if it is not an integer.
+func parseEnumValue(b []byte) (int64, bool) {
+	if bytes.IndexByte(b, '.') != -1 || bytes.IndexByte(b, 'n') != -1 {
+		return 0, false
+	}
+	i, err := strconv.ParseInt(string(b), 0, 64)
+	return i, err == nil
+}
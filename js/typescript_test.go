@@ -0,0 +1,199 @@
+package js
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/test"
+)
+
+func TestTypeScript(t *testing.T) {
+	var tests = []struct {
+		ts       string
+		expected string
+	}{
+		// annotations
+		{"let x: number = 5", "let x = 5"},
+		{"var a: string, b!: Array<Map<string, number>>= c", "var a, b = c"},
+		{"function f<T extends object = {}>(a: T, b?: number, ...c: string[]): T { return a }", "function f(a, b, ...c) { return a }"},
+		{"function f(this: Window, a: A.B<C>[]): asserts a is C {}", "function f(a) {}"},
+		{"function f(a: unknown): a is string {}", "function f(a) {}"},
+		{"try {} catch (e: unknown) {}", "try {} catch (e) {}"},
+		{"for (let i: number = 0; i < n; i++);", "for (let i = 0; i < n; i++) {}"},
+
+		// types
+		{"let a: (b: number) => void, c: new () => D, e: keyof typeof f", "let a, c, e"},
+		{"let a: A extends B ? C<infer D> : never", "let a"},
+		{"let a: { [K in keyof T]?: T[K] } & {x: 1}[]", "let a"},
+		{"let a: [b: number, c?: string, ...d: boolean[]] | readonly string[]", "let a"},
+		{"let a: `x${string}` | -1 | 'y' | 2n | import('z').Y<unknown>", "let a"},
+
+		// expressions
+		{"x = y as any as T[]", "x = y"},
+		{"x = y satisfies T", "x = y"},
+		{"a = b as any\n[1].forEach(f)", "a = b; [1].forEach(f)"},
+		{"a = b satisfies T\n(c)", "a = b; (c)"},
+		{"a = b as T[]\n`c`", "a = b; `c`"},
+		{"x = <T>y", "x = y"},
+		{"x = a!.b![c]!", "x = a.b[c]"},
+		{"x = f<string>(a) + new A<B<C>>()", "x = f(a) + new A()"},
+		{"x = f<T>`a`", "x = f`a`"},
+		{"x = a < b && c > (d)", "x = a < b && c > (d)"},
+		{"x = a < b > c", "x = a < b > c"},
+		{"x = (a: number, b: string = 'b'): void => {}", "x = (a, b = 'b') => {}"},
+		{"x = (a?, ...b: number[]) => a", "x = (a, ...b) => a"},
+		{"x = ({a, b}: {a: number, b: string}, [c]: T) => a", "x = ({a, b}, [c]) => a"},
+		{"x = <T,>(a: T): T => a", "x = (a) => a"},
+		{"x = async <T>(a: T) => a", "x = async (a) => a"},
+		{"x = a ? (b) : c", "x = a ? (b) : c"},
+		{"x = a ? (b) : (c) => d", "x = a ? (b) : (c) => d"},
+		{"x = a ? (b) : c => d", "x = a ? (b) : c => d"},
+		{"x = a ? (b): c => d : e", "x = a ? (b) => d : e"},
+		{"x = a ? (b): c => d ? e : f : g", "x = a ? (b) => d ? e : f : g"},
+		{"x = a ? f((b): c => d) : e", "x = a ? f((b) => d) : e"},
+		{"x = a ? b ? (c) : d => e : f", "x = a ? b ? (c) : d => e : f"},
+		{"x = a ? b ? c : (d): e => f : g", "x = a ? b ? c : (d) => f : g"},
+		{"x = a ? b ? (c): d => e : f : g", "x = a ? b ? (c) => e : f : g"},
+		{"x = {m<T>(a: T): T { return a }}", "x = {m(a) { return a }}"},
+
+		// declarations
+		{"interface A extends B<C> { x: number }\ntype T<U> = U | null; x", "x"},
+		{"declare const a: number; declare function f(): void\ndeclare module 'b' { }\nx", "x"},
+		{"function f(a: string): void; function f(a) {}", "function f(a) {}"},
+		{"type\nx", "type; x"},
+		{"interface\nx", "interface; x"},
+		{"a: interface B {}", "a: ;"},
+		{"export default interface A<T> extends B { x: T }\nx", "x"},
+		{"export default interface A {}; export default 1", "export default 1"},
+
+		// classes
+		{"class A<T> extends B<T> implements C, D<E> {}", "class A extends B {}"},
+		{"class A { private x: number = 1; static readonly y?: string; z!: number; declare w: string }", "class A { x = 1; static y; z }"},
+		{"class A { public static m<T>(a: T): T { return a } n(): void; n() {} get o(): number { return 1 } }", "class A { static m(a) { return a } n() {} get o() { return 1 } }"},
+		{"abstract class A { abstract m(): void; protected abstract x: number }", "class A {}"},
+		{"class A { [key: string]: any; readonly = 1; static = 2 }", "class A { readonly = 1; static = 2 }"},
+		{"class A extends B { constructor(public a, private readonly b = 1, c) { 'use strict'; super(); c() } }", "class A extends B { constructor(a, b = 1, c) { 'use strict'; super(); this.a = a; this.b = b; c() } }"},
+		{"class A { constructor(protected a: string) { b() } }", "class A { constructor(a) { this.a = a; b() } }"},
+
+		// enums
+		{"enum E { A, B = 5, C, 'D' = 'd' }", "var E = (function (E) { E[E[\"A\"] = 0] = \"A\"; E[E[\"B\"] = 5] = \"B\"; E[E[\"C\"] = 6] = \"C\"; E['D'] = 'd'; return E })(E || {})"},
+		{"const enum E { A = -2, B, C = 0x10, D }", "var E = (function (E) { E[E[\"A\"] = -2] = \"A\"; E[E[\"B\"] = -1] = \"B\"; E[E[\"C\"] = 0x10] = \"C\"; E[E[\"D\"] = 17] = \"D\"; return E })(E || {})"},
+		{"enum E { A = \"a\", B = A }", "var E = (function (E) { E[\"A\"] = \"a\"; E[E[\"B\"] = E.A] = \"B\"; return E })(E || {})"},
+		{"enum E { A = 1, 'B' = A | 2, C = ((A) => A)(B), D = f(x => A + x), E = E }", "var E = (function (E) { E[E[\"A\"] = 1] = \"A\"; E[E['B'] = E.A | 2] = 'B'; E[E[\"C\"] = ((A) => A)(E.B)] = \"C\"; E[E[\"D\"] = f(x => E.A + x)] = \"D\"; E[E[\"E\"] = E] = \"E\"; return E })(E || {})"},
+		{"enum E { A = 1 } enum F { B = A }", "var E = (function (E) { E[E[\"A\"] = 1] = \"A\"; return E })(E || {}); var F = (function (F) { F[F[\"B\"] = A] = \"B\"; return F })(F || {})"},
+		{"enum E { A = 1 << 2, B = `b`, }", "var E = (function (E) { E[E[\"A\"] = 1 << 2] = \"A\"; E[\"B\"] = `b`; return E })(E || {})"},
+
+		// modules
+		{"import type A from 'a'; import type { B } from 'b'; import type * as C from 'c'", ""},
+		{"import a, { type B, C } from 'a'; import { type D } from 'd'; import type, { e } from 'e'", "import a, {C} from 'a'; import type, {e} from 'e'"},
+		{"export type { A }; export type * from 'b'; export { type C, d }", "export {d}"},
+		{"export interface A {} export type B = C; export declare const d: number", ""},
+		{"export enum E { A }", "export var E = (function (E) { E[E[\"A\"] = 0] = \"A\"; return E })(E || {})"},
+		{"export abstract class A {} export default abstract class {}", "export class A {}; export default class {}"},
+		{"export function f(): void; export function f() {}", "export function f() {}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ts, func(t *testing.T) {
+			ast, err := Parse(parse.NewInputString(tt.ts), Options{TypeScript: true})
+			if err != io.EOF {
+				test.Error(t, err)
+			}
+			Walk(&spanVisitor{t: t, src: tt.ts}, ast)
+
+			expected, err := Parse(parse.NewInputString(tt.expected), Options{})
+			if err != io.EOF {
+				test.Error(t, err)
+			}
+			test.String(t, ast.JS(), expected.JS())
+		})
+	}
+
+	var errorTests = []struct {
+		ts  string
+		err string
+	}{
+		{"let x: = 5", "unexpected = in type"},
+		{"x = (a: number)", "expected => instead of EOF in arrow function"},
+		{"f<T>(a: T) {}", "unexpected : in expression"},
+		{"enum E { A = f(), B }", "enum member B must have an initializer"},
+		{"enum E { A; B }", "expected , or } instead of ; in enum declaration"},
+		{"namespace N {}", "TypeScript namespaces are not supported"},
+		{"type A = B<C", "expected > instead of EOF in type arguments"},
+		{"x = <T>", "unexpected EOF in expression"},
+		{"x = a ? (b) : c => [d", "unexpected EOF in expression"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.ts, func(t *testing.T) {
+			_, err := Parse(parse.NewInputString(tt.ts), Options{TypeScript: true})
+			test.That(t, err != io.EOF && err != nil)
+
+			e := err.Error()
+			if len(tt.err) < len(err.Error()) {
+				e = e[:len(tt.err)]
+			}
+			test.String(t, e, tt.err)
+		})
+	}
+
+	// TypeScript is opt-in
+	_, err := Parse(parse.NewInputString("let x: number"), Options{})
+	test.That(t, err != nil)
+}
+
+func TestTypeScriptScope(t *testing.T) {
+	ast, err := Parse(parse.NewInputString("enum E { A }\nclass A { constructor(private a) {} }\nE.A"), Options{TypeScript: true})
+	test.Error(t, err)
+
+	test.T(t, len(ast.BlockStmt.Scope.Declared), 2)
+	test.String(t, string(ast.BlockStmt.Scope.Declared[0].Data), "E")
+	test.T(t, ast.BlockStmt.Scope.Declared[0].Uses, uint16(3)) // declaration, E || {}, and E.A
+
+	method := ast.List[1].(*ClassDecl).List[0].Method
+	a := method.Params.List[0].Binding.(*Var)
+	test.T(t, a.Uses, uint16(2))
+	test.T(t, method.Body.List[0].(*ExprStmt).Value.(*BinaryExpr).Y, IExpr(a))
+
+	// references to enum members refer to the enum parameter
+	src := "enum E { A = 1, B = A, C = () => A }"
+	ast, err = Parse(parse.NewInputString(src), Options{TypeScript: true})
+	test.Error(t, err)
+	test.T(t, len(ast.BlockStmt.Scope.Undeclared), 0)
+	f := ast.List[0].(*VarDecl).List[0].Default.(*CallExpr).X.(*GroupExpr).X.(*FuncDecl)
+	param := f.Params.List[0].Binding.(*Var)
+	test.T(t, len(f.Body.Scope.Undeclared), 0)
+	arrow := f.Body.List[2].(*ExprStmt).Value.(*BinaryExpr).X.(*IndexExpr).Y.(*BinaryExpr).Y.(*ArrowFunc)
+	test.T(t, arrow.Body.Scope.Undeclared, VarArray{param})
+	members := []string{}
+	for _, ref := range AnalyzeScopes(ast).Refs(param) {
+		if name := src[ref.Start:ref.End]; name != "E" {
+			members = append(members, name)
+		}
+	}
+	test.T(t, members, []string{"A", "A"})
+}
+
+func TestTypeScriptEnumUses(t *testing.T) {
+	// uses of the enum parameter and spans of the member expressions do not depend on recorded references
+	src := "enum E { A = 1, B = A + A, C = () => A }"
+	for _, o := range []Options{{TypeScript: true}, {TypeScript: true, NoRefs: true}} {
+		ast, err := Parse(parse.NewInputString(src), o)
+		test.Error(t, err)
+		f := ast.List[0].(*VarDecl).List[0].Default.(*CallExpr).X.(*GroupExpr).X.(*FuncDecl)
+		test.T(t, f.Params.List[0].Binding.(*Var).Uses, uint16(11))
+		b := f.Body.List[1].(*ExprStmt).Value.(*BinaryExpr).X.(*IndexExpr).Y.(*BinaryExpr).Y.(*BinaryExpr)
+		test.T(t, b.X.(*DotExpr).Span, Span{20, 21})
+		test.T(t, b.Y.(*DotExpr).Span, Span{24, 25})
+	}
+}
+
+func TestTypeScriptPrinter(t *testing.T) {
+	src := "enum Color { Red, Green = 'g' }\nfunction paint(c: Color): void { draw(c as number) }"
+	ast, err := Parse(parse.NewInputString(src), Options{TypeScript: true})
+	test.Error(t, err)
+
+	buf := &bytes.Buffer{}
+	test.Error(t, NewPrinter(buf, PrintOptions{Compact: true}).Print(ast))
+	test.String(t, buf.String(), `var Color=(function(Color){Color[Color["Red"]=0]="Red";Color["Green"]='g';return Color})(Color||{});function paint(c){draw(c)}`)
+}
@@ -8,7 +8,7 @@ type IVisitor interface {
 	Exit(n INode)
 }
 
-// Walk traverses an AST in depth-first order
+// Walk traverses an AST in depth-first order, the children of a node are visited in the order in which they appear in the source
 func Walk(v IVisitor, n INode) {
 	if n == nil {
 		return
@@ -36,60 +36,60 @@ func Walk(v IVisitor, n INode) {
 	case *ExprStmt:
 		Walk(v, n.Value)
 	case *IfStmt:
+		Walk(v, n.Cond)
 		Walk(v, n.Body)
 		Walk(v, n.Else)
-		Walk(v, n.Cond)
 	case *DoWhileStmt:
 		Walk(v, n.Body)
 		Walk(v, n.Cond)
 	case *WhileStmt:
-		Walk(v, n.Body)
 		Walk(v, n.Cond)
+		Walk(v, n.Body)
 	case *ForStmt:
-		if n.Body != nil {
-			Walk(v, n.Body)
-		}
-
 		Walk(v, n.Init)
 		Walk(v, n.Cond)
 		Walk(v, n.Post)
-	case *ForInStmt:
+
 		if n.Body != nil {
 			Walk(v, n.Body)
 		}
-
+	case *ForInStmt:
 		Walk(v, n.Init)
 		Walk(v, n.Value)
-	case *ForOfStmt:
+
 		if n.Body != nil {
 			Walk(v, n.Body)
 		}
-
+	case *ForOfStmt:
 		Walk(v, n.Init)
 		Walk(v, n.Value)
+
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
 	case *CaseClause:
+		Walk(v, n.Cond)
+
 		if n.List != nil {
 			for i := 0; i < len(n.List); i++ {
 				Walk(v, n.List[i])
 			}
 		}
-
-		Walk(v, n.Cond)
 	case *SwitchStmt:
+		Walk(v, n.Init)
+
 		if n.List != nil {
 			for i := 0; i < len(n.List); i++ {
 				Walk(v, &n.List[i])
 			}
 		}
-
-		Walk(v, n.Init)
 	case *BranchStmt:
 		return
 	case *ReturnStmt:
 		Walk(v, n.Value)
 	case *WithStmt:
-		Walk(v, n.Body)
 		Walk(v, n.Cond)
+		Walk(v, n.Body)
 	case *LabelledStmt:
 		Walk(v, n.Value)
 	case *ThrowStmt:
@@ -99,6 +99,8 @@ func Walk(v IVisitor, n INode) {
 			Walk(v, n.Body)
 		}
 
+		Walk(v, n.Binding)
+
 		if n.Catch != nil {
 			Walk(v, n.Catch)
 		}
@@ -106,18 +108,24 @@ func Walk(v IVisitor, n INode) {
 		if n.Finally != nil {
 			Walk(v, n.Finally)
 		}
-
-		Walk(v, n.Binding)
 	case *DebuggerStmt:
 		return
+	case *BadStmt:
+		return
 	case *Alias:
 		return
+	case *ImportAttribute:
+		return
 	case *ImportStmt:
 		if n.List != nil {
 			for i := 0; i < len(n.List); i++ {
 				Walk(v, &n.List[i])
 			}
 		}
+
+		for i := 0; i < len(n.Attributes); i++ {
+			Walk(v, &n.Attributes[i])
+		}
 	case *ExportStmt:
 		if n.List != nil {
 			for i := 0; i < len(n.List); i++ {
@@ -126,6 +134,10 @@ func Walk(v IVisitor, n INode) {
 		}
 
 		Walk(v, n.Decl)
+
+		for i := 0; i < len(n.Attributes); i++ {
+			Walk(v, &n.Attributes[i])
+		}
 	case *DirectivePrologueStmt:
 		return
 	case *PropertyName:
@@ -173,35 +185,43 @@ func Walk(v IVisitor, n INode) {
 
 		Walk(v, n.Rest)
 	case *FuncDecl:
-		Walk(v, &n.Body)
-		Walk(v, &n.Params)
-
 		if n.Name != nil {
 			Walk(v, n.Name)
 		}
-	case *MethodDecl:
-		Walk(v, &n.Body)
+
 		Walk(v, &n.Params)
+		Walk(v, &n.Body)
+	case *MethodDecl:
+		for _, item := range n.Decorators {
+			Walk(v, item)
+		}
+
 		Walk(v, &n.Name)
+		Walk(v, &n.Params)
+		Walk(v, &n.Body)
 	case *Field:
+		for _, item := range n.Decorators {
+			Walk(v, item)
+		}
+
 		Walk(v, &n.Name)
 		Walk(v, n.Init)
 	case *ClassDecl:
+		for _, item := range n.Decorators {
+			Walk(v, item)
+		}
+
 		if n.Name != nil {
 			Walk(v, n.Name)
 		}
 
 		Walk(v, n.Extends)
 
-		for _, item := range n.List {
-			if item.StaticBlock != nil {
-				Walk(v, item.StaticBlock)
-			} else if item.Method != nil {
-				Walk(v, item.Method)
-			} else {
-				Walk(v, &item.Field)
-			}
+		for i := range n.List {
+			walkClassElement(v, &n.List[i])
 		}
+	case *ClassElement:
+		walkClassElement(v, n)
 	case *LiteralExpr:
 		return
 	case *Element:
@@ -228,13 +248,13 @@ func Walk(v IVisitor, n INode) {
 	case *TemplatePart:
 		Walk(v, n.Expr)
 	case *TemplateExpr:
+		Walk(v, n.Tag)
+
 		if n.List != nil {
 			for i := 0; i < len(n.List); i++ {
 				Walk(v, &n.List[i])
 			}
 		}
-
-		Walk(v, n.Tag)
 	case *GroupExpr:
 		Walk(v, n.X)
 	case *IndexExpr:
@@ -256,14 +276,14 @@ func Walk(v IVisitor, n INode) {
 			}
 		}
 	case *NewExpr:
+		Walk(v, n.X)
+
 		if n.Args != nil {
 			Walk(v, n.Args)
 		}
-
-		Walk(v, n.X)
 	case *CallExpr:
-		Walk(v, &n.Args)
 		Walk(v, n.X)
+		Walk(v, &n.Args)
 	case *UnaryExpr:
 		Walk(v, n.X)
 	case *BinaryExpr:
@@ -276,13 +296,43 @@ func Walk(v IVisitor, n INode) {
 	case *YieldExpr:
 		Walk(v, n.X)
 	case *ArrowFunc:
-		Walk(v, &n.Body)
 		Walk(v, &n.Params)
+		Walk(v, &n.Body)
 	case *CommaExpr:
 		for _, item := range n.List {
 			Walk(v, item)
 		}
+	case *JSXElement:
+		Walk(v, n.Name)
+		if n.Attrs != nil {
+			for i := 0; i < len(n.Attrs); i++ {
+				Walk(v, &n.Attrs[i])
+			}
+		}
+
+		for _, item := range n.Children {
+			Walk(v, item)
+		}
+	case *JSXAttribute:
+		Walk(v, n.Value)
+	case *JSXExpr:
+		Walk(v, n.X)
+	case *JSXName:
+		return
+	case *JSXText:
+		return
 	default:
 		return
 	}
 }
+
+// walkClassElement walks the static block, method, or field of a class element, the element itself is not visited.
+func walkClassElement(v IVisitor, item *ClassElement) {
+	if item.StaticBlock != nil {
+		Walk(v, item.StaticBlock)
+	} else if item.Method != nil {
+		Walk(v, item.Method)
+	} else {
+		Walk(v, &item.Field)
+	}
+}
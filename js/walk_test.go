@@ -2,6 +2,10 @@ package js
 
 import (
 	"bytes"
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
 	"testing"
 
 	"github.com/tdewolff/parse/v2"
@@ -43,70 +47,260 @@ func TestWalk(t *testing.T) {
 	})
 }
 
-func TestWalkNilNode(t *testing.T) {
-	nodes := []INode{
-		&AST{},
-		&Var{},
-		&BlockStmt{},
-		&EmptyStmt{},
-		&ExprStmt{},
-		&IfStmt{},
-		&DoWhileStmt{},
-		&WhileStmt{},
-		&ForStmt{},
-		&ForInStmt{},
-		&ForOfStmt{},
-		&CaseClause{},
-		&SwitchStmt{},
-		&BranchStmt{},
-		&ReturnStmt{},
-		&WithStmt{},
-		&LabelledStmt{},
-		&ThrowStmt{},
-		&TryStmt{},
-		&DebuggerStmt{},
-		&Alias{},
-		&ImportStmt{},
-		&ExportStmt{},
-		&DirectivePrologueStmt{},
-		&PropertyName{},
-		&BindingArray{},
-		&BindingObjectItem{},
-		&BindingObject{},
-		&BindingElement{},
-		&VarDecl{},
-		&Params{},
-		&FuncDecl{},
-		&MethodDecl{},
-		&Field{},
-		&ClassElement{},
-		&ClassDecl{},
-		&LiteralExpr{},
-		&Element{},
-		&ArrayExpr{},
-		&Property{},
-		&ObjectExpr{},
-		&TemplatePart{},
-		&TemplateExpr{},
-		&GroupExpr{},
-		&IndexExpr{},
-		&DotExpr{},
-		&NewTargetExpr{},
-		&ImportMetaExpr{},
-		&Arg{},
-		&Args{},
-		&NewExpr{},
-		&CallExpr{},
-		&UnaryExpr{},
-		&BinaryExpr{},
-		&CondExpr{},
-		&YieldExpr{},
-		&ArrowFunc{},
-	}
+// astNodes has a value of every node type in ast.go, see TestWalkNodeTypes
+var astNodes = []INode{
+	&AST{},
+	&Var{},
+	&BlockStmt{},
+	&EmptyStmt{},
+	&ExprStmt{},
+	&IfStmt{},
+	&DoWhileStmt{},
+	&WhileStmt{},
+	&ForStmt{},
+	&ForInStmt{},
+	&ForOfStmt{},
+	&CaseClause{},
+	&SwitchStmt{},
+	&BranchStmt{},
+	&ReturnStmt{},
+	&WithStmt{},
+	&LabelledStmt{},
+	&ThrowStmt{},
+	&TryStmt{},
+	&DebuggerStmt{},
+	&BadStmt{},
+	&Alias{},
+	&ImportAttribute{},
+	&ImportStmt{},
+	&ExportStmt{},
+	&DirectivePrologueStmt{},
+	&PropertyName{},
+	&BindingArray{},
+	&BindingObjectItem{},
+	&BindingObject{},
+	&BindingElement{},
+	&VarDecl{},
+	&Params{},
+	&FuncDecl{},
+	&MethodDecl{},
+	&Field{},
+	&ClassElement{},
+	&ClassDecl{},
+	&LiteralExpr{},
+	&Element{},
+	&ArrayExpr{},
+	&Property{},
+	&ObjectExpr{},
+	&TemplatePart{},
+	&TemplateExpr{},
+	&GroupExpr{},
+	&IndexExpr{},
+	&DotExpr{},
+	&NewTargetExpr{},
+	&ImportMetaExpr{},
+	&Arg{},
+	&Args{},
+	&NewExpr{},
+	&CallExpr{},
+	&UnaryExpr{},
+	&BinaryExpr{},
+	&CondExpr{},
+	&YieldExpr{},
+	&ArrowFunc{},
+	&CommaExpr{},
+	&JSXElement{},
+	&JSXName{},
+	&JSXAttribute{},
+	&JSXExpr{},
+	&JSXText{},
+}
 
+func TestWalkNilNode(t *testing.T) {
 	t.Run("TestWalkNilNode", func(t *testing.T) {
-		for _, n := range nodes {
+		for _, n := range astNodes {
 			Walk(&walker{}, n)
 		}
 	})
 }
+
+// TestWalkNodeTypes fails when a node type in ast.go is not in astNodes, or not handled by Walk or Rewrite.
+func TestWalkNodeTypes(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "ast.go", nil, 0)
+	test.Error(t, err)
+
+	// node types are the types with a JS method, and AST which embeds BlockStmt
+	types := []string{"AST"}
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*goast.FuncDecl); ok && fn.Recv != nil && fn.Name.Name == "JS" {
+			recv := fn.Recv.List[0].Type
+			if star, ok := recv.(*goast.StarExpr); ok {
+				recv = star.X
+			}
+			types = append(types, recv.(*goast.Ident).Name)
+		}
+	}
+
+	known := map[string]bool{}
+	for _, n := range astNodes {
+		known[reflect.TypeOf(n).Elem().Name()] = true
+	}
+	for _, name := range types {
+		if !known[name] {
+			t.Errorf("%s is missing from astNodes", name)
+		}
+	}
+	test.T(t, len(types), len(astNodes))
+
+	for _, filename := range []string{"walk.go", "rewrite.go"} {
+		file, err := parser.ParseFile(fset, filename, nil, 0)
+		test.Error(t, err)
+
+		cases := map[string]bool{}
+		goast.Inspect(file, func(n goast.Node) bool {
+			if clause, ok := n.(*goast.CaseClause); ok {
+				for _, expr := range clause.List {
+					if star, ok := expr.(*goast.StarExpr); ok {
+						if ident, ok := star.X.(*goast.Ident); ok {
+							cases[ident.Name] = true
+						}
+					}
+				}
+			}
+			return true
+		})
+		for _, name := range types {
+			if !cases[name] {
+				t.Errorf("%s is not handled in %s", name, filename)
+			}
+		}
+	}
+}
+
+var (
+	inodeType    = reflect.TypeOf((*INode)(nil)).Elem()
+	istmtType    = reflect.TypeOf((*IStmt)(nil)).Elem()
+	iexprType    = reflect.TypeOf((*IExpr)(nil)).Elem()
+	ibindingType = reflect.TypeOf((*IBinding)(nil)).Elem()
+)
+
+// fillChildren sets all child nodes of the struct v and returns them in the order of the fields.
+func fillChildren(v reflect.Value) []INode {
+	children := []INode{}
+	for i := 0; i < v.NumField(); i++ {
+		if field := v.Type().Field(i); field.Name == "Link" || field.PkgPath != "" {
+			continue // Var.Link and unexported fields are not children
+		}
+		children = append(children, fillChild(v.Field(i))...)
+	}
+	return children
+}
+
+func fillChild(f reflect.Value) []INode {
+	switch {
+	case f.Type() == istmtType:
+		n := &EmptyStmt{}
+		f.Set(reflect.ValueOf(n))
+		return []INode{n}
+	case f.Type() == iexprType:
+		n := &LiteralExpr{}
+		f.Set(reflect.ValueOf(n))
+		return []INode{n}
+	case f.Type() == ibindingType:
+		n := &Var{}
+		f.Set(reflect.ValueOf(n))
+		return []INode{n}
+	case f.Kind() == reflect.Ptr && f.Type().Implements(inodeType):
+		f.Set(reflect.New(f.Type().Elem()))
+		return []INode{f.Interface().(INode)}
+	case f.Kind() == reflect.Struct && f.Type() == reflect.TypeOf(ClassElement{}):
+		// only one of the static block, method, or field is a child
+		return []INode{&f.Addr().Interface().(*ClassElement).Field}
+	case f.Kind() == reflect.Struct && reflect.PtrTo(f.Type()).Implements(inodeType):
+		return []INode{f.Addr().Interface().(INode)}
+	case f.Kind() == reflect.Slice && f.Type().Elem().Kind() != reflect.Uint8:
+		f.Set(reflect.MakeSlice(f.Type(), 2, 2))
+		children := []INode{}
+		for i := 0; i < f.Len(); i++ {
+			children = append(children, fillChild(f.Index(i))...)
+		}
+		return children
+	}
+	return nil
+}
+
+type childVisitor struct {
+	root     INode
+	children []INode
+}
+
+func newChildVisitor(root INode) childVisitor {
+	return childVisitor{root, []INode{}}
+}
+
+func (v *childVisitor) Enter(n INode) IVisitor {
+	if n == v.root {
+		return v
+	}
+	v.children = append(v.children, n)
+	return nil
+}
+
+func (v *childVisitor) Exit(n INode) {}
+
+type childRewriter struct {
+	childVisitor
+}
+
+func (r *childRewriter) Enter(n INode) (INode, IRewriter) {
+	if r.childVisitor.Enter(n) == nil {
+		return n, nil
+	}
+	return n, r
+}
+
+func (r *childRewriter) Exit(n INode) INode {
+	return n
+}
+
+// testChildren compares the visited children by identity, since the zero values of children of the same type are equal.
+func testChildren(t *testing.T, name string, got, wanted []INode) {
+	t.Helper()
+	if len(got) != len(wanted) {
+		t.Errorf("%s: visited %d children instead of %d", name, len(got), len(wanted))
+		return
+	}
+	for i := range got {
+		if got[i] != wanted[i] {
+			t.Errorf("%s: child %d of type %T is not in source order", name, i, got[i])
+		}
+	}
+}
+
+// TestWalkChildren checks that Walk and Rewrite visit all children of each node type in source order.
+func TestWalkChildren(t *testing.T) {
+	for _, node := range astNodes {
+		typ := reflect.TypeOf(node).Elem()
+		t.Run(typ.Name(), func(t *testing.T) {
+			n := reflect.New(typ)
+			var children []INode
+			if typ == reflect.TypeOf(ClassElement{}) {
+				children = []INode{&n.Interface().(*ClassElement).Field}
+			} else {
+				children = fillChildren(n.Elem())
+			}
+			if typ == reflect.TypeOf(DoWhileStmt{}) {
+				children[0], children[1] = children[1], children[0] // body comes before the condition
+			}
+
+			v := newChildVisitor(n.Interface().(INode))
+			Walk(&v, v.root)
+			testChildren(t, "Walk", v.children, children)
+
+			r := &childRewriter{newChildVisitor(v.root)}
+			Rewrite(r, r.root)
+			testChildren(t, "Rewrite", r.children, children)
+		})
+	}
+}